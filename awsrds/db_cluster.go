@@ -2,13 +2,23 @@ package awsrds
 
 import (
 	"errors"
+	"time"
 )
 
 type DBCluster interface {
 	Describe(ID string) (DBClusterDetails, error)
 	Create(ID string, dbClusterDetails DBClusterDetails) error
 	Modify(ID string, dbClusterDetails DBClusterDetails, applyImmediately bool) error
+	// ModifyMasterPassword changes the master password straight away and nothing else
+	ModifyMasterPassword(ID string, password string) error
 	Delete(ID string, skipFinalSnapshot bool) error
+	CreateSnapshot(ID string, snapshotID string, tags map[string]string) error
+	DescribeSnapshot(snapshotID string) (DBSnapshotDetails, error)
+	ListSnapshots(ID string) ([]DBSnapshotDetails, error)
+	DeleteSnapshot(snapshotID string) error
+	Restore(ID string, snapshotID string, dbClusterDetails DBClusterDetails) error
+	// A zero restoreTime restores to the latest restorable time
+	RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbClusterDetails DBClusterDetails) error
 }
 
 type DBClusterDetails struct {
@@ -19,11 +29,14 @@ type DBClusterDetails struct {
 	BackupRetentionPeriod       int64
 	CharacterSetName            string
 	DBClusterArn                string
+	DbClusterResourceID         string
 	DBClusterParameterGroupName string
 	DBSubnetGroupName           string
 	DatabaseName                string
 	Endpoint                    string
+	ReaderEndpoint              string
 	Engine                      string
+	EngineMode                  string
 	EngineVersion               string
 	MasterUsername              string
 	MasterUserPassword          string
@@ -33,6 +46,19 @@ type DBClusterDetails struct {
 	PreferredMaintenanceWindow  string
 	VpcSecurityGroupIds         []string
 	Tags                        map[string]string
+	// Identifiers of the DB instances in the cluster
+	Members []string
+	// Only for clusters in the serverless engine mode
+	ScalingConfiguration *ScalingConfiguration
+	// Not supported by serverless clusters
+	IAMDatabaseAuthenticationEnabled bool
+}
+
+type ScalingConfiguration struct {
+	MinCapacity           int64
+	MaxCapacity           int64
+	AutoPause             bool
+	SecondsUntilAutoPause int64
 }
 
 var (
@@ -2,13 +2,25 @@ package awsrds
 
 import (
 	"errors"
+	"time"
 )
 
 type DBInstance interface {
 	Describe(ID string) (DBInstanceDetails, error)
 	Create(ID string, dbInstanceDetails DBInstanceDetails) error
 	Modify(ID string, dbInstanceDetails DBInstanceDetails, applyImmediately bool) error
+	// ModifyMasterPassword changes the master password straight away and nothing else
+	ModifyMasterPassword(ID string, password string) error
 	Delete(ID string, skipFinalSnapshot bool) error
+	CreateSnapshot(ID string, snapshotID string, tags map[string]string) error
+	DescribeSnapshot(snapshotID string) (DBSnapshotDetails, error)
+	ListSnapshots(ID string) ([]DBSnapshotDetails, error)
+	DeleteSnapshot(snapshotID string) error
+	Restore(ID string, snapshotID string, dbInstanceDetails DBInstanceDetails) error
+	// A zero restoreTime restores to the latest restorable time
+	RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbInstanceDetails DBInstanceDetails) error
+	CreateReadReplica(ID string, sourceID string, dbInstanceDetails DBInstanceDetails) error
+	ListTags(ID string) (map[string]string, error)
 }
 
 type DBInstanceDetails struct {
@@ -17,8 +29,10 @@ type DBInstanceDetails struct {
 	DBInstanceClass            string
 	Engine                     string
 	EngineVersion              string
+	AllowMajorVersionUpgrade   bool
 	Address                    string
 	AllocatedStorage           int64
+	MaxAllocatedStorage        int64
 	AutoMinorVersionUpgrade    bool
 	AvailabilityZone           string
 	BackupRetentionPeriod      int64
@@ -27,6 +41,7 @@ type DBInstanceDetails struct {
 	DBName                     string
 	DBClusterIdentifier        string
 	DBInstanceArn              string
+	DbiResourceID              string
 	DBParameterGroupName       string
 	DBSecurityGroups           []string
 	DBSubnetGroupName          string
@@ -46,6 +61,8 @@ type DBInstanceDetails struct {
 	StorageType                string
 	Tags                       map[string]string
 	VpcSecurityGroupIds        []string
+	// Only for DB instances which aren't in a DB cluster, the cluster has the setting for its instances
+	IAMDatabaseAuthenticationEnabled bool
 }
 
 var (
@@ -0,0 +1,23 @@
+package awsrds
+
+import (
+	"errors"
+	"time"
+)
+
+type DBSnapshotDetails struct {
+	Identifier       string
+	Arn              string
+	SourceIdentifier string
+	Status           string
+	SnapshotType     string
+	Engine           string
+	EngineVersion    string
+	MasterUsername   string
+	CreateTime       time.Time
+	Tags             map[string]string
+}
+
+var (
+	ErrDBSnapshotDoesNotExist = errors.New("rds db snapshot does not exist")
+)
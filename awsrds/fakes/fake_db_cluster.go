@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	"github.com/AusDTO/pe-rds-broker/awsrds"
 )
 
@@ -21,10 +23,41 @@ type FakeDBCluster struct {
 	ModifyApplyImmediately bool
 	ModifyError            error
 
+	ModifyMasterPasswordCalled   bool
+	ModifyMasterPasswordID       string
+	ModifyMasterPasswordPassword string
+	ModifyMasterPasswordError    error
+
 	DeleteCalled            bool
 	DeleteID                string
 	DeleteSkipFinalSnapshot bool
 	DeleteError             error
+
+	CreateSnapshotCalled     bool
+	CreateSnapshotID         string
+	CreateSnapshotSnapshotID string
+	CreateSnapshotTags       map[string]string
+	CreateSnapshotError      error
+
+	DescribeSnapshotCalled            bool
+	DescribeSnapshotID                string
+	DescribeSnapshotDBSnapshotDetails awsrds.DBSnapshotDetails
+	DescribeSnapshotError             error
+
+	ListSnapshotsCalled             bool
+	ListSnapshotsID                 string
+	ListSnapshotsDBSnapshotsDetails []awsrds.DBSnapshotDetails
+	ListSnapshotsError              error
+
+	DeleteSnapshotCalled     bool
+	DeleteSnapshotSnapshotID string
+	DeleteSnapshotError      error
+
+	RestoreCalled           bool
+	RestoreID               string
+	RestoreSnapshotID       string
+	RestoreDBClusterDetails awsrds.DBClusterDetails
+	RestoreError            error
 }
 
 func (f *FakeDBCluster) Describe(ID string) (awsrds.DBClusterDetails, error) {
@@ -51,6 +84,14 @@ func (f *FakeDBCluster) Modify(ID string, dbClusterDetails awsrds.DBClusterDetai
 	return f.ModifyError
 }
 
+func (f *FakeDBCluster) ModifyMasterPassword(ID string, password string) error {
+	f.ModifyMasterPasswordCalled = true
+	f.ModifyMasterPasswordID = ID
+	f.ModifyMasterPasswordPassword = password
+
+	return f.ModifyMasterPasswordError
+}
+
 func (f *FakeDBCluster) Delete(ID string, skipFinalSnapshot bool) error {
 	f.DeleteCalled = true
 	f.DeleteID = ID
@@ -58,3 +99,52 @@ func (f *FakeDBCluster) Delete(ID string, skipFinalSnapshot bool) error {
 
 	return f.DeleteError
 }
+
+func (f *FakeDBCluster) CreateSnapshot(ID string, snapshotID string, tags map[string]string) error {
+	f.CreateSnapshotCalled = true
+	f.CreateSnapshotID = ID
+	f.CreateSnapshotSnapshotID = snapshotID
+	f.CreateSnapshotTags = tags
+
+	return f.CreateSnapshotError
+}
+
+func (f *FakeDBCluster) DescribeSnapshot(snapshotID string) (awsrds.DBSnapshotDetails, error) {
+	f.DescribeSnapshotCalled = true
+	f.DescribeSnapshotID = snapshotID
+
+	return f.DescribeSnapshotDBSnapshotDetails, f.DescribeSnapshotError
+}
+
+func (f *FakeDBCluster) ListSnapshots(ID string) ([]awsrds.DBSnapshotDetails, error) {
+	f.ListSnapshotsCalled = true
+	f.ListSnapshotsID = ID
+
+	return f.ListSnapshotsDBSnapshotsDetails, f.ListSnapshotsError
+}
+
+func (f *FakeDBCluster) DeleteSnapshot(snapshotID string) error {
+	f.DeleteSnapshotCalled = true
+	f.DeleteSnapshotSnapshotID = snapshotID
+
+	return f.DeleteSnapshotError
+}
+
+func (f *FakeDBCluster) Restore(ID string, snapshotID string, dbClusterDetails awsrds.DBClusterDetails) error {
+	f.RestoreCalled = true
+	f.RestoreID = ID
+	f.RestoreSnapshotID = snapshotID
+	f.RestoreDBClusterDetails = dbClusterDetails
+
+	return f.RestoreError
+}
+
+func (f *FakeDBCluster) RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbClusterDetails awsrds.DBClusterDetails) error {
+	f.RestoreToPointInTimeCalled = true
+	f.RestoreToPointInTimeID = ID
+	f.RestoreToPointInTimeSourceID = sourceID
+	f.RestoreToPointInTimeRestoreTime = restoreTime
+	f.RestoreToPointInTimeDBClusterDetails = dbClusterDetails
+
+	return f.RestoreToPointInTimeError
+}
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	"github.com/AusDTO/pe-rds-broker/awsrds"
 )
 
@@ -9,34 +11,92 @@ type FakeDBInstance struct {
 	DescribeID                string
 	DescribeDBInstanceDetails awsrds.DBInstanceDetails
 	DescribeError             error
+	// Details of specific DB Instances (e.g. read replicas), falling back to DescribeDBInstanceDetails
+	DescribeDBInstancesDetails map[string]awsrds.DBInstanceDetails
 
 	CreateCalled            bool
 	CreateID                string
 	CreateDBInstanceDetails awsrds.DBInstanceDetails
 	CreateError             error
+	CreateIDs               []string
 
 	ModifyCalled            bool
 	ModifyID                string
 	ModifyDBInstanceDetails awsrds.DBInstanceDetails
 	ModifyApplyImmediately  bool
 	ModifyError             error
+	ModifyIDs               []string
+
+	ModifyMasterPasswordCalled   bool
+	ModifyMasterPasswordID       string
+	ModifyMasterPasswordPassword string
+	ModifyMasterPasswordError    error
 
 	DeleteCalled            bool
 	DeleteID                string
 	DeleteSkipFinalSnapshot bool
 	DeleteError             error
+	DeleteIDs               []string
+
+	CreateSnapshotCalled     bool
+	CreateSnapshotID         string
+	CreateSnapshotSnapshotID string
+	CreateSnapshotTags       map[string]string
+	CreateSnapshotError      error
+
+	DescribeSnapshotCalled            bool
+	DescribeSnapshotID                string
+	DescribeSnapshotDBSnapshotDetails awsrds.DBSnapshotDetails
+	DescribeSnapshotError             error
+
+	ListSnapshotsCalled             bool
+	ListSnapshotsID                 string
+	ListSnapshotsDBSnapshotsDetails []awsrds.DBSnapshotDetails
+	ListSnapshotsError              error
+
+	DeleteSnapshotCalled     bool
+	DeleteSnapshotSnapshotID string
+	DeleteSnapshotError      error
+
+	RestoreCalled            bool
+	RestoreID                string
+	RestoreSnapshotID        string
+	RestoreDBInstanceDetails awsrds.DBInstanceDetails
+	RestoreError             error
+
+	RestoreToPointInTimeCalled            bool
+	RestoreToPointInTimeID                string
+	RestoreToPointInTimeSourceID          string
+	RestoreToPointInTimeRestoreTime       time.Time
+	RestoreToPointInTimeDBInstanceDetails awsrds.DBInstanceDetails
+	RestoreToPointInTimeError             error
+
+	CreateReadReplicaCalled            bool
+	CreateReadReplicaIDs               []string
+	CreateReadReplicaSourceID          string
+	CreateReadReplicaDBInstanceDetails awsrds.DBInstanceDetails
+	CreateReadReplicaError             error
+
+	ListTagsCalled bool
+	ListTagsID     string
+	ListTagsTags   map[string]string
+	ListTagsError  error
 }
 
 func (f *FakeDBInstance) Describe(ID string) (awsrds.DBInstanceDetails, error) {
 	f.DescribeCalled = true
 	f.DescribeID = ID
 
+	if dbInstanceDetails, ok := f.DescribeDBInstancesDetails[ID]; ok {
+		return dbInstanceDetails, nil
+	}
 	return f.DescribeDBInstanceDetails, f.DescribeError
 }
 
 func (f *FakeDBInstance) Create(ID string, dbInstanceDetails awsrds.DBInstanceDetails) error {
 	f.CreateCalled = true
 	f.CreateID = ID
+	f.CreateIDs = append(f.CreateIDs, ID)
 	f.CreateDBInstanceDetails = dbInstanceDetails
 
 	return f.CreateError
@@ -45,16 +105,91 @@ func (f *FakeDBInstance) Create(ID string, dbInstanceDetails awsrds.DBInstanceDe
 func (f *FakeDBInstance) Modify(ID string, dbInstanceDetails awsrds.DBInstanceDetails, applyImmediately bool) error {
 	f.ModifyCalled = true
 	f.ModifyID = ID
+	f.ModifyIDs = append(f.ModifyIDs, ID)
 	f.ModifyDBInstanceDetails = dbInstanceDetails
 	f.ModifyApplyImmediately = applyImmediately
 
 	return f.ModifyError
 }
 
+func (f *FakeDBInstance) ModifyMasterPassword(ID string, password string) error {
+	f.ModifyMasterPasswordCalled = true
+	f.ModifyMasterPasswordID = ID
+	f.ModifyMasterPasswordPassword = password
+
+	return f.ModifyMasterPasswordError
+}
+
 func (f *FakeDBInstance) Delete(ID string, skipFinalSnapshot bool) error {
 	f.DeleteCalled = true
 	f.DeleteID = ID
+	f.DeleteIDs = append(f.DeleteIDs, ID)
 	f.DeleteSkipFinalSnapshot = skipFinalSnapshot
 
 	return f.DeleteError
 }
+
+func (f *FakeDBInstance) CreateSnapshot(ID string, snapshotID string, tags map[string]string) error {
+	f.CreateSnapshotCalled = true
+	f.CreateSnapshotID = ID
+	f.CreateSnapshotSnapshotID = snapshotID
+	f.CreateSnapshotTags = tags
+
+	return f.CreateSnapshotError
+}
+
+func (f *FakeDBInstance) DescribeSnapshot(snapshotID string) (awsrds.DBSnapshotDetails, error) {
+	f.DescribeSnapshotCalled = true
+	f.DescribeSnapshotID = snapshotID
+
+	return f.DescribeSnapshotDBSnapshotDetails, f.DescribeSnapshotError
+}
+
+func (f *FakeDBInstance) ListSnapshots(ID string) ([]awsrds.DBSnapshotDetails, error) {
+	f.ListSnapshotsCalled = true
+	f.ListSnapshotsID = ID
+
+	return f.ListSnapshotsDBSnapshotsDetails, f.ListSnapshotsError
+}
+
+func (f *FakeDBInstance) DeleteSnapshot(snapshotID string) error {
+	f.DeleteSnapshotCalled = true
+	f.DeleteSnapshotSnapshotID = snapshotID
+
+	return f.DeleteSnapshotError
+}
+
+func (f *FakeDBInstance) Restore(ID string, snapshotID string, dbInstanceDetails awsrds.DBInstanceDetails) error {
+	f.RestoreCalled = true
+	f.RestoreID = ID
+	f.RestoreSnapshotID = snapshotID
+	f.RestoreDBInstanceDetails = dbInstanceDetails
+
+	return f.RestoreError
+}
+
+func (f *FakeDBInstance) RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbInstanceDetails awsrds.DBInstanceDetails) error {
+	f.RestoreToPointInTimeCalled = true
+	f.RestoreToPointInTimeID = ID
+	f.RestoreToPointInTimeSourceID = sourceID
+	f.RestoreToPointInTimeRestoreTime = restoreTime
+	f.RestoreToPointInTimeDBInstanceDetails = dbInstanceDetails
+
+	return f.RestoreToPointInTimeError
+}
+
+func (f *FakeDBInstance) CreateReadReplica(ID string, sourceID string, dbInstanceDetails awsrds.DBInstanceDetails) error {
+	f.CreateReadReplicaCalled = true
+	f.CreateReadReplicaIDs = append(f.CreateReadReplicaIDs, ID)
+	f.CreateReadReplicaSourceID = sourceID
+	f.CreateReadReplicaDBInstanceDetails = dbInstanceDetails
+
+	return f.CreateReadReplicaError
+}
+
+func (f *FakeDBInstance) ListTags(ID string) (map[string]string, error) {
+	f.ListTagsCalled = true
+	f.ListTagsID = ID
+
+	return f.ListTagsTags, f.ListTagsError
+}
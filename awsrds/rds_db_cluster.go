@@ -114,6 +114,34 @@ func (r *RDSDBCluster) Modify(ID string, dbClusterDetails DBClusterDetails, appl
 	return nil
 }
 
+func (r *RDSDBCluster) ModifyMasterPassword(ID string, password string) error {
+	modifyDBClusterInput := &rds.ModifyDBClusterInput{
+		DBClusterIdentifier: aws.String(ID),
+		MasterUserPassword:  aws.String(password),
+		ApplyImmediately:    aws.Bool(true),
+	}
+	// The input isn't logged as it has the password
+	r.logger.Debug("modify-master-password", lager.Data{"id": ID})
+
+	modifyDBClusterOutput, err := r.rdssvc.ModifyDBCluster(modifyDBClusterInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBClusterDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+
+	r.logger.Debug("modify-master-password", lager.Data{"output": modifyDBClusterOutput})
+
+	return nil
+}
+
 func (r *RDSDBCluster) Delete(ID string, skipFinalSnapshot bool) error {
 	deleteDBClusterInput := r.buildDeleteDBClusterInput(ID, skipFinalSnapshot)
 	r.logger.Debug("delete-db-cluster", lager.Data{"input": deleteDBClusterInput})
@@ -136,6 +164,182 @@ func (r *RDSDBCluster) Delete(ID string, skipFinalSnapshot bool) error {
 
 	return nil
 }
+func (r *RDSDBCluster) CreateSnapshot(ID string, snapshotID string, tags map[string]string) error {
+	createDBClusterSnapshotInput := &rds.CreateDBClusterSnapshotInput{
+		DBClusterIdentifier:         aws.String(ID),
+		DBClusterSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	if len(tags) > 0 {
+		createDBClusterSnapshotInput.Tags = BuilRDSTags(tags)
+	}
+
+	r.logger.Debug("create-db-cluster-snapshot", lager.Data{"input": createDBClusterSnapshotInput})
+
+	createDBClusterSnapshotOutput, err := r.rdssvc.CreateDBClusterSnapshot(createDBClusterSnapshotInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBClusterDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("create-db-cluster-snapshot", lager.Data{"output": createDBClusterSnapshotOutput})
+
+	return nil
+}
+
+func (r *RDSDBCluster) DescribeSnapshot(snapshotID string) (DBSnapshotDetails, error) {
+	dbSnapshotDetails := DBSnapshotDetails{}
+
+	describeDBClusterSnapshotsInput := &rds.DescribeDBClusterSnapshotsInput{
+		DBClusterSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	r.logger.Debug("describe-db-cluster-snapshots", lager.Data{"input": describeDBClusterSnapshotsInput})
+
+	dbClusterSnapshots, err := r.rdssvc.DescribeDBClusterSnapshots(describeDBClusterSnapshotsInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return dbSnapshotDetails, ErrDBSnapshotDoesNotExist
+				}
+			}
+			return dbSnapshotDetails, errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return dbSnapshotDetails, err
+	}
+
+	for _, dbClusterSnapshot := range dbClusterSnapshots.DBClusterSnapshots {
+		if aws.StringValue(dbClusterSnapshot.DBClusterSnapshotIdentifier) == snapshotID {
+			r.logger.Debug("describe-db-cluster-snapshots", lager.Data{"db-cluster-snapshot": dbClusterSnapshot})
+			dbSnapshotDetails = r.buildDBClusterSnapshot(dbClusterSnapshot)
+			dbSnapshotDetails.Tags, err = ListTagsForResource(dbSnapshotDetails.Arn, r.rdssvc, r.logger)
+			if err != nil {
+				return dbSnapshotDetails, err
+			}
+			return dbSnapshotDetails, nil
+		}
+	}
+
+	return dbSnapshotDetails, ErrDBSnapshotDoesNotExist
+}
+
+// ListSnapshots lists the manual snapshots of the given cluster, including those taken when it was deleted
+func (r *RDSDBCluster) ListSnapshots(ID string) ([]DBSnapshotDetails, error) {
+	var dbSnapshotsDetails []DBSnapshotDetails
+
+	describeDBClusterSnapshotsInput := &rds.DescribeDBClusterSnapshotsInput{
+		DBClusterIdentifier: aws.String(ID),
+		SnapshotType:        aws.String("manual"),
+	}
+
+	r.logger.Debug("describe-db-cluster-snapshots", lager.Data{"input": describeDBClusterSnapshotsInput})
+
+	// The SDK doesn't paginate cluster snapshots for us
+	for {
+		dbClusterSnapshots, err := r.rdssvc.DescribeDBClusterSnapshots(describeDBClusterSnapshotsInput)
+		if err != nil {
+			r.logger.Error("aws-rds-error", err)
+			if awsErr, ok := err.(awserr.Error); ok {
+				return dbSnapshotsDetails, errors.New(awsErr.Code() + ": " + awsErr.Message())
+			}
+			return dbSnapshotsDetails, err
+		}
+
+		for _, dbClusterSnapshot := range dbClusterSnapshots.DBClusterSnapshots {
+			dbSnapshotDetails := r.buildDBClusterSnapshot(dbClusterSnapshot)
+			dbSnapshotDetails.Tags, err = ListTagsForResource(dbSnapshotDetails.Arn, r.rdssvc, r.logger)
+			if err != nil {
+				return dbSnapshotsDetails, err
+			}
+			dbSnapshotsDetails = append(dbSnapshotsDetails, dbSnapshotDetails)
+		}
+
+		if aws.StringValue(dbClusterSnapshots.Marker) == "" {
+			return dbSnapshotsDetails, nil
+		}
+		describeDBClusterSnapshotsInput.Marker = dbClusterSnapshots.Marker
+	}
+}
+
+func (r *RDSDBCluster) DeleteSnapshot(snapshotID string) error {
+	deleteDBClusterSnapshotInput := &rds.DeleteDBClusterSnapshotInput{
+		DBClusterSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	r.logger.Debug("delete-db-cluster-snapshot", lager.Data{"input": deleteDBClusterSnapshotInput})
+
+	deleteDBClusterSnapshotOutput, err := r.rdssvc.DeleteDBClusterSnapshot(deleteDBClusterSnapshotInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBSnapshotDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+
+	r.logger.Debug("delete-db-cluster-snapshot", lager.Data{"output": deleteDBClusterSnapshotOutput})
+
+	return nil
+}
+
+func (r *RDSDBCluster) Restore(ID string, snapshotID string, dbClusterDetails DBClusterDetails) error {
+	restoreDBClusterInput := r.buildRestoreDBClusterInput(ID, snapshotID, dbClusterDetails)
+	r.logger.Debug("restore-db-cluster-from-snapshot", lager.Data{"input": restoreDBClusterInput})
+
+	restoreDBClusterOutput, err := r.rdssvc.RestoreDBClusterFromSnapshot(restoreDBClusterInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBSnapshotDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("restore-db-cluster-from-snapshot", lager.Data{"output": restoreDBClusterOutput})
+
+	return nil
+}
+
+func (r *RDSDBCluster) RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbClusterDetails DBClusterDetails) error {
+	restoreDBClusterInput := r.buildRestoreDBClusterToPointInTimeInput(ID, sourceID, restoreTime, dbClusterDetails)
+	r.logger.Debug("restore-db-cluster-to-point-in-time", lager.Data{"input": restoreDBClusterInput})
+
+	restoreDBClusterOutput, err := r.rdssvc.RestoreDBClusterToPointInTime(restoreDBClusterInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBClusterDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("restore-db-cluster-to-point-in-time", lager.Data{"output": restoreDBClusterOutput})
+
+	return nil
+}
+
 func (r *RDSDBCluster) buildDBCluster(dbCluster *rds.DBCluster) DBClusterDetails {
 	dbClusterDetails := DBClusterDetails{
 		Identifier:       aws.StringValue(dbCluster.DBClusterIdentifier),
@@ -146,13 +350,45 @@ func (r *RDSDBCluster) buildDBCluster(dbCluster *rds.DBCluster) DBClusterDetails
 		MasterUsername:   aws.StringValue(dbCluster.MasterUsername),
 		AllocatedStorage: aws.Int64Value(dbCluster.AllocatedStorage),
 		Endpoint:         aws.StringValue(dbCluster.Endpoint),
+		ReaderEndpoint:   aws.StringValue(dbCluster.ReaderEndpoint),
 		Port:             aws.Int64Value(dbCluster.Port),
 		DBClusterArn:     aws.StringValue(dbCluster.DBClusterArn),
+
+		DbClusterResourceID:              aws.StringValue(dbCluster.DbClusterResourceId),
+		IAMDatabaseAuthenticationEnabled: aws.BoolValue(dbCluster.IAMDatabaseAuthenticationEnabled),
+	}
+
+	for _, member := range dbCluster.DBClusterMembers {
+		dbClusterDetails.Members = append(dbClusterDetails.Members, aws.StringValue(member.DBInstanceIdentifier))
+	}
+
+	dbClusterDetails.EngineMode = aws.StringValue(dbCluster.EngineMode)
+	if dbCluster.ScalingConfigurationInfo != nil {
+		dbClusterDetails.ScalingConfiguration = &ScalingConfiguration{
+			MinCapacity:           aws.Int64Value(dbCluster.ScalingConfigurationInfo.MinCapacity),
+			MaxCapacity:           aws.Int64Value(dbCluster.ScalingConfigurationInfo.MaxCapacity),
+			AutoPause:             aws.BoolValue(dbCluster.ScalingConfigurationInfo.AutoPause),
+			SecondsUntilAutoPause: aws.Int64Value(dbCluster.ScalingConfigurationInfo.SecondsUntilAutoPause),
+		}
 	}
 
 	return dbClusterDetails
 }
 
+func (r *RDSDBCluster) buildDBClusterSnapshot(dbClusterSnapshot *rds.DBClusterSnapshot) DBSnapshotDetails {
+	return DBSnapshotDetails{
+		Identifier:       aws.StringValue(dbClusterSnapshot.DBClusterSnapshotIdentifier),
+		Arn:              aws.StringValue(dbClusterSnapshot.DBClusterSnapshotArn),
+		SourceIdentifier: aws.StringValue(dbClusterSnapshot.DBClusterIdentifier),
+		Status:           aws.StringValue(dbClusterSnapshot.Status),
+		SnapshotType:     aws.StringValue(dbClusterSnapshot.SnapshotType),
+		Engine:           aws.StringValue(dbClusterSnapshot.Engine),
+		EngineVersion:    aws.StringValue(dbClusterSnapshot.EngineVersion),
+		MasterUsername:   aws.StringValue(dbClusterSnapshot.MasterUsername),
+		CreateTime:       aws.TimeValue(dbClusterSnapshot.SnapshotCreateTime),
+	}
+}
+
 func (r *RDSDBCluster) buildCreateDBClusterInput(ID string, dbClusterDetails DBClusterDetails) *rds.CreateDBClusterInput {
 	createDBClusterInput := &rds.CreateDBClusterInput{
 		DBClusterIdentifier: aws.String(ID),
@@ -183,6 +419,14 @@ func (r *RDSDBCluster) buildCreateDBClusterInput(ID string, dbClusterDetails DBC
 		createDBClusterInput.DBSubnetGroupName = aws.String(dbClusterDetails.DBSubnetGroupName)
 	}
 
+	if dbClusterDetails.EngineMode != "" {
+		createDBClusterInput.EngineMode = aws.String(dbClusterDetails.EngineMode)
+	}
+
+	if dbClusterDetails.IAMDatabaseAuthenticationEnabled {
+		createDBClusterInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+	}
+
 	if dbClusterDetails.EngineVersion != "" {
 		createDBClusterInput.EngineVersion = aws.String(dbClusterDetails.EngineVersion)
 	}
@@ -211,6 +455,10 @@ func (r *RDSDBCluster) buildCreateDBClusterInput(ID string, dbClusterDetails DBC
 		createDBClusterInput.PreferredMaintenanceWindow = aws.String(dbClusterDetails.PreferredMaintenanceWindow)
 	}
 
+	if dbClusterDetails.ScalingConfiguration != nil {
+		createDBClusterInput.ScalingConfiguration = buildScalingConfiguration(dbClusterDetails.ScalingConfiguration)
+	}
+
 	if len(dbClusterDetails.VpcSecurityGroupIds) > 0 {
 		createDBClusterInput.VpcSecurityGroupIds = aws.StringSlice(dbClusterDetails.VpcSecurityGroupIds)
 	}
@@ -222,6 +470,97 @@ func (r *RDSDBCluster) buildCreateDBClusterInput(ID string, dbClusterDetails DBC
 	return createDBClusterInput
 }
 
+// Settings that can't be given when restoring (parameter group, backup retention, ...)
+// have to be applied with a Modify once the restored DB Cluster is available
+func (r *RDSDBCluster) buildRestoreDBClusterInput(ID string, snapshotID string, dbClusterDetails DBClusterDetails) *rds.RestoreDBClusterFromSnapshotInput {
+	restoreDBClusterInput := &rds.RestoreDBClusterFromSnapshotInput{
+		DBClusterIdentifier: aws.String(ID),
+		Engine:              aws.String(dbClusterDetails.Engine),
+		SnapshotIdentifier:  aws.String(snapshotID),
+	}
+
+	if len(dbClusterDetails.AvailabilityZones) > 0 {
+		restoreDBClusterInput.AvailabilityZones = aws.StringSlice(dbClusterDetails.AvailabilityZones)
+	}
+
+	if dbClusterDetails.DBSubnetGroupName != "" {
+		restoreDBClusterInput.DBSubnetGroupName = aws.String(dbClusterDetails.DBSubnetGroupName)
+	}
+
+	if dbClusterDetails.EngineMode != "" {
+		restoreDBClusterInput.EngineMode = aws.String(dbClusterDetails.EngineMode)
+	}
+
+	if dbClusterDetails.IAMDatabaseAuthenticationEnabled {
+		restoreDBClusterInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+	}
+
+	if dbClusterDetails.EngineVersion != "" {
+		restoreDBClusterInput.EngineVersion = aws.String(dbClusterDetails.EngineVersion)
+	}
+
+	if dbClusterDetails.OptionGroupName != "" {
+		restoreDBClusterInput.OptionGroupName = aws.String(dbClusterDetails.OptionGroupName)
+	}
+
+	if dbClusterDetails.Port > 0 {
+		restoreDBClusterInput.Port = aws.Int64(dbClusterDetails.Port)
+	}
+
+	if dbClusterDetails.ScalingConfiguration != nil {
+		restoreDBClusterInput.ScalingConfiguration = buildScalingConfiguration(dbClusterDetails.ScalingConfiguration)
+	}
+
+	if len(dbClusterDetails.VpcSecurityGroupIds) > 0 {
+		restoreDBClusterInput.VpcSecurityGroupIds = aws.StringSlice(dbClusterDetails.VpcSecurityGroupIds)
+	}
+
+	if len(dbClusterDetails.Tags) > 0 {
+		restoreDBClusterInput.Tags = BuilRDSTags(dbClusterDetails.Tags)
+	}
+
+	return restoreDBClusterInput
+}
+
+func (r *RDSDBCluster) buildRestoreDBClusterToPointInTimeInput(ID string, sourceID string, restoreTime time.Time, dbClusterDetails DBClusterDetails) *rds.RestoreDBClusterToPointInTimeInput {
+	restoreDBClusterInput := &rds.RestoreDBClusterToPointInTimeInput{
+		DBClusterIdentifier:       aws.String(ID),
+		SourceDBClusterIdentifier: aws.String(sourceID),
+	}
+
+	if restoreTime.IsZero() {
+		restoreDBClusterInput.UseLatestRestorableTime = aws.Bool(true)
+	} else {
+		restoreDBClusterInput.RestoreToTime = aws.Time(restoreTime)
+	}
+
+	if dbClusterDetails.DBSubnetGroupName != "" {
+		restoreDBClusterInput.DBSubnetGroupName = aws.String(dbClusterDetails.DBSubnetGroupName)
+	}
+
+	if dbClusterDetails.IAMDatabaseAuthenticationEnabled {
+		restoreDBClusterInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+	}
+
+	if dbClusterDetails.OptionGroupName != "" {
+		restoreDBClusterInput.OptionGroupName = aws.String(dbClusterDetails.OptionGroupName)
+	}
+
+	if dbClusterDetails.Port > 0 {
+		restoreDBClusterInput.Port = aws.Int64(dbClusterDetails.Port)
+	}
+
+	if len(dbClusterDetails.VpcSecurityGroupIds) > 0 {
+		restoreDBClusterInput.VpcSecurityGroupIds = aws.StringSlice(dbClusterDetails.VpcSecurityGroupIds)
+	}
+
+	if len(dbClusterDetails.Tags) > 0 {
+		restoreDBClusterInput.Tags = BuilRDSTags(dbClusterDetails.Tags)
+	}
+
+	return restoreDBClusterInput
+}
+
 func (r *RDSDBCluster) buildModifyDBClusterInput(ID string, dbClusterDetails DBClusterDetails, applyImmediately bool) *rds.ModifyDBClusterInput {
 	modifyDBClusterInput := &rds.ModifyDBClusterInput{
 		DBClusterIdentifier: aws.String(ID),
@@ -236,6 +575,10 @@ func (r *RDSDBCluster) buildModifyDBClusterInput(ID string, dbClusterDetails DBC
 		modifyDBClusterInput.DBClusterParameterGroupName = aws.String(dbClusterDetails.DBClusterParameterGroupName)
 	}
 
+	if dbClusterDetails.ScalingConfiguration == nil {
+		modifyDBClusterInput.EnableIAMDatabaseAuthentication = aws.Bool(dbClusterDetails.IAMDatabaseAuthenticationEnabled)
+	}
+
 	if dbClusterDetails.MasterUserPassword != "" {
 		modifyDBClusterInput.MasterUserPassword = aws.String(dbClusterDetails.MasterUserPassword)
 	}
@@ -256,6 +599,11 @@ func (r *RDSDBCluster) buildModifyDBClusterInput(ID string, dbClusterDetails DBC
 		modifyDBClusterInput.PreferredMaintenanceWindow = aws.String(dbClusterDetails.PreferredMaintenanceWindow)
 	}
 
+	// The engine mode of a cluster can't be changed but the capacity of a serverless one can
+	if dbClusterDetails.ScalingConfiguration != nil {
+		modifyDBClusterInput.ScalingConfiguration = buildScalingConfiguration(dbClusterDetails.ScalingConfiguration)
+	}
+
 	if len(dbClusterDetails.VpcSecurityGroupIds) > 0 {
 		modifyDBClusterInput.VpcSecurityGroupIds = aws.StringSlice(dbClusterDetails.VpcSecurityGroupIds)
 	}
@@ -263,6 +611,26 @@ func (r *RDSDBCluster) buildModifyDBClusterInput(ID string, dbClusterDetails DBC
 	return modifyDBClusterInput
 }
 
+func buildScalingConfiguration(scalingConfiguration *ScalingConfiguration) *rds.ScalingConfiguration {
+	rdsScalingConfiguration := &rds.ScalingConfiguration{
+		AutoPause: aws.Bool(scalingConfiguration.AutoPause),
+	}
+
+	if scalingConfiguration.MinCapacity > 0 {
+		rdsScalingConfiguration.MinCapacity = aws.Int64(scalingConfiguration.MinCapacity)
+	}
+
+	if scalingConfiguration.MaxCapacity > 0 {
+		rdsScalingConfiguration.MaxCapacity = aws.Int64(scalingConfiguration.MaxCapacity)
+	}
+
+	if scalingConfiguration.SecondsUntilAutoPause > 0 {
+		rdsScalingConfiguration.SecondsUntilAutoPause = aws.Int64(scalingConfiguration.SecondsUntilAutoPause)
+	}
+
+	return rdsScalingConfiguration
+}
+
 func (r *RDSDBCluster) buildDeleteDBClusterInput(ID string, skipFinalSnapshot bool) *rds.DeleteDBClusterInput {
 	deleteDBClusterInput := &rds.DeleteDBClusterInput{
 		DBClusterIdentifier: aws.String(ID),
@@ -2,6 +2,7 @@ package awsrds_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -73,8 +74,11 @@ var _ = Describe("RDS DB Cluster", func() {
 				MasterUsername:   "test-master-username",
 				AllocatedStorage: int64(100),
 				Endpoint:         "test-endpoint",
+				ReaderEndpoint:   "test-reader-endpoint",
 				Port:             int64(3306),
 				DBClusterArn:     dbClusterArn,
+				Members:          []string{"test-instance-1", "test-instance-2"},
+				EngineMode:       "provisioned",
 			}
 
 			describeDBCluster = &rds.DBCluster{
@@ -86,8 +90,14 @@ var _ = Describe("RDS DB Cluster", func() {
 				MasterUsername:      aws.String("test-master-username"),
 				AllocatedStorage:    aws.Int64(100),
 				Endpoint:            aws.String("test-endpoint"),
+				ReaderEndpoint:      aws.String("test-reader-endpoint"),
 				Port:                aws.Int64(3306),
 				DBClusterArn:        aws.String(dbClusterArn),
+				DBClusterMembers: []*rds.DBClusterMember{
+					&rds.DBClusterMember{DBInstanceIdentifier: aws.String("test-instance-1"), IsClusterWriter: aws.Bool(true)},
+					&rds.DBClusterMember{DBInstanceIdentifier: aws.String("test-instance-2"), IsClusterWriter: aws.Bool(false)},
+				},
+				EngineMode: aws.String("provisioned"),
 			}
 			describeDBClusters = []*rds.DBCluster{describeDBCluster}
 
@@ -117,6 +127,39 @@ var _ = Describe("RDS DB Cluster", func() {
 			Expect(dbClusterDetails).To(Equal(properDBClusterDetails))
 		})
 
+		Context("when the DB Cluster has IAM database authentication", func() {
+			BeforeEach(func() {
+				describeDBCluster.DbClusterResourceId = aws.String("cluster-ABCDEFGHIJ")
+				describeDBCluster.IAMDatabaseAuthenticationEnabled = aws.Bool(true)
+			})
+
+			It("returns the resource ID", func() {
+				dbClusterDetails, err := rdsDBCluster.Describe(dbClusterIdentifier)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbClusterDetails.DbClusterResourceID).To(Equal("cluster-ABCDEFGHIJ"))
+				Expect(dbClusterDetails.IAMDatabaseAuthenticationEnabled).To(BeTrue())
+			})
+		})
+
+		Context("when the DB Cluster is serverless", func() {
+			BeforeEach(func() {
+				describeDBCluster.EngineMode = aws.String("serverless")
+				describeDBCluster.ScalingConfigurationInfo = &rds.ScalingConfigurationInfo{
+					MinCapacity:           aws.Int64(2),
+					MaxCapacity:           aws.Int64(8),
+					AutoPause:             aws.Bool(true),
+					SecondsUntilAutoPause: aws.Int64(600),
+				}
+			})
+
+			It("returns the scaling configuration", func() {
+				dbClusterDetails, err := rdsDBCluster.Describe(dbClusterIdentifier)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbClusterDetails.EngineMode).To(Equal("serverless"))
+				Expect(dbClusterDetails.ScalingConfiguration).To(Equal(&ScalingConfiguration{MinCapacity: 2, MaxCapacity: 8, AutoPause: true, SecondsUntilAutoPause: 600}))
+			})
+		})
+
 		Context("when the DB Cluster does not exists", func() {
 			JustBeforeEach(func() {
 				describeDBClustersInput = &rds.DescribeDBClustersInput{
@@ -278,6 +321,18 @@ var _ = Describe("RDS DB Cluster", func() {
 			})
 		})
 
+		Context("when has EngineMode", func() {
+			BeforeEach(func() {
+				dbClusterDetails.EngineMode = "serverless"
+				createDBClustersInput.EngineMode = aws.String("serverless")
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Create(dbClusterIdentifier, dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has EngineVersion", func() {
 			BeforeEach(func() {
 				dbClusterDetails.EngineVersion = "1.2.3"
@@ -362,6 +417,35 @@ var _ = Describe("RDS DB Cluster", func() {
 			})
 		})
 
+		Context("when has IAMDatabaseAuthenticationEnabled", func() {
+			BeforeEach(func() {
+				dbClusterDetails.IAMDatabaseAuthenticationEnabled = true
+				createDBClustersInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Create(dbClusterIdentifier, dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when has ScalingConfiguration", func() {
+			BeforeEach(func() {
+				dbClusterDetails.ScalingConfiguration = &ScalingConfiguration{MinCapacity: 2, MaxCapacity: 8, AutoPause: true, SecondsUntilAutoPause: 600}
+				createDBClustersInput.ScalingConfiguration = &rds.ScalingConfiguration{
+					MinCapacity:           aws.Int64(2),
+					MaxCapacity:           aws.Int64(8),
+					AutoPause:             aws.Bool(true),
+					SecondsUntilAutoPause: aws.Int64(600),
+				}
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Create(dbClusterIdentifier, dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has VpcSecurityGroupIds", func() {
 			BeforeEach(func() {
 				dbClusterDetails.VpcSecurityGroupIds = []string{"test-vpc-security-group-ids"}
@@ -441,8 +525,9 @@ var _ = Describe("RDS DB Cluster", func() {
 			describeDBClusterError = nil
 
 			modifyDBClusterInput = &rds.ModifyDBClusterInput{
-				DBClusterIdentifier: aws.String(dbClusterIdentifier),
-				ApplyImmediately:    aws.Bool(applyImmediately),
+				DBClusterIdentifier:             aws.String(dbClusterIdentifier),
+				ApplyImmediately:                aws.Bool(applyImmediately),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
 			}
 			modifyDBClusterError = nil
 
@@ -590,6 +675,34 @@ var _ = Describe("RDS DB Cluster", func() {
 			})
 		})
 
+		Context("when has ScalingConfiguration", func() {
+			BeforeEach(func() {
+				dbClusterDetails.ScalingConfiguration = &ScalingConfiguration{MaxCapacity: 16}
+				modifyDBClusterInput.ScalingConfiguration = &rds.ScalingConfiguration{
+					MaxCapacity: aws.Int64(16),
+					AutoPause:   aws.Bool(false),
+				}
+				modifyDBClusterInput.EnableIAMDatabaseAuthentication = nil
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Modify(dbClusterIdentifier, dbClusterDetails, applyImmediately)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when has IAMDatabaseAuthenticationEnabled", func() {
+			BeforeEach(func() {
+				dbClusterDetails.IAMDatabaseAuthenticationEnabled = true
+				modifyDBClusterInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Modify(dbClusterIdentifier, dbClusterDetails, applyImmediately)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has VpcSecurityGroupIds", func() {
 			BeforeEach(func() {
 				dbClusterDetails.VpcSecurityGroupIds = []string{"test-vpc-security-group-ids"}
@@ -662,6 +775,46 @@ var _ = Describe("RDS DB Cluster", func() {
 		})
 	})
 
+	var _ = Describe("ModifyMasterPassword", func() {
+		var modifyDBClusterError error
+
+		BeforeEach(func() {
+			modifyDBClusterError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("ModifyDBCluster"))
+				Expect(r.Params).To(Equal(&rds.ModifyDBClusterInput{
+					DBClusterIdentifier: aws.String(dbClusterIdentifier),
+					MasterUserPassword:  aws.String("new-password"),
+					ApplyImmediately:    aws.Bool(true),
+				}))
+				r.Error = modifyDBClusterError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("only changes the master password", func() {
+			err := rdsDBCluster.ModifyMasterPassword(dbClusterIdentifier, "new-password")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the DB Cluster does not exist", func() {
+			BeforeEach(func() {
+				awsError := awserr.New("code", "message", errors.New("operation failed"))
+				modifyDBClusterError = awserr.NewRequestFailure(awsError, 404, "request-id")
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBCluster.ModifyMasterPassword(dbClusterIdentifier, "new-password")
+				Expect(err).To(Equal(ErrDBClusterDoesNotExist))
+			})
+		})
+	})
+
 	var _ = Describe("Delete", func() {
 		var (
 			skipFinalSnapshot         bool
@@ -749,4 +902,403 @@ var _ = Describe("RDS DB Cluster", func() {
 			})
 		})
 	})
+
+	var _ = Describe("DescribeSnapshot", func() {
+		var (
+			snapshotArn string
+
+			describeSnapshotsInput *rds.DescribeDBClusterSnapshotsInput
+			describeSnapshotsError error
+			listTagsError          error
+		)
+
+		BeforeEach(func() {
+			snapshotArn = "arn:aws:rds:rds-region:account:snapshot:snapshot-id"
+			describeSnapshotsInput = &rds.DescribeDBClusterSnapshotsInput{
+				DBClusterSnapshotIdentifier: aws.String("snapshot-id"),
+			}
+			describeSnapshotsError = nil
+			listTagsError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				switch r.Operation.Name {
+				case "DescribeDBClusterSnapshots":
+					Expect(r.Params).To(Equal(describeSnapshotsInput))
+					data := r.Data.(*rds.DescribeDBClusterSnapshotsOutput)
+					data.DBClusterSnapshots = []*rds.DBClusterSnapshot{
+						&rds.DBClusterSnapshot{
+							DBClusterSnapshotIdentifier: aws.String("snapshot-id"),
+							DBClusterSnapshotArn:        aws.String(snapshotArn),
+							DBClusterIdentifier:         aws.String(dbClusterIdentifier),
+							Status:                      aws.String("available"),
+							Engine:                      aws.String("test-engine"),
+							MasterUsername:              aws.String("test-master-username"),
+						},
+					}
+					r.Error = describeSnapshotsError
+				case "ListTagsForResource":
+					Expect(r.Params).To(Equal(&rds.ListTagsForResourceInput{ResourceName: aws.String(snapshotArn)}))
+					data := r.Data.(*rds.ListTagsForResourceOutput)
+					data.TagList = []*rds.Tag{
+						&rds.Tag{Key: aws.String("Managed by"), Value: aws.String("github.com/AusDTO/pe-rds-broker")},
+					}
+					r.Error = listTagsError
+				default:
+					Fail("unexpected operation " + r.Operation.Name)
+				}
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the proper snapshot with its tags", func() {
+			snapshot, err := rdsDBCluster.DescribeSnapshot("snapshot-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.Identifier).To(Equal("snapshot-id"))
+			Expect(snapshot.Arn).To(Equal(snapshotArn))
+			Expect(snapshot.SourceIdentifier).To(Equal(dbClusterIdentifier))
+			Expect(snapshot.Status).To(Equal("available"))
+			Expect(snapshot.Engine).To(Equal("test-engine"))
+			Expect(snapshot.MasterUsername).To(Equal("test-master-username"))
+			Expect(snapshot.Tags).To(Equal(map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"}))
+		})
+
+		Context("when the snapshot does not exist", func() {
+			BeforeEach(func() {
+				describeSnapshotsInput = &rds.DescribeDBClusterSnapshotsInput{
+					DBClusterSnapshotIdentifier: aws.String("unknown"),
+				}
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBCluster.DescribeSnapshot("unknown")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(ErrDBSnapshotDoesNotExist))
+			})
+		})
+
+		Context("when describing the snapshot fails", func() {
+			BeforeEach(func() {
+				describeSnapshotsError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBCluster.DescribeSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+
+		Context("when listing the tags fails", func() {
+			BeforeEach(func() {
+				listTagsError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBCluster.DescribeSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+		})
+	})
+
+	var _ = Describe("Restore", func() {
+		var (
+			dbClusterDetails DBClusterDetails
+
+			restoreInput *rds.RestoreDBClusterFromSnapshotInput
+			restoreError error
+		)
+
+		BeforeEach(func() {
+			dbClusterDetails = DBClusterDetails{
+				Engine: "test-engine",
+			}
+
+			restoreInput = &rds.RestoreDBClusterFromSnapshotInput{
+				DBClusterIdentifier: aws.String(dbClusterIdentifier),
+				Engine:              aws.String("test-engine"),
+				SnapshotIdentifier:  aws.String("snapshot-id"),
+			}
+			restoreError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("RestoreDBClusterFromSnapshot"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.RestoreDBClusterFromSnapshotInput{}))
+				Expect(r.Params).To(Equal(restoreInput))
+				r.Error = restoreError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBCluster.Restore(dbClusterIdentifier, "snapshot-id", dbClusterDetails)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when has VpcSecurityGroupIds", func() {
+			BeforeEach(func() {
+				dbClusterDetails.VpcSecurityGroupIds = []string{"test-vpc-security-group-ids"}
+				restoreInput.VpcSecurityGroupIds = aws.StringSlice([]string{"test-vpc-security-group-ids"})
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Restore(dbClusterIdentifier, "snapshot-id", dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when has Tags", func() {
+			BeforeEach(func() {
+				dbClusterDetails.Tags = map[string]string{"Owner": "Cloud Foundry"}
+				restoreInput.Tags = []*rds.Tag{
+					&rds.Tag{Key: aws.String("Owner"), Value: aws.String("Cloud Foundry")},
+				}
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.Restore(dbClusterIdentifier, "snapshot-id", dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when restoring fails", func() {
+			BeforeEach(func() {
+				restoreError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBCluster.Restore(dbClusterIdentifier, "snapshot-id", dbClusterDetails)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("RestoreToPointInTime", func() {
+		var (
+			dbClusterDetails DBClusterDetails
+			restoreTime      time.Time
+
+			restoreInput *rds.RestoreDBClusterToPointInTimeInput
+			restoreError error
+		)
+
+		BeforeEach(func() {
+			dbClusterDetails = DBClusterDetails{}
+			restoreTime = time.Date(2017, 11, 1, 10, 20, 30, 0, time.UTC)
+
+			restoreInput = &rds.RestoreDBClusterToPointInTimeInput{
+				DBClusterIdentifier:       aws.String(dbClusterIdentifier),
+				SourceDBClusterIdentifier: aws.String("source-id"),
+				RestoreToTime:             aws.Time(restoreTime),
+			}
+			restoreError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("RestoreDBClusterToPointInTime"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.RestoreDBClusterToPointInTimeInput{}))
+				Expect(r.Params).To(Equal(restoreInput))
+				r.Error = restoreError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBCluster.RestoreToPointInTime(dbClusterIdentifier, "source-id", restoreTime, dbClusterDetails)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when restoring to the latest restorable time", func() {
+			BeforeEach(func() {
+				restoreTime = time.Time{}
+				restoreInput.RestoreToTime = nil
+				restoreInput.UseLatestRestorableTime = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBCluster.RestoreToPointInTime(dbClusterIdentifier, "source-id", restoreTime, dbClusterDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when restoring fails", func() {
+			BeforeEach(func() {
+				restoreError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBCluster.RestoreToPointInTime(dbClusterIdentifier, "source-id", restoreTime, dbClusterDetails)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("CreateSnapshot", func() {
+		var (
+			tags map[string]string
+
+			createSnapshotInput *rds.CreateDBClusterSnapshotInput
+			createSnapshotError error
+		)
+
+		BeforeEach(func() {
+			tags = map[string]string{"Owner": "Cloud Foundry"}
+
+			createSnapshotInput = &rds.CreateDBClusterSnapshotInput{
+				DBClusterIdentifier:         aws.String(dbClusterIdentifier),
+				DBClusterSnapshotIdentifier: aws.String("snapshot-id"),
+				Tags: []*rds.Tag{
+					&rds.Tag{Key: aws.String("Owner"), Value: aws.String("Cloud Foundry")},
+				},
+			}
+			createSnapshotError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("CreateDBClusterSnapshot"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.CreateDBClusterSnapshotInput{}))
+				Expect(r.Params).To(Equal(createSnapshotInput))
+				r.Error = createSnapshotError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBCluster.CreateSnapshot(dbClusterIdentifier, "snapshot-id", tags)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when creating the snapshot fails", func() {
+			BeforeEach(func() {
+				createSnapshotError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBCluster.CreateSnapshot(dbClusterIdentifier, "snapshot-id", tags)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+	var _ = Describe("ListSnapshots", func() {
+		var (
+			snapshotArn string
+
+			describeSnapshotsError error
+		)
+
+		BeforeEach(func() {
+			snapshotArn = "arn:aws:rds:rds-region:account:cluster-snapshot:snapshot-id"
+			describeSnapshotsError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				switch r.Operation.Name {
+				case "DescribeDBClusterSnapshots":
+					Expect(r.Params).To(Equal(&rds.DescribeDBClusterSnapshotsInput{
+						DBClusterIdentifier: aws.String(dbClusterIdentifier),
+						SnapshotType:        aws.String("manual"),
+					}))
+					data := r.Data.(*rds.DescribeDBClusterSnapshotsOutput)
+					data.DBClusterSnapshots = []*rds.DBClusterSnapshot{
+						&rds.DBClusterSnapshot{
+							DBClusterSnapshotIdentifier: aws.String("snapshot-id"),
+							DBClusterSnapshotArn:        aws.String(snapshotArn),
+							DBClusterIdentifier:         aws.String(dbClusterIdentifier),
+							Status:                      aws.String("available"),
+						},
+					}
+					r.Error = describeSnapshotsError
+				case "ListTagsForResource":
+					Expect(r.Params).To(Equal(&rds.ListTagsForResourceInput{ResourceName: aws.String(snapshotArn)}))
+					data := r.Data.(*rds.ListTagsForResourceOutput)
+					data.TagList = []*rds.Tag{
+						&rds.Tag{Key: aws.String("Managed by"), Value: aws.String("github.com/AusDTO/pe-rds-broker")},
+					}
+				default:
+					Fail("unexpected operation " + r.Operation.Name)
+				}
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the snapshots of the DB Cluster with their tags", func() {
+			snapshots, err := rdsDBCluster.ListSnapshots(dbClusterIdentifier)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].Identifier).To(Equal("snapshot-id"))
+			Expect(snapshots[0].SourceIdentifier).To(Equal(dbClusterIdentifier))
+			Expect(snapshots[0].Tags).To(Equal(map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"}))
+		})
+
+		Context("when describing the snapshots fails", func() {
+			BeforeEach(func() {
+				describeSnapshotsError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBCluster.ListSnapshots(dbClusterIdentifier)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("DeleteSnapshot", func() {
+		var (
+			deleteSnapshotError error
+		)
+
+		BeforeEach(func() {
+			deleteSnapshotError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("DeleteDBClusterSnapshot"))
+				Expect(r.Params).To(Equal(&rds.DeleteDBClusterSnapshotInput{DBClusterSnapshotIdentifier: aws.String("snapshot-id")}))
+				r.Error = deleteSnapshotError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBCluster.DeleteSnapshot("snapshot-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the snapshot does not exist", func() {
+			BeforeEach(func() {
+				awsError := awserr.New("code", "message", errors.New("operation failed"))
+				deleteSnapshotError = awserr.NewRequestFailure(awsError, 404, "request-id")
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBCluster.DeleteSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(ErrDBSnapshotDoesNotExist))
+			})
+		})
+	})
 })
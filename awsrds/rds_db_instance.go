@@ -125,6 +125,34 @@ func (r *RDSDBInstance) Modify(ID string, dbInstanceDetails DBInstanceDetails, a
 	return nil
 }
 
+func (r *RDSDBInstance) ModifyMasterPassword(ID string, password string) error {
+	modifyDBInstanceInput := &rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier: aws.String(ID),
+		MasterUserPassword:   aws.String(password),
+		ApplyImmediately:     aws.Bool(true),
+	}
+	// The input isn't logged as it has the password
+	r.logger.Debug("modify-master-password", lager.Data{"id": ID})
+
+	modifyDBInstanceOutput, err := r.rdssvc.ModifyDBInstance(modifyDBInstanceInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBInstanceDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+
+	r.logger.Debug("modify-master-password", lager.Data{"output": modifyDBInstanceOutput})
+
+	return nil
+}
+
 func (r *RDSDBInstance) Delete(ID string, skipFinalSnapshot bool) error {
 	deleteDBInstanceInput := r.buildDeleteDBInstanceInput(ID, skipFinalSnapshot)
 	r.logger.Debug("delete-db-instance", lager.Data{"input": deleteDBInstanceInput})
@@ -148,16 +176,226 @@ func (r *RDSDBInstance) Delete(ID string, skipFinalSnapshot bool) error {
 	return nil
 }
 
+func (r *RDSDBInstance) CreateSnapshot(ID string, snapshotID string, tags map[string]string) error {
+	createDBSnapshotInput := &rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(ID),
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	if len(tags) > 0 {
+		createDBSnapshotInput.Tags = BuilRDSTags(tags)
+	}
+
+	r.logger.Debug("create-db-snapshot", lager.Data{"input": createDBSnapshotInput})
+
+	createDBSnapshotOutput, err := r.rdssvc.CreateDBSnapshot(createDBSnapshotInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBInstanceDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("create-db-snapshot", lager.Data{"output": createDBSnapshotOutput})
+
+	return nil
+}
+
+func (r *RDSDBInstance) DescribeSnapshot(snapshotID string) (DBSnapshotDetails, error) {
+	dbSnapshotDetails := DBSnapshotDetails{}
+
+	describeDBSnapshotsInput := &rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	r.logger.Debug("describe-db-snapshots", lager.Data{"input": describeDBSnapshotsInput})
+
+	dbSnapshots, err := r.rdssvc.DescribeDBSnapshots(describeDBSnapshotsInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return dbSnapshotDetails, ErrDBSnapshotDoesNotExist
+				}
+			}
+			return dbSnapshotDetails, errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return dbSnapshotDetails, err
+	}
+
+	for _, dbSnapshot := range dbSnapshots.DBSnapshots {
+		if aws.StringValue(dbSnapshot.DBSnapshotIdentifier) == snapshotID {
+			r.logger.Debug("describe-db-snapshots", lager.Data{"db-snapshot": dbSnapshot})
+			dbSnapshotDetails = r.buildDBSnapshot(dbSnapshot)
+			dbSnapshotDetails.Tags, err = ListTagsForResource(dbSnapshotDetails.Arn, r.rdssvc, r.logger)
+			if err != nil {
+				return dbSnapshotDetails, err
+			}
+			return dbSnapshotDetails, nil
+		}
+	}
+
+	return dbSnapshotDetails, ErrDBSnapshotDoesNotExist
+}
+
+// ListSnapshots lists the manual snapshots of the given instance, including those taken when it was deleted
+func (r *RDSDBInstance) ListSnapshots(ID string) ([]DBSnapshotDetails, error) {
+	var dbSnapshotsDetails []DBSnapshotDetails
+
+	describeDBSnapshotsInput := &rds.DescribeDBSnapshotsInput{
+		DBInstanceIdentifier: aws.String(ID),
+		SnapshotType:         aws.String("manual"),
+	}
+
+	r.logger.Debug("describe-db-snapshots", lager.Data{"input": describeDBSnapshotsInput})
+
+	var dbSnapshots []*rds.DBSnapshot
+	err := r.rdssvc.DescribeDBSnapshotsPages(describeDBSnapshotsInput, func(page *rds.DescribeDBSnapshotsOutput, lastPage bool) bool {
+		dbSnapshots = append(dbSnapshots, page.DBSnapshots...)
+		return true
+	})
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			return dbSnapshotsDetails, errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return dbSnapshotsDetails, err
+	}
+
+	for _, dbSnapshot := range dbSnapshots {
+		dbSnapshotDetails := r.buildDBSnapshot(dbSnapshot)
+		dbSnapshotDetails.Tags, err = ListTagsForResource(dbSnapshotDetails.Arn, r.rdssvc, r.logger)
+		if err != nil {
+			return dbSnapshotsDetails, err
+		}
+		dbSnapshotsDetails = append(dbSnapshotsDetails, dbSnapshotDetails)
+	}
+
+	return dbSnapshotsDetails, nil
+}
+
+func (r *RDSDBInstance) DeleteSnapshot(snapshotID string) error {
+	deleteDBSnapshotInput := &rds.DeleteDBSnapshotInput{
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	r.logger.Debug("delete-db-snapshot", lager.Data{"input": deleteDBSnapshotInput})
+
+	deleteDBSnapshotOutput, err := r.rdssvc.DeleteDBSnapshot(deleteDBSnapshotInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBSnapshotDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+
+	r.logger.Debug("delete-db-snapshot", lager.Data{"output": deleteDBSnapshotOutput})
+
+	return nil
+}
+
+func (r *RDSDBInstance) Restore(ID string, snapshotID string, dbInstanceDetails DBInstanceDetails) error {
+	restoreDBInstanceInput := r.buildRestoreDBInstanceInput(ID, snapshotID, dbInstanceDetails)
+	r.logger.Debug("restore-db-instance-from-db-snapshot", lager.Data{"input": restoreDBInstanceInput})
+
+	restoreDBInstanceOutput, err := r.rdssvc.RestoreDBInstanceFromDBSnapshot(restoreDBInstanceInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBSnapshotDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("restore-db-instance-from-db-snapshot", lager.Data{"output": restoreDBInstanceOutput})
+
+	return nil
+}
+
+func (r *RDSDBInstance) RestoreToPointInTime(ID string, sourceID string, restoreTime time.Time, dbInstanceDetails DBInstanceDetails) error {
+	restoreDBInstanceInput := r.buildRestoreDBInstanceToPointInTimeInput(ID, sourceID, restoreTime, dbInstanceDetails)
+	r.logger.Debug("restore-db-instance-to-point-in-time", lager.Data{"input": restoreDBInstanceInput})
+
+	restoreDBInstanceOutput, err := r.rdssvc.RestoreDBInstanceToPointInTime(restoreDBInstanceInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBInstanceDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("restore-db-instance-to-point-in-time", lager.Data{"output": restoreDBInstanceOutput})
+
+	return nil
+}
+
+func (r *RDSDBInstance) CreateReadReplica(ID string, sourceID string, dbInstanceDetails DBInstanceDetails) error {
+	createDBInstanceReadReplicaInput := r.buildCreateDBInstanceReadReplicaInput(ID, sourceID, dbInstanceDetails)
+	r.logger.Debug("create-db-instance-read-replica", lager.Data{"input": createDBInstanceReadReplicaInput})
+
+	createDBInstanceReadReplicaOutput, err := r.rdssvc.CreateDBInstanceReadReplica(createDBInstanceReadReplicaInput)
+	if err != nil {
+		r.logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			if reqErr, ok := err.(awserr.RequestFailure); ok {
+				if reqErr.StatusCode() == 404 {
+					return ErrDBInstanceDoesNotExist
+				}
+			}
+			return errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return err
+	}
+	r.logger.Debug("create-db-instance-read-replica", lager.Data{"output": createDBInstanceReadReplicaOutput})
+
+	return nil
+}
+
+func (r *RDSDBInstance) ListTags(ID string) (map[string]string, error) {
+	dbInstanceDetails, err := r.Describe(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ListTagsForResource(dbInstanceDetails.DBInstanceArn, r.rdssvc, r.logger)
+}
+
 func (r *RDSDBInstance) buildDBInstance(dbInstance *rds.DBInstance) DBInstanceDetails {
 	dbInstanceDetails := DBInstanceDetails{
-		Identifier:       aws.StringValue(dbInstance.DBInstanceIdentifier),
-		Status:           aws.StringValue(dbInstance.DBInstanceStatus),
-		Engine:           aws.StringValue(dbInstance.Engine),
-		EngineVersion:    aws.StringValue(dbInstance.EngineVersion),
-		DBName:           aws.StringValue(dbInstance.DBName),
-		MasterUsername:   aws.StringValue(dbInstance.MasterUsername),
-		AllocatedStorage: aws.Int64Value(dbInstance.AllocatedStorage),
-		DBInstanceArn:    aws.StringValue(dbInstance.DBInstanceArn),
+		Identifier:          aws.StringValue(dbInstance.DBInstanceIdentifier),
+		Status:              aws.StringValue(dbInstance.DBInstanceStatus),
+		Engine:              aws.StringValue(dbInstance.Engine),
+		EngineVersion:       aws.StringValue(dbInstance.EngineVersion),
+		DBName:              aws.StringValue(dbInstance.DBName),
+		MasterUsername:      aws.StringValue(dbInstance.MasterUsername),
+		AllocatedStorage:    aws.Int64Value(dbInstance.AllocatedStorage),
+		MaxAllocatedStorage: aws.Int64Value(dbInstance.MaxAllocatedStorage),
+		DBInstanceArn:       aws.StringValue(dbInstance.DBInstanceArn),
+		DbiResourceID:       aws.StringValue(dbInstance.DbiResourceId),
+		DBClusterIdentifier: aws.StringValue(dbInstance.DBClusterIdentifier),
+
+		IAMDatabaseAuthenticationEnabled: aws.BoolValue(dbInstance.IAMDatabaseAuthenticationEnabled),
 	}
 
 	if dbInstance.Endpoint != nil {
@@ -175,6 +413,20 @@ func (r *RDSDBInstance) buildDBInstance(dbInstance *rds.DBInstance) DBInstanceDe
 	return dbInstanceDetails
 }
 
+func (r *RDSDBInstance) buildDBSnapshot(dbSnapshot *rds.DBSnapshot) DBSnapshotDetails {
+	return DBSnapshotDetails{
+		Identifier:       aws.StringValue(dbSnapshot.DBSnapshotIdentifier),
+		Arn:              aws.StringValue(dbSnapshot.DBSnapshotArn),
+		SourceIdentifier: aws.StringValue(dbSnapshot.DBInstanceIdentifier),
+		Status:           aws.StringValue(dbSnapshot.Status),
+		SnapshotType:     aws.StringValue(dbSnapshot.SnapshotType),
+		Engine:           aws.StringValue(dbSnapshot.Engine),
+		EngineVersion:    aws.StringValue(dbSnapshot.EngineVersion),
+		MasterUsername:   aws.StringValue(dbSnapshot.MasterUsername),
+		CreateTime:       aws.TimeValue(dbSnapshot.SnapshotCreateTime),
+	}
+}
+
 func (r *RDSDBInstance) buildCreateDBInstanceInput(ID string, dbInstanceDetails DBInstanceDetails) *rds.CreateDBInstanceInput {
 	createDBInstanceInput := &rds.CreateDBInstanceInput{
 		DBInstanceIdentifier: aws.String(ID),
@@ -185,6 +437,10 @@ func (r *RDSDBInstance) buildCreateDBInstanceInput(ID string, dbInstanceDetails
 		createDBInstanceInput.AllocatedStorage = aws.Int64(dbInstanceDetails.AllocatedStorage)
 	}
 
+	if dbInstanceDetails.MaxAllocatedStorage > 0 {
+		createDBInstanceInput.MaxAllocatedStorage = aws.Int64(dbInstanceDetails.MaxAllocatedStorage)
+	}
+
 	createDBInstanceInput.AutoMinorVersionUpgrade = aws.Bool(dbInstanceDetails.AutoMinorVersionUpgrade)
 
 	if dbInstanceDetails.AvailabilityZone != "" {
@@ -203,6 +459,8 @@ func (r *RDSDBInstance) buildCreateDBInstanceInput(ID string, dbInstanceDetails
 
 	if dbInstanceDetails.DBClusterIdentifier != "" {
 		createDBInstanceInput.DBClusterIdentifier = aws.String(dbInstanceDetails.DBClusterIdentifier)
+	} else {
+		createDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(dbInstanceDetails.IAMDatabaseAuthenticationEnabled)
 	}
 
 	if dbInstanceDetails.DBInstanceClass != "" {
@@ -286,6 +544,171 @@ func (r *RDSDBInstance) buildCreateDBInstanceInput(ID string, dbInstanceDetails
 	return createDBInstanceInput
 }
 
+// Settings that can't be given when restoring (parameter group, backup retention, security groups, ...)
+// have to be applied with a Modify once the restored DB Instance is available
+func (r *RDSDBInstance) buildRestoreDBInstanceInput(ID string, snapshotID string, dbInstanceDetails DBInstanceDetails) *rds.RestoreDBInstanceFromDBSnapshotInput {
+	restoreDBInstanceInput := &rds.RestoreDBInstanceFromDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(ID),
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	}
+
+	restoreDBInstanceInput.AutoMinorVersionUpgrade = aws.Bool(dbInstanceDetails.AutoMinorVersionUpgrade)
+
+	if dbInstanceDetails.AvailabilityZone != "" {
+		restoreDBInstanceInput.AvailabilityZone = aws.String(dbInstanceDetails.AvailabilityZone)
+	}
+
+	restoreDBInstanceInput.CopyTagsToSnapshot = aws.Bool(dbInstanceDetails.CopyTagsToSnapshot)
+
+	if dbInstanceDetails.DBInstanceClass != "" {
+		restoreDBInstanceInput.DBInstanceClass = aws.String(dbInstanceDetails.DBInstanceClass)
+	}
+
+	if dbInstanceDetails.DBSubnetGroupName != "" {
+		restoreDBInstanceInput.DBSubnetGroupName = aws.String(dbInstanceDetails.DBSubnetGroupName)
+	}
+
+	if dbInstanceDetails.LicenseModel != "" {
+		restoreDBInstanceInput.LicenseModel = aws.String(dbInstanceDetails.LicenseModel)
+	}
+
+	restoreDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(dbInstanceDetails.IAMDatabaseAuthenticationEnabled)
+
+	restoreDBInstanceInput.MultiAZ = aws.Bool(dbInstanceDetails.MultiAZ)
+
+	if dbInstanceDetails.OptionGroupName != "" {
+		restoreDBInstanceInput.OptionGroupName = aws.String(dbInstanceDetails.OptionGroupName)
+	}
+
+	if dbInstanceDetails.Port > 0 {
+		restoreDBInstanceInput.Port = aws.Int64(dbInstanceDetails.Port)
+	}
+
+	restoreDBInstanceInput.PubliclyAccessible = aws.Bool(dbInstanceDetails.PubliclyAccessible)
+
+	if dbInstanceDetails.StorageType != "" {
+		restoreDBInstanceInput.StorageType = aws.String(dbInstanceDetails.StorageType)
+	}
+
+	if dbInstanceDetails.Iops > 0 {
+		restoreDBInstanceInput.Iops = aws.Int64(dbInstanceDetails.Iops)
+	}
+
+	if len(dbInstanceDetails.Tags) > 0 {
+		restoreDBInstanceInput.Tags = BuilRDSTags(dbInstanceDetails.Tags)
+	}
+
+	return restoreDBInstanceInput
+}
+
+func (r *RDSDBInstance) buildRestoreDBInstanceToPointInTimeInput(ID string, sourceID string, restoreTime time.Time, dbInstanceDetails DBInstanceDetails) *rds.RestoreDBInstanceToPointInTimeInput {
+	restoreDBInstanceInput := &rds.RestoreDBInstanceToPointInTimeInput{
+		TargetDBInstanceIdentifier: aws.String(ID),
+		SourceDBInstanceIdentifier: aws.String(sourceID),
+	}
+
+	if restoreTime.IsZero() {
+		restoreDBInstanceInput.UseLatestRestorableTime = aws.Bool(true)
+	} else {
+		restoreDBInstanceInput.RestoreTime = aws.Time(restoreTime)
+	}
+
+	restoreDBInstanceInput.AutoMinorVersionUpgrade = aws.Bool(dbInstanceDetails.AutoMinorVersionUpgrade)
+
+	if dbInstanceDetails.AvailabilityZone != "" {
+		restoreDBInstanceInput.AvailabilityZone = aws.String(dbInstanceDetails.AvailabilityZone)
+	}
+
+	restoreDBInstanceInput.CopyTagsToSnapshot = aws.Bool(dbInstanceDetails.CopyTagsToSnapshot)
+
+	if dbInstanceDetails.DBInstanceClass != "" {
+		restoreDBInstanceInput.DBInstanceClass = aws.String(dbInstanceDetails.DBInstanceClass)
+	}
+
+	if dbInstanceDetails.DBSubnetGroupName != "" {
+		restoreDBInstanceInput.DBSubnetGroupName = aws.String(dbInstanceDetails.DBSubnetGroupName)
+	}
+
+	if dbInstanceDetails.LicenseModel != "" {
+		restoreDBInstanceInput.LicenseModel = aws.String(dbInstanceDetails.LicenseModel)
+	}
+
+	restoreDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(dbInstanceDetails.IAMDatabaseAuthenticationEnabled)
+
+	restoreDBInstanceInput.MultiAZ = aws.Bool(dbInstanceDetails.MultiAZ)
+
+	if dbInstanceDetails.OptionGroupName != "" {
+		restoreDBInstanceInput.OptionGroupName = aws.String(dbInstanceDetails.OptionGroupName)
+	}
+
+	if dbInstanceDetails.Port > 0 {
+		restoreDBInstanceInput.Port = aws.Int64(dbInstanceDetails.Port)
+	}
+
+	restoreDBInstanceInput.PubliclyAccessible = aws.Bool(dbInstanceDetails.PubliclyAccessible)
+
+	if dbInstanceDetails.StorageType != "" {
+		restoreDBInstanceInput.StorageType = aws.String(dbInstanceDetails.StorageType)
+	}
+
+	if dbInstanceDetails.Iops > 0 {
+		restoreDBInstanceInput.Iops = aws.Int64(dbInstanceDetails.Iops)
+	}
+
+	if len(dbInstanceDetails.Tags) > 0 {
+		restoreDBInstanceInput.Tags = BuilRDSTags(dbInstanceDetails.Tags)
+	}
+
+	return restoreDBInstanceInput
+}
+
+// Read replicas inherit the engine, storage and master user of their source so only the settings
+// that can differ between the two are given
+func (r *RDSDBInstance) buildCreateDBInstanceReadReplicaInput(ID string, sourceID string, dbInstanceDetails DBInstanceDetails) *rds.CreateDBInstanceReadReplicaInput {
+	createDBInstanceReadReplicaInput := &rds.CreateDBInstanceReadReplicaInput{
+		DBInstanceIdentifier:       aws.String(ID),
+		SourceDBInstanceIdentifier: aws.String(sourceID),
+	}
+
+	createDBInstanceReadReplicaInput.AutoMinorVersionUpgrade = aws.Bool(dbInstanceDetails.AutoMinorVersionUpgrade)
+
+	if dbInstanceDetails.AvailabilityZone != "" {
+		createDBInstanceReadReplicaInput.AvailabilityZone = aws.String(dbInstanceDetails.AvailabilityZone)
+	}
+
+	createDBInstanceReadReplicaInput.CopyTagsToSnapshot = aws.Bool(dbInstanceDetails.CopyTagsToSnapshot)
+
+	if dbInstanceDetails.DBInstanceClass != "" {
+		createDBInstanceReadReplicaInput.DBInstanceClass = aws.String(dbInstanceDetails.DBInstanceClass)
+	}
+
+	createDBInstanceReadReplicaInput.EnableIAMDatabaseAuthentication = aws.Bool(dbInstanceDetails.IAMDatabaseAuthenticationEnabled)
+
+	if dbInstanceDetails.OptionGroupName != "" {
+		createDBInstanceReadReplicaInput.OptionGroupName = aws.String(dbInstanceDetails.OptionGroupName)
+	}
+
+	if dbInstanceDetails.Port > 0 {
+		createDBInstanceReadReplicaInput.Port = aws.Int64(dbInstanceDetails.Port)
+	}
+
+	createDBInstanceReadReplicaInput.PubliclyAccessible = aws.Bool(dbInstanceDetails.PubliclyAccessible)
+
+	if dbInstanceDetails.StorageType != "" {
+		createDBInstanceReadReplicaInput.StorageType = aws.String(dbInstanceDetails.StorageType)
+	}
+
+	if dbInstanceDetails.Iops > 0 {
+		createDBInstanceReadReplicaInput.Iops = aws.Int64(dbInstanceDetails.Iops)
+	}
+
+	if len(dbInstanceDetails.Tags) > 0 {
+		createDBInstanceReadReplicaInput.Tags = BuilRDSTags(dbInstanceDetails.Tags)
+	}
+
+	return createDBInstanceReadReplicaInput
+}
+
 func (r *RDSDBInstance) buildModifyDBInstanceInput(ID string, dbInstanceDetails DBInstanceDetails, oldDBInstanceDetails DBInstanceDetails, applyImmediately bool) *rds.ModifyDBInstanceInput {
 	modifyDBInstanceInput := &rds.ModifyDBInstanceInput{
 		DBInstanceIdentifier: aws.String(ID),
@@ -300,6 +723,10 @@ func (r *RDSDBInstance) buildModifyDBInstanceInput(ID string, dbInstanceDetails
 		}
 	}
 
+	if dbInstanceDetails.MaxAllocatedStorage > 0 {
+		modifyDBInstanceInput.MaxAllocatedStorage = aws.Int64(dbInstanceDetails.MaxAllocatedStorage)
+	}
+
 	modifyDBInstanceInput.AutoMinorVersionUpgrade = aws.Bool(dbInstanceDetails.AutoMinorVersionUpgrade)
 
 	if dbInstanceDetails.BackupRetentionPeriod > 0 {
@@ -322,13 +749,18 @@ func (r *RDSDBInstance) buildModifyDBInstanceInput(ID string, dbInstanceDetails
 
 	if dbInstanceDetails.EngineVersion != "" && dbInstanceDetails.EngineVersion != oldDBInstanceDetails.EngineVersion {
 		modifyDBInstanceInput.EngineVersion = aws.String(dbInstanceDetails.EngineVersion)
-		modifyDBInstanceInput.AllowMajorVersionUpgrade = aws.Bool(r.allowMajorVersionUpgrade(dbInstanceDetails.EngineVersion, oldDBInstanceDetails.EngineVersion))
+		modifyDBInstanceInput.AllowMajorVersionUpgrade = aws.Bool(dbInstanceDetails.AllowMajorVersionUpgrade || r.allowMajorVersionUpgrade(dbInstanceDetails.EngineVersion, oldDBInstanceDetails.EngineVersion))
 	}
 
 	if dbInstanceDetails.MasterUserPassword != "" {
 		modifyDBInstanceInput.MasterUserPassword = aws.String(dbInstanceDetails.MasterUserPassword)
 	}
 
+	// The setting of DB instances in a DB cluster is the cluster's
+	if oldDBInstanceDetails.DBClusterIdentifier == "" {
+		modifyDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(dbInstanceDetails.IAMDatabaseAuthenticationEnabled)
+	}
+
 	modifyDBInstanceInput.MultiAZ = aws.Bool(dbInstanceDetails.MultiAZ)
 
 	if dbInstanceDetails.OptionGroupName != "" {
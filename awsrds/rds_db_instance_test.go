@@ -2,6 +2,7 @@ package awsrds_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -131,6 +132,34 @@ var _ = Describe("RDS DB Instance", func() {
 			})
 		})
 
+		Context("when RDS DB Instance has IAM database authentication", func() {
+			BeforeEach(func() {
+				describeDBInstance.DbiResourceId = aws.String("db-ABCDEFGHIJ")
+				describeDBInstance.IAMDatabaseAuthenticationEnabled = aws.Bool(true)
+				properDBInstanceDetails.DbiResourceID = "db-ABCDEFGHIJ"
+				properDBInstanceDetails.IAMDatabaseAuthenticationEnabled = true
+			})
+
+			It("returns the proper DB Instance", func() {
+				dbInstanceDetails, err := rdsDBInstance.Describe(dbInstanceIdentifier)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstanceDetails).To(Equal(properDBInstanceDetails))
+			})
+		})
+
+		Context("when RDS DB Instance has storage autoscaling", func() {
+			BeforeEach(func() {
+				describeDBInstance.MaxAllocatedStorage = aws.Int64(500)
+				properDBInstanceDetails.MaxAllocatedStorage = int64(500)
+			})
+
+			It("returns the proper DB Instance", func() {
+				dbInstanceDetails, err := rdsDBInstance.Describe(dbInstanceIdentifier)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstanceDetails).To(Equal(properDBInstanceDetails))
+			})
+		})
+
 		Context("when RDS DB Instance has pending modifications", func() {
 			BeforeEach(func() {
 				describeDBInstance.PendingModifiedValues = &rds.PendingModifiedValues{
@@ -212,13 +241,14 @@ var _ = Describe("RDS DB Instance", func() {
 			}
 
 			createDBInstanceInput = &rds.CreateDBInstanceInput{
-				DBInstanceIdentifier:    aws.String(dbInstanceIdentifier),
-				Engine:                  aws.String("test-engine"),
-				AutoMinorVersionUpgrade: aws.Bool(false),
-				CopyTagsToSnapshot:      aws.Bool(false),
-				MultiAZ:                 aws.Bool(false),
-				PubliclyAccessible:      aws.Bool(false),
-				StorageEncrypted:        aws.Bool(false),
+				DBInstanceIdentifier:            aws.String(dbInstanceIdentifier),
+				Engine:                          aws.String("test-engine"),
+				AutoMinorVersionUpgrade:         aws.Bool(false),
+				CopyTagsToSnapshot:              aws.Bool(false),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
+				MultiAZ:                         aws.Bool(false),
+				PubliclyAccessible:              aws.Bool(false),
+				StorageEncrypted:                aws.Bool(false),
 			}
 			createDBInstanceError = nil
 		})
@@ -252,6 +282,18 @@ var _ = Describe("RDS DB Instance", func() {
 			})
 		})
 
+		Context("when has MaxAllocatedStorage", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.MaxAllocatedStorage = 500
+				createDBInstanceInput.MaxAllocatedStorage = aws.Int64(500)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Create(dbInstanceIdentifier, dbInstanceDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has AutoMinorVersionUpgrade", func() {
 			BeforeEach(func() {
 				dbInstanceDetails.AutoMinorVersionUpgrade = true
@@ -312,10 +354,23 @@ var _ = Describe("RDS DB Instance", func() {
 			})
 		})
 
+		Context("when has IAMDatabaseAuthenticationEnabled", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.IAMDatabaseAuthenticationEnabled = true
+				createDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Create(dbInstanceIdentifier, dbInstanceDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has DBClusterIdentifier", func() {
 			BeforeEach(func() {
 				dbInstanceDetails.DBClusterIdentifier = "test-db-cluster-identifier"
 				createDBInstanceInput.DBClusterIdentifier = aws.String("test-db-cluster-identifier")
+				createDBInstanceInput.EnableIAMDatabaseAuthentication = nil
 			})
 
 			It("does not return error", func() {
@@ -643,11 +698,12 @@ var _ = Describe("RDS DB Instance", func() {
 			describeDBInstanceError = nil
 
 			modifyDBInstanceInput = &rds.ModifyDBInstanceInput{
-				DBInstanceIdentifier:    aws.String(dbInstanceIdentifier),
-				ApplyImmediately:        aws.Bool(applyImmediately),
-				AutoMinorVersionUpgrade: aws.Bool(false),
-				CopyTagsToSnapshot:      aws.Bool(false),
-				MultiAZ:                 aws.Bool(false),
+				DBInstanceIdentifier:            aws.String(dbInstanceIdentifier),
+				ApplyImmediately:                aws.Bool(applyImmediately),
+				AutoMinorVersionUpgrade:         aws.Bool(false),
+				CopyTagsToSnapshot:              aws.Bool(false),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
+				MultiAZ:                         aws.Bool(false),
 			}
 			modifyDBInstanceError = nil
 
@@ -742,6 +798,18 @@ var _ = Describe("RDS DB Instance", func() {
 			})
 		})
 
+		Context("when has MaxAllocatedStorage", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.MaxAllocatedStorage = 1000
+				modifyDBInstanceInput.MaxAllocatedStorage = aws.Int64(1000)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Modify(dbInstanceIdentifier, dbInstanceDetails, applyImmediately)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has AutoMinorVersionUpgrade", func() {
 			BeforeEach(func() {
 				dbInstanceDetails.AutoMinorVersionUpgrade = true
@@ -838,6 +906,44 @@ var _ = Describe("RDS DB Instance", func() {
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
+
+			Context("and major version upgrades are allowed", func() {
+				BeforeEach(func() {
+					dbInstanceDetails.EngineVersion = "10.1"
+					dbInstanceDetails.AllowMajorVersionUpgrade = true
+					modifyDBInstanceInput.EngineVersion = aws.String("10.1")
+					modifyDBInstanceInput.AllowMajorVersionUpgrade = aws.Bool(true)
+				})
+
+				It("does not return error", func() {
+					err := rdsDBInstance.Modify(dbInstanceIdentifier, dbInstanceDetails, applyImmediately)
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when has IAMDatabaseAuthenticationEnabled", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.IAMDatabaseAuthenticationEnabled = true
+				modifyDBInstanceInput.EnableIAMDatabaseAuthentication = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Modify(dbInstanceIdentifier, dbInstanceDetails, applyImmediately)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("and the DB Instance is in a DB Cluster", func() {
+				BeforeEach(func() {
+					describeDBInstance.DBClusterIdentifier = aws.String("test-db-cluster-identifier")
+					modifyDBInstanceInput.EnableIAMDatabaseAuthentication = nil
+				})
+
+				It("leaves the setting to the DB Cluster", func() {
+					err := rdsDBInstance.Modify(dbInstanceIdentifier, dbInstanceDetails, applyImmediately)
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
 		})
 
 		Context("when has MultiAZ", func() {
@@ -983,6 +1089,46 @@ var _ = Describe("RDS DB Instance", func() {
 		})
 	})
 
+	var _ = Describe("ModifyMasterPassword", func() {
+		var modifyDBInstanceError error
+
+		BeforeEach(func() {
+			modifyDBInstanceError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("ModifyDBInstance"))
+				Expect(r.Params).To(Equal(&rds.ModifyDBInstanceInput{
+					DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+					MasterUserPassword:   aws.String("new-password"),
+					ApplyImmediately:     aws.Bool(true),
+				}))
+				r.Error = modifyDBInstanceError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("only changes the master password", func() {
+			err := rdsDBInstance.ModifyMasterPassword(dbInstanceIdentifier, "new-password")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the DB Instance does not exist", func() {
+			BeforeEach(func() {
+				awsError := awserr.New("code", "message", errors.New("operation failed"))
+				modifyDBInstanceError = awserr.NewRequestFailure(awsError, 404, "request-id")
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.ModifyMasterPassword(dbInstanceIdentifier, "new-password")
+				Expect(err).To(Equal(ErrDBInstanceDoesNotExist))
+			})
+		})
+	})
+
 	var _ = Describe("Delete", func() {
 		var (
 			skipFinalSnapshot         bool
@@ -1070,4 +1216,569 @@ var _ = Describe("RDS DB Instance", func() {
 			})
 		})
 	})
+
+	var _ = Describe("DescribeSnapshot", func() {
+		var (
+			snapshotArn string
+
+			describeSnapshotsInput *rds.DescribeDBSnapshotsInput
+			describeSnapshotsError error
+			listTagsError          error
+		)
+
+		BeforeEach(func() {
+			snapshotArn = "arn:aws:rds:rds-region:account:snapshot:snapshot-id"
+			describeSnapshotsInput = &rds.DescribeDBSnapshotsInput{
+				DBSnapshotIdentifier: aws.String("snapshot-id"),
+			}
+			describeSnapshotsError = nil
+			listTagsError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				switch r.Operation.Name {
+				case "DescribeDBSnapshots":
+					Expect(r.Params).To(Equal(describeSnapshotsInput))
+					data := r.Data.(*rds.DescribeDBSnapshotsOutput)
+					data.DBSnapshots = []*rds.DBSnapshot{
+						&rds.DBSnapshot{
+							DBSnapshotIdentifier: aws.String("snapshot-id"),
+							DBSnapshotArn:        aws.String(snapshotArn),
+							DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+							Status:               aws.String("available"),
+							Engine:               aws.String("test-engine"),
+							MasterUsername:       aws.String("test-master-username"),
+						},
+					}
+					r.Error = describeSnapshotsError
+				case "ListTagsForResource":
+					Expect(r.Params).To(Equal(&rds.ListTagsForResourceInput{ResourceName: aws.String(snapshotArn)}))
+					data := r.Data.(*rds.ListTagsForResourceOutput)
+					data.TagList = []*rds.Tag{
+						&rds.Tag{Key: aws.String("Managed by"), Value: aws.String("github.com/AusDTO/pe-rds-broker")},
+					}
+					r.Error = listTagsError
+				default:
+					Fail("unexpected operation " + r.Operation.Name)
+				}
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the proper snapshot with its tags", func() {
+			snapshot, err := rdsDBInstance.DescribeSnapshot("snapshot-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.Identifier).To(Equal("snapshot-id"))
+			Expect(snapshot.Arn).To(Equal(snapshotArn))
+			Expect(snapshot.SourceIdentifier).To(Equal(dbInstanceIdentifier))
+			Expect(snapshot.Status).To(Equal("available"))
+			Expect(snapshot.Engine).To(Equal("test-engine"))
+			Expect(snapshot.MasterUsername).To(Equal("test-master-username"))
+			Expect(snapshot.Tags).To(Equal(map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"}))
+		})
+
+		Context("when the snapshot does not exist", func() {
+			BeforeEach(func() {
+				describeSnapshotsInput = &rds.DescribeDBSnapshotsInput{
+					DBSnapshotIdentifier: aws.String("unknown"),
+				}
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBInstance.DescribeSnapshot("unknown")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(ErrDBSnapshotDoesNotExist))
+			})
+		})
+
+		Context("when describing the snapshot fails", func() {
+			BeforeEach(func() {
+				describeSnapshotsError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBInstance.DescribeSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+
+		Context("when listing the tags fails", func() {
+			BeforeEach(func() {
+				listTagsError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBInstance.DescribeSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+		})
+	})
+
+	var _ = Describe("Restore", func() {
+		var (
+			dbInstanceDetails DBInstanceDetails
+
+			restoreInput *rds.RestoreDBInstanceFromDBSnapshotInput
+			restoreError error
+		)
+
+		BeforeEach(func() {
+			dbInstanceDetails = DBInstanceDetails{
+				Engine: "test-engine",
+			}
+
+			restoreInput = &rds.RestoreDBInstanceFromDBSnapshotInput{
+				DBInstanceIdentifier:            aws.String(dbInstanceIdentifier),
+				DBSnapshotIdentifier:            aws.String("snapshot-id"),
+				AutoMinorVersionUpgrade:         aws.Bool(false),
+				CopyTagsToSnapshot:              aws.Bool(false),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
+				MultiAZ:                         aws.Bool(false),
+				PubliclyAccessible:              aws.Bool(false),
+			}
+			restoreError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("RestoreDBInstanceFromDBSnapshot"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.RestoreDBInstanceFromDBSnapshotInput{}))
+				Expect(r.Params).To(Equal(restoreInput))
+				r.Error = restoreError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBInstance.Restore(dbInstanceIdentifier, "snapshot-id", dbInstanceDetails)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when has DBInstanceClass", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.DBInstanceClass = "db.m3.test"
+				restoreInput.DBInstanceClass = aws.String("db.m3.test")
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Restore(dbInstanceIdentifier, "snapshot-id", dbInstanceDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when has Tags", func() {
+			BeforeEach(func() {
+				dbInstanceDetails.Tags = map[string]string{"Owner": "Cloud Foundry"}
+				restoreInput.Tags = []*rds.Tag{
+					&rds.Tag{Key: aws.String("Owner"), Value: aws.String("Cloud Foundry")},
+				}
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.Restore(dbInstanceIdentifier, "snapshot-id", dbInstanceDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when restoring fails", func() {
+			BeforeEach(func() {
+				restoreError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.Restore(dbInstanceIdentifier, "snapshot-id", dbInstanceDetails)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("RestoreToPointInTime", func() {
+		var (
+			dbInstanceDetails DBInstanceDetails
+			restoreTime       time.Time
+
+			restoreInput *rds.RestoreDBInstanceToPointInTimeInput
+			restoreError error
+		)
+
+		BeforeEach(func() {
+			dbInstanceDetails = DBInstanceDetails{}
+			restoreTime = time.Date(2017, 11, 1, 10, 20, 30, 0, time.UTC)
+
+			restoreInput = &rds.RestoreDBInstanceToPointInTimeInput{
+				TargetDBInstanceIdentifier:      aws.String(dbInstanceIdentifier),
+				SourceDBInstanceIdentifier:      aws.String("source-id"),
+				AutoMinorVersionUpgrade:         aws.Bool(false),
+				CopyTagsToSnapshot:              aws.Bool(false),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
+				MultiAZ:                         aws.Bool(false),
+				PubliclyAccessible:              aws.Bool(false),
+				RestoreTime:                     aws.Time(restoreTime),
+			}
+			restoreError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("RestoreDBInstanceToPointInTime"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.RestoreDBInstanceToPointInTimeInput{}))
+				Expect(r.Params).To(Equal(restoreInput))
+				r.Error = restoreError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBInstance.RestoreToPointInTime(dbInstanceIdentifier, "source-id", restoreTime, dbInstanceDetails)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when restoring to the latest restorable time", func() {
+			BeforeEach(func() {
+				restoreTime = time.Time{}
+				restoreInput.RestoreTime = nil
+				restoreInput.UseLatestRestorableTime = aws.Bool(true)
+			})
+
+			It("does not return error", func() {
+				err := rdsDBInstance.RestoreToPointInTime(dbInstanceIdentifier, "source-id", restoreTime, dbInstanceDetails)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when restoring fails", func() {
+			BeforeEach(func() {
+				restoreError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.RestoreToPointInTime(dbInstanceIdentifier, "source-id", restoreTime, dbInstanceDetails)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("CreateReadReplica", func() {
+		var (
+			dbInstanceDetails DBInstanceDetails
+
+			createReadReplicaInput *rds.CreateDBInstanceReadReplicaInput
+			createReadReplicaError error
+		)
+
+		BeforeEach(func() {
+			dbInstanceDetails = DBInstanceDetails{
+				DBInstanceClass: "db.m3.small",
+				Tags:            map[string]string{"Owner": "Cloud Foundry"},
+			}
+
+			createReadReplicaInput = &rds.CreateDBInstanceReadReplicaInput{
+				DBInstanceIdentifier:            aws.String(dbInstanceIdentifier + "-replica-1"),
+				SourceDBInstanceIdentifier:      aws.String(dbInstanceIdentifier),
+				DBInstanceClass:                 aws.String("db.m3.small"),
+				AutoMinorVersionUpgrade:         aws.Bool(false),
+				CopyTagsToSnapshot:              aws.Bool(false),
+				EnableIAMDatabaseAuthentication: aws.Bool(false),
+				PubliclyAccessible:              aws.Bool(false),
+				Tags: []*rds.Tag{
+					&rds.Tag{Key: aws.String("Owner"), Value: aws.String("Cloud Foundry")},
+				},
+			}
+			createReadReplicaError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("CreateDBInstanceReadReplica"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.CreateDBInstanceReadReplicaInput{}))
+				Expect(r.Params).To(Equal(createReadReplicaInput))
+				r.Error = createReadReplicaError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBInstance.CreateReadReplica(dbInstanceIdentifier+"-replica-1", dbInstanceIdentifier, dbInstanceDetails)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when creating the read replica fails", func() {
+			BeforeEach(func() {
+				createReadReplicaError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.CreateReadReplica(dbInstanceIdentifier+"-replica-1", dbInstanceIdentifier, dbInstanceDetails)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("and it is an AWS error", func() {
+				BeforeEach(func() {
+					createReadReplicaError = awserr.New("code", "message", errors.New("operation failed"))
+				})
+
+				It("returns the proper error", func() {
+					err := rdsDBInstance.CreateReadReplica(dbInstanceIdentifier+"-replica-1", dbInstanceIdentifier, dbInstanceDetails)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("code: message"))
+				})
+			})
+
+			Context("and the source DB Instance does not exist", func() {
+				BeforeEach(func() {
+					createReadReplicaError = awserr.NewRequestFailure(awserr.New("code", "message", errors.New("operation failed")), 404, "request-id")
+				})
+
+				It("returns the proper error", func() {
+					err := rdsDBInstance.CreateReadReplica(dbInstanceIdentifier+"-replica-1", dbInstanceIdentifier, dbInstanceDetails)
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(ErrDBInstanceDoesNotExist))
+				})
+			})
+		})
+	})
+
+	var _ = Describe("ListTags", func() {
+		var listTagsForResourceInput *rds.ListTagsForResourceInput
+
+		BeforeEach(func() {
+			listTagsForResourceInput = &rds.ListTagsForResourceInput{
+				ResourceName: aws.String(dbInstanceArn),
+			}
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				switch r.Operation.Name {
+				case "DescribeDBInstances":
+					data := r.Data.(*rds.DescribeDBInstancesOutput)
+					data.DBInstances = []*rds.DBInstance{
+						&rds.DBInstance{
+							DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+							DBInstanceArn:        aws.String(dbInstanceArn),
+						},
+					}
+				case "ListTagsForResource":
+					Expect(r.Params).To(Equal(listTagsForResourceInput))
+					data := r.Data.(*rds.ListTagsForResourceOutput)
+					data.TagList = []*rds.Tag{
+						&rds.Tag{Key: aws.String("Organization ID"), Value: aws.String("organization-id")},
+					}
+				default:
+					Fail("unexpected operation " + r.Operation.Name)
+				}
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the tags of the DB Instance", func() {
+			tags, err := rdsDBInstance.ListTags(dbInstanceIdentifier)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(Equal(map[string]string{"Organization ID": "organization-id"}))
+		})
+
+		Context("when the DB Instance does not exist", func() {
+			It("returns the proper error", func() {
+				_, err := rdsDBInstance.ListTags("unknown")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(ErrDBInstanceDoesNotExist))
+			})
+		})
+	})
+
+	var _ = Describe("CreateSnapshot", func() {
+		var (
+			tags map[string]string
+
+			createSnapshotInput *rds.CreateDBSnapshotInput
+			createSnapshotError error
+		)
+
+		BeforeEach(func() {
+			tags = map[string]string{"Owner": "Cloud Foundry"}
+
+			createSnapshotInput = &rds.CreateDBSnapshotInput{
+				DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+				DBSnapshotIdentifier: aws.String("snapshot-id"),
+				Tags: []*rds.Tag{
+					&rds.Tag{Key: aws.String("Owner"), Value: aws.String("Cloud Foundry")},
+				},
+			}
+			createSnapshotError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("CreateDBSnapshot"))
+				Expect(r.Params).To(BeAssignableToTypeOf(&rds.CreateDBSnapshotInput{}))
+				Expect(r.Params).To(Equal(createSnapshotInput))
+				r.Error = createSnapshotError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBInstance.CreateSnapshot(dbInstanceIdentifier, "snapshot-id", tags)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when creating the snapshot fails", func() {
+			BeforeEach(func() {
+				createSnapshotError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.CreateSnapshot(dbInstanceIdentifier, "snapshot-id", tags)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+	var _ = Describe("ListSnapshots", func() {
+		var (
+			snapshotArn string
+
+			describeSnapshotsError error
+		)
+
+		BeforeEach(func() {
+			snapshotArn = "arn:aws:rds:rds-region:account:snapshot:snapshot-id"
+			describeSnapshotsError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				switch r.Operation.Name {
+				case "DescribeDBSnapshots":
+					Expect(r.Params).To(Equal(&rds.DescribeDBSnapshotsInput{
+						DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+						SnapshotType:         aws.String("manual"),
+					}))
+					data := r.Data.(*rds.DescribeDBSnapshotsOutput)
+					data.DBSnapshots = []*rds.DBSnapshot{
+						&rds.DBSnapshot{
+							DBSnapshotIdentifier: aws.String("snapshot-id"),
+							DBSnapshotArn:        aws.String(snapshotArn),
+							DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+							Status:               aws.String("available"),
+						},
+					}
+					r.Error = describeSnapshotsError
+				case "ListTagsForResource":
+					Expect(r.Params).To(Equal(&rds.ListTagsForResourceInput{ResourceName: aws.String(snapshotArn)}))
+					data := r.Data.(*rds.ListTagsForResourceOutput)
+					data.TagList = []*rds.Tag{
+						&rds.Tag{Key: aws.String("Managed by"), Value: aws.String("github.com/AusDTO/pe-rds-broker")},
+					}
+				default:
+					Fail("unexpected operation " + r.Operation.Name)
+				}
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the snapshots of the DB Instance with their tags", func() {
+			snapshots, err := rdsDBInstance.ListSnapshots(dbInstanceIdentifier)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].Identifier).To(Equal("snapshot-id"))
+			Expect(snapshots[0].SourceIdentifier).To(Equal(dbInstanceIdentifier))
+			Expect(snapshots[0].Tags).To(Equal(map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"}))
+		})
+
+		Context("when describing the snapshots fails", func() {
+			BeforeEach(func() {
+				describeSnapshotsError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				_, err := rdsDBInstance.ListSnapshots(dbInstanceIdentifier)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
+
+	var _ = Describe("DeleteSnapshot", func() {
+		var (
+			deleteSnapshotError error
+		)
+
+		BeforeEach(func() {
+			deleteSnapshotError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("DeleteDBSnapshot"))
+				Expect(r.Params).To(Equal(&rds.DeleteDBSnapshotInput{DBSnapshotIdentifier: aws.String("snapshot-id")}))
+				r.Error = deleteSnapshotError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("does not return error", func() {
+			err := rdsDBInstance.DeleteSnapshot("snapshot-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when deleting the snapshot fails", func() {
+			BeforeEach(func() {
+				deleteSnapshotError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				err := rdsDBInstance.DeleteSnapshot("snapshot-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("and it is an AWS RDS error", func() {
+				BeforeEach(func() {
+					deleteSnapshotError = awserr.New("code", "message", errors.New("operation failed"))
+				})
+
+				It("returns the proper error", func() {
+					err := rdsDBInstance.DeleteSnapshot("snapshot-id")
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("code: message"))
+				})
+			})
+
+			Context("and it is a 404 error", func() {
+				BeforeEach(func() {
+					awsError := awserr.New("code", "message", errors.New("operation failed"))
+					deleteSnapshotError = awserr.NewRequestFailure(awsError, 404, "request-id")
+				})
+
+				It("returns the proper error", func() {
+					err := rdsDBInstance.DeleteSnapshot("snapshot-id")
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(ErrDBSnapshotDoesNotExist))
+				})
+			})
+		})
+	})
 })
@@ -40,3 +40,29 @@ func AddTagsToResource(resourceARN string, tags []*rds.Tag, rdssvc *rds.RDS, log
 
 	return nil
 }
+
+func ListTagsForResource(resourceARN string, rdssvc *rds.RDS, logger lager.Logger) (map[string]string, error) {
+	listTagsForResourceInput := &rds.ListTagsForResourceInput{
+		ResourceName: aws.String(resourceARN),
+	}
+
+	logger.Debug("list-tags-for-resource", lager.Data{"input": listTagsForResourceInput})
+
+	listTagsForResourceOutput, err := rdssvc.ListTagsForResource(listTagsForResourceInput)
+	if err != nil {
+		logger.Error("aws-rds-error", err)
+		if awsErr, ok := err.(awserr.Error); ok {
+			return nil, errors.New(awsErr.Code() + ": " + awsErr.Message())
+		}
+		return nil, err
+	}
+
+	logger.Debug("list-tags-for-resource", lager.Data{"output": listTagsForResourceOutput})
+
+	tags := make(map[string]string)
+	for _, tag := range listTagsForResourceOutput.TagList {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return tags, nil
+}
@@ -126,4 +126,53 @@ var _ = Describe("RDS Utils", func() {
 			})
 		})
 	})
+
+	var _ = Describe("ListTagsForResource", func() {
+		var (
+			resourceARN string
+
+			listTagsForResourceError error
+		)
+
+		BeforeEach(func() {
+			resourceARN = "arn:aws:rds:rds-region:account:snapshot:identifier"
+			listTagsForResourceError = nil
+		})
+
+		JustBeforeEach(func() {
+			rdssvc.Handlers.Clear()
+
+			rdsCall = func(r *request.Request) {
+				Expect(r.Operation.Name).To(Equal("ListTagsForResource"))
+				Expect(r.Params).To(Equal(&rds.ListTagsForResourceInput{ResourceName: aws.String(resourceARN)}))
+				data := r.Data.(*rds.ListTagsForResourceOutput)
+				data.TagList = []*rds.Tag{
+					&rds.Tag{
+						Key:   aws.String("Owner"),
+						Value: aws.String("Cloud Foundry"),
+					},
+				}
+				r.Error = listTagsForResourceError
+			}
+			rdssvc.Handlers.Send.PushBack(rdsCall)
+		})
+
+		It("returns the tags as a map", func() {
+			tags, err := ListTagsForResource(resourceARN, rdssvc, logger)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(Equal(map[string]string{"Owner": "Cloud Foundry"}))
+		})
+
+		Context("when listing the tags fails with an AWS error", func() {
+			BeforeEach(func() {
+				listTagsForResourceError = awserr.New("code", "message", errors.New("operation failed"))
+			})
+
+			It("returns the proper error", func() {
+				_, err := ListTagsForResource(resourceARN, rdssvc, logger)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("code: message"))
+			})
+		})
+	})
 })
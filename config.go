@@ -34,6 +34,14 @@ func LoadConfig(envVars *cfcommon.EnvVars, configFile string) (config *Config, e
 		return nil, err
 	}
 
+	if config.RDSConfig.CACertificateFile != "" {
+		caCertificate, err := ioutil.ReadFile(config.RDSConfig.CACertificateFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RDSConfig.CACertificate = string(caCertificate)
+	}
+
 	config.RDSConfig.DBPrefix = envVars.MustString("DB_PREFIX")
 	for _, service := range config.RDSConfig.Catalog.Services {
 		for idx, sp := range service.Plans {
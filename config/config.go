@@ -28,6 +28,9 @@ type DBConfig struct {
 	DBName   string
 	Sslmode  SSLMode
 	Port     int64
+
+	// PEM file of the CA certificates to verify the server against with verify-full, the system ones are used without it
+	CACertificateFile string
 }
 
 type EnvConfig struct {
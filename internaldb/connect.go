@@ -49,7 +49,7 @@ func DBInit(dbConfig *config.DBConfig, logger lager.Logger) (*gorm.DB, error) {
 }
 
 func migrate(db *gorm.DB, dbConfig *config.DBConfig, logger lager.Logger) {
-	db.AutoMigrate(&DBInstance{}, &DBUser{}, &DBBinding{})
+	db.AutoMigrate(&DBInstance{}, &DBUser{}, &DBBinding{}, &DBOperation{}, &DBSnapshot{}, &DBReplica{})
 	// AutoMigrate does not handle FK contraints, nor does sqlite
 	if dbConfig.DBType == "postgres" {
 		err := db.Model(&DBUser{}).AddForeignKey(
@@ -70,5 +70,23 @@ func migrate(db *gorm.DB, dbConfig *config.DBConfig, logger lager.Logger) {
 		if err != nil {
 			logger.Error("add-fk", err)
 		}
+		err = db.Model(&DBOperation{}).AddForeignKey(
+			"db_instance_id",
+			"db_instances(id)",
+			"CASCADE",
+			"RESTRICT",
+		).Error
+		if err != nil {
+			logger.Error("add-fk", err)
+		}
+		err = db.Model(&DBReplica{}).AddForeignKey(
+			"db_instance_id",
+			"db_instances(id)",
+			"CASCADE",
+			"RESTRICT",
+		).Error
+		if err != nil {
+			logger.Error("add-fk", err)
+		}
 	}
 }
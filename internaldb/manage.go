@@ -5,6 +5,8 @@ import (
 
 	"code.cloudfoundry.org/lager"
 	"github.com/jinzhu/gorm"
+
+	"github.com/AusDTO/pe-rds-broker/utils"
 )
 
 func RotateKey(db *gorm.DB, old_key, new_key []byte, logger lager.Logger, failFast bool) error {
@@ -25,6 +27,22 @@ func RotateKey(db *gorm.DB, old_key, new_key []byte, logger lager.Logger, failFa
 			logger.Error("encrypt-password", err, lager.Data{"instance": instance.InstanceID, "user": user.Username})
 			return err
 		}
+		// A master password rotation may be part way through
+		pending, err := user.PendingPassword(old_key)
+		if err != nil {
+			logger.Error("decrypt-pending-password", err, lager.Data{"instance": instance.InstanceID, "user": user.Username})
+			return err
+		}
+		if pending != "" {
+			user.PendingIV, err = utils.RandIV()
+			if err == nil {
+				user.PendingEncryptedPassword, err = utils.Encrypt(pending, new_key, user.PendingIV)
+			}
+			if err != nil {
+				logger.Error("encrypt-pending-password", err, lager.Data{"instance": instance.InstanceID, "user": user.Username})
+				return err
+			}
+		}
 		err = db.Save(&user).Error
 		if err != nil {
 			logger.Error("save-password", err, lager.Data{"instance": instance.InstanceID, "user": user.Username})
@@ -21,7 +21,18 @@ type DBInstance struct {
 	DBName     string
 	ServiceID  string
 	PlanID     string
+	State      InstanceState
 	Users      []DBUser
+	// Where the instance was provisioned, so clones can be limited to the same organization.
+	// Empty for instances provisioned before this was recorded.
+	OrganizationID string
+	SpaceID        string
+	// Read replicas are created once the instance is available so the number wanted is kept
+	// alongside the ones which exist
+	ReadReplicaCount int64
+	Replicas         []DBReplica
+	// Set once the instance has been upgraded past the engine version of its plan
+	EngineVersion string
 }
 
 type DBUser struct {
@@ -35,6 +46,11 @@ type DBUser struct {
 	IV                []byte
 	Type              DBUserType
 	Bindings          []DBBinding
+	// A new master password is kept apart until RDS has finished resetting to it
+	PendingEncryptedPassword []byte
+	PendingIV                []byte
+	// When the password was last rotated, nil if it never has been
+	PasswordRotatedAt *time.Time
 }
 
 type DBBinding struct {
@@ -46,12 +62,25 @@ type DBBinding struct {
 	DBUserID  uint64
 }
 
+// Instances are saved as pending before any resources are created and only become active
+// once provisioning has succeeded. Instances saved before this was tracked have an empty state
+// and are treated as active.
+type InstanceState string
+
+const (
+	InstancePending InstanceState = "pending"
+	InstanceActive  InstanceState = "active"
+)
+
 type DBUserType string
 
 const (
 	Master    DBUserType = "master"
 	SuperUser DBUserType = "superuser"
 	Standard  DBUserType = "standard"
+	ReadOnly  DBUserType = "read_only"
+	// Full access users which log in with an IAM authentication token, their password is never used
+	IAM DBUserType = "iam"
 )
 
 // Remember to DB.Save() from the caller
@@ -60,6 +89,7 @@ func NewInstance(serviceID, planID, instanceID, dbPrefix string, key []byte) (*D
 		ServiceID:  serviceID,
 		PlanID:     planID,
 		InstanceID: instanceID,
+		State:      InstancePending,
 		Users:      make([]DBUser, 1),
 		DBName:     fmt.Sprintf("%s_%s", dbPrefix, strings.Replace(instanceID, "-", "_", -1)),
 	}
@@ -74,13 +104,45 @@ func NewInstance(serviceID, planID, instanceID, dbPrefix string, key []byte) (*D
 // Use this wrapper so we always preload the users
 func FindInstance(db *gorm.DB, instanceID string) *DBInstance {
 	var instance DBInstance
-	err := db.Where(&DBInstance{InstanceID: instanceID}).Preload("Users.Bindings").First(&instance).Error
+	err := db.Where(&DBInstance{InstanceID: instanceID}).Preload("Users.Bindings").Preload("Replicas").First(&instance).Error
 	if err != nil {
 		return nil
 	}
 	return &instance
 }
 
+// InstanceIDs are the IDs of every instance, including ones still being provisioned
+func InstanceIDs(db *gorm.DB) ([]string, error) {
+	var instanceIDs []string
+	err := db.Model(&DBInstance{}).Pluck("instance_id", &instanceIDs).Error
+	return instanceIDs, err
+}
+
+// MasterPasswordsRotatedBefore returns the instances whose master password was last rotated, or first set,
+// before the given time
+func MasterPasswordsRotatedBefore(db *gorm.DB, before time.Time) ([]DBInstance, error) {
+	var instances []DBInstance
+	err := db.Select("db_instances.*").
+		Joins("JOIN db_users ON db_users.db_instance_id = db_instances.id").
+		Where("db_users.type = ? AND COALESCE(db_users.password_rotated_at, db_users.created_at) < ?", string(Master), before).
+		Order("db_instances.id").
+		Find(&instances).Error
+	return instances, err
+}
+
+func (i *DBInstance) Activate(db *gorm.DB) error {
+	return db.Model(i).Update("state", InstanceActive).Error
+}
+
+// SetEngineVersion records the engine version the instance has been upgraded to
+func (i *DBInstance) SetEngineVersion(db *gorm.DB, version string) error {
+	return db.Model(i).Update("engine_version", version).Error
+}
+
+func (i *DBInstance) IsActive() bool {
+	return i.State == InstanceActive || i.State == ""
+}
+
 func (i *DBInstance) Bind(db *gorm.DB, bindingID, username string, userType DBUserType, key []byte) (user DBUser, new bool, err error) {
 	current_user := i.User(username)
 	if current_user == nil {
@@ -149,6 +211,14 @@ func (i *DBInstance) Delete(db *gorm.DB) error {
 			return err
 		}
 	}
+	err = db.Where("db_instance_id = ?", i.ID).Delete(DBOperation{}).Error
+	if err != nil {
+		return err
+	}
+	err = db.Where("db_instance_id = ?", i.ID).Delete(DBReplica{}).Error
+	if err != nil {
+		return err
+	}
 	return db.Delete(i).Error
 }
 
@@ -189,6 +259,66 @@ func (u *DBUser) Password(key []byte) (string, error) {
 	return utils.Decrypt(u.EncryptedPassword, key, u.IV)
 }
 
+// SetPendingPassword generates a new password which replaces the current one once CommitPendingPassword
+// is called. Until then Password still returns the current one.
+func (u *DBUser) SetPendingPassword(db *gorm.DB, key []byte) (string, error) {
+	password, err := utils.RandPassword()
+	if err != nil {
+		return "", err
+	}
+	iv, err := utils.RandIV()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := utils.Encrypt(password, key, iv)
+	if err != nil {
+		return "", err
+	}
+	err = db.Model(u).Updates(map[string]interface{}{"pending_encrypted_password": encrypted, "pending_iv": iv}).Error
+	return password, err
+}
+
+// PendingPassword returns the password set by SetPendingPassword, or an empty string if there isn't one
+func (u *DBUser) PendingPassword(key []byte) (string, error) {
+	if len(u.PendingEncryptedPassword) == 0 {
+		return "", nil
+	}
+	return utils.Decrypt(u.PendingEncryptedPassword, key, u.PendingIV)
+}
+
+// CommitPendingPassword makes the pending password the current one in a single update
+func (u *DBUser) CommitPendingPassword(db *gorm.DB) error {
+	if len(u.PendingEncryptedPassword) == 0 {
+		return errors.New("No pending password")
+	}
+	now := time.Now()
+	return db.Model(u).Updates(map[string]interface{}{
+		"encrypted_password":         u.PendingEncryptedPassword,
+		"iv":                         u.PendingIV,
+		"pending_encrypted_password": []byte{},
+		"pending_iv":                 []byte{},
+		"password_rotated_at":        &now,
+	}).Error
+}
+
+// ClearPendingPassword drops the pending password, keeping the current one
+func (u *DBUser) ClearPendingPassword(db *gorm.DB) error {
+	return db.Model(u).Updates(map[string]interface{}{"pending_encrypted_password": []byte{}, "pending_iv": []byte{}}).Error
+}
+
+// SavePasswords saves the passwords of users in one transaction so either all of them change or none do
+func SavePasswords(db *gorm.DB, users []DBUser) error {
+	tx := db.Begin()
+	for _, user := range users {
+		err := tx.Model(&user).Updates(map[string]interface{}{"encrypted_password": user.EncryptedPassword, "iv": user.IV}).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
 // We currently preload all users from the database and do the search
 // in go code. We could also load the ones we need on demand using appropriate
 // database queries. Given the number of users is expected to be small
@@ -202,9 +332,18 @@ func (i *DBInstance) MasterUser() *DBUser {
 	return nil
 }
 
+// Instances restored from a snapshot keep the master username of the snapshot
+func (i *DBInstance) SetMasterUsername(username string) {
+	for idx := range i.Users {
+		if i.Users[idx].Type == Master {
+			i.Users[idx].Username = username
+		}
+	}
+}
+
 func (i *DBInstance) BindingUser(bindingID string) (*DBUser, *DBBinding) {
 	for _, user := range i.Users {
-		if user.Type == Standard {
+		if user.Type == Standard || user.Type == ReadOnly || user.Type == IAM {
 			for _, binding := range user.Bindings {
 				if binding.BindingID == bindingID {
 					return &user, &binding
@@ -224,6 +363,14 @@ func (i *DBInstance) User(username string) *DBUser {
 	return nil
 }
 
+// FindUsers finds the users called username across every instance. Instances on a shared server
+// have their users on the same server so their names can't clash.
+func FindUsers(db *gorm.DB, username string) ([]DBUser, error) {
+	var users []DBUser
+	err := db.Where(&DBUser{Username: username}).Find(&users).Error
+	return users, err
+}
+
 func (i *DBInstance) UnderscoreID() string {
 	return strings.Replace(i.InstanceID, "-", "_", -1)
 }
@@ -238,3 +385,21 @@ func (u *DBUserType) Scan(value interface{}) error {
 func (u DBUserType) Value() (driver.Value, error) {
 	return string(u), nil
 }
+
+// Unlike the other custom types, this column was added after the table
+// so existing rows will be NULL
+func (s *InstanceState) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = InstanceState(v)
+	case string:
+		*s = InstanceState(v)
+	default:
+		*s = ""
+	}
+	return nil
+}
+
+func (s InstanceState) Value() (driver.Value, error) {
+	return string(s), nil
+}
@@ -3,6 +3,13 @@ package internaldb_test
 import (
 	. "github.com/AusDTO/pe-rds-broker/internaldb"
 
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/AusDTO/pe-rds-broker/config"
+	"github.com/jinzhu/gorm"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -27,12 +34,38 @@ var _ = Describe("Models", func() {
 			Expect(instance.InstanceID).To(Equal("instance-id"))
 		})
 
+		It("starts pending", func() {
+			instance, err := NewInstance(serviceID, planID, instanceID, dbPrefix, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instance.State).To(Equal(InstancePending))
+			Expect(instance.IsActive()).To(BeFalse())
+		})
+
 		It("errors with bad encryption key", func() {
 			_, err := NewInstance(serviceID, planID, instanceID, dbPrefix, make([]byte, 3))
 			Expect(err).To(HaveOccurred())
 		})
 	})
 
+	Describe("SetMasterUsername", func() {
+		It("only renames the master user", func() {
+			instance := DBInstance{Users: []DBUser{{Type: Standard, Username: "bind"}, {Type: Master, Username: "old"}}}
+			instance.SetMasterUsername("new")
+			Expect(instance.MasterUser().Username).To(Equal("new"))
+			Expect(instance.Users[0].Username).To(Equal("bind"))
+		})
+	})
+
+	Describe("IsActive", func() {
+		It("treats instances saved before states were tracked as active", func() {
+			Expect((&DBInstance{}).IsActive()).To(BeTrue())
+		})
+
+		It("is true for active instances", func() {
+			Expect((&DBInstance{State: InstanceActive}).IsActive()).To(BeTrue())
+		})
+	})
+
 	Describe("NewUser", func() {
 		Context("has random", func() {
 			var (
@@ -124,4 +157,84 @@ var _ = Describe("Models", func() {
 			})
 		})
 	})
+
+	Describe("InstanceIDs", func() {
+		It("includes pending instances", func() {
+			logger := lager.NewLogger("models_test")
+			logger.RegisterSink(lagertest.NewTestSink())
+			os.Remove("/tmp/test.sqlite3")
+			db, err := DBInit(&config.DBConfig{DBType: "sqlite3", DBName: "/tmp/test.sqlite3"}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			for _, id := range []string{"instance-1", "instance-2"} {
+				instance, err := NewInstance(serviceID, planID, id, dbPrefix, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(db.Save(instance).Error).NotTo(HaveOccurred())
+			}
+
+			Expect(InstanceIDs(db)).To(ConsistOf("instance-1", "instance-2"))
+		})
+	})
+
+	Describe("master password rotation", func() {
+		var (
+			db       *gorm.DB
+			instance *DBInstance
+		)
+
+		BeforeEach(func() {
+			logger := lager.NewLogger("models_test")
+			logger.RegisterSink(lagertest.NewTestSink())
+			var err error
+			os.Remove("/tmp/test.sqlite3")
+			db, err = DBInit(&config.DBConfig{DBType: "sqlite3", DBName: "/tmp/test.sqlite3"}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			instance, err = NewInstance(serviceID, planID, instanceID, dbPrefix, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(db.Save(instance).Error).NotTo(HaveOccurred())
+		})
+
+		masterUser := func() *DBUser {
+			return FindInstance(db, instanceID).MasterUser()
+		}
+
+		It("keeps the current password until the pending one is committed", func() {
+			oldPassword, err := masterUser().Password(encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			newPassword, err := masterUser().SetPendingPassword(db, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newPassword).NotTo(Equal(oldPassword))
+			Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+			Expect(masterUser().PendingPassword(encryptionKey)).To(Equal(newPassword))
+
+			Expect(masterUser().CommitPendingPassword(db)).To(Succeed())
+			Expect(masterUser().Password(encryptionKey)).To(Equal(newPassword))
+			Expect(masterUser().PendingPassword(encryptionKey)).To(BeEmpty())
+			Expect(masterUser().PasswordRotatedAt).NotTo(BeNil())
+		})
+
+		It("drops a cleared pending password", func() {
+			oldPassword, err := masterUser().Password(encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = masterUser().SetPendingPassword(db, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(masterUser().ClearPendingPassword(db)).To(Succeed())
+			Expect(masterUser().PendingPassword(encryptionKey)).To(BeEmpty())
+			Expect(masterUser().CommitPendingPassword(db)).NotTo(Succeed())
+			Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+		})
+
+		It("finds the instances with old master passwords", func() {
+			instances, err := MasterPasswordsRotatedBefore(db, time.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(HaveLen(1))
+			Expect(instances[0].InstanceID).To(Equal(instanceID))
+
+			_, err = masterUser().SetPendingPassword(db, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(masterUser().CommitPendingPassword(db)).To(Succeed())
+			instances, err = MasterPasswordsRotatedBefore(db, time.Now().Add(-time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,175 @@
+package internaldb
+
+import (
+	"database/sql/driver"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DBOperation records an asynchronous (or synchronous) change made to an instance
+// so that LastOperation can report on that specific change rather than guessing
+// from the current RDS status.
+type DBOperation struct {
+	// Managed by gorm
+	ID        uint64 `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Managed by us
+	DBInstanceID uint64
+	Type         OperationType
+	State        OperationState
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+	PlanID       string
+	Parameters   string
+	Description  string
+	// Operations that need more than one call to RDS record which step they are up to
+	Stage string
+	// When the operation got to its stage, which is NULL for operations recorded before it was added
+	StageStartedAt *time.Time
+}
+
+type OperationType string
+
+const (
+	ProvisionOperation   OperationType = "provision"
+	UpdateOperation      OperationType = "update"
+	DeprovisionOperation OperationType = "deprovision"
+	// Engine version upgrades are updates which are reported on separately
+	UpgradeOperation OperationType = "upgrade"
+)
+
+// These deliberately match the values of brokerapi.LastOperationState
+type OperationState string
+
+const (
+	OperationInProgress OperationState = "in progress"
+	OperationSucceeded  OperationState = "succeeded"
+	OperationFailed     OperationState = "failed"
+)
+
+// Remember to DB.Save() from the caller
+func (i *DBInstance) NewOperation(operationType OperationType, planID string, parameters []byte) *DBOperation {
+	now := time.Now()
+	return &DBOperation{
+		DBInstanceID:   i.ID,
+		Type:           operationType,
+		State:          OperationInProgress,
+		StartedAt:      now,
+		PlanID:         planID,
+		Parameters:     string(parameters),
+		StageStartedAt: &now,
+	}
+}
+
+// FindOperation looks up an operation of the given instance using the
+// operation data previously returned to the cloud controller.
+func FindOperation(db *gorm.DB, instance *DBInstance, operationData string) *DBOperation {
+	id, err := strconv.ParseUint(operationData, 10, 64)
+	if err != nil {
+		return nil
+	}
+	var operation DBOperation
+	err = db.Where("id = ? AND db_instance_id = ?", id, instance.ID).First(&operation).Error
+	if err != nil {
+		return nil
+	}
+	return &operation
+}
+
+// LatestOperation returns the most recently started operation of the given instance
+func LatestOperation(db *gorm.DB, instance *DBInstance) *DBOperation {
+	var operation DBOperation
+	err := db.Where("db_instance_id = ?", instance.ID).Last(&operation).Error
+	if err != nil {
+		return nil
+	}
+	return &operation
+}
+
+// InProgressOperations returns the unfinished operations at the given stage along with the IDs of their
+// instances, for operations the broker finishes itself rather than waiting for the cloud controller to ask
+func InProgressOperations(db *gorm.DB, stage string) (map[string]DBOperation, error) {
+	var rows []struct {
+		DBOperation
+		InstanceID string
+	}
+	err := db.Table("db_operations").
+		Select("db_operations.*, db_instances.instance_id").
+		Joins("JOIN db_instances ON db_instances.id = db_operations.db_instance_id").
+		Where("db_operations.state = ? AND db_operations.stage = ?", string(OperationInProgress), stage).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	operations := map[string]DBOperation{}
+	for _, row := range rows {
+		operations[row.InstanceID] = row.DBOperation
+	}
+	return operations, nil
+}
+
+func (o *DBOperation) OperationData() string {
+	return strconv.FormatUint(o.ID, 10)
+}
+
+func (o *DBOperation) Finished() bool {
+	return o.State != OperationInProgress
+}
+
+func (o *DBOperation) SetStage(db *gorm.DB, stage string) error {
+	return db.Model(o).Updates(map[string]interface{}{"stage": stage, "stage_started_at": time.Now()}).Error
+}
+
+// ClaimStage moves an unfinished operation from one stage to another unless something else has moved it
+// on first, so only one caller goes on to do the work of the new stage
+func (o *DBOperation) ClaimStage(db *gorm.DB, from string, to string) (bool, error) {
+	now := time.Now()
+	result := db.Model(&DBOperation{}).
+		Where("id = ? AND stage = ? AND state = ?", o.ID, from, string(OperationInProgress)).
+		Updates(map[string]interface{}{"stage": to, "stage_started_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	o.Stage = to
+	o.StageStartedAt = &now
+	return true, nil
+}
+
+func (o *DBOperation) Succeed(db *gorm.DB, description string) error {
+	return o.finish(db, OperationSucceeded, description)
+}
+
+func (o *DBOperation) Fail(db *gorm.DB, description string) error {
+	return o.finish(db, OperationFailed, description)
+}
+
+func (o *DBOperation) finish(db *gorm.DB, state OperationState, description string) error {
+	now := time.Now()
+	o.State = state
+	o.Description = description
+	o.FinishedAt = &now
+	return db.Save(o).Error
+}
+
+// Ensure custom string types work with gorm/sql
+// https://github.com/jinzhu/gorm/issues/302
+func (t *OperationType) Scan(value interface{}) error {
+	*t = OperationType(value.([]byte))
+	return nil
+}
+
+func (t OperationType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+func (s *OperationState) Scan(value interface{}) error {
+	*s = OperationState(value.([]byte))
+	return nil
+}
+
+func (s OperationState) Value() (driver.Value, error) {
+	return string(s), nil
+}
@@ -0,0 +1,169 @@
+package internaldb_test
+
+import (
+	. "github.com/AusDTO/pe-rds-broker/internaldb"
+
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/AusDTO/pe-rds-broker/config"
+	"github.com/jinzhu/gorm"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Operations", func() {
+	var (
+		db       *gorm.DB
+		instance *DBInstance
+	)
+
+	BeforeEach(func() {
+		logger := lager.NewLogger("operations_test")
+		logger.RegisterSink(lagertest.NewTestSink())
+		var err error
+		os.Remove("/tmp/test.sqlite3")
+		db, err = DBInit(&config.DBConfig{DBType: "sqlite3", DBName: "/tmp/test.sqlite3"}, logger)
+		Expect(err).NotTo(HaveOccurred())
+		instance, err = NewInstance("service-id", "plan-id", "instance-id", "cf", make([]byte, 32))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.Save(instance).Error).NotTo(HaveOccurred())
+	})
+
+	Describe("NewOperation", func() {
+		It("starts in progress", func() {
+			operation := instance.NewOperation(UpdateOperation, "new-plan-id", []byte(`{"apply_immediately":true}`))
+			Expect(operation.DBInstanceID).To(Equal(instance.ID))
+			Expect(operation.Type).To(Equal(UpdateOperation))
+			Expect(operation.State).To(Equal(OperationInProgress))
+			Expect(operation.PlanID).To(Equal("new-plan-id"))
+			Expect(operation.Parameters).To(Equal(`{"apply_immediately":true}`))
+			Expect(operation.StartedAt).NotTo(BeZero())
+			Expect(operation.Finished()).To(BeFalse())
+		})
+	})
+
+	Describe("FindOperation", func() {
+		var operation *DBOperation
+
+		BeforeEach(func() {
+			operation = instance.NewOperation(ProvisionOperation, "plan-id", nil)
+			Expect(db.Save(operation).Error).NotTo(HaveOccurred())
+		})
+
+		It("finds the operation from its operation data", func() {
+			found := FindOperation(db, instance, operation.OperationData())
+			Expect(found).NotTo(BeNil())
+			Expect(found.ID).To(Equal(operation.ID))
+			Expect(found.Type).To(Equal(ProvisionOperation))
+		})
+
+		It("doesn't find garbage operation data", func() {
+			Expect(FindOperation(db, instance, "not-an-id")).To(BeNil())
+		})
+
+		It("doesn't find operations of other instances", func() {
+			other, err := NewInstance("service-id", "plan-id", "other-id", "cf", make([]byte, 32))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(db.Save(other).Error).NotTo(HaveOccurred())
+			Expect(FindOperation(db, other, operation.OperationData())).To(BeNil())
+		})
+	})
+
+	Describe("LatestOperation", func() {
+		It("returns nil when there are no operations", func() {
+			Expect(LatestOperation(db, instance)).To(BeNil())
+		})
+
+		It("returns the most recent operation", func() {
+			Expect(db.Save(instance.NewOperation(ProvisionOperation, "plan-id", nil)).Error).NotTo(HaveOccurred())
+			Expect(db.Save(instance.NewOperation(UpdateOperation, "plan-id", nil)).Error).NotTo(HaveOccurred())
+			Expect(LatestOperation(db, instance).Type).To(Equal(UpdateOperation))
+		})
+	})
+
+	Describe("finishing", func() {
+		var operation *DBOperation
+
+		BeforeEach(func() {
+			operation = instance.NewOperation(ProvisionOperation, "plan-id", nil)
+			Expect(db.Save(operation).Error).NotTo(HaveOccurred())
+		})
+
+		It("records success", func() {
+			Expect(operation.Succeed(db, "all done")).To(Succeed())
+			found := FindOperation(db, instance, operation.OperationData())
+			Expect(found.State).To(Equal(OperationSucceeded))
+			Expect(found.Description).To(Equal("all done"))
+			Expect(found.FinishedAt).NotTo(BeNil())
+			Expect(found.Finished()).To(BeTrue())
+		})
+
+		It("records failure", func() {
+			Expect(operation.Fail(db, "oh no")).To(Succeed())
+			found := FindOperation(db, instance, operation.OperationData())
+			Expect(found.State).To(Equal(OperationFailed))
+			Expect(found.Description).To(Equal("oh no"))
+		})
+
+		It("records the stage without finishing", func() {
+			Expect(operation.SetStage(db, "next-step")).To(Succeed())
+			found := FindOperation(db, instance, operation.OperationData())
+			Expect(found.Stage).To(Equal("next-step"))
+			Expect(found.StageStartedAt).NotTo(BeNil())
+			Expect(found.Finished()).To(BeFalse())
+		})
+
+		It("lets one caller claim the next stage", func() {
+			Expect(operation.SetStage(db, "waiting")).To(Succeed())
+			other := FindOperation(db, instance, operation.OperationData())
+
+			claimed, err := operation.ClaimStage(db, "waiting", "working")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).To(BeTrue())
+			Expect(operation.Stage).To(Equal("working"))
+
+			claimed, err = other.ClaimStage(db, "waiting", "working")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).To(BeFalse())
+			Expect(FindOperation(db, instance, operation.OperationData()).Stage).To(Equal("working"))
+		})
+
+		It("doesn't claim stages of finished operations", func() {
+			Expect(operation.SetStage(db, "waiting")).To(Succeed())
+			Expect(operation.Fail(db, "oh no")).To(Succeed())
+
+			claimed, err := operation.ClaimStage(db, "waiting", "working")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).To(BeFalse())
+		})
+	})
+
+	Describe("InProgressOperations", func() {
+		It("finds the unfinished operations at a stage by instance", func() {
+			operation := instance.NewOperation(UpdateOperation, "plan-id", nil)
+			operation.Stage = "next-step"
+			Expect(db.Save(operation).Error).NotTo(HaveOccurred())
+			finished := instance.NewOperation(UpdateOperation, "plan-id", nil)
+			finished.Stage = "next-step"
+			Expect(db.Save(finished).Error).NotTo(HaveOccurred())
+			Expect(finished.Succeed(db, "all done")).To(Succeed())
+			other := instance.NewOperation(UpdateOperation, "plan-id", nil)
+			Expect(db.Save(other).Error).NotTo(HaveOccurred())
+
+			operations, err := InProgressOperations(db, "next-step")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operations).To(HaveLen(1))
+			Expect(operations).To(HaveKey("instance-id"))
+			Expect(operations["instance-id"].ID).To(Equal(operation.ID))
+		})
+	})
+
+	It("are deleted with their instance", func() {
+		operation := instance.NewOperation(ProvisionOperation, "plan-id", nil)
+		Expect(db.Save(operation).Error).NotTo(HaveOccurred())
+		Expect(instance.Delete(db)).To(Succeed())
+		Expect(LatestOperation(db, instance)).To(BeNil())
+	})
+})
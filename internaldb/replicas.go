@@ -0,0 +1,55 @@
+package internaldb
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DBReplica records a read replica of a dedicated instance so it can be described, included in
+// binding credentials and deleted along with the instance
+type DBReplica struct {
+	// Managed by gorm
+	ID        uint64 `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Managed by us
+	DBInstanceID uint64
+	Identifier   string `gorm:"unique_index"`
+}
+
+func (i *DBInstance) AddReplica(db *gorm.DB, identifier string) (*DBReplica, error) {
+	replica := DBReplica{DBInstanceID: i.ID, Identifier: identifier}
+	if err := db.Create(&replica).Error; err != nil {
+		return nil, err
+	}
+	i.Replicas = append(i.Replicas, replica)
+	return &replica, nil
+}
+
+func (i *DBInstance) RemoveReplica(db *gorm.DB, identifier string) error {
+	for idx, replica := range i.Replicas {
+		if replica.Identifier == identifier {
+			if err := db.Delete(&replica).Error; err != nil {
+				return err
+			}
+			i.Replicas = append(i.Replicas[:idx], i.Replicas[idx+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (i *DBInstance) Replica(identifier string) *DBReplica {
+	for _, replica := range i.Replicas {
+		if replica.Identifier == identifier {
+			return &replica
+		}
+	}
+	return nil
+}
+
+// SetReadReplicaCount records how many read replicas the instance should have
+func (i *DBInstance) SetReadReplicaCount(db *gorm.DB, count int64) error {
+	return db.Model(i).Update("read_replica_count", count).Error
+}
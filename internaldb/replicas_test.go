@@ -0,0 +1,68 @@
+package internaldb_test
+
+import (
+	. "github.com/AusDTO/pe-rds-broker/internaldb"
+
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/AusDTO/pe-rds-broker/config"
+	"github.com/jinzhu/gorm"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Replicas", func() {
+	var (
+		db       *gorm.DB
+		instance *DBInstance
+	)
+
+	BeforeEach(func() {
+		logger := lager.NewLogger("replicas_test")
+		logger.RegisterSink(lagertest.NewTestSink())
+		var err error
+		os.Remove("/tmp/test.sqlite3")
+		db, err = DBInit(&config.DBConfig{DBType: "sqlite3", DBName: "/tmp/test.sqlite3"}, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		instance, err = NewInstance("service-id", "plan-id", "instance-id", "cf", make([]byte, 32))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.Save(instance).Error).NotTo(HaveOccurred())
+	})
+
+	It("loads the replicas with the instance", func() {
+		_, err := instance.AddReplica(db, "cf-instance-id-replica-1")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = instance.AddReplica(db, "cf-instance-id-replica-2")
+		Expect(err).NotTo(HaveOccurred())
+
+		found := FindInstance(db, "instance-id")
+		Expect(found.Replicas).To(HaveLen(2))
+		Expect(found.Replicas[0].Identifier).To(Equal("cf-instance-id-replica-1"))
+		Expect(found.Replicas[1].Identifier).To(Equal("cf-instance-id-replica-2"))
+	})
+
+	It("removes a replica", func() {
+		_, err := instance.AddReplica(db, "cf-instance-id-replica-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.RemoveReplica(db, "cf-instance-id-replica-1")).To(Succeed())
+		Expect(instance.Replicas).To(BeEmpty())
+		Expect(FindInstance(db, "instance-id").Replicas).To(BeEmpty())
+	})
+
+	It("records the number of replicas wanted", func() {
+		Expect(instance.SetReadReplicaCount(db, 2)).To(Succeed())
+		Expect(FindInstance(db, "instance-id").ReadReplicaCount).To(Equal(int64(2)))
+	})
+
+	It("deletes the replicas with the instance", func() {
+		_, err := instance.AddReplica(db, "cf-instance-id-replica-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.Delete(db)).To(Succeed())
+		var count int
+		Expect(db.Model(&DBReplica{}).Count(&count).Error).NotTo(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+})
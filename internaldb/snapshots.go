@@ -0,0 +1,83 @@
+package internaldb
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DBSnapshot records an RDS snapshot the broker took (or had RDS take when deleting) so they can be
+// listed and expired. Snapshots outlive their instance so they refer to it by its ID rather than
+// with a foreign key.
+type DBSnapshot struct {
+	// Managed by gorm
+	ID        uint64 `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Managed by us
+	SnapshotID string `gorm:"unique_index"`
+	InstanceID string `gorm:"index"`
+	Type       SnapshotType
+	// Aurora snapshots are of the whole cluster
+	Cluster bool
+}
+
+type SnapshotType string
+
+const (
+	ManualSnapshot SnapshotType = "manual"
+	CloneSnapshot  SnapshotType = "clone"
+	FinalSnapshot  SnapshotType = "final"
+)
+
+// RecordSnapshot saves a snapshot unless it is already recorded
+func RecordSnapshot(db *gorm.DB, instanceID, snapshotID string, snapshotType SnapshotType, cluster bool) (*DBSnapshot, error) {
+	snapshot := DBSnapshot{
+		SnapshotID: snapshotID,
+		InstanceID: instanceID,
+		Type:       snapshotType,
+		Cluster:    cluster,
+	}
+	err := db.Where(&DBSnapshot{SnapshotID: snapshotID}).FirstOrCreate(&snapshot).Error
+	return &snapshot, err
+}
+
+func FindSnapshot(db *gorm.DB, snapshotID string) *DBSnapshot {
+	var snapshot DBSnapshot
+	err := db.Where(&DBSnapshot{SnapshotID: snapshotID}).First(&snapshot).Error
+	if err != nil {
+		return nil
+	}
+	return &snapshot
+}
+
+// ListSnapshots returns the snapshots of the given instance, oldest first. An empty instance ID lists
+// the snapshots of every instance.
+func ListSnapshots(db *gorm.DB, instanceID string) ([]DBSnapshot, error) {
+	var snapshots []DBSnapshot
+	err := db.Where(&DBSnapshot{InstanceID: instanceID}).Order("created_at, id").Find(&snapshots).Error
+	return snapshots, err
+}
+
+// ExpiredSnapshots returns the snapshots of the given type recorded before the given time
+func ExpiredSnapshots(db *gorm.DB, snapshotType SnapshotType, before time.Time) ([]DBSnapshot, error) {
+	var snapshots []DBSnapshot
+	err := db.Where("type = ? AND created_at < ?", string(snapshotType), before).Order("created_at, id").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func (s *DBSnapshot) Delete(db *gorm.DB) error {
+	return db.Delete(s).Error
+}
+
+// Ensure custom string types work with gorm/sql
+// https://github.com/jinzhu/gorm/issues/302
+func (t *SnapshotType) Scan(value interface{}) error {
+	*t = SnapshotType(value.([]byte))
+	return nil
+}
+
+func (t SnapshotType) Value() (driver.Value, error) {
+	return string(t), nil
+}
@@ -0,0 +1,105 @@
+package internaldb_test
+
+import (
+	. "github.com/AusDTO/pe-rds-broker/internaldb"
+
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/AusDTO/pe-rds-broker/config"
+	"github.com/jinzhu/gorm"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshots", func() {
+	var db *gorm.DB
+
+	BeforeEach(func() {
+		logger := lager.NewLogger("snapshots_test")
+		logger.RegisterSink(lagertest.NewTestSink())
+		var err error
+		os.Remove("/tmp/test.sqlite3")
+		db, err = DBInit(&config.DBConfig{DBType: "sqlite3", DBName: "/tmp/test.sqlite3"}, logger)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("RecordSnapshot", func() {
+		It("records the snapshot", func() {
+			_, err := RecordSnapshot(db, "instance-id", "snapshot-id", ManualSnapshot, true)
+			Expect(err).NotTo(HaveOccurred())
+			snapshot := FindSnapshot(db, "snapshot-id")
+			Expect(snapshot).NotTo(BeNil())
+			Expect(snapshot.InstanceID).To(Equal("instance-id"))
+			Expect(snapshot.Type).To(Equal(ManualSnapshot))
+			Expect(snapshot.Cluster).To(BeTrue())
+		})
+
+		It("doesn't record the same snapshot twice", func() {
+			first, err := RecordSnapshot(db, "instance-id", "snapshot-id", FinalSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := RecordSnapshot(db, "instance-id", "snapshot-id", FinalSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.ID).To(Equal(first.ID))
+			snapshots, err := ListSnapshots(db, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+		})
+	})
+
+	Describe("ListSnapshots", func() {
+		BeforeEach(func() {
+			for _, snapshotID := range []string{"one", "two"} {
+				_, err := RecordSnapshot(db, "instance-id", snapshotID, ManualSnapshot, false)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err := RecordSnapshot(db, "other-instance-id", "three", ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("lists the snapshots of an instance", func() {
+			snapshots, err := ListSnapshots(db, "instance-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+			Expect(snapshots[0].SnapshotID).To(Equal("one"))
+			Expect(snapshots[1].SnapshotID).To(Equal("two"))
+		})
+
+		It("lists every snapshot", func() {
+			snapshots, err := ListSnapshots(db, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(3))
+		})
+	})
+
+	Describe("ExpiredSnapshots", func() {
+		BeforeEach(func() {
+			old, err := RecordSnapshot(db, "instance-id", "old-final", FinalSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(db.Model(old).Update("created_at", time.Now().Add(-48*time.Hour)).Error).NotTo(HaveOccurred())
+			_, err = RecordSnapshot(db, "instance-id", "new-final", FinalSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			old, err = RecordSnapshot(db, "instance-id", "old-manual", ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(db.Model(old).Update("created_at", time.Now().Add(-48*time.Hour)).Error).NotTo(HaveOccurred())
+		})
+
+		It("only finds old snapshots of the given type", func() {
+			snapshots, err := ExpiredSnapshots(db, FinalSnapshot, time.Now().Add(-24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			Expect(snapshots[0].SnapshotID).To(Equal("old-final"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the record", func() {
+			snapshot, err := RecordSnapshot(db, "instance-id", "snapshot-id", ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot.Delete(db)).To(Succeed())
+			Expect(FindSnapshot(db, "snapshot-id")).To(BeNil())
+		})
+	})
+})
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"net/http"
+	"time"
 
 	cfcommon "github.com/govau/cf-common"
 
@@ -94,6 +95,17 @@ func main() {
 
 	brokerAPI := brokerapi.New(serviceBroker, logger, credentials)
 	http.Handle("/", brokerAPI)
+	http.Handle("/admin/", rdsbroker.NewAdminHandler(serviceBroker, credentials, logger))
+
+	if interval := configYml.RDSConfig.SnapshotRetention.IntervalHours; interval > 0 {
+		go serviceBroker.RunSnapshotRetention(time.Duration(interval) * time.Hour)
+	}
+
+	if interval := configYml.RDSConfig.MasterPasswordRotation.IntervalHours; interval > 0 {
+		go serviceBroker.RunMasterPasswordRotation(time.Duration(interval) * time.Hour)
+	}
+
+	go serviceBroker.RunSharedDatabaseCopies()
 
 	logger.Info("RDS Service Broker started on port " + port + "...")
 	logger.Fatal("listen-serve", http.ListenAndServe(":"+port, nil))
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cfcommon "github.com/govau/cf-common"
+)
+
+var (
+	brokerURL    string
+	wait         bool
+	pollInterval time.Duration
+)
+
+type lastOperation struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+func init() {
+	flag.StringVar(&brokerURL, "url", "http://localhost:3000", "URL of the broker")
+	flag.BoolVar(&wait, "wait", true, "Wait for the rotation to finish")
+	flag.DurationVar(&pollInterval, "poll", 15*time.Second, "How often to check on the rotation while waiting")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] rotate-master-password <instance-id>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	envVar := cfcommon.NewDefaultEnvLookup()
+	username := envVar.MustString("RDSBROKER_USERNAME")
+	password := envVar.MustString("RDSBROKER_PASSWORD")
+
+	var err error
+	switch flag.Arg(0) {
+	case "rotate-master-password":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		err = rotateMasterPassword(flag.Arg(1), username, password)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// rotateMasterPassword starts the rotation then, unless told not to, asks the broker about it until it has
+// finished. Asking is what has the broker record the new password once RDS has reset it.
+func rotateMasterPassword(instanceID, username, password string) error {
+	instancePath := "/admin/instances/" + url.PathEscape(instanceID)
+
+	var response struct {
+		Operation string `json:"operation"`
+	}
+	if err := request(http.MethodPost, instancePath+"/rotate-master-password", username, password, &response); err != nil {
+		return err
+	}
+	fmt.Printf("Rotating the master password of %s\n", instanceID)
+	if !wait {
+		return nil
+	}
+
+	for {
+		var operation lastOperation
+		if err := request(http.MethodGet, instancePath+"/last-operation?operation="+url.QueryEscape(response.Operation), username, password, &operation); err != nil {
+			return err
+		}
+		fmt.Println(operation.Description)
+		switch operation.State {
+		case "succeeded":
+			return nil
+		case "failed":
+			return fmt.Errorf("Failed to rotate the master password of %s", instanceID)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func request(method, path, username, password string, response interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(brokerURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var adminError struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &adminError) == nil && adminError.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, adminError.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(body, response)
+}
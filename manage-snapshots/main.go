@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cfcommon "github.com/govau/cf-common"
+)
+
+var (
+	brokerURL  string
+	instanceID string
+)
+
+type snapshot struct {
+	SnapshotID string    `json:"snapshot_id"`
+	InstanceID string    `json:"instance_id"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func init() {
+	flag.StringVar(&brokerURL, "url", "http://localhost:3000", "URL of the broker")
+	flag.StringVar(&instanceID, "instance", "", "Only list the snapshots of this service instance")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] list | delete <snapshot-id> | expire\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	envVar := cfcommon.NewDefaultEnvLookup()
+	username := envVar.MustString("RDSBROKER_USERNAME")
+	password := envVar.MustString("RDSBROKER_PASSWORD")
+
+	var err error
+	switch flag.Arg(0) {
+	case "list":
+		err = list(username, password)
+	case "delete":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		err = request(http.MethodDelete, "/admin/snapshots/"+url.PathEscape(flag.Arg(1)), username, password, nil)
+		if err == nil {
+			fmt.Printf("Deleted snapshot %s\n", flag.Arg(1))
+		}
+	case "expire":
+		var response struct {
+			Expired []string `json:"expired"`
+		}
+		err = request(http.MethodPost, "/admin/retention", username, password, &response)
+		if err == nil {
+			fmt.Printf("Expired %d snapshot(s)\n", len(response.Expired))
+			for _, snapshotID := range response.Expired {
+				fmt.Println(snapshotID)
+			}
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func list(username, password string) error {
+	path := "/admin/snapshots"
+	if instanceID != "" {
+		path += "?instance_id=" + url.QueryEscape(instanceID)
+	}
+
+	var snapshots []snapshot
+	if err := request(http.MethodGet, path, username, password, &snapshots); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tINSTANCE\tTYPE\tSTATUS\tCREATED")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.SnapshotID, s.InstanceID, s.Type, s.Status, s.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func request(method, path, username, password string, response interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(brokerURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var adminError struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &adminError) == nil && adminError.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, adminError.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(body, response)
+}
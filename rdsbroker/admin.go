@@ -0,0 +1,192 @@
+package rdsbroker
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+const adminSnapshotsPath = "/admin/snapshots"
+const adminRetentionPath = "/admin/retention"
+const adminInstancesPath = "/admin/instances"
+
+type adminHandler struct {
+	broker      *RDSBroker
+	credentials brokerapi.BrokerCredentials
+	logger      lager.Logger
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+type expireResponse struct {
+	Expired []string `json:"expired"`
+}
+
+type operationResponse struct {
+	Operation string `json:"operation"`
+}
+
+type lastOperationResponse struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+// NewAdminHandler serves the operator API for managing snapshots and master passwords. It is protected
+// by the same credentials as the broker API.
+//
+//	GET    /admin/snapshots[?instance_id=<id>]                      lists recorded snapshots
+//	DELETE /admin/snapshots/<snapshot-id>                           deletes a snapshot
+//	POST   /admin/retention                                         applies the snapshot retention policy now
+//	POST   /admin/instances/<id>/rotate-master-password             starts rotating the master password
+//	GET    /admin/instances/<id>/last-operation[?operation=<data>]  reports on an operation of an instance
+func NewAdminHandler(broker *RDSBroker, credentials brokerapi.BrokerCredentials, logger lager.Logger) http.Handler {
+	h := &adminHandler{
+		broker:      broker,
+		credentials: credentials,
+		logger:      logger.Session("admin"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(adminSnapshotsPath, h.snapshots)
+	mux.HandleFunc(adminSnapshotsPath+"/", h.snapshot)
+	mux.HandleFunc(adminRetentionPath, h.retention)
+	mux.HandleFunc(adminInstancesPath+"/", h.instance)
+
+	return h.authenticate(mux)
+}
+
+func (h *adminHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(h.credentials.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(h.credentials.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="rds-broker"`)
+			h.respond(w, http.StatusUnauthorized, adminError{Error: "Not Authorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *adminHandler) snapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respond(w, http.StatusMethodNotAllowed, adminError{Error: "Method not allowed"})
+		return
+	}
+
+	snapshots, err := h.broker.ListSnapshots(r.URL.Query().Get("instance_id"))
+	if err != nil {
+		h.logger.Error("list-snapshots", err)
+		h.respond(w, http.StatusInternalServerError, adminError{Error: err.Error()})
+		return
+	}
+
+	h.respond(w, http.StatusOK, snapshots)
+}
+
+func (h *adminHandler) snapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.respond(w, http.StatusMethodNotAllowed, adminError{Error: "Method not allowed"})
+		return
+	}
+
+	snapshotID := strings.TrimPrefix(r.URL.Path, adminSnapshotsPath+"/")
+	if snapshotID == "" || strings.Contains(snapshotID, "/") {
+		h.respond(w, http.StatusNotFound, adminError{Error: "Not found"})
+		return
+	}
+
+	if internaldb.FindSnapshot(h.broker.internalDB, snapshotID) == nil {
+		h.respond(w, http.StatusNotFound, adminError{Error: "Snapshot '" + snapshotID + "' not found"})
+		return
+	}
+
+	if err := h.broker.DeleteSnapshot(snapshotID); err != nil {
+		h.logger.Error("delete-snapshot", err, lager.Data{"snapshot-id": snapshotID})
+		h.respond(w, http.StatusUnprocessableEntity, adminError{Error: err.Error()})
+		return
+	}
+
+	h.respond(w, http.StatusOK, struct{}{})
+}
+
+func (h *adminHandler) retention(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respond(w, http.StatusMethodNotAllowed, adminError{Error: "Method not allowed"})
+		return
+	}
+
+	expired, err := h.broker.ExpireSnapshots(time.Now())
+	if err != nil {
+		h.logger.Error("expire-snapshots", err)
+		h.respond(w, http.StatusInternalServerError, adminError{Error: err.Error()})
+		return
+	}
+
+	h.respond(w, http.StatusOK, expireResponse{Expired: expired})
+}
+
+func (h *adminHandler) instance(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, adminInstancesPath+"/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		h.respond(w, http.StatusNotFound, adminError{Error: "Not found"})
+		return
+	}
+	instanceID := parts[0]
+
+	switch parts[1] {
+	case "rotate-master-password":
+		if r.Method != http.MethodPost {
+			h.respond(w, http.StatusMethodNotAllowed, adminError{Error: "Method not allowed"})
+			return
+		}
+		operation, err := h.broker.RotateMasterPassword(instanceID)
+		if err != nil {
+			h.logger.Error("rotate-master-password", err, lager.Data{instanceIDLogKey: instanceID})
+			h.respondWithError(w, err)
+			return
+		}
+		h.respond(w, http.StatusOK, operationResponse{Operation: operation})
+	case "last-operation":
+		if r.Method != http.MethodGet {
+			h.respond(w, http.StatusMethodNotAllowed, adminError{Error: "Method not allowed"})
+			return
+		}
+		lastOperation, err := h.broker.LastOperation(r.Context(), instanceID, brokerapi.PollDetails{OperationData: r.URL.Query().Get("operation")})
+		if err != nil {
+			h.logger.Error("last-operation", err, lager.Data{instanceIDLogKey: instanceID})
+			h.respondWithError(w, err)
+			return
+		}
+		h.respond(w, http.StatusOK, lastOperationResponse{State: string(lastOperation.State), Description: lastOperation.Description})
+	default:
+		h.respond(w, http.StatusNotFound, adminError{Error: "Not found"})
+	}
+}
+
+func (h *adminHandler) respondWithError(w http.ResponseWriter, err error) {
+	if err == brokerapi.ErrInstanceDoesNotExist {
+		h.respond(w, http.StatusNotFound, adminError{Error: err.Error()})
+		return
+	}
+	h.respond(w, http.StatusUnprocessableEntity, adminError{Error: err.Error()})
+}
+
+func (h *adminHandler) respond(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("encode-response", err)
+	}
+}
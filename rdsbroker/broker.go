@@ -16,31 +16,51 @@ import (
 	"github.com/AusDTO/pe-rds-broker/config"
 	"github.com/AusDTO/pe-rds-broker/internaldb"
 	"github.com/AusDTO/pe-rds-broker/sqlengine"
+	"github.com/AusDTO/pe-rds-broker/utils"
 )
 
 const instanceIDLogKey = "instance-id"
 const bindingIDLogKey = "binding-id"
 const detailsLogKey = "details"
 const asyncAllowedLogKey = "async-allowed"
+const operationDataLogKey = "operation-data"
+
+// This tag is used by the IAM policy to grant access to modify the database
+// Don't change this tag without also changing iam_policy.json and the IAM policy in your AWS account
+const managedByTag = "Managed by"
+const managedByValue = "github.com/AusDTO/pe-rds-broker"
+
+const organizationIDTag = "Organization ID"
+
+const resetMasterPasswordStage = "reset-master-password"
+const cloneSnapshotStage = "clone-snapshot"
+const createSnapshotStage = "create-snapshot"
 
 var rdsStatus2State = map[string]brokerapi.LastOperationState{
-	"available":                    brokerapi.Succeeded,
-	"backing-up":                   brokerapi.InProgress,
-	"creating":                     brokerapi.InProgress,
-	"deleting":                     brokerapi.InProgress,
-	"maintenance":                  brokerapi.InProgress,
-	"modifying":                    brokerapi.InProgress,
-	"rebooting":                    brokerapi.InProgress,
-	"renaming":                     brokerapi.InProgress,
-	"resetting-master-credentials": brokerapi.InProgress,
-	"upgrading":                    brokerapi.InProgress,
+	"available":                       brokerapi.Succeeded,
+	"backing-up":                      brokerapi.InProgress,
+	"configuring-enhanced-monitoring": brokerapi.InProgress,
+	"creating":                        brokerapi.InProgress,
+	"deleting":                        brokerapi.InProgress,
+	"maintenance":                     brokerapi.InProgress,
+	"modifying":                       brokerapi.InProgress,
+	"rebooting":                       brokerapi.InProgress,
+	"renaming":                        brokerapi.InProgress,
+	"resetting-master-credentials":    brokerapi.InProgress,
+	"storage-optimization":            brokerapi.InProgress,
+	"upgrading":                       brokerapi.InProgress,
 }
 
 type RDSBroker struct {
+	region                       string
 	dbPrefix                     string
 	allowUserProvisionParameters bool
 	allowUserUpdateParameters    bool
 	allowUserBindParameters      bool
+	snapshotRetention            SnapshotRetention
+	masterPasswordRotation       MasterPasswordRotation
+	caCertificateFile            string
+	caCertificate                string
 	catalog                      Catalog
 	dbInstance                   awsrds.DBInstance
 	dbCluster                    awsrds.DBCluster
@@ -63,10 +83,15 @@ func New(
 	encryptionKey []byte,
 ) *RDSBroker {
 	return &RDSBroker{
+		region:                       config.Region,
 		dbPrefix:                     config.DBPrefix,
 		allowUserProvisionParameters: config.AllowUserProvisionParameters,
 		allowUserUpdateParameters:    config.AllowUserUpdateParameters,
 		allowUserBindParameters:      config.AllowUserBindParameters,
+		snapshotRetention:            config.SnapshotRetention,
+		masterPasswordRotation:       config.MasterPasswordRotation,
+		caCertificateFile:            config.CACertificateFile,
+		caCertificate:                config.CACertificate,
 		catalog:                      config.Catalog,
 		dbInstance:                   dbInstance,
 		dbCluster:                    dbCluster,
@@ -78,7 +103,7 @@ func New(
 	}
 }
 
-func (b *RDSBroker) Services(context context.Context) []brokerapi.Service {
+func (b *RDSBroker) Services(context context.Context) ([]brokerapi.Service, error) {
 	b.logger.Debug("services")
 
 	var services []brokerapi.Service
@@ -91,14 +116,14 @@ func (b *RDSBroker) Services(context context.Context) []brokerapi.Service {
 	servicesStr, err := json.Marshal(b.catalog.Services)
 	if err != nil {
 		b.logger.Error("marshal-error", err)
-		return services
+		return services, err
 	}
 
 	if err = json.Unmarshal(servicesStr, &services); err != nil {
 		b.logger.Error("unmarshal-error", err)
-		return services
+		return services, err
 	}
-	return services
+	return services, nil
 }
 
 func (b *RDSBroker) Provision(context context.Context, instanceID string, details brokerapi.ProvisionDetails, asyncAllowed bool) (brokerapi.ProvisionedServiceSpec, error) {
@@ -114,11 +139,9 @@ func (b *RDSBroker) Provision(context context.Context, instanceID string, detail
 		return provisionSpec, brokerapi.ErrAsyncRequired
 	}
 
-	provisionParameters := ProvisionParameters{}
-	if b.allowUserProvisionParameters && len(details.RawParameters) > 0 {
-		if err := json.Unmarshal(details.RawParameters, &provisionParameters); err != nil {
-			return provisionSpec, err
-		}
+	provisionParameters, err := b.provisionParameters(details.RawParameters)
+	if err != nil {
+		return provisionSpec, err
 	}
 
 	servicePlan, ok := b.catalog.FindServicePlan(details.ServiceID, details.PlanID)
@@ -126,50 +149,189 @@ func (b *RDSBroker) Provision(context context.Context, instanceID string, detail
 		return provisionSpec, fmt.Errorf("Service Plan '%s' not found", details.PlanID)
 	}
 
-	// There's a potential race condition here but it's better than nothing
-	if internaldb.FindInstance(b.internalDB, instanceID) != nil {
-		return provisionSpec, errors.New("Instance already exists")
-	}
-
-	instance, err := internaldb.NewInstance(details.ServiceID, details.PlanID, instanceID, b.dbPrefix, b.encryptionKey)
+	replicaCount, err := readReplicaCount(servicePlan, provisionParameters.ReadReplicaCount)
 	if err != nil {
 		return provisionSpec, err
 	}
 
-	if servicePlan.RDSProperties.Shared {
-		sqlEngine := b.sharedEngines[servicePlan.RDSProperties.Engine]
-		err := sqlEngine.CreateDB(instance.DBName)
+	var restore restoreSource
+	if provisionParameters.restoring() {
+		if servicePlan.RDSProperties.Shared && provisionParameters.CloneFrom == "" {
+			return provisionSpec, errors.New("Restoring is not supported for shared plans")
+		}
+		restore, err = b.findRestoreSource(provisionParameters, servicePlan, details.OrganizationGUID)
 		if err != nil {
 			return provisionSpec, err
 		}
-		provisionSpec.IsAsync = false
-	} else {
-		if strings.ToLower(servicePlan.RDSProperties.Engine) == "aurora" {
-			createDBCluster := b.createDBCluster(instance, servicePlan, provisionParameters, details)
-			if err = b.dbCluster.Create(b.dbClusterIdentifier(instance), *createDBCluster); err != nil {
-				return provisionSpec, err
-			}
-			defer func() {
-				if err != nil {
-					b.dbCluster.Delete(b.dbClusterIdentifier(instance), servicePlan.RDSProperties.SkipFinalSnapshot)
-				}
-			}()
-		}
+	}
 
-		createDBInstance := b.createDBInstance(instance, servicePlan, provisionParameters, details)
-		if err = b.dbInstance.Create(b.dbInstanceIdentifier(instance), *createDBInstance); err != nil {
+	// There's a potential race condition here but the unique index on the instance ID catches it when saving
+	if existing := internaldb.FindInstance(b.internalDB, instanceID); existing != nil {
+		if existing.IsActive() {
+			return provisionSpec, errors.New("Instance already exists")
+		}
+		if err := b.takeOverPending(existing); err != nil {
 			return provisionSpec, err
 		}
 	}
 
+	instance, err := internaldb.NewInstance(details.ServiceID, details.PlanID, instanceID, b.dbPrefix, b.encryptionKey)
+	if err != nil {
+		return provisionSpec, err
+	}
+	if restore.masterUsername != "" {
+		// The master username can't be changed when restoring, only the password
+		instance.SetMasterUsername(restore.masterUsername)
+	}
+	instance.OrganizationID = details.OrganizationGUID
+	instance.SpaceID = details.SpaceGUID
+	instance.ReadReplicaCount = replicaCount
+
+	// Save a pending reference before creating anything so we never create resources we can't track
 	if err = b.internalDB.Save(instance).Error; err != nil {
-		// TODO rollback
-		return provisionSpec, errors.New("RDS instance created but failed to save reference to local database")
+		return provisionSpec, errors.New("Failed to save reference to local database")
 	}
 
+	rollback := newSaga(b.logger.Session("provision", lager.Data{instanceIDLogKey: instanceID}))
+	rollback.add("delete-internal", func() error {
+		return instance.Delete(b.internalDB)
+	})
+
+	stage := ""
+	switch {
+	case servicePlan.RDSProperties.Shared:
+		provisionSpec.IsAsync = false
+		err = b.createSharedResources(instance, servicePlan, restore, rollback)
+	case restore.cloneFrom != nil:
+		// The clone is restored from this snapshot once it's available, see cloneLastOperation
+		stage = cloneSnapshotStage
+		err = b.createCloneSnapshot(instance, servicePlan, restore.cloneFrom, rollback)
+	default:
+		err = b.createDedicatedResources(instance, servicePlan, provisionParameters, details, restore, rollback)
+	}
+	if err != nil {
+		rollback.run()
+		return provisionSpec, err
+	}
+
+	if err = instance.Activate(b.internalDB); err != nil {
+		rollback.run()
+		return provisionSpec, errors.New("Failed to save reference to local database")
+	}
+
+	provisionSpec.OperationData = b.startOperation(instance, internaldb.ProvisionOperation, details.PlanID, details.RawParameters, provisionSpec.IsAsync, stage)
+
 	return provisionSpec, nil
 }
 
+func (b *RDSBroker) createSharedResources(instance *internaldb.DBInstance, servicePlan ServicePlan, restore restoreSource, rollback *saga) error {
+	sqlEngine := b.sharedEngines[servicePlan.RDSProperties.Engine]
+	if restore.cloneFrom == nil {
+		if err := sqlEngine.CreateDB(instance.DBName); err != nil {
+			return err
+		}
+	} else {
+		if err := sqlEngine.CopyDB(restore.cloneFrom.DBName, instance.DBName); err != nil {
+			return err
+		}
+	}
+	rollback.add("drop-db", func() error {
+		return sqlEngine.DropDB(instance.DBName)
+	})
+
+	if restore.cloneFrom != nil {
+		return b.reassignClonedObjects(instance, servicePlan, restore.cloneFrom)
+	}
+	return nil
+}
+
+// The objects in a cloned database still belong to the owner role of the source instance so they
+// are given to the owner role of this instance
+func (b *RDSBroker) reassignClonedObjects(instance *internaldb.DBInstance, servicePlan ServicePlan, source *internaldb.DBInstance) error {
+	sqlEngine, err := b.sharedSqlEngine(instance, servicePlan.RDSProperties.Engine)
+	if err != nil {
+		return err
+	}
+	defer sqlEngine.Close()
+
+	sourceOwner := sqlEngine.OwnerRole(source.InstanceID)
+	if sourceOwner == "" {
+		return nil
+	}
+	return sqlEngine.ReassignOwnership(sourceOwner, sqlEngine.OwnerRole(instance.InstanceID))
+}
+
+// Dedicated clones start with a snapshot of the source which is tagged like its source so it can be restored
+func (b *RDSBroker) createCloneSnapshot(instance *internaldb.DBInstance, servicePlan ServicePlan, source *internaldb.DBInstance, rollback *saga) error {
+	tags := b.dbTags("Created", source.ServiceID, source.PlanID, instance.OrganizationID, source.SpaceID)
+	var err error
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		err = b.dbCluster.CreateSnapshot(b.dbClusterIdentifier(source), b.cloneSnapshotIdentifier(instance), tags)
+	} else {
+		err = b.dbInstance.CreateSnapshot(b.dbInstanceIdentifier(source), b.cloneSnapshotIdentifier(instance), tags)
+	}
+	if err != nil {
+		return err
+	}
+
+	b.recordSnapshot(source.InstanceID, b.cloneSnapshotIdentifier(instance), internaldb.CloneSnapshot, servicePlan)
+	rollback.add("delete-clone-snapshot", func() error {
+		// RDS may not delete it while it's still being created, in which case snapshot retention sees to it
+		return b.DeleteSnapshot(b.cloneSnapshotIdentifier(instance))
+	})
+	return nil
+}
+
+func (b *RDSBroker) createDedicatedResources(instance *internaldb.DBInstance, servicePlan ServicePlan, provisionParameters ProvisionParameters, details brokerapi.ProvisionDetails, restore restoreSource, rollback *saga) error {
+	// Nothing has been written to these yet so there's no point keeping a final snapshot when rolling back
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		var err error
+		switch {
+		case restore.snapshotID != "":
+			err = b.dbCluster.Restore(b.dbClusterIdentifier(instance), restore.snapshotID, *b.restoreDBCluster(servicePlan, details))
+		case restore.sourceIdentifier != "":
+			err = b.dbCluster.RestoreToPointInTime(b.dbClusterIdentifier(instance), restore.sourceIdentifier, restore.restoreTime, *b.restoreDBCluster(servicePlan, details))
+		default:
+			err = b.dbCluster.Create(b.dbClusterIdentifier(instance), *b.createDBCluster(instance, servicePlan, provisionParameters, details))
+		}
+		if err != nil {
+			return err
+		}
+		rollback.add("delete-db-cluster", func() error {
+			return b.dbCluster.Delete(b.dbClusterIdentifier(instance), true)
+		})
+
+		if servicePlan.RDSProperties.serverless() {
+			return nil
+		}
+
+		// Restoring a cluster doesn't restore its instances
+		restore = restoreSource{}
+	}
+
+	var err error
+	switch {
+	case restore.snapshotID != "":
+		err = b.dbInstance.Restore(b.dbInstanceIdentifier(instance), restore.snapshotID, *b.restoreDBInstance(servicePlan, details))
+	case restore.sourceIdentifier != "":
+		err = b.dbInstance.RestoreToPointInTime(b.dbInstanceIdentifier(instance), restore.sourceIdentifier, restore.restoreTime, *b.restoreDBInstance(servicePlan, details))
+	default:
+		err = b.dbInstance.Create(b.dbInstanceIdentifier(instance), *b.createDBInstance(instance, servicePlan, provisionParameters, details))
+	}
+	if err != nil {
+		return err
+	}
+	rollback.add("delete-db-instance", func() error {
+		return b.dbInstance.Delete(b.dbInstanceIdentifier(instance), true)
+	})
+
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return b.createClusterInstances(instance, servicePlan, *b.createDBInstance(instance, servicePlan, provisionParameters, details), rollback)
+	}
+
+	return nil
+}
+
 func (b *RDSBroker) Update(context context.Context, instanceID string, details brokerapi.UpdateDetails, asyncAllowed bool) (brokerapi.UpdateServiceSpec, error) {
 	b.logger.Debug("update", lager.Data{
 		instanceIDLogKey:   instanceID,
@@ -183,11 +345,9 @@ func (b *RDSBroker) Update(context context.Context, instanceID string, details b
 		return updateSpec, brokerapi.ErrAsyncRequired
 	}
 
-	updateParameters := UpdateParameters{}
-	if b.allowUserUpdateParameters && len(details.RawParameters) > 0 {
-		if err := json.Unmarshal(details.RawParameters, &updateParameters); err != nil {
-			return updateSpec, err
-		}
+	updateParameters, err := b.updateParameters(details.RawParameters)
+	if err != nil {
+		return updateSpec, err
 	}
 
 	instance, service, oldPlan, err := b.findObjects(instanceID)
@@ -195,6 +355,22 @@ func (b *RDSBroker) Update(context context.Context, instanceID string, details b
 		return updateSpec, err
 	}
 
+	if updateParameters.RotateBindingCredentials != nil {
+		return b.rotateBindingCredentials(instance, oldPlan, updateParameters, details)
+	}
+
+	if updateParameters.RotateMasterPassword {
+		return b.rotateMasterPasswordUpdate(instance, oldPlan, updateParameters, details)
+	}
+
+	if updateParameters.CreateSnapshot != "" {
+		return b.createManualSnapshot(instance, oldPlan, updateParameters, details)
+	}
+
+	if updateParameters.EngineVersion != "" {
+		return b.upgradeEngineVersion(instance, oldPlan, updateParameters, details)
+	}
+
 	newPlan, ok := b.catalog.FindServicePlan(instance.ServiceID, details.PlanID)
 	if !ok {
 		return updateSpec, fmt.Errorf("Service Plan '%s' not found", details.PlanID)
@@ -204,15 +380,23 @@ func (b *RDSBroker) Update(context context.Context, instanceID string, details b
 		return updateSpec, brokerapi.ErrPlanChangeNotSupported
 	}
 
+	if oldPlan.RDSProperties.Shared && !newPlan.RDSProperties.Shared {
+		return b.migrateToDedicated(instance, newPlan, updateParameters, details)
+	}
+
+	if hasIAMUsers(instance) && !newPlan.RDSProperties.IAMDatabaseAuthentication {
+		return updateSpec, errors.New("Bindings using IAM database authentication have to be removed before moving to a plan without it")
+	}
+
+	if updateParameters.AllocatedStorage > 0 {
+		if err := b.checkAllocatedStorage(instance, newPlan, updateParameters.AllocatedStorage); err != nil {
+			return updateSpec, err
+		}
+	}
+
 	// Handle extensions before updating the RDS instance in case the update takes the database down
 	if updateParameters.Extensions != nil {
-		var sqlEngine sqlengine.SQLEngine
-		var err error
-		if newPlan.RDSProperties.Shared {
-			sqlEngine, err = b.sharedSqlEngine(instance, newPlan.RDSProperties.Engine)
-		} else {
-			sqlEngine, err = b.dedicatedSqlEngine(instance, newPlan.RDSProperties.Engine)
-		}
+		sqlEngine, err := b.instanceSqlEngine(instance, newPlan)
 		if err != nil {
 			return updateSpec, err
 		}
@@ -223,23 +407,126 @@ func (b *RDSBroker) Update(context context.Context, instanceID string, details b
 		}
 	}
 
+	if newPlan.RDSProperties.RequireTLS != oldPlan.RDSProperties.RequireTLS {
+		if err := b.setRequireTLS(instance, newPlan); err != nil {
+			return updateSpec, err
+		}
+	}
+
+	replicaCount := instance.ReadReplicaCount
+	if updateParameters.ReadReplicaCount != nil || newPlan.ID != oldPlan.ID {
+		replicaCount, err = readReplicaCount(newPlan, updateParameters.ReadReplicaCount)
+		if err != nil {
+			return updateSpec, err
+		}
+	}
+
 	if !newPlan.RDSProperties.Shared {
 		updateSpec.IsAsync = true
-		if strings.ToLower(newPlan.RDSProperties.Engine) == "aurora" {
+		if isAurora(newPlan.RDSProperties.Engine) {
 			modifyDBCluster := b.modifyDBCluster(instance, newPlan, updateParameters, details)
 			if err := b.dbCluster.Modify(b.dbClusterIdentifier(instance), *modifyDBCluster, updateParameters.ApplyImmediately); err != nil {
 				return updateSpec, err
 			}
 		}
 
-		modifyDBInstance := b.modifyDBInstance(instance, newPlan, updateParameters, details)
-		if err := b.dbInstance.Modify(b.dbInstanceIdentifier(instance), *modifyDBInstance, updateParameters.ApplyImmediately); err != nil {
-			if err == awsrds.ErrDBInstanceDoesNotExist {
-				return updateSpec, brokerapi.ErrInstanceDoesNotExist
+		// Serverless clusters have no DB instances to modify
+		if !newPlan.RDSProperties.serverless() {
+			if err := b.modifyDBInstances(instance, newPlan, updateParameters, details); err != nil {
+				return updateSpec, err
 			}
-			return updateSpec, err
 		}
+
+		// The replicas themselves are created or deleted once the instance is available again, see replicasLastOperation
+		if replicaCount != instance.ReadReplicaCount {
+			if err := instance.SetReadReplicaCount(b.internalDB, replicaCount); err != nil {
+				return updateSpec, errors.New("Failed to save reference to local database")
+			}
+		}
+	}
+
+	updateSpec.OperationData = b.startOperation(instance, internaldb.UpdateOperation, newPlan.ID, details.RawParameters, updateSpec.IsAsync, "")
+	if !updateSpec.IsAsync {
+		b.updateInstancePlan(instance, newPlan.ID)
+	}
+
+	return updateSpec, nil
+}
+
+// modifyDBInstances applies a plan to the DB instance along with the rest of its Aurora cluster
+func (b *RDSBroker) modifyDBInstances(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) error {
+	modifyDBInstance := b.modifyDBInstance(instance, servicePlan, updateParameters, details)
+	if err := b.dbInstance.Modify(b.dbInstanceIdentifier(instance), *modifyDBInstance, updateParameters.ApplyImmediately); err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return brokerapi.ErrInstanceDoesNotExist
+		}
+		return err
+	}
+
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return b.scaleClusterInstances(instance, servicePlan, *modifyDBInstance, updateParameters.ApplyImmediately)
+	}
+	return nil
+}
+
+// checkAllocatedStorage only lets the storage of an instance grow, and only to less than the plan's
+// max_allocated_storage, which RDS needs to be higher to keep autoscaling. RDS can't shrink storage
+// so this is caught before anything is changed.
+func (b *RDSBroker) checkAllocatedStorage(instance *internaldb.DBInstance, servicePlan ServicePlan, allocatedStorage int64) error {
+	maxAllocatedStorage := servicePlan.RDSProperties.MaxAllocatedStorage
+	if maxAllocatedStorage == 0 {
+		return fmt.Errorf("allocated_storage is not supported for plan '%s'", servicePlan.Name)
+	}
+	if allocatedStorage >= maxAllocatedStorage {
+		return fmt.Errorf("allocated_storage must be less than %d for plan '%s'", maxAllocatedStorage, servicePlan.Name)
+	}
+
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return brokerapi.ErrInstanceDoesNotExist
+		}
+		return err
+	}
+	if allocatedStorage < dbInstanceDetails.AllocatedStorage {
+		return fmt.Errorf("allocated_storage can't be less than the current %d", dbInstanceDetails.AllocatedStorage)
+	}
+	return nil
+}
+
+// createManualSnapshot handles an update that only takes a snapshot. It's kept apart from modifying the
+// instance so a snapshot taken before a risky change can't be affected by the change itself.
+func (b *RDSBroker) createManualSnapshot(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) (brokerapi.UpdateServiceSpec, error) {
+	updateSpec := brokerapi.UpdateServiceSpec{IsAsync: true}
+
+	if servicePlan.RDSProperties.Shared {
+		return updateSpec, errors.New("Snapshots are not supported for shared plans")
+	}
+	if (details.PlanID != "" && details.PlanID != instance.PlanID) || updateParameters.modifies() {
+		return updateSpec, errors.New("create_snapshot can't be combined with other changes")
+	}
+	if !utils.IsValidSnapshotName(updateParameters.CreateSnapshot) {
+		return updateSpec, errors.New("create_snapshot must only contain letters, digits and single hyphens")
+	}
+
+	snapshotID := b.manualSnapshotIdentifier(instance, updateParameters.CreateSnapshot)
+	tags := b.dbTags("Created", instance.ServiceID, instance.PlanID, b.instanceOrganizationID(instance, servicePlan), instance.SpaceID)
+
+	var err error
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		err = b.dbCluster.CreateSnapshot(b.dbClusterIdentifier(instance), snapshotID, tags)
+	} else {
+		err = b.dbInstance.CreateSnapshot(b.dbInstanceIdentifier(instance), snapshotID, tags)
+	}
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist || err == awsrds.ErrDBClusterDoesNotExist {
+			return updateSpec, brokerapi.ErrInstanceDoesNotExist
+		}
+		return updateSpec, err
 	}
+	b.recordSnapshot(instance.InstanceID, snapshotID, internaldb.ManualSnapshot, servicePlan)
+
+	updateSpec.OperationData = b.startOperation(instance, internaldb.UpdateOperation, instance.PlanID, details.RawParameters, updateSpec.IsAsync, createSnapshotStage)
 
 	return updateSpec, nil
 }
@@ -257,13 +544,19 @@ func (b *RDSBroker) Deprovision(context context.Context, instanceID string, deta
 		return deprovisionSpec, brokerapi.ErrAsyncRequired
 	}
 
+	// The cloud controller deprovisions instances whose provision failed, which may have left them pending
+	if pending := internaldb.FindInstance(b.internalDB, instanceID); pending != nil && !pending.IsActive() {
+		deprovisionSpec.IsAsync = false
+		return deprovisionSpec, b.deprovisionPending(pending)
+	}
+
 	instance, _, servicePlan, err := b.findObjects(instanceID)
 	if err != nil {
 		return deprovisionSpec, err
 	}
 
 	skipDBInstanceFinalSnapshot := servicePlan.RDSProperties.SkipFinalSnapshot
-	if strings.ToLower(servicePlan.RDSProperties.Engine) == "aurora" {
+	if isAurora(servicePlan.RDSProperties.Engine) {
 		skipDBInstanceFinalSnapshot = true
 	}
 
@@ -287,25 +580,46 @@ func (b *RDSBroker) Deprovision(context context.Context, instanceID string, deta
 		}
 		deprovisionSpec.IsAsync = false
 	} else {
-		if err := b.dbInstance.Delete(b.dbInstanceIdentifier(instance), skipDBInstanceFinalSnapshot); err != nil {
-			if err == awsrds.ErrDBInstanceDoesNotExist {
+		if err := b.deleteReplicas(instance); err != nil {
+			return deprovisionSpec, err
+		}
+
+		if isAurora(servicePlan.RDSProperties.Engine) {
+			if err := b.deleteClusterInstances(instance); err != nil {
+				return deprovisionSpec, err
+			}
+		}
+
+		if servicePlan.RDSProperties.serverless() {
+			// There are no DB instances in the way so the cluster is deleted straight away
+			err = b.dbCluster.Delete(b.dbClusterIdentifier(instance), servicePlan.RDSProperties.SkipFinalSnapshot)
+		} else {
+			err = b.dbInstance.Delete(b.dbInstanceIdentifier(instance), skipDBInstanceFinalSnapshot)
+		}
+		if err != nil {
+			if err == awsrds.ErrDBInstanceDoesNotExist || err == awsrds.ErrDBClusterDoesNotExist {
+				// There is nothing left to delete so neither should there be a local reference
+				if err := instance.Delete(b.internalDB); err != nil {
+					b.logger.Error("delete-internal", err)
+				}
 				return deprovisionSpec, brokerapi.ErrInstanceDoesNotExist
 			}
 			return deprovisionSpec, err
 		}
 
-		if strings.ToLower(servicePlan.RDSProperties.Engine) == "aurora" {
+		if isAurora(servicePlan.RDSProperties.Engine) && !servicePlan.RDSProperties.serverless() {
 			b.dbCluster.Delete(b.dbClusterIdentifier(instance), servicePlan.RDSProperties.SkipFinalSnapshot)
 		}
 
 		// We do not delete the internal reference to the DB here because we've only started the delete process
-		// and we still need the reference for LastOperation()
+		// and we still need the reference for LastOperation(). It is deleted once the deprovision operation finishes.
+		deprovisionSpec.OperationData = b.startOperation(instance, internaldb.DeprovisionOperation, instance.PlanID, nil, deprovisionSpec.IsAsync, "")
 	}
 
 	return deprovisionSpec, nil
 }
 
-func (b *RDSBroker) Bind(context context.Context, instanceID, bindingID string, details brokerapi.BindDetails) (brokerapi.Binding, error) {
+func (b *RDSBroker) Bind(context context.Context, instanceID, bindingID string, details brokerapi.BindDetails, asyncAllowed bool) (brokerapi.Binding, error) {
 	b.logger.Debug("bind", lager.Data{
 		instanceIDLogKey: instanceID,
 		bindingIDLogKey:  bindingID,
@@ -323,24 +637,39 @@ func (b *RDSBroker) Bind(context context.Context, instanceID, bindingID string,
 		return binding, errors.New("Service is not bindable")
 	}
 
-	var sqlEngine sqlengine.SQLEngine
-	if servicePlan.RDSProperties.Shared {
-		sqlEngine = b.sharedEngines[servicePlan.RDSProperties.Engine]
-	} else {
-		var err error
-		sqlEngine, err = b.dedicatedSqlEngine(instance, servicePlan.RDSProperties.Engine)
-		if err != nil {
-			return binding, err
-		}
-		defer sqlEngine.Close()
+	bindParameters, err := b.bindParameters(details.RawParameters)
+	if err != nil {
+		return binding, err
+	}
+	userType, err := bindParameters.userType()
+	if err != nil {
+		return binding, err
+	}
+	if userType == internaldb.IAM && !servicePlan.RDSProperties.IAMDatabaseAuthentication {
+		return binding, fmt.Errorf("Service Plan '%s' doesn't support IAM database authentication", servicePlan.ID)
 	}
 
-	username, err := sqlEngine.CreateUsername(instance.InstanceID)
+	sqlEngine, closeEngine, err := b.bindingSqlEngine(instance, servicePlan, userType)
 	if err != nil {
 		return binding, err
 	}
+	if closeEngine {
+		defer sqlEngine.Close()
+	}
 
-	user, new, err := instance.Bind(b.internalDB, bindingID, username, internaldb.Standard, b.encryptionKey)
+	username := bindParameters.Username
+	if username != "" {
+		if err := b.checkBindUsername(sqlEngine, instance, servicePlan, username, userType); err != nil {
+			return binding, err
+		}
+	} else {
+		username, err = sqlEngine.CreateUsername(instance.InstanceID)
+		if err != nil {
+			return binding, err
+		}
+	}
+
+	user, new, err := instance.Bind(b.internalDB, bindingID, username, userType, b.encryptionKey)
 	if err != nil {
 		return binding, err
 	}
@@ -351,16 +680,33 @@ func (b *RDSBroker) Bind(context context.Context, instanceID, bindingID string,
 	}
 
 	if new {
-		if err = sqlEngine.CreateUser(user.Username, userPassword); err != nil {
+		if user.Type == internaldb.IAM {
+			err = sqlEngine.CreateIAMUser(user.Username)
+		} else {
+			err = sqlEngine.CreateUser(user.Username, userPassword, servicePlan.RDSProperties.RequireTLS)
+		}
+		if err != nil {
 			return binding, err
 		}
 
-		if err = sqlEngine.GrantPrivileges(instance.DBName, user.Username); err != nil {
+		if err = b.grantPrivileges(sqlEngine, instance, user); err != nil {
 			return binding, err
 		}
 	}
 
-	binding.Credentials = &CredentialsHash{
+	credentials, err := b.bindingCredentials(sqlEngine, instance, service, servicePlan, user, userPassword)
+	if err != nil {
+		return binding, err
+	}
+	binding.Credentials = credentials
+
+	return binding, nil
+}
+
+// bindingCredentials are the credentials handed to apps for a binding user, whether it's just been bound
+// or the binding is being fetched again
+func (b *RDSBroker) bindingCredentials(sqlEngine sqlengine.SQLEngine, instance *internaldb.DBInstance, service Service, servicePlan ServicePlan, user internaldb.DBUser, userPassword string) (*CredentialsHash, error) {
+	credentials := &CredentialsHash{
 		Host:     sqlEngine.Config().Url,
 		Port:     sqlEngine.Config().Port,
 		Name:     instance.DBName,
@@ -372,46 +718,82 @@ func (b *RDSBroker) Bind(context context.Context, instanceID, bindingID string,
 		// Alternate names for some applications (e.g. stratos)
 		Hostname: sqlEngine.Config().Url,
 		DBName:   instance.DBName,
+
+		ReadOnly: user.Type == internaldb.ReadOnly,
 	}
 
-	return binding, nil
+	if sqlEngine.Config().CACertificateFile != "" {
+		credentials.CACertificate = b.caCertificate
+	}
+
+	if len(instance.Replicas) > 0 {
+		credentials.ReplicaHosts = b.replicaHosts(instance)
+		if len(credentials.ReplicaHosts) > 0 {
+			credentials.ReadOnlyURI = replicaURI(credentials.URI, credentials.ReplicaHosts[0], credentials.Port)
+		}
+	}
+	if isAurora(servicePlan.RDSProperties.Engine) && !servicePlan.RDSProperties.serverless() {
+		credentials.ReaderEndpoint = b.readerEndpoint(instance)
+		if credentials.ReaderEndpoint != "" {
+			credentials.ReadOnlyURI = replicaURI(credentials.URI, credentials.ReaderEndpoint, credentials.Port)
+		}
+	}
+	if user.Type == internaldb.IAM {
+		if err := b.iamCredentials(credentials, instance, servicePlan); err != nil {
+			return nil, err
+		}
+	}
+
+	templated, err := service.CredentialTemplates.render(credentialTemplateData{
+		Host:     credentials.Host,
+		Port:     credentials.Port,
+		DBName:   credentials.Name,
+		Username: credentials.Username,
+		Password: credentials.Password,
+		Engine:   servicePlan.RDSProperties.Engine,
+	})
+	if err != nil {
+		return nil, err
+	}
+	credentials.Templated = templated
+
+	return credentials, nil
 }
 
-func (b *RDSBroker) Unbind(context context.Context, instanceID, bindingID string, details brokerapi.UnbindDetails) error {
+func (b *RDSBroker) Unbind(context context.Context, instanceID, bindingID string, details brokerapi.UnbindDetails, asyncAllowed bool) (brokerapi.UnbindSpec, error) {
 	b.logger.Debug("unbind", lager.Data{
 		instanceIDLogKey: instanceID,
 		bindingIDLogKey:  bindingID,
 		detailsLogKey:    details,
 	})
 
+	unbindSpec := brokerapi.UnbindSpec{}
+
 	instance, _, servicePlan, err := b.findObjects(instanceID)
 	if err != nil {
-		return err
+		return unbindSpec, err
 	}
 
 	user, delete, err := instance.Unbind(b.internalDB, bindingID)
 	if err != nil {
-		return err
+		return unbindSpec, err
 	}
 
 	if delete {
-		var sqlEngine sqlengine.SQLEngine
-		if servicePlan.RDSProperties.Shared {
-			sqlEngine = b.sharedEngines[servicePlan.RDSProperties.Engine]
-		} else {
-			sqlEngine, err = b.dedicatedSqlEngine(instance, servicePlan.RDSProperties.Engine)
-			if err != nil {
-				return err
-			}
+		sqlEngine, closeEngine, err := b.bindingSqlEngine(instance, servicePlan, user.Type)
+		if err != nil {
+			return unbindSpec, err
+		}
+		if closeEngine {
 			defer sqlEngine.Close()
 		}
 
-		if err = sqlEngine.RevokePrivileges(instance.DBName, user.Username); err != nil {
-			return err
+		if err = b.revokePrivileges(sqlEngine, instance, user); err != nil {
+			return unbindSpec, err
 		}
 
 		if err = sqlEngine.DropUser(user.Username); err != nil {
-			return err
+			return unbindSpec, err
 		}
 
 		if err = user.Delete(b.internalDB); err != nil {
@@ -420,12 +802,13 @@ func (b *RDSBroker) Unbind(context context.Context, instanceID, bindingID string
 		}
 	}
 
-	return nil
+	return unbindSpec, nil
 }
 
-func (b *RDSBroker) LastOperation(context context.Context, instanceID, operationData string) (brokerapi.LastOperation, error) {
+func (b *RDSBroker) LastOperation(context context.Context, instanceID string, details brokerapi.PollDetails) (brokerapi.LastOperation, error) {
 	b.logger.Debug("last-operation", lager.Data{
-		instanceIDLogKey: instanceID,
+		instanceIDLogKey:    instanceID,
+		operationDataLogKey: details.OperationData,
 	})
 
 	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
@@ -435,10 +818,108 @@ func (b *RDSBroker) LastOperation(context context.Context, instanceID, operation
 		return lastOperation, err
 	}
 
-	if servicePlan.RDSProperties.Shared {
-		// shared instances don't have async operations
-		return brokerapi.LastOperation{State: brokerapi.Failed, Description: "No last operation"}, nil
+	operation := b.findOperation(instance, details.OperationData)
+	if operation == nil {
+		// Instances created before operations were recorded
+		if servicePlan.RDSProperties.Shared {
+			// shared instances don't have async operations
+			return brokerapi.LastOperation{State: brokerapi.Failed, Description: "No last operation"}, nil
+		}
+		return b.dedicatedLastOperation(instance, servicePlan)
+	}
+
+	if operation.Finished() {
+		return brokerapi.LastOperation{
+			State:       brokerapi.LastOperationState(operation.State),
+			Description: operation.Description,
+		}, nil
+	}
+
+	switch operation.Type {
+	case internaldb.ProvisionOperation:
+		lastOperation, err = b.provisionLastOperation(instance, servicePlan, operation)
+	case internaldb.DeprovisionOperation:
+		return b.deprovisionLastOperation(instance, servicePlan)
+	case internaldb.UpdateOperation:
+		switch operation.Stage {
+		case createSnapshotStage:
+			lastOperation, err = b.snapshotLastOperation(instance, servicePlan, operation)
+		case migrateToDedicatedStage, copyQueuedStage, copyDatabaseStage, copyFailedStage, copyTimedOutStage, dropSharedDatabaseStage:
+			lastOperation, err = b.migrationLastOperation(instance, servicePlan, operation)
+		case rotateMasterPasswordStage:
+			lastOperation, err = b.masterPasswordLastOperation(instance, servicePlan, operation)
+		default:
+			lastOperation, err = b.updateLastOperation(instance, servicePlan, operation)
+		}
+	case internaldb.UpgradeOperation:
+		lastOperation, err = b.upgradeLastOperation(instance, operation)
+	default:
+		lastOperation, err = b.dedicatedLastOperation(instance, servicePlan)
+	}
+	if err != nil {
+		return lastOperation, err
+	}
+
+	if lastOperation.State == brokerapi.Succeeded && operation.Stage == "" &&
+		(operation.Type == internaldb.ProvisionOperation || operation.Type == internaldb.UpdateOperation) {
+		lastOperation = b.tlsLastOperation(instance, operation, lastOperation)
+	}
+
+	b.finishOperation(instance, operation, lastOperation)
+
+	return lastOperation, nil
+}
+
+// Updates finish once the instance has applied the changes of the plan it's moving to and has the
+// read replicas or cluster instances the update asked for
+func (b *RDSBroker) updateLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation, err := b.dedicatedLastOperation(instance, servicePlan)
+	if err != nil || lastOperation.State != brokerapi.Succeeded || servicePlan.RDSProperties.Shared {
+		return lastOperation, err
+	}
+
+	if newPlan, ok := b.catalog.FindServicePlan(instance.ServiceID, operation.PlanID); ok {
+		servicePlan = newPlan
 	}
+	return b.secondaryInstancesLastOperation(instance, servicePlan, lastOperation)
+}
+
+// dedicatedLastOperation reports on the DB instance, or the DB cluster itself when it's serverless
+func (b *RDSBroker) dedicatedLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan) (brokerapi.LastOperation, error) {
+	if servicePlan.RDSProperties.serverless() {
+		return b.dbClusterLastOperation(instance)
+	}
+	return b.dbInstanceLastOperation(instance)
+}
+
+func (b *RDSBroker) dbClusterLastOperation(instance *internaldb.DBInstance) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
+
+	dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBClusterDoesNotExist {
+			// The cluster doesn't exist on AWS but we have a local reference to it
+			// We should get rid of our local reference
+			if err := instance.Delete(b.internalDB); err != nil {
+				b.logger.Error("delete-internal", err)
+			}
+
+			return lastOperation, brokerapi.ErrInstanceDoesNotExist
+		}
+		return lastOperation, err
+	}
+
+	lastOperation.Description = fmt.Sprintf("DB Cluster '%s' status is '%s'", b.dbClusterIdentifier(instance), dbClusterDetails.Status)
+
+	if state, ok := rdsStatus2State[dbClusterDetails.Status]; ok {
+		lastOperation.State = state
+	}
+
+	return lastOperation, nil
+}
+
+func (b *RDSBroker) dbInstanceLastOperation(instance *internaldb.DBInstance) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
 
 	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
 	if err != nil {
@@ -468,6 +949,174 @@ func (b *RDSBroker) LastOperation(context context.Context, instanceID, operation
 	return lastOperation, nil
 }
 
+// Restored instances keep the master password of the original and miss the settings that can't be
+// given when restoring, so once the restore has finished the plan is applied again along with
+// the master password generated for this instance.
+func (b *RDSBroker) provisionLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	if operation.Stage == cloneSnapshotStage {
+		return b.cloneLastOperation(instance, servicePlan, operation)
+	}
+
+	lastOperation, err := b.dedicatedLastOperation(instance, servicePlan)
+	if err != nil || lastOperation.State != brokerapi.Succeeded {
+		return lastOperation, err
+	}
+
+	provisionParameters, err := b.provisionParameters([]byte(operation.Parameters))
+	if err != nil || !provisionParameters.restoring() || operation.Stage != "" {
+		return b.secondaryInstancesLastOperation(instance, servicePlan, lastOperation)
+	}
+
+	if err = b.resetMasterPassword(instance, servicePlan); err != nil {
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+	b.syncDBName(instance, servicePlan)
+
+	if err = operation.SetStage(b.internalDB, resetMasterPasswordStage); err != nil {
+		b.logger.Error("set-operation-stage", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+
+	return brokerapi.LastOperation{
+		State:       brokerapi.InProgress,
+		Description: fmt.Sprintf("Resetting the master password of restored DB Instance '%s'", b.dbInstanceIdentifier(instance)),
+	}, nil
+}
+
+// Dedicated clones wait for the snapshot of their source before restoring from it. From then on the
+// provision carries on like any other restore.
+func (b *RDSBroker) cloneLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	snapshotID := b.cloneSnapshotIdentifier(instance)
+
+	snapshot, err := b.describeSnapshot(snapshotID, servicePlan)
+	if err != nil {
+		if err == awsrds.ErrDBSnapshotDoesNotExist {
+			return brokerapi.LastOperation{
+				State:       brokerapi.Failed,
+				Description: fmt.Sprintf("Snapshot '%s' of the cloned instance has gone", snapshotID),
+			}, nil
+		}
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+
+	switch snapshot.Status {
+	case "available":
+	case "creating":
+		return brokerapi.LastOperation{
+			State:       brokerapi.InProgress,
+			Description: fmt.Sprintf("Snapshot '%s' status is '%s'", snapshotID, snapshot.Status),
+		}, nil
+	default:
+		return brokerapi.LastOperation{
+			State:       brokerapi.Failed,
+			Description: fmt.Sprintf("Snapshot '%s' status is '%s'", snapshotID, snapshot.Status),
+		}, nil
+	}
+
+	provisionParameters, err := b.provisionParameters([]byte(operation.Parameters))
+	if err != nil {
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+	details := brokerapi.ProvisionDetails{
+		ServiceID:        instance.ServiceID,
+		PlanID:           instance.PlanID,
+		OrganizationGUID: instance.OrganizationID,
+		SpaceGUID:        instance.SpaceID,
+	}
+
+	// The internal reference is kept so the failed instance can still be deprovisioned
+	rollback := newSaga(b.logger.Session("clone", lager.Data{instanceIDLogKey: instance.InstanceID}))
+	if err = b.createDedicatedResources(instance, servicePlan, provisionParameters, details, restoreSource{snapshotID: snapshotID}, rollback); err != nil {
+		rollback.run()
+		return brokerapi.LastOperation{
+			State:       brokerapi.Failed,
+			Description: fmt.Sprintf("Failed to restore snapshot '%s': %s", snapshotID, err),
+		}, nil
+	}
+
+	if err = operation.SetStage(b.internalDB, ""); err != nil {
+		b.logger.Error("set-operation-stage", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+
+	return brokerapi.LastOperation{
+		State:       brokerapi.InProgress,
+		Description: fmt.Sprintf("Restoring DB Instance '%s' from snapshot '%s'", b.dbInstanceIdentifier(instance), snapshotID),
+	}, nil
+}
+
+func (b *RDSBroker) snapshotLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
+
+	updateParameters, err := b.updateParameters([]byte(operation.Parameters))
+	if err != nil {
+		return lastOperation, err
+	}
+	snapshotID := b.manualSnapshotIdentifier(instance, updateParameters.CreateSnapshot)
+
+	snapshot, err := b.describeSnapshot(snapshotID, servicePlan)
+	if err != nil {
+		if err == awsrds.ErrDBSnapshotDoesNotExist {
+			lastOperation.Description = fmt.Sprintf("Snapshot '%s' has gone", snapshotID)
+			return lastOperation, nil
+		}
+		return lastOperation, err
+	}
+
+	lastOperation.Description = fmt.Sprintf("Snapshot '%s' status is '%s'", snapshotID, snapshot.Status)
+	switch snapshot.Status {
+	case "available":
+		lastOperation.State = brokerapi.Succeeded
+	case "creating":
+		lastOperation.State = brokerapi.InProgress
+	}
+
+	return lastOperation, nil
+}
+
+func (b *RDSBroker) deprovisionLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.InProgress}
+
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err == nil {
+		lastOperation.Description = fmt.Sprintf("DB Instance '%s' status is '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.Status)
+		return lastOperation, nil
+	}
+	if err != awsrds.ErrDBInstanceDoesNotExist {
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+
+	replicaOperation, err := b.replicasDeprovisionLastOperation(instance)
+	if err != nil {
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+	if replicaOperation != nil {
+		return *replicaOperation, nil
+	}
+
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+		if err == nil {
+			lastOperation.Description = fmt.Sprintf("DB Cluster '%s' status is '%s'", b.dbClusterIdentifier(instance), dbClusterDetails.Status)
+			return lastOperation, nil
+		}
+		if err != awsrds.ErrDBClusterDoesNotExist && err != awsrds.ErrDBInstanceDoesNotExist {
+			return brokerapi.LastOperation{State: brokerapi.Failed}, err
+		}
+	}
+
+	if !servicePlan.RDSProperties.SkipFinalSnapshot {
+		b.recordFinalSnapshots(instance, servicePlan)
+	}
+
+	// Everything on AWS is gone so the deprovision is complete. This also removes the operation itself.
+	if err := instance.Delete(b.internalDB); err != nil {
+		b.logger.Error("delete-internal", err)
+	}
+
+	lastOperation.State = brokerapi.Succeeded
+	lastOperation.Description = fmt.Sprintf("DB Instance '%s' has been deleted", b.dbInstanceIdentifier(instance))
+	return lastOperation, nil
+}
+
 func (b *RDSBroker) dbClusterIdentifier(instance *internaldb.DBInstance) string {
 	return fmt.Sprintf("%s-%s", b.dbPrefix, strings.Replace(instance.InstanceID, "_", "-", -1))
 }
@@ -477,7 +1126,7 @@ func (b *RDSBroker) dbInstanceIdentifier(instance *internaldb.DBInstance) string
 }
 
 func (b *RDSBroker) dbConnInfo(instance *internaldb.DBInstance, engine string) (dbAddress, dbName string, dbPort int64, err error) {
-	if strings.ToLower(engine) == "aurora" {
+	if isAurora(engine) {
 		var dbClusterDetails awsrds.DBClusterDetails
 		dbClusterDetails, err = b.dbCluster.Describe(b.dbClusterIdentifier(instance))
 		if err != nil {
@@ -516,22 +1165,45 @@ func (b *RDSBroker) dbConnInfo(instance *internaldb.DBInstance, engine string) (
 }
 
 func (b *RDSBroker) dedicatedSqlEngine(instance *internaldb.DBInstance, engine string) (sqlEngine sqlengine.SQLEngine, err error) {
-	conf := config.DBConfig{Sslmode: config.RequireNoVerify}
-	conf.Url, conf.DBName, conf.Port, err = b.dbConnInfo(instance, engine)
-	if err != nil {
-		return
-	}
-
 	masterUser := instance.MasterUser()
 	if masterUser == nil {
 		err = errors.New("Failed to find master user")
 		return
 	}
-	conf.Username = masterUser.Username
-	conf.Password, err = masterUser.Password(b.encryptionKey)
+	password, err := masterUser.Password(b.encryptionKey)
 	if err != nil {
 		return
 	}
+	sqlEngine, err = b.masterSqlEngine(instance, engine, password)
+	if err != nil {
+		return
+	}
+
+	// Once RDS has reset the master password only the pending one works, until the rotation is finished
+	pendingPassword, err := masterUser.PendingPassword(b.encryptionKey)
+	if err != nil {
+		sqlEngine.Close()
+		return nil, err
+	}
+	if pendingPassword == "" || sqlEngine.Ping() == nil {
+		return sqlEngine, nil
+	}
+	sqlEngine.Close()
+	return b.masterSqlEngine(instance, engine, pendingPassword)
+}
+
+// masterSqlEngine connects to the database of a dedicated instance as its master user with the given password
+func (b *RDSBroker) masterSqlEngine(instance *internaldb.DBInstance, engine string, password string) (sqlEngine sqlengine.SQLEngine, err error) {
+	conf := config.DBConfig{Sslmode: config.RequireNoVerify, Password: password}
+	if b.caCertificateFile != "" {
+		conf.Sslmode = config.Verify
+		conf.CACertificateFile = b.caCertificateFile
+	}
+	conf.Url, conf.DBName, conf.Port, err = b.dbConnInfo(instance, engine)
+	if err != nil {
+		return
+	}
+	conf.Username = instance.MasterUser().Username
 
 	sqlEngine, err = b.sqlProvider.GetSQLEngine(engine)
 	if err != nil {
@@ -562,6 +1234,70 @@ func (b *RDSBroker) sharedSqlEngine(instance *internaldb.DBInstance, engine stri
 	return
 }
 
+// bindingSqlEngine connects to where the users of bindings are managed, which for shared plans is the
+// shared server. Read only privileges are granted on the tables of the database so they need a connection
+// to it rather than the one the shared server is managed through. The engine only needs closing when
+// closeEngine is set.
+func (b *RDSBroker) bindingSqlEngine(instance *internaldb.DBInstance, servicePlan ServicePlan, userType internaldb.DBUserType) (sqlEngine sqlengine.SQLEngine, closeEngine bool, err error) {
+	engine := servicePlan.RDSProperties.Engine
+	switch {
+	case servicePlan.RDSProperties.Shared && userType != internaldb.ReadOnly:
+		return b.sharedEngines[engine], false, nil
+	case servicePlan.RDSProperties.Shared:
+		sqlEngine, err = b.sharedSqlEngine(instance, engine)
+	default:
+		sqlEngine, err = b.dedicatedSqlEngine(instance, engine)
+	}
+	return sqlEngine, err == nil, err
+}
+
+// resetMasterPassword gives a restored instance the master password the broker has for it
+func (b *RDSBroker) resetMasterPassword(instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	masterUser := instance.MasterUser()
+	if masterUser == nil {
+		return errors.New("Failed to find master user")
+	}
+	password, err := masterUser.Password(b.encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	// Restores don't take all of the settings of the plan so it's applied again along with the password
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		modifyDBCluster := b.dbClusterFromPlan(servicePlan)
+		modifyDBCluster.MasterUserPassword = password
+		return b.dbCluster.Modify(b.dbClusterIdentifier(instance), *modifyDBCluster, true)
+	}
+
+	modifyDBInstance := b.dbInstanceFromPlan(servicePlan)
+	modifyDBInstance.MasterUserPassword = password
+	return b.dbInstance.Modify(b.dbInstanceIdentifier(instance), *modifyDBInstance, true)
+}
+
+// setMasterPassword has RDS reset the master password, which it does straight away whatever the maintenance window.
+// Nothing else is sent, the instance may have moved on from its plan, for example to a newer engine version.
+func (b *RDSBroker) setMasterPassword(instance *internaldb.DBInstance, servicePlan ServicePlan, password string) error {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return b.dbCluster.ModifyMasterPassword(b.dbClusterIdentifier(instance), password)
+	}
+	return b.dbInstance.ModifyMasterPassword(b.dbInstanceIdentifier(instance), password)
+}
+
+// Restored instances keep the database name of the original
+func (b *RDSBroker) syncDBName(instance *internaldb.DBInstance, servicePlan ServicePlan) {
+	_, dbName, _, err := b.dbConnInfo(instance, servicePlan.RDSProperties.Engine)
+	if err != nil {
+		b.logger.Error("sync-db-name", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return
+	}
+	if dbName == instance.DBName {
+		return
+	}
+	if err = b.internalDB.Model(instance).Update("db_name", dbName).Error; err != nil {
+		b.logger.Error("sync-db-name", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+}
+
 func (b *RDSBroker) createDBCluster(instance *internaldb.DBInstance, servicePlan ServicePlan, provisionParameters ProvisionParameters, details brokerapi.ProvisionDetails) *awsrds.DBClusterDetails {
 	dbClusterDetails := b.dbClusterFromPlan(servicePlan)
 	dbClusterDetails.DatabaseName = instance.DBName
@@ -590,6 +1326,12 @@ func (b *RDSBroker) createDBCluster(instance *internaldb.DBInstance, servicePlan
 	return dbClusterDetails
 }
 
+func (b *RDSBroker) restoreDBCluster(servicePlan ServicePlan, details brokerapi.ProvisionDetails) *awsrds.DBClusterDetails {
+	dbClusterDetails := b.dbClusterFromPlan(servicePlan)
+	dbClusterDetails.Tags = b.dbTags("Created", details.ServiceID, details.PlanID, details.OrganizationGUID, details.SpaceGUID)
+	return dbClusterDetails
+}
+
 func (b *RDSBroker) modifyDBCluster(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) *awsrds.DBClusterDetails {
 	dbClusterDetails := b.dbClusterFromPlan(servicePlan)
 
@@ -615,6 +1357,21 @@ func (b *RDSBroker) dbClusterFromPlan(servicePlan ServicePlan) *awsrds.DBCluster
 		Engine: servicePlan.RDSProperties.Engine,
 	}
 
+	if servicePlan.RDSProperties.EngineMode != "" {
+		dbClusterDetails.EngineMode = servicePlan.RDSProperties.EngineMode
+	}
+
+	if servicePlan.RDSProperties.serverless() {
+		dbClusterDetails.ScalingConfiguration = &awsrds.ScalingConfiguration{
+			MinCapacity:           servicePlan.RDSProperties.MinCapacity,
+			MaxCapacity:           servicePlan.RDSProperties.MaxCapacity,
+			AutoPause:             servicePlan.RDSProperties.AutoPause,
+			SecondsUntilAutoPause: servicePlan.RDSProperties.SecondsUntilAutoPause,
+		}
+	}
+
+	dbClusterDetails.IAMDatabaseAuthenticationEnabled = servicePlan.RDSProperties.IAMDatabaseAuthentication
+
 	if servicePlan.RDSProperties.AvailabilityZone != "" {
 		dbClusterDetails.AvailabilityZones = []string{servicePlan.RDSProperties.AvailabilityZone}
 	}
@@ -657,7 +1414,7 @@ func (b *RDSBroker) dbClusterFromPlan(servicePlan ServicePlan) *awsrds.DBCluster
 func (b *RDSBroker) createDBInstance(instance *internaldb.DBInstance, servicePlan ServicePlan, provisionParameters ProvisionParameters, details brokerapi.ProvisionDetails) *awsrds.DBInstanceDetails {
 	dbInstanceDetails := b.dbInstanceFromPlan(servicePlan)
 
-	if strings.ToLower(servicePlan.RDSProperties.Engine) == "aurora" {
+	if isAurora(servicePlan.RDSProperties.Engine) {
 		dbInstanceDetails.DBClusterIdentifier = b.dbClusterIdentifier(instance)
 	} else {
 		dbInstanceDetails.DBName = instance.DBName
@@ -691,10 +1448,20 @@ func (b *RDSBroker) createDBInstance(instance *internaldb.DBInstance, servicePla
 	return dbInstanceDetails
 }
 
+func (b *RDSBroker) restoreDBInstance(servicePlan ServicePlan, details brokerapi.ProvisionDetails) *awsrds.DBInstanceDetails {
+	dbInstanceDetails := b.dbInstanceFromPlan(servicePlan)
+	dbInstanceDetails.Tags = b.dbTags("Created", details.ServiceID, details.PlanID, details.OrganizationGUID, details.SpaceGUID)
+	return dbInstanceDetails
+}
+
 func (b *RDSBroker) modifyDBInstance(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) *awsrds.DBInstanceDetails {
 	dbInstanceDetails := b.dbInstanceFromPlan(servicePlan)
 
-	if strings.ToLower(servicePlan.RDSProperties.Engine) != "aurora" {
+	if !isAurora(servicePlan.RDSProperties.Engine) {
+		if updateParameters.AllocatedStorage > 0 {
+			dbInstanceDetails.AllocatedStorage = updateParameters.AllocatedStorage
+		}
+
 		if updateParameters.BackupRetentionPeriod > 0 {
 			dbInstanceDetails.BackupRetentionPeriod = updateParameters.BackupRetentionPeriod
 		}
@@ -708,6 +1475,9 @@ func (b *RDSBroker) modifyDBInstance(instance *internaldb.DBInstance, servicePla
 		dbInstanceDetails.PreferredMaintenanceWindow = updateParameters.PreferredMaintenanceWindow
 	}
 
+	// Don't take an upgraded instance back to its plan's engine version
+	dbInstanceDetails.EngineVersion = engineVersion(instance, servicePlan)
+
 	dbInstanceDetails.Tags = b.dbTags("Updated", details.ServiceID, details.PlanID, "", "")
 
 	return dbInstanceDetails
@@ -749,11 +1519,15 @@ func (b *RDSBroker) dbInstanceFromPlan(servicePlan ServicePlan) *awsrds.DBInstan
 
 	dbInstanceDetails.PubliclyAccessible = servicePlan.RDSProperties.PubliclyAccessible
 
-	if strings.ToLower(servicePlan.RDSProperties.Engine) != "aurora" {
+	if !isAurora(servicePlan.RDSProperties.Engine) {
 		if servicePlan.RDSProperties.AllocatedStorage > 0 {
 			dbInstanceDetails.AllocatedStorage = servicePlan.RDSProperties.AllocatedStorage
 		}
 
+		if servicePlan.RDSProperties.MaxAllocatedStorage > 0 {
+			dbInstanceDetails.MaxAllocatedStorage = servicePlan.RDSProperties.MaxAllocatedStorage
+		}
+
 		if servicePlan.RDSProperties.BackupRetentionPeriod > 0 {
 			dbInstanceDetails.BackupRetentionPeriod = servicePlan.RDSProperties.BackupRetentionPeriod
 		}
@@ -778,6 +1552,9 @@ func (b *RDSBroker) dbInstanceFromPlan(servicePlan ServicePlan) *awsrds.DBInstan
 			dbInstanceDetails.LicenseModel = servicePlan.RDSProperties.LicenseModel
 		}
 
+		// The cluster has the setting for Aurora instances
+		dbInstanceDetails.IAMDatabaseAuthenticationEnabled = servicePlan.RDSProperties.IAMDatabaseAuthentication
+
 		dbInstanceDetails.MultiAZ = servicePlan.RDSProperties.MultiAZ
 
 		if servicePlan.RDSProperties.Port > 0 {
@@ -805,9 +1582,7 @@ func (b *RDSBroker) dbInstanceFromPlan(servicePlan ServicePlan) *awsrds.DBInstan
 func (b *RDSBroker) dbTags(action, serviceID, planID, organizationID, spaceID string) map[string]string {
 	tags := make(map[string]string)
 
-	// This tag is used by the IAM policy to grant access to modify the database
-	// Don't change this tag without also changing iam_policy.json and the IAM policy in your AWS account
-	tags["Managed by"] = "github.com/AusDTO/pe-rds-broker"
+	tags[managedByTag] = managedByValue
 
 	tags["Owner"] = "Cloud Foundry"
 
@@ -824,7 +1599,7 @@ func (b *RDSBroker) dbTags(action, serviceID, planID, organizationID, spaceID st
 	}
 
 	if organizationID != "" {
-		tags["Organization ID"] = organizationID
+		tags[organizationIDTag] = organizationID
 	}
 
 	if spaceID != "" {
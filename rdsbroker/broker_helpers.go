@@ -1,16 +1,26 @@
 package rdsbroker
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/lager"
+	"github.com/AusDTO/pe-rds-broker/awsrds"
 	"github.com/AusDTO/pe-rds-broker/internaldb"
+	"github.com/AusDTO/pe-rds-broker/sqlengine"
+	"github.com/AusDTO/pe-rds-broker/utils"
 	"github.com/pivotal-cf/brokerapi"
 )
 
 func (b *RDSBroker) findObjects(instanceID string) (instance *internaldb.DBInstance, service Service, plan ServicePlan, err error) {
 	var ok bool
 	instance = internaldb.FindInstance(b.internalDB, instanceID)
-	if instance == nil {
+	// The cloud controller doesn't know about instances until they've been provisioned
+	if instance == nil || !instance.IsActive() {
+		instance = nil
 		err = brokerapi.ErrInstanceDoesNotExist
 		return
 	}
@@ -35,12 +45,407 @@ func CanUpdate(oldPlan, newPlan ServicePlan, service Service, parameters UpdateP
 		return false
 	}
 	if oldPlan.ID != newPlan.ID {
-		if oldPlan.RDSProperties.Shared != newPlan.RDSProperties.Shared {
+		// Instances can move off a shared server but not onto one
+		if !oldPlan.RDSProperties.Shared && newPlan.RDSProperties.Shared {
 			return false
 		}
 		if oldPlan.RDSProperties.Engine != newPlan.RDSProperties.Engine {
 			return false
 		}
+		if oldPlan.RDSProperties.serverless() != newPlan.RDSProperties.serverless() {
+			return false
+		}
 	}
 	return true
 }
+
+// startOperation records a new operation against the instance and returns the operation data to hand back
+// to the cloud controller. Synchronous operations are recorded as already succeeded.
+// Failing to record an operation is logged but not fatal, LastOperation falls back to the RDS status.
+func (b *RDSBroker) startOperation(instance *internaldb.DBInstance, operationType internaldb.OperationType, planID string, parameters []byte, async bool, stage string) string {
+	operation := instance.NewOperation(operationType, planID, parameters)
+	operation.Stage = stage
+	if err := b.internalDB.Save(operation).Error; err != nil {
+		b.logger.Error("save-operation", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return ""
+	}
+	if !async {
+		if err := operation.Succeed(b.internalDB, fmt.Sprintf("%s completed", strings.Title(string(operationType)))); err != nil {
+			b.logger.Error("finish-operation", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		}
+		return ""
+	}
+	return operation.OperationData()
+}
+
+// findOperation finds the operation the cloud controller is asking about. Older cloud controllers
+// (or instances created before operations were recorded) don't send operation data in which case
+// we use the most recent operation, if any.
+func (b *RDSBroker) findOperation(instance *internaldb.DBInstance, operationData string) *internaldb.DBOperation {
+	if operationData != "" {
+		if operation := internaldb.FindOperation(b.internalDB, instance, operationData); operation != nil {
+			return operation
+		}
+	}
+	return internaldb.LatestOperation(b.internalDB, instance)
+}
+
+// finishOperation records the outcome of an operation once it is no longer in progress
+func (b *RDSBroker) finishOperation(instance *internaldb.DBInstance, operation *internaldb.DBOperation, lastOperation brokerapi.LastOperation) {
+	var err error
+	switch lastOperation.State {
+	case brokerapi.Succeeded:
+		if operation.Type == internaldb.UpdateOperation {
+			b.updateInstancePlan(instance, operation.PlanID)
+		}
+		err = operation.Succeed(b.internalDB, lastOperation.Description)
+	case brokerapi.Failed:
+		err = operation.Fail(b.internalDB, lastOperation.Description)
+	}
+	if err != nil {
+		b.logger.Error("finish-operation", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+}
+
+func (b *RDSBroker) updateInstancePlan(instance *internaldb.DBInstance, planID string) {
+	if planID == "" || planID == instance.PlanID {
+		return
+	}
+	if err := b.internalDB.Model(instance).Update("plan_id", planID).Error; err != nil {
+		b.logger.Error("update-plan", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+}
+
+func (b *RDSBroker) provisionParameters(rawParameters []byte) (ProvisionParameters, error) {
+	provisionParameters := ProvisionParameters{}
+	if b.allowUserProvisionParameters && len(rawParameters) > 0 {
+		if err := json.Unmarshal(rawParameters, &provisionParameters); err != nil {
+			return provisionParameters, err
+		}
+	}
+	return provisionParameters, nil
+}
+
+func (b *RDSBroker) updateParameters(rawParameters []byte) (UpdateParameters, error) {
+	updateParameters := UpdateParameters{}
+	if b.allowUserUpdateParameters && len(rawParameters) > 0 {
+		if err := json.Unmarshal(rawParameters, &updateParameters); err != nil {
+			return updateParameters, err
+		}
+	}
+	return updateParameters, nil
+}
+
+func (b *RDSBroker) bindParameters(rawParameters []byte) (BindParameters, error) {
+	bindParameters := BindParameters{}
+	if b.allowUserBindParameters && len(rawParameters) > 0 {
+		if err := json.Unmarshal(rawParameters, &bindParameters); err != nil {
+			return bindParameters, err
+		}
+	}
+	return bindParameters, nil
+}
+
+// checkBindUsername makes sure a username asked for when binding is either new or belongs to another
+// binding of the same instance with the same role, in which case the bindings share the user. Names of
+// other users or roles on the server, and the owner role of any instance, would let the binding act as
+// them so are refused whether or not the broker created them.
+func (b *RDSBroker) checkBindUsername(sqlEngine sqlengine.SQLEngine, instance *internaldb.DBInstance, servicePlan ServicePlan, username string, userType internaldb.DBUserType) error {
+	if !utils.IsSimpleIdentifier(username) {
+		return errors.New("username must start with a letter and only contain letters, digits and underscores")
+	}
+
+	// Owner roles only exist once an instance has been bound, so they're checked for every instance
+	instanceIDs, err := internaldb.InstanceIDs(b.internalDB)
+	if err != nil {
+		return err
+	}
+	for _, instanceID := range instanceIDs {
+		if owner := sqlEngine.OwnerRole(instanceID); owner != "" && username == owner {
+			return fmt.Errorf("Username '%s' is reserved for the owner of a database", username)
+		}
+	}
+
+	if user := instance.User(username); user != nil {
+		if user.Type != userType {
+			return fmt.Errorf("Username '%s' is already in use", username)
+		}
+		return nil
+	}
+
+	exists, err := sqlEngine.UserExists(username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("Username '%s' is already in use", username)
+	}
+
+	if servicePlan.RDSProperties.Shared {
+		if username == b.sharedEngines[servicePlan.RDSProperties.Engine].Config().Username {
+			return fmt.Errorf("Username '%s' is already in use", username)
+		}
+		users, err := internaldb.FindUsers(b.internalDB, username)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			return fmt.Errorf("Username '%s' is already in use", username)
+		}
+	}
+
+	return nil
+}
+
+// grantPrivileges gives a new binding user access to the database of its instance. Users with full access
+// act as the owner role of the database, where the engine has one.
+func (b *RDSBroker) grantPrivileges(sqlEngine sqlengine.SQLEngine, instance *internaldb.DBInstance, user internaldb.DBUser) error {
+	owner := sqlEngine.OwnerRole(instance.InstanceID)
+	if user.Type == internaldb.ReadOnly {
+		return sqlEngine.GrantReadOnlyPrivileges(instance.DBName, user.Username, tableOwners(owner))
+	}
+
+	if err := sqlEngine.GrantPrivileges(instance.DBName, user.Username); err != nil {
+		return err
+	}
+	// Instances bound before each binding had its own login bind with the owner role itself
+	if owner == "" || owner == user.Username {
+		return nil
+	}
+	if err := sqlEngine.CreateOwnerRole(instance.DBName, owner); err != nil {
+		return err
+	}
+	return sqlEngine.GrantOwnerRole(owner, user.Username)
+}
+
+// revokePrivileges takes back what grantPrivileges gave a binding user
+func (b *RDSBroker) revokePrivileges(sqlEngine sqlengine.SQLEngine, instance *internaldb.DBInstance, user internaldb.DBUser) error {
+	owner := sqlEngine.OwnerRole(instance.InstanceID)
+	if user.Type == internaldb.ReadOnly {
+		return sqlEngine.RevokeReadOnlyPrivileges(instance.DBName, user.Username, tableOwners(owner))
+	}
+
+	// A binding made before each had its own login is the owner role, which keeps its privileges for
+	// the other bindings and the objects it owns
+	if owner != "" && owner == user.Username {
+		return nil
+	}
+	if err := sqlEngine.RevokePrivileges(instance.DBName, user.Username); err != nil {
+		return err
+	}
+	if owner == "" {
+		return nil
+	}
+	return sqlEngine.RevokeOwnerRole(owner, user.Username)
+}
+
+// tableOwners are the roles whose tables read only users should be able to read
+func tableOwners(owner string) []string {
+	if owner == "" {
+		return nil
+	}
+	return []string{owner}
+}
+
+// restoreSource is what a new instance is restored from, either a snapshot, another instance at a
+// point in time or another instance to clone. The zero value means a new empty instance.
+type restoreSource struct {
+	snapshotID       string
+	sourceIdentifier string
+	restoreTime      time.Time
+	masterUsername   string
+	cloneFrom        *internaldb.DBInstance
+}
+
+func (b *RDSBroker) findRestoreSource(provisionParameters ProvisionParameters, servicePlan ServicePlan, organizationID string) (restoreSource, error) {
+	given := 0
+	for _, source := range []string{provisionParameters.RestoreFromSnapshot, provisionParameters.RestoreFromInstance, provisionParameters.CloneFrom} {
+		if source != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		return restoreSource{}, errors.New("Only one of restore_from_snapshot, restore_from_instance and clone_from can be given")
+	}
+
+	switch {
+	case provisionParameters.CloneFrom != "":
+		return b.cloneSource(provisionParameters.CloneFrom, servicePlan, organizationID)
+	case provisionParameters.RestoreFromSnapshot != "":
+		snapshot, err := b.restorableSnapshot(provisionParameters.RestoreFromSnapshot, servicePlan, organizationID)
+		if err != nil {
+			return restoreSource{}, err
+		}
+		return restoreSource{snapshotID: snapshot.Identifier, masterUsername: snapshot.MasterUsername}, nil
+	}
+
+	return b.pointInTimeSource(provisionParameters, servicePlan, organizationID)
+}
+
+// sourceInstance finds an active instance belonging to the given organization. Like snapshots, instances
+// that fail the checks are reported as not found so the existence of other organizations' instances isn't leaked.
+func (b *RDSBroker) sourceInstance(instanceID string, organizationID string) (*internaldb.DBInstance, ServicePlan, error) {
+	notFound := fmt.Errorf("Instance '%s' not found", instanceID)
+
+	source := internaldb.FindInstance(b.internalDB, instanceID)
+	if source == nil || !source.IsActive() || organizationID == "" {
+		return nil, ServicePlan{}, notFound
+	}
+
+	sourcePlan, ok := b.catalog.FindServicePlan(source.ServiceID, source.PlanID)
+	if !ok {
+		return nil, ServicePlan{}, fmt.Errorf("Service Plan '%s' not found", source.PlanID)
+	}
+
+	if source.OrganizationID != "" {
+		if source.OrganizationID != organizationID {
+			return nil, ServicePlan{}, notFound
+		}
+		return source, sourcePlan, nil
+	}
+
+	// Instances provisioned before the organization was recorded can only be checked by their tags
+	// which shared instances don't have
+	if sourcePlan.RDSProperties.Shared {
+		return nil, ServicePlan{}, notFound
+	}
+	tags, err := b.dbInstance.ListTags(b.dbInstanceIdentifier(source))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return nil, ServicePlan{}, notFound
+		}
+		return nil, ServicePlan{}, err
+	}
+	if tags[organizationIDTag] != organizationID {
+		return nil, ServicePlan{}, notFound
+	}
+
+	return source, sourcePlan, nil
+}
+
+// pointInTimeSource only allows restoring dedicated instances of the same engine
+func (b *RDSBroker) pointInTimeSource(provisionParameters ProvisionParameters, servicePlan ServicePlan, organizationID string) (restoreSource, error) {
+	restore := restoreSource{}
+
+	var err error
+	restore.restoreTime, err = provisionParameters.pointInTime()
+	if err != nil {
+		return restore, err
+	}
+
+	source, sourcePlan, err := b.sourceInstance(provisionParameters.RestoreFromInstance, organizationID)
+	if err != nil {
+		return restore, err
+	}
+	if sourcePlan.RDSProperties.Shared {
+		return restore, fmt.Errorf("Instance '%s' is on a shared plan and can't be restored to a point in time", provisionParameters.RestoreFromInstance)
+	}
+	if strings.ToLower(sourcePlan.RDSProperties.Engine) != strings.ToLower(servicePlan.RDSProperties.Engine) {
+		return restore, fmt.Errorf("Instance '%s' is a '%s' database and can't be restored into a '%s' plan", provisionParameters.RestoreFromInstance, sourcePlan.RDSProperties.Engine, servicePlan.RDSProperties.Engine)
+	}
+
+	masterUser := source.MasterUser()
+	if masterUser == nil {
+		return restore, errors.New("Failed to find master user")
+	}
+	restore.masterUsername = masterUser.Username
+
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		restore.sourceIdentifier = b.dbClusterIdentifier(source)
+	} else {
+		restore.sourceIdentifier = b.dbInstanceIdentifier(source)
+	}
+
+	return restore, nil
+}
+
+// cloneSource only allows cloning instances of the same engine on the same kind of plan
+func (b *RDSBroker) cloneSource(instanceID string, servicePlan ServicePlan, organizationID string) (restoreSource, error) {
+	restore := restoreSource{}
+
+	source, sourcePlan, err := b.sourceInstance(instanceID, organizationID)
+	if err != nil {
+		return restore, err
+	}
+	if strings.ToLower(sourcePlan.RDSProperties.Engine) != strings.ToLower(servicePlan.RDSProperties.Engine) {
+		return restore, fmt.Errorf("Instance '%s' is a '%s' database and can't be cloned into a '%s' plan", instanceID, sourcePlan.RDSProperties.Engine, servicePlan.RDSProperties.Engine)
+	}
+	if sourcePlan.RDSProperties.Shared && !servicePlan.RDSProperties.Shared {
+		return restore, fmt.Errorf("Instance '%s' is on a shared plan and can't be cloned into a dedicated plan", instanceID)
+	}
+	if !sourcePlan.RDSProperties.Shared && servicePlan.RDSProperties.Shared {
+		return restore, fmt.Errorf("Instance '%s' is on a dedicated plan and can't be cloned into a shared plan", instanceID)
+	}
+
+	restore.cloneFrom = source
+	if !servicePlan.RDSProperties.Shared {
+		masterUser := source.MasterUser()
+		if masterUser == nil {
+			return restore, errors.New("Failed to find master user")
+		}
+		restore.masterUsername = masterUser.Username
+	}
+
+	return restore, nil
+}
+
+// cloneSnapshotIdentifier is the snapshot a dedicated clone is restored from. It's derived from the new
+// instance so LastOperation can find it again.
+func (b *RDSBroker) cloneSnapshotIdentifier(instance *internaldb.DBInstance) string {
+	return b.dbInstanceIdentifier(instance) + "-clone"
+}
+
+// restorableSnapshot only allows restoring snapshots of databases managed by this broker that
+// belong to the same organization. Snapshots that fail the checks are reported as not found so
+// the existence of other organizations' snapshots isn't leaked.
+func (b *RDSBroker) restorableSnapshot(snapshotID string, servicePlan ServicePlan, organizationID string) (awsrds.DBSnapshotDetails, error) {
+	snapshot, err := b.describeSnapshot(snapshotID, servicePlan)
+	if err != nil {
+		if err == awsrds.ErrDBSnapshotDoesNotExist {
+			return snapshot, fmt.Errorf("Snapshot '%s' not found", snapshotID)
+		}
+		return snapshot, err
+	}
+
+	if snapshot.Tags[managedByTag] != managedByValue || organizationID == "" || snapshot.Tags[organizationIDTag] != organizationID {
+		return snapshot, fmt.Errorf("Snapshot '%s' not found", snapshotID)
+	}
+
+	if strings.ToLower(snapshot.Engine) != strings.ToLower(servicePlan.RDSProperties.Engine) {
+		return snapshot, fmt.Errorf("Snapshot '%s' is of a '%s' database and can't be restored into a '%s' plan", snapshotID, snapshot.Engine, servicePlan.RDSProperties.Engine)
+	}
+
+	if snapshot.Status != "available" {
+		return snapshot, fmt.Errorf("Snapshot '%s' status is '%s'", snapshotID, snapshot.Status)
+	}
+
+	return snapshot, nil
+}
+
+// describeSnapshot describes a cluster snapshot for aurora plans and an instance snapshot otherwise
+func (b *RDSBroker) describeSnapshot(snapshotID string, servicePlan ServicePlan) (awsrds.DBSnapshotDetails, error) {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return b.dbCluster.DescribeSnapshot(snapshotID)
+	}
+	return b.dbInstance.DescribeSnapshot(snapshotID)
+}
+
+// Snapshot identifiers are unique across the whole AWS account so the names users give snapshots
+// are prefixed with the identifier of their instance
+func (b *RDSBroker) manualSnapshotIdentifier(instance *internaldb.DBInstance, name string) string {
+	return b.dbInstanceIdentifier(instance) + "-" + name
+}
+
+// instanceOrganizationID finds the organization of an instance, falling back to its tags for
+// dedicated instances provisioned before the organization was recorded
+func (b *RDSBroker) instanceOrganizationID(instance *internaldb.DBInstance, servicePlan ServicePlan) string {
+	if instance.OrganizationID != "" || servicePlan.RDSProperties.Shared {
+		return instance.OrganizationID
+	}
+	tags, err := b.dbInstance.ListTags(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		b.logger.Error("list-tags", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return ""
+	}
+	return tags[organizationIDTag]
+}
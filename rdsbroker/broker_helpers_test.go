@@ -47,8 +47,8 @@ var _ = Describe("canUpdate", func() {
 			oldPlan.RDSProperties.Shared = true
 			newPlan.RDSProperties.Shared = false
 		})
-		It("fails", func() {
-			Expect(update).To(BeFalse())
+		It("succeeds", func() {
+			Expect(update).To(BeTrue())
 		})
 	})
 
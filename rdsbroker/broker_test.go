@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,6 +25,7 @@ import (
 	"github.com/AusDTO/pe-rds-broker/config"
 	"github.com/AusDTO/pe-rds-broker/internaldb"
 	sqlfake "github.com/AusDTO/pe-rds-broker/sqlengine/fakes"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
 )
 
@@ -57,8 +62,14 @@ var _ = Describe("RDS Broker", func() {
 		allowUserUpdateParameters    bool
 		allowUserBindParameters      bool
 		serviceBindable              bool
+		instancesRetrievable         bool
+		bindingsRetrievable          bool
 		planUpdateable               bool
 		skipFinalSnapshot            bool
+		snapshotRetention            SnapshotRetention
+		masterPasswordRotation       MasterPasswordRotation
+		caCertificateFile            string
+		credentialTemplates          CredentialTemplates
 
 		instanceID           = "instance-id"
 		bindingID            = "binding-id"
@@ -72,8 +83,14 @@ var _ = Describe("RDS Broker", func() {
 		allowUserUpdateParameters = true
 		allowUserBindParameters = true
 		serviceBindable = true
+		instancesRetrievable = true
+		bindingsRetrievable = true
 		planUpdateable = true
 		skipFinalSnapshot = true
+		snapshotRetention = SnapshotRetention{}
+		masterPasswordRotation = MasterPasswordRotation{}
+		caCertificateFile = ""
+		credentialTemplates = nil
 
 		dbInstance = &rdsfake.FakeDBInstance{}
 		dbCluster = &rdsfake.FakeDBCluster{}
@@ -139,20 +156,26 @@ var _ = Describe("RDS Broker", func() {
 		}
 
 		service1 = Service{
-			ID:             "Service-1",
-			Name:           "Service 1",
-			Description:    "This is the Service 1",
-			Bindable:       serviceBindable,
-			PlanUpdateable: planUpdateable,
-			Plans:          []ServicePlan{plan1, plan3},
+			ID:                   "Service-1",
+			Name:                 "Service 1",
+			Description:          "This is the Service 1",
+			Bindable:             serviceBindable,
+			InstancesRetrievable: instancesRetrievable,
+			BindingsRetrievable:  bindingsRetrievable,
+			PlanUpdateable:       planUpdateable,
+			Plans:                []ServicePlan{plan1, plan3},
+
+			CredentialTemplates: credentialTemplates,
 		}
 		service2 = Service{
-			ID:             "Service-2",
-			Name:           "Service 2",
-			Description:    "This is the Service 2",
-			Bindable:       serviceBindable,
-			PlanUpdateable: planUpdateable,
-			Plans:          []ServicePlan{plan2},
+			ID:                   "Service-2",
+			Name:                 "Service 2",
+			Description:          "This is the Service 2",
+			Bindable:             serviceBindable,
+			InstancesRetrievable: instancesRetrievable,
+			BindingsRetrievable:  bindingsRetrievable,
+			PlanUpdateable:       planUpdateable,
+			Plans:                []ServicePlan{plan2},
 		}
 
 		catalog = Catalog{
@@ -165,8 +188,14 @@ var _ = Describe("RDS Broker", func() {
 			AllowUserProvisionParameters: allowUserProvisionParameters,
 			AllowUserUpdateParameters:    allowUserUpdateParameters,
 			AllowUserBindParameters:      allowUserBindParameters,
+			SnapshotRetention:            snapshotRetention,
+			MasterPasswordRotation:       masterPasswordRotation,
+			CACertificateFile:            caCertificateFile,
 			Catalog:                      catalog,
 		}
+		if caCertificateFile != "" {
+			configYml.CACertificate = "ca-certificate"
+		}
 
 		logger = lager.NewLogger("rdsbroker_test")
 		testSink = lagertest.NewTestSink()
@@ -175,11 +204,21 @@ var _ = Describe("RDS Broker", func() {
 		rdsBroker = New(configYml, dbInstance, dbCluster, sqlProvider, logger, internalDB, sharedPostgres, sharedMysql, encryptionKey)
 	})
 
-	var MakeInstance = func() *internaldb.DBInstance {
+	// MakePendingInstance saves an instance which started being provisioned age ago
+	var MakePendingInstance = func(age time.Duration) *internaldb.DBInstance {
 		instance, err := internaldb.NewInstance(service1.ID, plan1.ID, instanceID, configYml.DBPrefix, encryptionKey)
 		Expect(err).NotTo(HaveOccurred())
 		err = internalDB.Save(instance).Error
 		Expect(err).NotTo(HaveOccurred())
+		instance.CreatedAt = time.Now().Add(-age)
+		Expect(internalDB.Model(instance).UpdateColumn("created_at", instance.CreatedAt).Error).NotTo(HaveOccurred())
+		return instance
+	}
+
+	// MakeInstance saves an instance which has been provisioned
+	var MakeInstance = func() *internaldb.DBInstance {
+		instance := MakePendingInstance(0)
+		Expect(instance.Activate(internalDB)).To(Succeed())
 		return instance
 	}
 
@@ -191,11 +230,13 @@ var _ = Describe("RDS Broker", func() {
 		BeforeEach(func() {
 			properCatalogResponse = []brokerapi.Service{
 				brokerapi.Service{
-					ID:            "Service-1",
-					Name:          "Service 1",
-					Description:   "This is the Service 1",
-					Bindable:      serviceBindable,
-					PlanUpdatable: planUpdateable,
+					ID:                   "Service-1",
+					Name:                 "Service 1",
+					Description:          "This is the Service 1",
+					Bindable:             serviceBindable,
+					InstancesRetrievable: instancesRetrievable,
+					BindingsRetrievable:  bindingsRetrievable,
+					PlanUpdatable:        planUpdateable,
 					Plans: []brokerapi.ServicePlan{
 						brokerapi.ServicePlan{
 							ID:          "Plan-1",
@@ -210,11 +251,13 @@ var _ = Describe("RDS Broker", func() {
 					},
 				},
 				brokerapi.Service{
-					ID:            "Service-2",
-					Name:          "Service 2",
-					Description:   "This is the Service 2",
-					Bindable:      serviceBindable,
-					PlanUpdatable: planUpdateable,
+					ID:                   "Service-2",
+					Name:                 "Service 2",
+					Description:          "This is the Service 2",
+					Bindable:             serviceBindable,
+					InstancesRetrievable: instancesRetrievable,
+					BindingsRetrievable:  bindingsRetrievable,
+					PlanUpdatable:        planUpdateable,
 					Plans: []brokerapi.ServicePlan{
 						brokerapi.ServicePlan{
 							ID:          "Plan-2",
@@ -227,7 +270,8 @@ var _ = Describe("RDS Broker", func() {
 		})
 
 		It("returns the proper CatalogResponse", func() {
-			brokerCatalog := rdsBroker.Services(context.Background())
+			brokerCatalog, err := rdsBroker.Services(context.Background())
+			Expect(err).ToNot(HaveOccurred())
 			Expect(brokerCatalog).To(Equal(properCatalogResponse))
 		})
 
@@ -252,7 +296,8 @@ var _ = Describe("RDS Broker", func() {
 			acceptsIncomplete = true
 
 			properProvisionedServiceSpec = brokerapi.ProvisionedServiceSpec{
-				IsAsync: true,
+				IsAsync:       true,
+				OperationData: "1",
 			}
 		})
 
@@ -286,6 +331,77 @@ var _ = Describe("RDS Broker", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("marks the internaldb instance active", func() {
+			_, err := Provision()
+			Expect(err).ToNot(HaveOccurred())
+			instance := internaldb.FindInstance(internalDB, instanceID)
+			Expect(instance).NotTo(BeNil())
+			Expect(instance.State).To(Equal(internaldb.InstanceActive))
+		})
+
+		It("records the provision operation", func() {
+			provisionedServiceSpec, err := Provision()
+			Expect(err).ToNot(HaveOccurred())
+			instance := internaldb.FindInstance(internalDB, instanceID)
+			Expect(instance).NotTo(BeNil())
+			operation := internaldb.FindOperation(internalDB, instance, provisionedServiceSpec.OperationData)
+			Expect(operation).NotTo(BeNil())
+			Expect(operation.Type).To(Equal(internaldb.ProvisionOperation))
+			Expect(operation.State).To(Equal(internaldb.OperationInProgress))
+			Expect(operation.PlanID).To(Equal("Plan-1"))
+		})
+
+		Context("when the plan has read replicas", func() {
+			BeforeEach(func() {
+				rdsProperties1.ReadReplicaCount = 2
+			})
+
+			It("records how many read replicas to create once the DB Instance is available", func() {
+				_, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.CreateReadReplicaCalled).To(BeFalse())
+				Expect(internaldb.FindInstance(internalDB, instanceID).ReadReplicaCount).To(Equal(int64(2)))
+			})
+
+			Context("and the user asks for a different number", func() {
+				BeforeEach(func() {
+					provisionDetails.RawParameters = json.RawMessage(`{"read_replica_count": 1}`)
+				})
+
+				It("uses the number the user asked for", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID).ReadReplicaCount).To(Equal(int64(1)))
+				})
+			})
+
+			Context("and the user asks for too many", func() {
+				BeforeEach(func() {
+					provisionDetails.RawParameters = json.RawMessage(`{"read_replica_count": 6}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("read_replica_count must be between 0 and 5"))
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when asking for read replicas of an Aurora plan", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				provisionDetails.RawParameters = json.RawMessage(`{"read_replica_count": 1}`)
+			})
+
+			It("returns the proper error", func() {
+				_, err := Provision()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Read replicas are not supported for plan 'Plan 1'"))
+			})
+		})
+
 		Context("when has AllocatedStorage", func() {
 			BeforeEach(func() {
 				rdsProperties1.AllocatedStorage = int64(100)
@@ -457,6 +573,18 @@ var _ = Describe("RDS Broker", func() {
 			})
 		})
 
+		Context("when has IAMDatabaseAuthentication", func() {
+			BeforeEach(func() {
+				rdsProperties1.IAMDatabaseAuthentication = true
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Provision()
+				Expect(dbInstance.CreateDBInstanceDetails.IAMDatabaseAuthenticationEnabled).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when has DBParameterGroupName", func() {
 			BeforeEach(func() {
 				rdsProperties1.DBParameterGroupName = "test-db-parameter-group-name"
@@ -915,11 +1043,24 @@ var _ = Describe("RDS Broker", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("operation failed"))
 			})
+
+			It("does not keep the internaldb instance", func() {
+				_, err := Provision()
+				Expect(err).To(HaveOccurred())
+				Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+			})
+
+			It("has nothing to delete", func() {
+				_, err := Provision()
+				Expect(err).To(HaveOccurred())
+				Expect(dbInstance.DeleteCalled).To(BeFalse())
+			})
 		})
 
 		Context("when instance id already exists", func() {
 			BeforeEach(func() {
-				MakeInstance()
+				instance := MakeInstance()
+				Expect(instance.Activate(internalDB)).To(Succeed())
 			})
 
 			It("returns the proper error", func() {
@@ -935,6 +1076,66 @@ var _ = Describe("RDS Broker", func() {
 			})
 		})
 
+		Context("when instance id is still pending", func() {
+			BeforeEach(func() {
+				MakePendingInstance(time.Minute)
+			})
+
+			It("returns the proper error", func() {
+				_, err := Provision()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Instance is already being provisioned"))
+				Expect(dbInstance.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when instance id was left pending by an abandoned provision", func() {
+			BeforeEach(func() {
+				MakePendingInstance(time.Hour)
+				dbInstance.DeleteError = awsrds.ErrDBInstanceDoesNotExist
+			})
+
+			It("takes it over", func() {
+				provisionedServiceSpec, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provisionedServiceSpec.IsAsync).To(BeTrue())
+				Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.CreateCalled).To(BeTrue())
+				Expect(internaldb.FindInstance(internalDB, instanceID).IsActive()).To(BeTrue())
+			})
+
+			Context("and its DB Instance was created", func() {
+				BeforeEach(func() {
+					dbInstance.DeleteError = nil
+				})
+
+				It("deletes it and waits for it to go", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'cf-instance-id' is being cleaned up after an earlier provision failed, try again later"))
+					Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+					Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+				})
+			})
+
+			Context("and the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+					rdsProperties1.Engine = "postgres"
+				})
+
+				It("drops its database and takes it over", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sharedPostgres.DropDBCalled).To(BeTrue())
+					Expect(sharedPostgres.CreateDBCalled).To(BeTrue())
+					Expect(dbInstance.DeleteCalled).To(BeFalse())
+				})
+			})
+		})
+
 		Context("when shared instance", func() {
 			BeforeEach(func() {
 				rdsProperties1.Shared = true
@@ -962,6 +1163,34 @@ var _ = Describe("RDS Broker", func() {
 					Expect(sharedMysql.CreateDBCalled).To(BeFalse())
 					Expect(err).ToNot(HaveOccurred())
 				})
+
+				Context("when creating the database fails", func() {
+					BeforeEach(func() {
+						sharedPostgres.CreateDBError = errors.New("create failed")
+					})
+
+					It("returns the proper error", func() {
+						_, err := Provision()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("create failed"))
+					})
+
+					It("does not keep the internaldb instance", func() {
+						_, err := Provision()
+						Expect(err).To(HaveOccurred())
+						Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+						Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+					})
+				})
+
+				It("records a completed provision operation", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					operation := internaldb.LatestOperation(internalDB, internaldb.FindInstance(internalDB, instanceID))
+					Expect(operation).NotTo(BeNil())
+					Expect(operation.Type).To(Equal(internaldb.ProvisionOperation))
+					Expect(operation.State).To(Equal(internaldb.OperationSucceeded))
+				})
 			})
 
 			Context("with mysql", func() {
@@ -1033,559 +1262,841 @@ var _ = Describe("RDS Broker", func() {
 					Expect(err).To(HaveOccurred())
 					Expect(dbCluster.DeleteCalled).To(BeTrue())
 					Expect(dbCluster.DeleteID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeTrue())
 				})
-			})
-		})
-	})
-
-	var _ = Describe("Update", func() {
-		var (
-			updateDetails     brokerapi.UpdateDetails
-			acceptsIncomplete bool
-		)
 
-		BeforeEach(func() {
-			updateDetails = brokerapi.UpdateDetails{
-				ServiceID:     "Service-1",
-				PlanID:        "Plan-3",
-				RawParameters: json.RawMessage(""),
-				PreviousValues: brokerapi.PreviousValues{
-					PlanID:    "Plan-1",
-					ServiceID: "Service-1",
-					OrgID:     "organization-id",
-					SpaceID:   "space-id",
-				},
-			}
-			acceptsIncomplete = true
-			MakeInstance()
-		})
+				It("does not keep the internaldb instance", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
 
-		Update := func() (brokerapi.UpdateServiceSpec, error) {
-			return rdsBroker.Update(context.Background(), instanceID, updateDetails, acceptsIncomplete)
-		}
+			Context("when Engine is Aurora PostgreSQL", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora-postgresql"
+				})
 
-		It("returns the proper response", func() {
-			updateSpec, err := Update()
-			Expect(err).ToNot(HaveOccurred())
-			Expect(updateSpec.IsAsync).To(BeTrue())
-		})
+				It("creates a DB Cluster", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.CreateCalled).To(BeTrue())
+					Expect(dbCluster.CreateID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.CreateDBClusterDetails.Engine).To(Equal("aurora-postgresql"))
+					Expect(dbInstance.CreateDBInstanceDetails.Engine).To(Equal("aurora-postgresql"))
+					Expect(dbInstance.CreateDBInstanceDetails.DBClusterIdentifier).To(Equal(dbClusterIdentifier))
+					Expect(dbInstance.CreateDBInstanceDetails.MasterUsername).To(BeEmpty())
+				})
+			})
 
-		It("makes the proper calls", func() {
-			_, err := Update()
-			Expect(err).ToNot(HaveOccurred())
-			Expect(dbInstance.ModifyCalled).To(BeTrue())
-			Expect(dbInstance.ModifyID).To(Equal(dbInstanceIdentifier))
-			Expect(dbInstance.ModifyDBInstanceDetails.DBInstanceClass).To(Equal("db.m2.test"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Engine).To(Equal("test-engine-1"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Owner"]).To(Equal("Cloud Foundry"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Updated by"]).To(Equal("AWS RDS Service Broker"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Tags).To(HaveKey("Updated at"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Service ID"]).To(Equal("Service-1"))
-			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Plan ID"]).To(Equal("Plan-3"))
-			Expect(sqlEngine.SetExtensionsCalled).To(BeFalse())
-		})
+			Context("when EngineMode is serverless", func() {
+				BeforeEach(func() {
+					rdsProperties1.EngineMode = "serverless"
+					rdsProperties1.MinCapacity = 2
+					rdsProperties1.MaxCapacity = 8
+					rdsProperties1.AutoPause = true
+					rdsProperties1.SecondsUntilAutoPause = 600
+				})
 
-		Context("when has AllocatedStorage", func() {
-			BeforeEach(func() {
-				rdsProperties3.AllocatedStorage = int64(100)
+				It("creates a serverless DB Cluster without a DB Instance", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.CreateCalled).To(BeTrue())
+					Expect(dbCluster.CreateDBClusterDetails.EngineMode).To(Equal("serverless"))
+					Expect(dbCluster.CreateDBClusterDetails.ScalingConfiguration).To(Equal(&awsrds.ScalingConfiguration{
+						MinCapacity:           2,
+						MaxCapacity:           8,
+						AutoPause:             true,
+						SecondsUntilAutoPause: 600,
+					}))
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+				})
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.AllocatedStorage).To(Equal(int64(100)))
+			Context("when the plan has several cluster instances", func() {
+				BeforeEach(func() {
+					rdsProperties1.ClusterInstanceCount = 3
+				})
+
+				It("creates every instance in the cluster", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.CreateIDs).To(Equal([]string{dbInstanceIdentifier, dbInstanceIdentifier + "-2", dbInstanceIdentifier + "-3"}))
+					Expect(dbInstance.CreateDBInstanceDetails.DBClusterIdentifier).To(Equal(dbClusterIdentifier))
+				})
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when creating the DB Cluster fails", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					dbCluster.CreateError = errors.New("operation failed")
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.AllocatedStorage).To(Equal(int64(0)))
+				It("does not create the DB Instance", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+					Expect(dbCluster.DeleteCalled).To(BeFalse())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
 				})
 			})
 		})
 
-		Context("when has AutoMinorVersionUpgrade", func() {
+		Context("when restoring from a snapshot", func() {
+			var snapshotDetails awsrds.DBSnapshotDetails
+
 			BeforeEach(func() {
-				rdsProperties3.AutoMinorVersionUpgrade = true
+				provisionDetails.RawParameters = json.RawMessage(`{"restore_from_snapshot": "snapshot-id"}`)
+				snapshotDetails = awsrds.DBSnapshotDetails{
+					Identifier:     "snapshot-id",
+					Status:         "available",
+					Engine:         "test-engine-1",
+					MasterUsername: "snapshot-master",
+					Tags: map[string]string{
+						"Managed by":      "github.com/AusDTO/pe-rds-broker",
+						"Organization ID": "organization-id",
+					},
+				}
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.AutoMinorVersionUpgrade).To(BeTrue())
+			JustBeforeEach(func() {
+				dbInstance.DescribeSnapshotDBSnapshotDetails = snapshotDetails
+				dbCluster.DescribeSnapshotDBSnapshotDetails = snapshotDetails
 			})
-		})
 
-		Context("when has AvailabilityZone", func() {
-			BeforeEach(func() {
-				rdsProperties3.AvailabilityZone = "test-az"
+			It("restores instead of creating", func() {
+				_, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DescribeSnapshotID).To(Equal("snapshot-id"))
+				Expect(dbInstance.CreateCalled).To(BeFalse())
+				Expect(dbInstance.RestoreCalled).To(BeTrue())
+				Expect(dbInstance.RestoreID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.RestoreSnapshotID).To(Equal("snapshot-id"))
+				Expect(dbInstance.RestoreDBInstanceDetails.DBInstanceClass).To(Equal("db.m1.test"))
+				Expect(dbInstance.RestoreDBInstanceDetails.Tags["Managed by"]).To(Equal("github.com/AusDTO/pe-rds-broker"))
+				Expect(dbInstance.RestoreDBInstanceDetails.Tags["Organization ID"]).To(Equal("organization-id"))
+				Expect(dbInstance.RestoreDBInstanceDetails.Tags["Space ID"]).To(Equal("space-id"))
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
+			It("keeps the master username of the snapshot", func() {
+				_, err := Provision()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.AvailabilityZone).To(Equal("test-az"))
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.MasterUser().Username).To(Equal("snapshot-master"))
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when the snapshot belongs to another organization", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					snapshotDetails.Tags["Organization ID"] = "other-organization-id"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.AvailabilityZone).To(Equal("test-az"))
-					Expect(dbCluster.ModifyDBClusterDetails.AvailabilityZones).To(Equal([]string{"test-az"}))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Snapshot 'snapshot-id' not found"))
+					Expect(dbInstance.RestoreCalled).To(BeFalse())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
 				})
 			})
-		})
 
-		Context("when has BackupRetentionPeriod", func() {
-			BeforeEach(func() {
-				rdsProperties3.BackupRetentionPeriod = int64(7)
-			})
+			Context("when the snapshot is not managed by the broker", func() {
+				BeforeEach(func() {
+					delete(snapshotDetails.Tags, "Managed by")
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(7)))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Snapshot 'snapshot-id' not found"))
+					Expect(dbInstance.RestoreCalled).To(BeFalse())
+				})
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when the snapshot does not exist", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					dbInstance.DescribeSnapshotError = awsrds.ErrDBSnapshotDoesNotExist
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.BackupRetentionPeriod).To(Equal(int64(7)))
-					Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(0)))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Snapshot 'snapshot-id' not found"))
 				})
 			})
 
-			Context("but has BackupRetentionPeriod Parameter", func() {
+			Context("when the snapshot is of a different engine", func() {
 				BeforeEach(func() {
-					updateDetails.RawParameters = json.RawMessage("{\"backup_retention_period\": 12}")
+					snapshotDetails.Engine = "test-engine-2"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(12)))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Snapshot 'snapshot-id' is of a 'test-engine-2' database and can't be restored into a 'test-engine-1' plan"))
 				})
+			})
 
-				Context("when Engine is Aurora", func() {
-					BeforeEach(func() {
-						rdsProperties1.Engine = "aurora"
-						rdsProperties3.Engine = "aurora"
-					})
+			Context("when the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
 
-					It("makes the proper calls", func() {
-						_, err := Update()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(dbCluster.ModifyDBClusterDetails.BackupRetentionPeriod).To(Equal(int64(12)))
-						Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(0)))
-					})
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Restoring is not supported for shared plans"))
 				})
 			})
-		})
 
-		Context("when has CharacterSetName", func() {
-			BeforeEach(func() {
-				rdsProperties3.CharacterSetName = "test-characterset-name"
-			})
+			Context("when restoring the DB Instance fails", func() {
+				BeforeEach(func() {
+					dbInstance.RestoreError = errors.New("operation failed")
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.CharacterSetName).To(Equal("test-characterset-name"))
+				It("does not keep the internaldb instance", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
 			})
 
 			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
 					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					snapshotDetails.Engine = "aurora"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
+				It("restores the DB Cluster and creates a DB Instance in it", func() {
+					_, err := Provision()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.CharacterSetName).To(Equal(""))
+					Expect(dbCluster.DescribeSnapshotID).To(Equal("snapshot-id"))
+					Expect(dbCluster.CreateCalled).To(BeFalse())
+					Expect(dbCluster.RestoreCalled).To(BeTrue())
+					Expect(dbCluster.RestoreID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.RestoreSnapshotID).To(Equal("snapshot-id"))
+					Expect(dbCluster.RestoreDBClusterDetails.Engine).To(Equal("aurora"))
+					Expect(dbCluster.RestoreDBClusterDetails.Tags["Organization ID"]).To(Equal("organization-id"))
+					Expect(dbInstance.RestoreCalled).To(BeFalse())
+					Expect(dbInstance.CreateCalled).To(BeTrue())
+					Expect(dbInstance.CreateDBInstanceDetails.DBClusterIdentifier).To(Equal(dbClusterIdentifier))
 				})
 			})
 		})
 
-		Context("when has CopyTagsToSnapshot", func() {
-			BeforeEach(func() {
-				rdsProperties3.CopyTagsToSnapshot = true
-			})
-
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.CopyTagsToSnapshot).To(BeTrue())
-			})
-		})
+		Context("when restoring from an instance at a point in time", func() {
+			var (
+				sourceInstance *internaldb.DBInstance
+				restoreTime    string
+			)
 
-		Context("when has DBParameterGroupName", func() {
 			BeforeEach(func() {
-				rdsProperties3.DBParameterGroupName = "test-db-parameter-group-name"
+				restoreTime = "2017-11-01T10:20:30Z"
+				dbInstance.ListTagsTags = map[string]string{
+					"Managed by":      "github.com/AusDTO/pe-rds-broker",
+					"Organization ID": "organization-id",
+				}
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.DBParameterGroupName).To(Equal("test-db-parameter-group-name"))
+			JustBeforeEach(func() {
+				provisionDetails.RawParameters = json.RawMessage(`{"restore_from_instance": "source-instance-id", "restore_time": "` + restoreTime + `"}`)
+				var err error
+				sourceInstance, err = internaldb.NewInstance(service1.ID, plan1.ID, "source-instance-id", configYml.DBPrefix, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(internalDB.Save(sourceInstance).Error).NotTo(HaveOccurred())
+				Expect(sourceInstance.Activate(internalDB)).To(Succeed())
 			})
-		})
 
-		Context("when has DBSecurityGroups", func() {
-			BeforeEach(func() {
-				rdsProperties3.DBSecurityGroups = []string{"test-db-security-group"}
+			It("restores the source instance", func() {
+				_, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ListTagsID).To(Equal("cf-source-instance-id"))
+				Expect(dbInstance.CreateCalled).To(BeFalse())
+				Expect(dbInstance.RestoreToPointInTimeCalled).To(BeTrue())
+				Expect(dbInstance.RestoreToPointInTimeID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.RestoreToPointInTimeSourceID).To(Equal("cf-source-instance-id"))
+				Expect(dbInstance.RestoreToPointInTimeRestoreTime).To(Equal(time.Date(2017, 11, 1, 10, 20, 30, 0, time.UTC)))
+				Expect(dbInstance.RestoreToPointInTimeDBInstanceDetails.DBInstanceClass).To(Equal("db.m1.test"))
+				Expect(dbInstance.RestoreToPointInTimeDBInstanceDetails.Tags["Organization ID"]).To(Equal("organization-id"))
+				Expect(dbInstance.RestoreToPointInTimeDBInstanceDetails.Tags["Space ID"]).To(Equal("space-id"))
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
+			It("is tracked like any other provision", func() {
+				provisionedServiceSpec, err := Provision()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.DBSecurityGroups).To(Equal([]string{"test-db-security-group"}))
+				Expect(provisionedServiceSpec.IsAsync).To(BeTrue())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.MasterUser().Username).To(Equal(sourceInstance.MasterUser().Username))
+				Expect(instance.MasterUser().EncryptedPassword).NotTo(Equal(sourceInstance.MasterUser().EncryptedPassword))
+				operation := internaldb.FindOperation(internalDB, instance, provisionedServiceSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.ProvisionOperation))
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when restoring to the latest restorable time", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					restoreTime = "latest"
 				})
 
 				It("makes the proper calls", func() {
-					_, err := Update()
+					_, err := Provision()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.DBSecurityGroups).To(BeNil())
+					Expect(dbInstance.RestoreToPointInTimeRestoreTime.IsZero()).To(BeTrue())
 				})
 			})
-		})
 
-		Context("when has DBSubnetGroupName", func() {
-			BeforeEach(func() {
-				rdsProperties3.DBSubnetGroupName = "test-db-subnet-group-name"
-			})
+			Context("when the restore time is not valid", func() {
+				BeforeEach(func() {
+					restoreTime = "yesterday"
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.DBSubnetGroupName).To(Equal("test-db-subnet-group-name"))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("restore_time must be 'latest' or an RFC3339 timestamp"))
+					Expect(dbInstance.RestoreToPointInTimeCalled).To(BeFalse())
+				})
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when the restore time is missing", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					restoreTime = ""
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.DBSubnetGroupName).To(Equal("test-db-subnet-group-name"))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("restore_time is required when restoring from an instance"))
 				})
 			})
-		})
 
-		Context("when has EngineVersion", func() {
-			BeforeEach(func() {
-				rdsProperties3.EngineVersion = "1.2.3"
-			})
+			Context("when the source instance belongs to another organization", func() {
+				BeforeEach(func() {
+					dbInstance.ListTagsTags["Organization ID"] = "other-organization-id"
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.2.3"))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'source-instance-id' not found"))
+					Expect(dbInstance.RestoreToPointInTimeCalled).To(BeFalse())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when the source instance is of a different engine", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					provisionDetails.ServiceID = "Service-2"
+					provisionDetails.PlanID = "Plan-2"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.2.3"))
-					Expect(dbCluster.ModifyDBClusterDetails.EngineVersion).To(Equal("1.2.3"))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'source-instance-id' is a 'test-engine-1' database and can't be restored into a 'test-engine-2' plan"))
 				})
 			})
-		})
 
-		Context("when has Iops", func() {
-			BeforeEach(func() {
-				rdsProperties3.Iops = int64(1000)
-			})
+			Context("when also restoring from a snapshot", func() {
+				JustBeforeEach(func() {
+					provisionDetails.RawParameters = json.RawMessage(`{"restore_from_instance": "source-instance-id", "restore_time": "latest", "restore_from_snapshot": "snapshot-id"}`)
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.Iops).To(Equal(int64(1000)))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Only one of restore_from_snapshot, restore_from_instance and clone_from can be given"))
+				})
 			})
 
 			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
 					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
+				It("restores the source DB Cluster and creates a DB Instance in it", func() {
+					_, err := Provision()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.Iops).To(Equal(int64(0)))
+					Expect(dbCluster.CreateCalled).To(BeFalse())
+					Expect(dbCluster.RestoreToPointInTimeCalled).To(BeTrue())
+					Expect(dbCluster.RestoreToPointInTimeID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.RestoreToPointInTimeSourceID).To(Equal("cf-source-instance-id"))
+					Expect(dbInstance.RestoreToPointInTimeCalled).To(BeFalse())
+					Expect(dbInstance.CreateDBInstanceDetails.DBClusterIdentifier).To(Equal(dbClusterIdentifier))
 				})
 			})
 		})
 
-		Context("when has KmsKeyID", func() {
+		Context("when cloning an instance", func() {
+			var (
+				sourceInstance       *internaldb.DBInstance
+				sourcePlanID         string
+				sourceOrganizationID string
+			)
+
 			BeforeEach(func() {
-				rdsProperties3.KmsKeyID = "test-kms-key-id"
+				provisionDetails.RawParameters = json.RawMessage(`{"clone_from": "source-instance-id"}`)
+				sourcePlanID = "Plan-1"
+				sourceOrganizationID = "organization-id"
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
+			JustBeforeEach(func() {
+				var err error
+				sourceInstance, err = internaldb.NewInstance(service1.ID, sourcePlanID, "source-instance-id", configYml.DBPrefix, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				sourceInstance.OrganizationID = sourceOrganizationID
+				sourceInstance.SpaceID = "source-space-id"
+				Expect(internalDB.Save(sourceInstance).Error).NotTo(HaveOccurred())
+				Expect(sourceInstance.Activate(internalDB)).To(Succeed())
+			})
+
+			It("snapshots the source instance", func() {
+				_, err := Provision()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.KmsKeyID).To(Equal("test-kms-key-id"))
+				Expect(dbInstance.CreateCalled).To(BeFalse())
+				Expect(dbInstance.RestoreCalled).To(BeFalse())
+				Expect(dbInstance.ListTagsCalled).To(BeFalse())
+				Expect(dbInstance.CreateSnapshotCalled).To(BeTrue())
+				Expect(dbInstance.CreateSnapshotID).To(Equal("cf-source-instance-id"))
+				Expect(dbInstance.CreateSnapshotSnapshotID).To(Equal("cf-instance-id-clone"))
+				Expect(dbInstance.CreateSnapshotTags["Managed by"]).To(Equal("github.com/AusDTO/pe-rds-broker"))
+				Expect(dbInstance.CreateSnapshotTags["Organization ID"]).To(Equal("organization-id"))
+				Expect(dbInstance.CreateSnapshotTags["Space ID"]).To(Equal("source-space-id"))
+			})
+
+			It("records the clone snapshot stage", func() {
+				provisionedServiceSpec, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provisionedServiceSpec.IsAsync).To(BeTrue())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.MasterUser().Username).To(Equal(sourceInstance.MasterUser().Username))
+				Expect(instance.OrganizationID).To(Equal("organization-id"))
+				Expect(instance.SpaceID).To(Equal("space-id"))
+				operation := internaldb.FindOperation(internalDB, instance, provisionedServiceSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.ProvisionOperation))
+				Expect(operation.Stage).To(Equal("clone-snapshot"))
 			})
 
-			Context("when Engine is Aurora", func() {
+			It("records the clone snapshot against the source instance", func() {
+				_, err := Provision()
+				Expect(err).ToNot(HaveOccurred())
+				snapshot := internaldb.FindSnapshot(internalDB, "cf-instance-id-clone")
+				Expect(snapshot).NotTo(BeNil())
+				Expect(snapshot.InstanceID).To(Equal("source-instance-id"))
+				Expect(snapshot.Type).To(Equal(internaldb.CloneSnapshot))
+			})
+
+			Context("when the source instance belongs to another organization", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					sourceOrganizationID = "other-organization-id"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.KmsKeyID).To(Equal(""))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'source-instance-id' not found"))
+					Expect(dbInstance.CreateSnapshotCalled).To(BeFalse())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
 				})
 			})
-		})
 
-		Context("when has LicenseModel", func() {
-			BeforeEach(func() {
-				rdsProperties3.LicenseModel = "test-license-model"
+			Context("when the source instance doesn't record its organization", func() {
+				BeforeEach(func() {
+					sourceOrganizationID = ""
+					dbInstance.ListTagsTags = map[string]string{"Organization ID": "organization-id"}
+				})
+
+				It("checks the tags of the source instance", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ListTagsID).To(Equal("cf-source-instance-id"))
+					Expect(dbInstance.CreateSnapshotCalled).To(BeTrue())
+				})
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.LicenseModel).To(Equal("test-license-model"))
+			Context("when the source instance is of a different engine", func() {
+				BeforeEach(func() {
+					provisionDetails.ServiceID = "Service-2"
+					provisionDetails.PlanID = "Plan-2"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'source-instance-id' is a 'test-engine-1' database and can't be cloned into a 'test-engine-2' plan"))
+				})
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("when the source instance is on a shared plan", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					rdsProperties3.Shared = true
+					sourcePlanID = "Plan-3"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.LicenseModel).To(Equal(""))
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Instance 'source-instance-id' is on a shared plan and can't be cloned into a dedicated plan"))
 				})
 			})
-		})
 
-		Context("when has MultiAZ", func() {
-			BeforeEach(func() {
-				rdsProperties3.MultiAZ = true
+			Context("when also restoring from a snapshot", func() {
+				BeforeEach(func() {
+					provisionDetails.RawParameters = json.RawMessage(`{"clone_from": "source-instance-id", "restore_from_snapshot": "snapshot-id"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Only one of restore_from_snapshot, restore_from_instance and clone_from can be given"))
+				})
 			})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.MultiAZ).To(BeTrue())
+			Context("when creating the snapshot fails", func() {
+				BeforeEach(func() {
+					dbInstance.CreateSnapshotError = errors.New("operation failed")
+				})
+
+				It("does not keep the internaldb instance", func() {
+					_, err := Provision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
 			})
 
 			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
 					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
 				})
 
-				It("makes the proper calls", func() {
-					_, err := Update()
+				It("snapshots the source DB Cluster", func() {
+					_, err := Provision()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.MultiAZ).To(BeFalse())
+					Expect(dbCluster.CreateSnapshotCalled).To(BeTrue())
+					Expect(dbCluster.CreateSnapshotID).To(Equal("cf-source-instance-id"))
+					Expect(dbCluster.CreateSnapshotSnapshotID).To(Equal("cf-instance-id-clone"))
+					Expect(dbCluster.CreateCalled).To(BeFalse())
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+					Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-clone").Cluster).To(BeTrue())
 				})
 			})
-		})
 
-		Context("when has OptionGroupName", func() {
-			BeforeEach(func() {
-				rdsProperties3.OptionGroupName = "test-option-group-name"
-			})
+			Context("when the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+					rdsProperties1.Engine = "postgres"
+					sqlEngine.OwnerRoleName = "owner_role"
+				})
 
-			It("makes the proper calls", func() {
-				_, err := Update()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.OptionGroupName).To(Equal("test-option-group-name"))
+				It("copies the source database", func() {
+					provisionedServiceSpec, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(provisionedServiceSpec.IsAsync).To(BeFalse())
+					Expect(sharedPostgres.CreateDBCalled).To(BeFalse())
+					Expect(sharedPostgres.CopyDBCalled).To(BeTrue())
+					Expect(sharedPostgres.CopyDBSourceDBName).To(Equal("cf_source_instance_id"))
+					Expect(sharedPostgres.CopyDBDBName).To(Equal(dbName))
+					Expect(dbInstance.CreateSnapshotCalled).To(BeFalse())
+				})
+
+				It("reassigns the copied objects to the new instance", func() {
+					_, err := Provision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
+					Expect(sqlEngine.ReassignOwnershipCalled).To(BeTrue())
+					Expect(sqlEngine.CloseCalled).To(BeTrue())
+				})
+
+				Context("when reassigning the objects fails", func() {
+					BeforeEach(func() {
+						sqlEngine.ReassignOwnershipError = errors.New("reassign failed")
+					})
+
+					It("drops the copy", func() {
+						_, err := Provision()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("reassign failed"))
+						Expect(sharedPostgres.DropDBCalled).To(BeTrue())
+						Expect(sharedPostgres.DropDBDBName).To(Equal(dbName))
+						Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+					})
+				})
+
+				Context("when the source instance is dedicated", func() {
+					BeforeEach(func() {
+						rdsProperties3.Engine = "postgres"
+						sourcePlanID = "Plan-3"
+					})
+
+					It("returns the proper error", func() {
+						_, err := Provision()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Instance 'source-instance-id' is on a dedicated plan and can't be cloned into a shared plan"))
+						Expect(sharedPostgres.CopyDBCalled).To(BeFalse())
+					})
+				})
 			})
 		})
+	})
 
-		Context("when has Port", func() {
+	var _ = Describe("Update", func() {
+		var (
+			updateDetails     brokerapi.UpdateDetails
+			acceptsIncomplete bool
+		)
+
+		BeforeEach(func() {
+			updateDetails = brokerapi.UpdateDetails{
+				ServiceID:     "Service-1",
+				PlanID:        "Plan-3",
+				RawParameters: json.RawMessage(""),
+				PreviousValues: brokerapi.PreviousValues{
+					PlanID:    "Plan-1",
+					ServiceID: "Service-1",
+					OrgID:     "organization-id",
+					SpaceID:   "space-id",
+				},
+			}
+			acceptsIncomplete = true
+			MakeInstance()
+		})
+
+		Update := func() (brokerapi.UpdateServiceSpec, error) {
+			return rdsBroker.Update(context.Background(), instanceID, updateDetails, acceptsIncomplete)
+		}
+
+		It("returns the proper response", func() {
+			updateSpec, err := Update()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updateSpec.IsAsync).To(BeTrue())
+			Expect(updateSpec.OperationData).NotTo(BeEmpty())
+		})
+
+		It("records the update operation", func() {
+			updateSpec, err := Update()
+			Expect(err).ToNot(HaveOccurred())
+			instance := internaldb.FindInstance(internalDB, instanceID)
+			operation := internaldb.FindOperation(internalDB, instance, updateSpec.OperationData)
+			Expect(operation).NotTo(BeNil())
+			Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+			Expect(operation.State).To(Equal(internaldb.OperationInProgress))
+			Expect(operation.PlanID).To(Equal("Plan-3"))
+			Expect(instance.PlanID).To(Equal("Plan-1"))
+		})
+
+		It("makes the proper calls", func() {
+			_, err := Update()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dbInstance.ModifyCalled).To(BeTrue())
+			Expect(dbInstance.ModifyID).To(Equal(dbInstanceIdentifier))
+			Expect(dbInstance.ModifyDBInstanceDetails.DBInstanceClass).To(Equal("db.m2.test"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Engine).To(Equal("test-engine-1"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Owner"]).To(Equal("Cloud Foundry"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Updated by"]).To(Equal("AWS RDS Service Broker"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Tags).To(HaveKey("Updated at"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Service ID"]).To(Equal("Service-1"))
+			Expect(dbInstance.ModifyDBInstanceDetails.Tags["Plan ID"]).To(Equal("Plan-3"))
+			Expect(sqlEngine.SetExtensionsCalled).To(BeFalse())
+		})
+
+		Context("when changing the number of read replicas", func() {
 			BeforeEach(func() {
-				rdsProperties3.Port = int64(3306)
+				updateDetails.PlanID = "Plan-1"
+				updateDetails.RawParameters = json.RawMessage(`{"read_replica_count": 3}`)
 			})
 
-			It("makes the proper calls", func() {
+			It("records the new number of read replicas", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.Port).To(Equal(int64(3306)))
+				Expect(internaldb.FindInstance(internalDB, instanceID).ReadReplicaCount).To(Equal(int64(3)))
+				Expect(dbInstance.CreateReadReplicaCalled).To(BeFalse())
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("and the number is negative", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					updateDetails.RawParameters = json.RawMessage(`{"read_replica_count": -1}`)
 				})
 
-				It("makes the proper calls", func() {
+				It("returns the proper error", func() {
 					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.Port).To(Equal(int64(3306)))
-					Expect(dbInstance.ModifyDBInstanceDetails.Port).To(Equal(int64(0)))
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("read_replica_count must be between 0 and 5"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
 				})
 			})
 		})
 
-		Context("when has PreferredBackupWindow", func() {
+		Context("when growing the storage", func() {
 			BeforeEach(func() {
-				rdsProperties3.PreferredBackupWindow = "test-preferred-backup-window"
+				updateDetails.RawParameters = json.RawMessage(`{"allocated_storage": 500}`)
+				rdsProperties3.MaxAllocatedStorage = 1000
+				dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+					Status:           "available",
+					AllocatedStorage: 300,
+				}
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window"))
+				Expect(dbInstance.ModifyCalled).To(BeTrue())
+				Expect(dbInstance.ModifyDBInstanceDetails.AllocatedStorage).To(Equal(int64(500)))
+				Expect(dbInstance.ModifyDBInstanceDetails.MaxAllocatedStorage).To(Equal(int64(1000)))
 			})
 
-			Context("when Engine is Aurora", func() {
+			Context("and it is more than the plan allows", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					updateDetails.RawParameters = json.RawMessage(`{"allocated_storage": 2000}`)
 				})
 
-				It("makes the proper calls", func() {
+				It("returns the proper error", func() {
 					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window"))
-					Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal(""))
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("allocated_storage must be less than 1000 for plan 'Plan 3'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
 				})
 			})
 
-			Context("but has PreferredBackupWindow Parameter", func() {
+			Context("and it is as much as the plan allows", func() {
 				BeforeEach(func() {
-					updateDetails.RawParameters = json.RawMessage("{\"preferred_backup_window\": \"test-preferred-backup-window-parameter\"}")
+					updateDetails.RawParameters = json.RawMessage(`{"allocated_storage": 1000}`)
 				})
 
-				It("makes the proper calls", func() {
+				It("returns the proper error", func() {
 					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window-parameter"))
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("allocated_storage must be less than 1000 for plan 'Plan 3'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
 				})
+			})
 
-				Context("when Engine is Aurora", func() {
-					BeforeEach(func() {
-						rdsProperties1.Engine = "aurora"
-						rdsProperties3.Engine = "aurora"
-					})
+			Context("and it is less than the current storage", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"allocated_storage": 250}`)
+				})
 
-					It("makes the proper calls", func() {
-						_, err := Update()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(dbCluster.ModifyDBClusterDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window-parameter"))
-						Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal(""))
-					})
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("allocated_storage can't be less than the current 300"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("and the plan has no maximum", func() {
+				BeforeEach(func() {
+					rdsProperties3.MaxAllocatedStorage = 0
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("allocated_storage is not supported for plan 'Plan 3'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
 				})
 			})
 		})
 
-		Context("when has PreferredMaintenanceWindow", func() {
+		Context("when the new plan has read replicas", func() {
 			BeforeEach(func() {
-				rdsProperties3.PreferredMaintenanceWindow = "test-preferred-maintenance-window"
+				rdsProperties3.ReadReplicaCount = 1
 			})
 
-			It("makes the proper calls", func() {
+			It("records the number of read replicas of the new plan", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window"))
+				Expect(internaldb.FindInstance(internalDB, instanceID).ReadReplicaCount).To(Equal(int64(1)))
 			})
+		})
 
-			Context("when Engine is Aurora", func() {
+		Context("when the new plan has several cluster instances", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				rdsProperties3.Engine = "aurora"
+				rdsProperties3.ClusterInstanceCount = 3
+				dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
+					Members: []string{dbInstanceIdentifier, dbInstanceIdentifier + "-2"},
+				}
+			})
+
+			It("modifies the existing instances and creates the missing ones", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyIDs).To(Equal([]string{dbInstanceIdentifier, dbInstanceIdentifier + "-2"}))
+				Expect(dbInstance.CreateIDs).To(Equal([]string{dbInstanceIdentifier + "-3"}))
+				Expect(dbInstance.CreateDBInstanceDetails.DBClusterIdentifier).To(Equal(dbClusterIdentifier))
+				Expect(dbInstance.CreateDBInstanceDetails.DBInstanceClass).To(Equal("db.m2.test"))
+				Expect(dbInstance.DeleteCalled).To(BeFalse())
+			})
+
+			Context("when the new plan has fewer cluster instances", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "aurora"
-					rdsProperties3.Engine = "aurora"
+					rdsProperties3.ClusterInstanceCount = 1
+					dbCluster.DescribeDBClusterDetails.Members = []string{dbInstanceIdentifier, dbInstanceIdentifier + "-2", dbInstanceIdentifier + "-3"}
 				})
 
-				It("makes the proper calls", func() {
+				It("deletes the instances beyond the plan's count", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window"))
+					Expect(dbInstance.ModifyIDs).To(Equal([]string{dbInstanceIdentifier}))
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+					Expect(dbInstance.DeleteIDs).To(Equal([]string{dbInstanceIdentifier + "-2", dbInstanceIdentifier + "-3"}))
+					Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
 				})
 			})
 
-			Context("but has PreferredMaintenanceWindow Parameter", func() {
+			Context("when describing the DB Cluster fails", func() {
 				BeforeEach(func() {
-					updateDetails.RawParameters = json.RawMessage("{\"preferred_maintenance_window\": \"test-preferred-maintenance-window-parameter\"}")
+					dbCluster.DescribeError = errors.New("operation failed")
 				})
 
-				It("makes the proper calls", func() {
+				It("returns the proper error", func() {
 					_, err := Update()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window-parameter"))
-				})
-
-				Context("when Engine is Aurora", func() {
-					BeforeEach(func() {
-						rdsProperties1.Engine = "aurora"
-						rdsProperties3.Engine = "aurora"
-					})
-
-					It("makes the proper calls", func() {
-						_, err := Update()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(dbCluster.ModifyDBClusterDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window-parameter"))
-					})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
 				})
 			})
 		})
 
-		Context("when has PubliclyAccessible", func() {
+		Context("when EngineMode is serverless", func() {
 			BeforeEach(func() {
-				rdsProperties3.PubliclyAccessible = true
+				rdsProperties1.Engine = "aurora"
+				rdsProperties1.EngineMode = "serverless"
+				rdsProperties3.Engine = "aurora"
+				rdsProperties3.EngineMode = "serverless"
+				rdsProperties3.MaxCapacity = 16
 			})
 
-			It("makes the proper calls", func() {
+			It("only modifies the DB Cluster", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.PubliclyAccessible).To(BeTrue())
+				Expect(dbCluster.ModifyCalled).To(BeTrue())
+				Expect(dbCluster.ModifyDBClusterDetails.ScalingConfiguration).To(Equal(&awsrds.ScalingConfiguration{MaxCapacity: 16}))
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+				Expect(dbInstance.CreateCalled).To(BeFalse())
+			})
+
+			Context("when the new plan is not serverless", func() {
+				BeforeEach(func() {
+					rdsProperties3.EngineMode = ""
+					rdsProperties3.MaxCapacity = 0
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrPlanChangeNotSupported))
+				})
 			})
 		})
 
-		Context("when has StorageEncrypted", func() {
+		Context("when has AllocatedStorage", func() {
 			BeforeEach(func() {
-				rdsProperties3.StorageEncrypted = true
+				rdsProperties3.AllocatedStorage = int64(100)
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.StorageEncrypted).To(BeTrue())
+				Expect(dbInstance.ModifyDBInstanceDetails.AllocatedStorage).To(Equal(int64(100)))
 			})
 
 			Context("when Engine is Aurora", func() {
@@ -1597,20 +2108,32 @@ var _ = Describe("RDS Broker", func() {
 				It("makes the proper calls", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.StorageEncrypted).To(BeFalse())
+					Expect(dbInstance.ModifyDBInstanceDetails.AllocatedStorage).To(Equal(int64(0)))
 				})
 			})
 		})
 
-		Context("when has StorageType", func() {
+		Context("when has AutoMinorVersionUpgrade", func() {
 			BeforeEach(func() {
-				rdsProperties3.StorageType = "test-storage-type"
+				rdsProperties3.AutoMinorVersionUpgrade = true
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.StorageType).To(Equal("test-storage-type"))
+				Expect(dbInstance.ModifyDBInstanceDetails.AutoMinorVersionUpgrade).To(BeTrue())
+			})
+		})
+
+		Context("when has AvailabilityZone", func() {
+			BeforeEach(func() {
+				rdsProperties3.AvailabilityZone = "test-az"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.AvailabilityZone).To(Equal("test-az"))
 			})
 
 			Context("when Engine is Aurora", func() {
@@ -1622,20 +2145,21 @@ var _ = Describe("RDS Broker", func() {
 				It("makes the proper calls", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbInstance.ModifyDBInstanceDetails.StorageType).To(Equal(""))
+					Expect(dbInstance.ModifyDBInstanceDetails.AvailabilityZone).To(Equal("test-az"))
+					Expect(dbCluster.ModifyDBClusterDetails.AvailabilityZones).To(Equal([]string{"test-az"}))
 				})
 			})
 		})
 
-		Context("when has VpcSecurityGroupIds", func() {
+		Context("when has BackupRetentionPeriod", func() {
 			BeforeEach(func() {
-				rdsProperties3.VpcSecurityGroupIds = []string{"test-vpc-security-group-ids"}
+				rdsProperties3.BackupRetentionPeriod = int64(7)
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.ModifyDBInstanceDetails.VpcSecurityGroupIds).To(Equal([]string{"test-vpc-security-group-ids"}))
+				Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(7)))
 			})
 
 			Context("when Engine is Aurora", func() {
@@ -1647,1026 +2171,4687 @@ var _ = Describe("RDS Broker", func() {
 				It("makes the proper calls", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.VpcSecurityGroupIds).To(Equal([]string{"test-vpc-security-group-ids"}))
-					Expect(dbInstance.ModifyDBInstanceDetails.VpcSecurityGroupIds).To(BeNil())
-				})
+					Expect(dbCluster.ModifyDBClusterDetails.BackupRetentionPeriod).To(Equal(int64(7)))
+					Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(0)))
+				})
+			})
+
+			Context("but has BackupRetentionPeriod Parameter", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage("{\"backup_retention_period\": 12}")
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(12)))
+				})
+
+				Context("when Engine is Aurora", func() {
+					BeforeEach(func() {
+						rdsProperties1.Engine = "aurora"
+						rdsProperties3.Engine = "aurora"
+					})
+
+					It("makes the proper calls", func() {
+						_, err := Update()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbCluster.ModifyDBClusterDetails.BackupRetentionPeriod).To(Equal(int64(12)))
+						Expect(dbInstance.ModifyDBInstanceDetails.BackupRetentionPeriod).To(Equal(int64(0)))
+					})
+				})
 			})
 		})
 
-		Context("when has extensions", func() {
+		Context("when has CharacterSetName", func() {
 			BeforeEach(func() {
-				updateDetails.RawParameters = json.RawMessage(`{"extensions": ["one", "two"]}`)
+				rdsProperties3.CharacterSetName = "test-characterset-name"
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(sqlEngine.SetExtensionsCalled).To(BeTrue())
-				Expect(sqlEngine.SetExtensionsExtensions).To(Equal([]string{"one", "two"}))
+				Expect(dbInstance.ModifyDBInstanceDetails.CharacterSetName).To(Equal("test-characterset-name"))
 			})
 
-			Context("but it fails", func() {
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					sqlEngine.SetExtensionsError = errors.New("failed to set extensions")
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
-				It("returns the proper error", func() {
+				It("makes the proper calls", func() {
 					_, err := Update()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(Equal("failed to set extensions"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.CharacterSetName).To(Equal(""))
 				})
 			})
 		})
 
-		Context("when plan is shared", func() {
+		Context("when has CopyTagsToSnapshot", func() {
 			BeforeEach(func() {
-				rdsProperties1.Shared = true
-				rdsProperties3.Shared = true
+				rdsProperties3.CopyTagsToSnapshot = true
 			})
 
-			It("returns the proper response", func() {
-				updateSpec, err := Update()
+			It("makes the proper calls", func() {
+				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(updateSpec.IsAsync).To(BeFalse())
+				Expect(dbInstance.ModifyDBInstanceDetails.CopyTagsToSnapshot).To(BeTrue())
 			})
+		})
 
-			It("does not modify the dbinstance or cluster", func() {
+		Context("when has IAMDatabaseAuthentication", func() {
+			BeforeEach(func() {
+				rdsProperties3.IAMDatabaseAuthentication = true
+			})
+
+			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.IAMDatabaseAuthenticationEnabled).To(BeTrue())
+			})
+		})
+
+		Context("when bindings use IAM database authentication", func() {
+			BeforeEach(func() {
+				_, _, err := internaldb.FindInstance(internalDB, instanceID).Bind(internalDB, "binding-id", "iam_user", internaldb.IAM, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("doesn't move to a plan without it", func() {
+				_, err := Update()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Bindings using IAM database authentication have to be removed before moving to a plan without it"))
 				Expect(dbInstance.ModifyCalled).To(BeFalse())
-				Expect(dbCluster.ModifyCalled).To(BeFalse())
 			})
 
-			Context("and has extensions", func() {
+			Context("and the new plan has it", func() {
 				BeforeEach(func() {
-					updateDetails.RawParameters = json.RawMessage(`{"extensions": ["one", "two"]}`)
-					rdsProperties1.Engine = "postgres"
-					rdsProperties3.Engine = "postgres"
+					rdsProperties3.IAMDatabaseAuthentication = true
 				})
 
 				It("makes the proper calls", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(sqlEngine.SetExtensionsCalled).To(BeTrue())
-					Expect(sqlEngine.SetExtensionsExtensions).To(Equal([]string{"one", "two"}))
+					Expect(dbInstance.ModifyCalled).To(BeTrue())
 				})
 			})
 		})
 
-		Context("when request does not accept incomplete", func() {
-			BeforeEach(func() {
-				acceptsIncomplete = false
-			})
-
-			It("returns the proper error", func() {
-				_, err := Update()
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(Equal(brokerapi.ErrAsyncRequired))
-			})
-		})
-
-		Context("when Parameters are not valid", func() {
+		Context("when moving to a plan requiring TLS", func() {
 			BeforeEach(func() {
-				updateDetails.RawParameters = json.RawMessage("{\"backup_retention_period\": \"invalid\"}")
+				rdsProperties3.RequireTLS = true
 			})
 
-			It("returns the proper error", func() {
+			It("doesn't connect to an instance without bindings", func() {
 				_, err := Update()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("json: cannot unmarshal string"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
+				Expect(sqlEngine.SetRequireTLSCalled).To(BeFalse())
 			})
 
-			Context("and user update parameters are not allowed", func() {
+			Context("and the instance has bindings", func() {
 				BeforeEach(func() {
-					allowUserUpdateParameters = false
+					_, _, err := internaldb.FindInstance(internalDB, instanceID).Bind(internalDB, "binding-id", "app_user", internaldb.Standard, encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
 				})
 
-				It("does not return an error", func() {
+				It("requires TLS for their users", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.SetRequireTLSUsers).To(Equal(map[string]bool{"app_user": true}))
+					Expect(sqlEngine.CloseCalled).To(BeTrue())
+					Expect(dbInstance.ModifyCalled).To(BeTrue())
+				})
+
+				Context("and that fails", func() {
+					BeforeEach(func() {
+						sqlEngine.SetRequireTLSError = errors.New("operation failed")
+					})
+
+					It("returns the proper error", func() {
+						_, err := Update()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("operation failed"))
+						Expect(dbInstance.ModifyCalled).To(BeFalse())
+					})
 				})
 			})
 		})
 
-		Context("when Plans is not updateable", func() {
+		Context("when moving from a plan requiring TLS", func() {
 			BeforeEach(func() {
-				planUpdateable = false
+				rdsProperties1.RequireTLS = true
+				_, _, err := internaldb.FindInstance(internalDB, instanceID).Bind(internalDB, "binding-id", "app_user", internaldb.Standard, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
 			})
 
-			It("returns the proper error", func() {
+			It("stops requiring TLS for the binding users", func() {
 				_, err := Update()
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(Equal(brokerapi.ErrPlanChangeNotSupported))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.SetRequireTLSUsers).To(Equal(map[string]bool{"app_user": false}))
 			})
 		})
 
-		Context("when Service Plan is not found", func() {
+		Context("when has DBParameterGroupName", func() {
 			BeforeEach(func() {
-				updateDetails.PlanID = "unknown"
+				rdsProperties3.DBParameterGroupName = "test-db-parameter-group-name"
 			})
 
-			It("returns the proper error", func() {
+			It("makes the proper calls", func() {
 				_, err := Update()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Service Plan 'unknown' not found"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.DBParameterGroupName).To(Equal("test-db-parameter-group-name"))
 			})
 		})
 
-		Context("when modifying the DB Instance fails", func() {
+		Context("when has DBSecurityGroups", func() {
 			BeforeEach(func() {
-				dbInstance.ModifyError = errors.New("operation failed")
+				rdsProperties3.DBSecurityGroups = []string{"test-db-security-group"}
 			})
 
-			It("returns the proper error", func() {
+			It("makes the proper calls", func() {
 				_, err := Update()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("operation failed"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.DBSecurityGroups).To(Equal([]string{"test-db-security-group"}))
 			})
 
-			Context("when the DB Instance does not exists", func() {
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					dbInstance.ModifyError = awsrds.ErrDBInstanceDoesNotExist
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
-				It("returns the proper error", func() {
+				It("makes the proper calls", func() {
 					_, err := Update()
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.DBSecurityGroups).To(BeNil())
 				})
 			})
 		})
 
-		Context("when Engine is Aurora", func() {
+		Context("when has DBSubnetGroupName", func() {
 			BeforeEach(func() {
-				rdsProperties1.Engine = "aurora"
-				rdsProperties3.Engine = "aurora"
+				rdsProperties3.DBSubnetGroupName = "test-db-subnet-group-name"
 			})
 
 			It("makes the proper calls", func() {
 				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbCluster.ModifyCalled).To(BeTrue())
-				Expect(dbCluster.ModifyID).To(Equal(dbClusterIdentifier))
-				Expect(dbCluster.ModifyDBClusterDetails.Engine).To(Equal("aurora"))
-				Expect(dbCluster.ModifyDBClusterDetails.Tags["Owner"]).To(Equal("Cloud Foundry"))
-				Expect(dbCluster.ModifyDBClusterDetails.Tags["Updated by"]).To(Equal("AWS RDS Service Broker"))
-				Expect(dbCluster.ModifyDBClusterDetails.Tags).To(HaveKey("Updated at"))
-				Expect(dbCluster.ModifyDBClusterDetails.Tags["Service ID"]).To(Equal("Service-1"))
-				Expect(dbCluster.ModifyDBClusterDetails.Tags["Plan ID"]).To(Equal("Plan-3"))
+				Expect(dbInstance.ModifyDBInstanceDetails.DBSubnetGroupName).To(Equal("test-db-subnet-group-name"))
 			})
 
-			Context("when has DBClusterParameterGroupName", func() {
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					rdsProperties3.DBClusterParameterGroupName = "test-db-cluster-parameter-group-name"
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
 				It("makes the proper calls", func() {
 					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(dbCluster.ModifyDBClusterDetails.DBClusterParameterGroupName).To(Equal("test-db-cluster-parameter-group-name"))
+					Expect(dbCluster.ModifyDBClusterDetails.DBSubnetGroupName).To(Equal("test-db-subnet-group-name"))
 				})
 			})
 		})
-	})
-
-	var _ = Describe("Deprovision", func() {
-		var (
-			deprovisionDetails brokerapi.DeprovisionDetails
-			acceptsIncomplete  bool
-			instance           *internaldb.DBInstance
-		)
-
-		BeforeEach(func() {
-			deprovisionDetails = brokerapi.DeprovisionDetails{
-				ServiceID: "Service-1",
-				PlanID:    "Plan-1",
-			}
-			acceptsIncomplete = true
-			instance = MakeInstance()
-		})
-
-		Deprovision := func() (brokerapi.DeprovisionServiceSpec, error) {
-			return rdsBroker.Deprovision(context.Background(), instanceID, deprovisionDetails, acceptsIncomplete)
-		}
-
-		It("returns the proper response", func() {
-			deprovisionSpec, err := Deprovision()
-			Expect(deprovisionSpec.IsAsync).To(BeTrue())
-			Expect(err).ToNot(HaveOccurred())
-		})
-
-		It("makes the proper calls", func() {
-			_, err := Deprovision()
-			Expect(dbInstance.DeleteCalled).To(BeTrue())
-			Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
-			Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
-			Expect(err).ToNot(HaveOccurred())
-		})
-
-		It("doesn't delete the internaldb instance", func() {
-			_, err := Deprovision()
-			Expect(err).ToNot(HaveOccurred())
-			Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
-		})
 
-		Context("when it does not skip final snaphot", func() {
+		Context("when has EngineVersion", func() {
 			BeforeEach(func() {
-				rdsProperties1.SkipFinalSnapshot = false
+				rdsProperties3.EngineVersion = "1.2.3"
 			})
 
 			It("makes the proper calls", func() {
-				_, err := Deprovision()
-				Expect(dbInstance.DeleteCalled).To(BeTrue())
-				Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
-				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeFalse())
+				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.2.3"))
 			})
-		})
 
-		Context("when request does not accept incomplete", func() {
-			BeforeEach(func() {
-				acceptsIncomplete = false
-			})
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
 
-			It("returns the proper error", func() {
-				_, err := Deprovision()
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(Equal(brokerapi.ErrAsyncRequired))
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.2.3"))
+					Expect(dbCluster.ModifyDBClusterDetails.EngineVersion).To(Equal("1.2.3"))
+				})
 			})
 		})
 
-		Context("when deleting the DB Instance fails", func() {
+		Context("when has Iops", func() {
 			BeforeEach(func() {
-				dbInstance.DeleteError = errors.New("operation failed")
+				rdsProperties3.Iops = int64(1000)
 			})
 
-			It("returns the proper error", func() {
-				_, err := Deprovision()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("operation failed"))
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.Iops).To(Equal(int64(1000)))
 			})
 
-			Context("when the DB instance does not exists", func() {
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					dbInstance.DeleteError = awsrds.ErrDBInstanceDoesNotExist
-				})
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
 
-				It("returns the proper error", func() {
-					_, err := Deprovision()
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.Iops).To(Equal(int64(0)))
 				})
 			})
 		})
 
-		Context("when shared instance", func() {
+		Context("when has KmsKeyID", func() {
 			BeforeEach(func() {
-				rdsProperties1.Shared = true
+				rdsProperties3.KmsKeyID = "test-kms-key-id"
 			})
 
-			Context("with postgres", func() {
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.KmsKeyID).To(Equal("test-kms-key-id"))
+			})
+
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "postgres"
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
-				It("returns the proper response", func() {
-					deprovisionSpec, err := Deprovision()
+				It("makes the proper calls", func() {
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(deprovisionSpec.IsAsync).To(BeFalse())
+					Expect(dbInstance.ModifyDBInstanceDetails.KmsKeyID).To(Equal(""))
+				})
+			})
+		})
+
+		Context("when has LicenseModel", func() {
+			BeforeEach(func() {
+				rdsProperties3.LicenseModel = "test-license-model"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.LicenseModel).To(Equal("test-license-model"))
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
 				It("makes the proper calls", func() {
-					_, err := Deprovision()
-					Expect(dbInstance.DeleteCalled).To(BeFalse())
-					Expect(sharedPostgres.DropDBCalled).To(BeTrue())
-					Expect(sharedPostgres.DropDBDBName).To(Equal(dbName))
-					Expect(sharedMysql.DropDBCalled).To(BeFalse())
-					Expect(sharedPostgres.DropUserCalled).To(BeTrue())
-					Expect(sharedPostgres.DropUserUsername).To(Equal(instance.MasterUser().Username))
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.LicenseModel).To(Equal(""))
 				})
+			})
+		})
 
-				It("deletes the internaldb instance", func() {
-					_, err := Deprovision()
+		Context("when has MultiAZ", func() {
+			BeforeEach(func() {
+				rdsProperties3.MultiAZ = true
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.MultiAZ).To(BeTrue())
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+					Expect(dbInstance.ModifyDBInstanceDetails.MultiAZ).To(BeFalse())
 				})
 			})
+		})
 
-			Context("with mysql", func() {
+		Context("when has OptionGroupName", func() {
+			BeforeEach(func() {
+				rdsProperties3.OptionGroupName = "test-option-group-name"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.OptionGroupName).To(Equal("test-option-group-name"))
+			})
+		})
+
+		Context("when has Port", func() {
+			BeforeEach(func() {
+				rdsProperties3.Port = int64(3306)
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.Port).To(Equal(int64(3306)))
+			})
+
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "mysql"
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
-				It("returns the proper response", func() {
-					deprovisionSpec, err := Deprovision()
+				It("makes the proper calls", func() {
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(deprovisionSpec.IsAsync).To(BeFalse())
+					Expect(dbCluster.ModifyDBClusterDetails.Port).To(Equal(int64(3306)))
+					Expect(dbInstance.ModifyDBInstanceDetails.Port).To(Equal(int64(0)))
+				})
+			})
+		})
+
+		Context("when has PreferredBackupWindow", func() {
+			BeforeEach(func() {
+				rdsProperties3.PreferredBackupWindow = "test-preferred-backup-window"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window"))
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
 				It("makes the proper calls", func() {
-					_, err := Deprovision()
-					Expect(dbInstance.DeleteCalled).To(BeFalse())
-					Expect(sharedPostgres.DropDBCalled).To(BeFalse())
-					Expect(sharedMysql.DropDBCalled).To(BeTrue())
-					Expect(sharedMysql.DropDBDBName).To(Equal(dbName))
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.ModifyDBClusterDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window"))
+					Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal(""))
 				})
+			})
 
-				It("deletes the internaldb instance", func() {
-					_, err := Deprovision()
+			Context("but has PreferredBackupWindow Parameter", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage("{\"preferred_backup_window\": \"test-preferred-backup-window-parameter\"}")
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+					Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window-parameter"))
+				})
+
+				Context("when Engine is Aurora", func() {
+					BeforeEach(func() {
+						rdsProperties1.Engine = "aurora"
+						rdsProperties3.Engine = "aurora"
+					})
+
+					It("makes the proper calls", func() {
+						_, err := Update()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbCluster.ModifyDBClusterDetails.PreferredBackupWindow).To(Equal("test-preferred-backup-window-parameter"))
+						Expect(dbInstance.ModifyDBInstanceDetails.PreferredBackupWindow).To(Equal(""))
+					})
 				})
 			})
 		})
 
-		Context("when Engine is Aurora", func() {
+		Context("when has PreferredMaintenanceWindow", func() {
 			BeforeEach(func() {
-				rdsProperties1.Engine = "aurora"
+				rdsProperties3.PreferredMaintenanceWindow = "test-preferred-maintenance-window"
 			})
 
 			It("makes the proper calls", func() {
-				_, err := Deprovision()
-				Expect(dbCluster.DeleteCalled).To(BeTrue())
-				Expect(dbCluster.DeleteID).To(Equal(dbClusterIdentifier))
-				Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeTrue())
-				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+				_, err := Update()
 				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window"))
 			})
 
-			Context("when it does not skip final snaphot", func() {
+			Context("when Engine is Aurora", func() {
 				BeforeEach(func() {
-					rdsProperties1.SkipFinalSnapshot = false
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
 				})
 
 				It("makes the proper calls", func() {
-					_, err := Deprovision()
-					Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeFalse())
-					Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.ModifyDBClusterDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window"))
 				})
 			})
 
-			Context("when deleting the DB Instance fails", func() {
+			Context("but has PreferredMaintenanceWindow Parameter", func() {
 				BeforeEach(func() {
-					dbCluster.DeleteError = errors.New("operation failed")
+					updateDetails.RawParameters = json.RawMessage("{\"preferred_maintenance_window\": \"test-preferred-maintenance-window-parameter\"}")
 				})
 
-				It("does not return an error", func() {
-					_, err := Deprovision()
+				It("makes the proper calls", func() {
+					_, err := Update()
 					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window-parameter"))
 				})
-			})
-		})
-	})
 
-	var _ = Describe("Bind", func() {
-		var (
-			bindDetails brokerapi.BindDetails
-			instance    *internaldb.DBInstance
-		)
+				Context("when Engine is Aurora", func() {
+					BeforeEach(func() {
+						rdsProperties1.Engine = "aurora"
+						rdsProperties3.Engine = "aurora"
+					})
 
-		BeforeEach(func() {
-			bindDetails = brokerapi.BindDetails{
-				ServiceID:     "Service-1",
-				PlanID:        "Plan-1",
-				AppGUID:       "Application-1",
-				RawParameters: json.RawMessage(""),
-			}
-			rdsProperties1.Engine = "postgres"
+					It("makes the proper calls", func() {
+						_, err := Update()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbCluster.ModifyDBClusterDetails.PreferredMaintenanceWindow).To(Equal("test-preferred-maintenance-window-parameter"))
+					})
+				})
+			})
+		})
 
-			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
-				Identifier: dbInstanceIdentifier,
-				Address:    "endpoint-address",
-				Port:       3306,
-			}
+		Context("when has PubliclyAccessible", func() {
+			BeforeEach(func() {
+				rdsProperties3.PubliclyAccessible = true
+			})
 
-			dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
-				Identifier: dbClusterIdentifier,
-				Endpoint:   "endpoint-address",
-				Port:       3306,
-			}
-			instance = MakeInstance()
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.PubliclyAccessible).To(BeTrue())
+			})
 		})
 
-		Bind := func() (brokerapi.Binding, error) {
-			return rdsBroker.Bind(context.Background(), instanceID, bindingID, bindDetails)
-		}
-
-		It("returns the proper response", func() {
-			bindingResponse, err := Bind()
-			Expect(err).ToNot(HaveOccurred())
-			credentials := bindingResponse.Credentials.(*CredentialsHash)
-			Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
-			Expect(credentials.Host).To(Equal("endpoint-address"))
-			Expect(credentials.Port).To(Equal(int64(3306)))
-			Expect(credentials.Name).To(Equal(dbName))
-			Expect(credentials.Username).ToNot(BeEmpty())
-			Expect(credentials.Password).ToNot(BeEmpty())
-			Expect(credentials.URI).To(ContainSubstring("@endpoint-address:3306/%s?reconnect=true", dbName))
-			Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://endpoint-address:3306/%s?user=%s&password=", dbName, credentials.Username))
+		Context("when has StorageEncrypted", func() {
+			BeforeEach(func() {
+				rdsProperties3.StorageEncrypted = true
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.StorageEncrypted).To(BeTrue())
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.StorageEncrypted).To(BeFalse())
+				})
+			})
 		})
 
-		It("makes the proper calls", func() {
-			bindingResponse, err := Bind()
-			Expect(err).ToNot(HaveOccurred())
-			credentials := bindingResponse.Credentials.(*CredentialsHash)
-			Expect(dbCluster.DescribeCalled).To(BeFalse())
-			Expect(dbInstance.DescribeCalled).To(BeTrue())
-			Expect(dbInstance.DescribeID).To(Equal(dbInstanceIdentifier))
-			Expect(sqlProvider.GetSQLEngineCalled).To(BeTrue())
-			Expect(sqlProvider.GetSQLEngineEngine).To(Equal("postgres"))
-			Expect(sqlEngine.OpenCalled).To(BeTrue())
-			Expect(sqlEngine.OpenConfig.Url).To(Equal("endpoint-address"))
-			Expect(sqlEngine.OpenConfig.Port).To(Equal(int64(3306)))
-			Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
-			Expect(sqlEngine.OpenConfig.Username).ToNot(BeEmpty())
-			Expect(sqlEngine.OpenConfig.Password).ToNot(BeEmpty())
-			Expect(sqlEngine.CreateDBCalled).To(BeFalse())
-			Expect(sqlEngine.CreateUserCalled).To(BeTrue())
-			Expect(sqlEngine.CreateUserUsername).To(Equal(credentials.Username))
-			Expect(sqlEngine.CreateUserPassword).To(Equal(credentials.Password))
-			Expect(sqlEngine.GrantPrivilegesCalled).To(BeTrue())
-			Expect(sqlEngine.GrantPrivilegesDBName).To(Equal(dbName))
-			Expect(sqlEngine.GrantPrivilegesUsername).To(Equal(credentials.Username))
-			Expect(sqlEngine.CloseCalled).To(BeTrue())
+		Context("when has StorageType", func() {
+			BeforeEach(func() {
+				rdsProperties3.StorageType = "test-storage-type"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.StorageType).To(Equal("test-storage-type"))
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.StorageType).To(Equal(""))
+				})
+			})
 		})
 
-		Context("when Service is not bindable", func() {
+		Context("when has VpcSecurityGroupIds", func() {
 			BeforeEach(func() {
-				serviceBindable = false
+				rdsProperties3.VpcSecurityGroupIds = []string{"test-vpc-security-group-ids"}
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.VpcSecurityGroupIds).To(Equal([]string{"test-vpc-security-group-ids"}))
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties3.Engine = "aurora"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.ModifyDBClusterDetails.VpcSecurityGroupIds).To(Equal([]string{"test-vpc-security-group-ids"}))
+					Expect(dbInstance.ModifyDBInstanceDetails.VpcSecurityGroupIds).To(BeNil())
+				})
+			})
+		})
+
+		Context("when has extensions", func() {
+			BeforeEach(func() {
+				updateDetails.RawParameters = json.RawMessage(`{"extensions": ["one", "two"]}`)
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.SetExtensionsCalled).To(BeTrue())
+				Expect(sqlEngine.SetExtensionsExtensions).To(Equal([]string{"one", "two"}))
+			})
+
+			Context("when Engine is Aurora PostgreSQL", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora-postgresql"
+					rdsProperties3.Engine = "aurora-postgresql"
+				})
+
+				It("sets the extensions through the DB Cluster", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlProvider.GetSQLEngineEngine).To(Equal("aurora-postgresql"))
+					Expect(dbCluster.DescribeCalled).To(BeTrue())
+					Expect(sqlEngine.SetExtensionsCalled).To(BeTrue())
+					Expect(sqlEngine.SetExtensionsExtensions).To(Equal([]string{"one", "two"}))
+					Expect(dbCluster.ModifyCalled).To(BeTrue())
+				})
+			})
+
+			Context("but it fails", func() {
+				BeforeEach(func() {
+					sqlEngine.SetExtensionsError = errors.New("failed to set extensions")
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("failed to set extensions"))
+				})
+			})
+		})
+
+		Context("when plan is shared", func() {
+			BeforeEach(func() {
+				rdsProperties1.Shared = true
+				rdsProperties3.Shared = true
+			})
+
+			It("returns the proper response", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeFalse())
+			})
+
+			It("does not modify the dbinstance or cluster", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+				Expect(dbCluster.ModifyCalled).To(BeFalse())
+			})
+
+			It("switches the instance to the new plan", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.PlanID).To(Equal("Plan-3"))
+				operation := internaldb.LatestOperation(internalDB, instance)
+				Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+				Expect(operation.State).To(Equal(internaldb.OperationSucceeded))
+			})
+
+			Context("and has extensions", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"extensions": ["one", "two"]}`)
+					rdsProperties1.Engine = "postgres"
+					rdsProperties3.Engine = "postgres"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.SetExtensionsCalled).To(BeTrue())
+					Expect(sqlEngine.SetExtensionsExtensions).To(Equal([]string{"one", "two"}))
+				})
+			})
+		})
+
+		Context("when moving from a shared plan to a dedicated plan", func() {
+			BeforeEach(func() {
+				rdsProperties1.Shared = true
+				rdsProperties1.Engine = "postgres"
+				rdsProperties3.Engine = "postgres"
+			})
+
+			It("creates the DB Instance", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeTrue())
+				Expect(dbInstance.CreateCalled).To(BeTrue())
+				Expect(dbInstance.CreateID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.CreateDBInstanceDetails.DBInstanceClass).To(Equal("db.m2.test"))
+				Expect(dbInstance.CreateDBInstanceDetails.DBName).To(Equal(dbName))
+				Expect(dbInstance.CreateDBInstanceDetails.Tags["Plan ID"]).To(Equal("Plan-3"))
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+			})
+
+			It("stays on the shared plan until the database has been copied", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.PlanID).To(Equal("Plan-1"))
+				operation := internaldb.FindOperation(internalDB, instance, updateSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+				Expect(operation.PlanID).To(Equal("Plan-3"))
+				Expect(operation.Stage).To(Equal("migrate-to-dedicated"))
+				Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+			})
+
+			Context("and other changes are asked for", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"backup_retention_period": 7}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Moving to a dedicated plan can't be combined with other changes"))
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+				})
+			})
+
+			Context("and the database has objects which can't be copied", func() {
+				BeforeEach(func() {
+					sqlEngine.UnsupportedObjectsResult = []string{"view public.totals", "function public.total"}
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Database 'cf_instance_id' can't be copied as it has: view public.totals, function public.total"))
+					Expect(dbInstance.CreateCalled).To(BeFalse())
+				})
+			})
+
+			Context("when creating the DB Instance fails", func() {
+				BeforeEach(func() {
+					dbInstance.CreateError = errors.New("operation failed")
+				})
+
+				It("returns the proper error and stays on the shared plan", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					Expect(instance.PlanID).To(Equal("Plan-1"))
+				})
+			})
+		})
+
+		Context("when moving from a dedicated plan to a shared plan", func() {
+			BeforeEach(func() {
+				rdsProperties3.Shared = true
 			})
 
 			It("returns the proper error", func() {
-				_, err := Bind()
+				_, err := Update()
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Service is not bindable"))
+				Expect(err).To(Equal(brokerapi.ErrPlanChangeNotSupported))
 			})
 		})
 
-		Context("when describing the DB Instance fails", func() {
+		Context("when request does not accept incomplete", func() {
 			BeforeEach(func() {
-				dbInstance.DescribeError = errors.New("operation failed")
+				acceptsIncomplete = false
 			})
 
 			It("returns the proper error", func() {
-				_, err := Bind()
+				_, err := Update()
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("operation failed"))
+				Expect(err).To(Equal(brokerapi.ErrAsyncRequired))
+			})
+		})
+
+		Context("when Parameters are not valid", func() {
+			BeforeEach(func() {
+				updateDetails.RawParameters = json.RawMessage("{\"backup_retention_period\": \"invalid\"}")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Update()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("json: cannot unmarshal string"))
 			})
 
-			Context("when the DB Instance does not exists", func() {
-				BeforeEach(func() {
-					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+			Context("and user update parameters are not allowed", func() {
+				BeforeEach(func() {
+					allowUserUpdateParameters = false
+				})
+
+				It("does not return an error", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when Plans is not updateable", func() {
+			BeforeEach(func() {
+				planUpdateable = false
+			})
+
+			It("returns the proper error", func() {
+				_, err := Update()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrPlanChangeNotSupported))
+			})
+		})
+
+		Context("when Service Plan is not found", func() {
+			BeforeEach(func() {
+				updateDetails.PlanID = "unknown"
+			})
+
+			It("returns the proper error", func() {
+				_, err := Update()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Service Plan 'unknown' not found"))
+			})
+		})
+
+		Context("when modifying the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.ModifyError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Update()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("when the DB Instance does not exists", func() {
+				BeforeEach(func() {
+					dbInstance.ModifyError = awsrds.ErrDBInstanceDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				})
+			})
+		})
+
+		Context("when Engine is Aurora", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				rdsProperties3.Engine = "aurora"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbCluster.ModifyCalled).To(BeTrue())
+				Expect(dbCluster.ModifyID).To(Equal(dbClusterIdentifier))
+				Expect(dbCluster.ModifyDBClusterDetails.Engine).To(Equal("aurora"))
+				Expect(dbCluster.ModifyDBClusterDetails.Tags["Owner"]).To(Equal("Cloud Foundry"))
+				Expect(dbCluster.ModifyDBClusterDetails.Tags["Updated by"]).To(Equal("AWS RDS Service Broker"))
+				Expect(dbCluster.ModifyDBClusterDetails.Tags).To(HaveKey("Updated at"))
+				Expect(dbCluster.ModifyDBClusterDetails.Tags["Service ID"]).To(Equal("Service-1"))
+				Expect(dbCluster.ModifyDBClusterDetails.Tags["Plan ID"]).To(Equal("Plan-3"))
+			})
+
+			Context("when has DBClusterParameterGroupName", func() {
+				BeforeEach(func() {
+					rdsProperties3.DBClusterParameterGroupName = "test-db-cluster-parameter-group-name"
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.ModifyDBClusterDetails.DBClusterParameterGroupName).To(Equal("test-db-cluster-parameter-group-name"))
+				})
+			})
+		})
+
+		Context("when rotating binding credentials", func() {
+			var (
+				appPassword   string
+				otherPassword string
+			)
+
+			bind := func(bindingID, username string, userType internaldb.DBUserType) string {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				user, _, err := instance.Bind(internalDB, bindingID, username, userType, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				password, err := user.Password(encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				return password
+			}
+
+			BeforeEach(func() {
+				updateDetails.PlanID = "Plan-1"
+				updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": "all"}`)
+				appPassword = bind("binding-id", "app_user", internaldb.Standard)
+				bind("shared-binding-id", "app_user", internaldb.Standard)
+				otherPassword = bind("other-binding-id", "other_user", internaldb.ReadOnly)
+			})
+
+			savedPassword := func(username string) string {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				password, err := instance.User(username).Password(encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				return password
+			}
+
+			It("changes the passwords without modifying the DB Instance", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeFalse())
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+				Expect(sqlEngine.SetPasswordPasswords).To(HaveLen(2))
+				Expect(sqlEngine.SetPasswordPasswords["app_user"]).NotTo(Equal(appPassword))
+				Expect(sqlEngine.SetPasswordPasswords["other_user"]).NotTo(Equal(otherPassword))
+			})
+
+			It("saves the new passwords", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(savedPassword("app_user")).To(Equal(sqlEngine.SetPasswordPasswords["app_user"]))
+				Expect(savedPassword("other_user")).To(Equal(sqlEngine.SetPasswordPasswords["other_user"]))
+			})
+
+			It("records the update operation as done", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				operation := internaldb.LatestOperation(internalDB, instance)
+				Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+				Expect(operation.State).To(Equal(internaldb.OperationSucceeded))
+			})
+
+			Context("when given binding IDs", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": ["binding-id", "shared-binding-id"]}`)
+				})
+
+				It("only changes the passwords of their users", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.SetPasswordPasswords).To(HaveLen(1))
+					Expect(savedPassword("app_user")).To(Equal(sqlEngine.SetPasswordPasswords["app_user"]))
+					Expect(savedPassword("other_user")).To(Equal(otherPassword))
+				})
+			})
+
+			Context("when a binding uses IAM database authentication", func() {
+				BeforeEach(func() {
+					bind("iam-binding-id", "iam_user", internaldb.IAM)
+				})
+
+				It("leaves it out of all the bindings", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.SetPasswordPasswords).To(HaveLen(2))
+					Expect(sqlEngine.SetPasswordPasswords).NotTo(HaveKey("iam_user"))
+				})
+
+				Context("and it's given", func() {
+					BeforeEach(func() {
+						updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": ["binding-id", "iam-binding-id"]}`)
+					})
+
+					It("returns the proper error", func() {
+						_, err := Update()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Binding 'iam-binding-id' uses IAM database authentication so has no password to rotate"))
+						Expect(sqlEngine.SetPasswordCalled).To(BeFalse())
+					})
+				})
+			})
+
+			Context("when a binding is unknown", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": ["binding-id", "unknown-binding-id"]}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Binding 'unknown-binding-id' not found"))
+					Expect(sqlEngine.SetPasswordCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the parameter is not valid", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": "some"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("rotate_binding_credentials must be 'all' or a list of binding IDs"))
+				})
+			})
+
+			Context("when also changing other parameters", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"rotate_binding_credentials": "all", "create_snapshot": "pre-release-42"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("rotate_binding_credentials can't be combined with other changes"))
+					Expect(sqlEngine.SetPasswordCalled).To(BeFalse())
+					Expect(dbInstance.CreateSnapshotCalled).To(BeFalse())
+				})
+			})
+
+			Context("when setting a password fails", func() {
+				BeforeEach(func() {
+					sqlEngine.SetPasswordError = errors.New("set password failed")
+					sqlEngine.SetPasswordFailUsername = "other_user"
+				})
+
+				It("puts back the passwords already changed", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("set password failed"))
+					Expect(sqlEngine.SetPasswordPasswords["app_user"]).To(Equal(appPassword))
+					Expect(savedPassword("app_user")).To(Equal(appPassword))
+					Expect(savedPassword("other_user")).To(Equal(otherPassword))
+				})
+			})
+
+			Context("when the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+					rdsProperties1.Engine = "postgres"
+				})
+
+				It("changes the passwords on the shared server", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sharedPostgres.SetPasswordPasswords).To(HaveLen(2))
+					Expect(savedPassword("app_user")).To(Equal(sharedPostgres.SetPasswordPasswords["app_user"]))
+				})
+			})
+		})
+
+		Context("when rotating the master password", func() {
+			var oldPassword string
+
+			BeforeEach(func() {
+				updateDetails.PlanID = "Plan-1"
+				updateDetails.RawParameters = json.RawMessage(`{"rotate_master_password": true}`)
+				dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{Status: "available"}
+				var err error
+				oldPassword, err = internaldb.FindInstance(internalDB, instanceID).MasterUser().Password(encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			masterUser := func() *internaldb.DBUser {
+				return internaldb.FindInstance(internalDB, instanceID).MasterUser()
+			}
+
+			It("has RDS reset the master password straight away", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeTrue())
+				Expect(dbInstance.ModifyMasterPasswordCalled).To(BeTrue())
+				Expect(dbInstance.ModifyMasterPasswordID).To(Equal(dbInstanceIdentifier))
+				pendingPassword, err := masterUser().PendingPassword(encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dbInstance.ModifyMasterPasswordPassword).To(Equal(pendingPassword))
+				Expect(pendingPassword).NotTo(Equal(oldPassword))
+			})
+
+			It("does not apply the plan's settings", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+			})
+
+			Context("when the DB Instance has been upgraded past the plan's engine version", func() {
+				BeforeEach(func() {
+					rdsProperties1.EngineVersion = "9.4.7"
+					dbInstance.DescribeDBInstanceDetails.EngineVersion = "9.5.2"
+				})
+
+				It("only resets the master password", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyMasterPasswordCalled).To(BeTrue())
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			It("keeps the old password until the new one has been set", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+			})
+
+			It("records the rotation stage", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				operation := internaldb.FindOperation(internalDB, instance, updateSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+				Expect(operation.Stage).To(Equal("rotate-master-password"))
+			})
+
+			Context("when also changing other parameters", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"rotate_master_password": true, "apply_immediately": true}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("rotate_master_password can't be combined with other changes"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the DB Instance is not available", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeDBInstanceDetails.Status = "modifying"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("DB Instance 'cf-instance-id' must be available to rotate its master password, its status is 'modifying'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when resetting the master password fails", func() {
+				BeforeEach(func() {
+					dbInstance.ModifyMasterPasswordError = errors.New("operation failed")
+				})
+
+				It("keeps the old password", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+					Expect(masterUser().PendingPassword(encryptionKey)).To(BeEmpty())
+					Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+				})
+			})
+
+			Context("when the plan is aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{Status: "available"}
+				})
+
+				It("has RDS reset the master password of the DB Cluster", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.ModifyMasterPasswordCalled).To(BeTrue())
+					Expect(dbCluster.ModifyMasterPasswordID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.ModifyMasterPasswordPassword).NotTo(BeEmpty())
+					Expect(dbCluster.ModifyCalled).To(BeFalse())
+					Expect(dbInstance.ModifyMasterPasswordCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Master password rotation is not supported for shared plans"))
+				})
+			})
+		})
+
+		Context("when creating a snapshot", func() {
+			BeforeEach(func() {
+				updateDetails.PlanID = "Plan-1"
+				updateDetails.RawParameters = json.RawMessage(`{"create_snapshot": "pre-release-42"}`)
+				dbInstance.ListTagsTags = map[string]string{"Organization ID": "organization-id"}
+			})
+
+			It("snapshots the DB Instance without modifying it", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeTrue())
+				Expect(dbInstance.ModifyCalled).To(BeFalse())
+				Expect(dbInstance.CreateSnapshotCalled).To(BeTrue())
+				Expect(dbInstance.CreateSnapshotID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.CreateSnapshotSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+				Expect(dbInstance.CreateSnapshotTags["Managed by"]).To(Equal("github.com/AusDTO/pe-rds-broker"))
+				Expect(dbInstance.CreateSnapshotTags["Service ID"]).To(Equal("Service-1"))
+				Expect(dbInstance.CreateSnapshotTags["Plan ID"]).To(Equal("Plan-1"))
+				Expect(dbInstance.CreateSnapshotTags["Organization ID"]).To(Equal("organization-id"))
+			})
+
+			It("records the snapshot stage", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				operation := internaldb.FindOperation(internalDB, instance, updateSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.UpdateOperation))
+				Expect(operation.Stage).To(Equal("create-snapshot"))
+			})
+
+			It("records the snapshot", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				snapshot := internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")
+				Expect(snapshot).NotTo(BeNil())
+				Expect(snapshot.InstanceID).To(Equal(instanceID))
+				Expect(snapshot.Type).To(Equal(internaldb.ManualSnapshot))
+				Expect(snapshot.Cluster).To(BeFalse())
+			})
+
+			Context("when also changing the plan", func() {
+				BeforeEach(func() {
+					updateDetails.PlanID = "Plan-3"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("create_snapshot can't be combined with other changes"))
+					Expect(dbInstance.CreateSnapshotCalled).To(BeFalse())
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when also changing other parameters", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"create_snapshot": "pre-release-42", "apply_immediately": true}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("create_snapshot can't be combined with other changes"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the name is not valid", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"create_snapshot": "pre_release"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("create_snapshot must only contain letters, digits and single hyphens"))
+				})
+			})
+
+			Context("when the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Snapshots are not supported for shared plans"))
+				})
+			})
+
+			Context("when creating the snapshot fails", func() {
+				BeforeEach(func() {
+					dbInstance.CreateSnapshotError = errors.New("operation failed")
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+				})
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+				})
+
+				It("snapshots the DB Cluster", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbCluster.CreateSnapshotCalled).To(BeTrue())
+					Expect(dbCluster.CreateSnapshotID).To(Equal(dbClusterIdentifier))
+					Expect(dbCluster.CreateSnapshotSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+					Expect(dbCluster.ModifyCalled).To(BeFalse())
+					Expect(dbInstance.CreateSnapshotCalled).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when upgrading the engine version", func() {
+			BeforeEach(func() {
+				updateDetails.PlanID = "Plan-1"
+				updateDetails.RawParameters = json.RawMessage(`{"engine_version": "1.2.4", "apply_immediately": true}`)
+				rdsProperties1.AllowedEngineVersions = []string{"1.2.4", "1.3.1"}
+				dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+					Status:        "available",
+					EngineVersion: "1.2.3",
+				}
+			})
+
+			It("modifies the engine version of the DB Instance", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updateSpec.IsAsync).To(BeTrue())
+				Expect(dbInstance.ModifyCalled).To(BeTrue())
+				Expect(dbInstance.ModifyID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.ModifyDBInstanceDetails.DBInstanceClass).To(Equal("db.m1.test"))
+				Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.2.4"))
+				Expect(dbInstance.ModifyDBInstanceDetails.AllowMajorVersionUpgrade).To(BeFalse())
+				Expect(dbInstance.ModifyApplyImmediately).To(BeTrue())
+				Expect(sqlEngine.ExtensionsCalled).To(BeFalse())
+			})
+
+			It("records the upgrade operation", func() {
+				updateSpec, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				operation := internaldb.FindOperation(internalDB, instance, updateSpec.OperationData)
+				Expect(operation.Type).To(Equal(internaldb.UpgradeOperation))
+				Expect(operation.PlanID).To(Equal("Plan-1"))
+				Expect(instance.EngineVersion).To(BeEmpty())
+			})
+
+			Context("when the version is not allowed", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"engine_version": "1.2.5"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Engine version '1.2.5' is not allowed for plan 'Plan 1'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the instance is already on the version", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeDBInstanceDetails.EngineVersion = "1.2.4"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("DB Instance 'cf-instance-id' is already on engine version '1.2.4'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when also changing the plan", func() {
+				BeforeEach(func() {
+					updateDetails.PlanID = "Plan-3"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("engine_version can't be combined with other changes"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when also changing other parameters", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"engine_version": "1.2.4", "read_replica_count": 1}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("engine_version can't be combined with other changes"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when Engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Engine version upgrades are not supported for plan 'Plan 1'"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+					Expect(dbCluster.ModifyCalled).To(BeFalse())
+				})
+			})
+
+			Context("when it is a major version upgrade", func() {
+				BeforeEach(func() {
+					updateDetails.RawParameters = json.RawMessage(`{"engine_version": "1.3.1"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Update()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Upgrading from engine version '1.2.3' to '1.3.1' is a major version upgrade and needs allow_major_version_upgrade"))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+
+				Context("and major version upgrades are allowed", func() {
+					BeforeEach(func() {
+						updateDetails.RawParameters = json.RawMessage(`{"engine_version": "1.3.1", "allow_major_version_upgrade": true}`)
+					})
+
+					It("allows the major version upgrade", func() {
+						_, err := Update()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.3.1"))
+						Expect(dbInstance.ModifyDBInstanceDetails.AllowMajorVersionUpgrade).To(BeTrue())
+					})
+
+					Context("but the instance has read replicas", func() {
+						BeforeEach(func() {
+							instance := internaldb.FindInstance(internalDB, instanceID)
+							_, err := instance.AddReplica(internalDB, "cf-instance-id-replica-1")
+							Expect(err).NotTo(HaveOccurred())
+						})
+
+						It("returns the proper error", func() {
+							_, err := Update()
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(Equal("Read replicas have to be removed with read_replica_count before a major version upgrade"))
+							Expect(dbInstance.ModifyCalled).To(BeFalse())
+						})
+					})
+
+					Context("but the plan has its own parameter group", func() {
+						BeforeEach(func() {
+							rdsProperties1.DBParameterGroupName = "test-db-parameter-group-name"
+						})
+
+						It("returns the proper error", func() {
+							_, err := Update()
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(Equal("Major version upgrades are not supported for plan 'Plan 1' as it has its own parameter or option group"))
+						})
+					})
+				})
+			})
+
+			Context("when Engine is PostgreSQL", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "postgres"
+					rdsProperties1.EngineVersion = "9.6.8"
+					rdsProperties1.AllowedEngineVersions = []string{"9.6.9", "10.4"}
+					dbInstance.DescribeDBInstanceDetails.EngineVersion = "9.6.8"
+					updateDetails.RawParameters = json.RawMessage(`{"engine_version": "10.4", "allow_major_version_upgrade": true}`)
+					sqlEngine.ExtensionsExtensions = []string{"pgcrypto"}
+				})
+
+				It("checks the installed extensions before upgrading", func() {
+					_, err := Update()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.OpenCalled).To(BeTrue())
+					Expect(sqlEngine.ExtensionsCalled).To(BeTrue())
+					Expect(sqlEngine.CloseCalled).To(BeTrue())
+					Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("10.4"))
+					Expect(dbInstance.ModifyDBInstanceDetails.AllowMajorVersionUpgrade).To(BeTrue())
+				})
+
+				Context("and an extension can't be upgraded", func() {
+					BeforeEach(func() {
+						sqlEngine.ExtensionsExtensions = []string{"pgcrypto", "chkpass"}
+					})
+
+					It("returns the proper error", func() {
+						_, err := Update()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Extension 'chkpass' has to be removed before a major version upgrade"))
+						Expect(dbInstance.ModifyCalled).To(BeFalse())
+					})
+				})
+
+				Context("and it is a minor version upgrade", func() {
+					BeforeEach(func() {
+						updateDetails.RawParameters = json.RawMessage(`{"engine_version": "9.6.9"}`)
+					})
+
+					It("doesn't check the extensions", func() {
+						_, err := Update()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(sqlEngine.ExtensionsCalled).To(BeFalse())
+						Expect(dbInstance.ModifyDBInstanceDetails.AllowMajorVersionUpgrade).To(BeFalse())
+					})
+				})
+			})
+		})
+
+		Context("when the instance has been upgraded past its plan's engine version", func() {
+			BeforeEach(func() {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				Expect(instance.SetEngineVersion(internalDB, "1.3.5")).To(Succeed())
+			})
+
+			It("keeps the upgraded engine version", func() {
+				_, err := Update()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.ModifyDBInstanceDetails.EngineVersion).To(Equal("1.3.5"))
+			})
+		})
+	})
+
+	var _ = Describe("Deprovision", func() {
+		var (
+			deprovisionDetails brokerapi.DeprovisionDetails
+			acceptsIncomplete  bool
+			instance           *internaldb.DBInstance
+		)
+
+		BeforeEach(func() {
+			deprovisionDetails = brokerapi.DeprovisionDetails{
+				ServiceID: "Service-1",
+				PlanID:    "Plan-1",
+			}
+			acceptsIncomplete = true
+			instance = MakeInstance()
+		})
+
+		Deprovision := func() (brokerapi.DeprovisionServiceSpec, error) {
+			return rdsBroker.Deprovision(context.Background(), instanceID, deprovisionDetails, acceptsIncomplete)
+		}
+
+		It("returns the proper response", func() {
+			deprovisionSpec, err := Deprovision()
+			Expect(deprovisionSpec.IsAsync).To(BeTrue())
+			Expect(deprovisionSpec.OperationData).NotTo(BeEmpty())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("records the deprovision operation", func() {
+			deprovisionSpec, err := Deprovision()
+			Expect(err).ToNot(HaveOccurred())
+			operation := internaldb.FindOperation(internalDB, instance, deprovisionSpec.OperationData)
+			Expect(operation).NotTo(BeNil())
+			Expect(operation.Type).To(Equal(internaldb.DeprovisionOperation))
+			Expect(operation.State).To(Equal(internaldb.OperationInProgress))
+		})
+
+		It("makes the proper calls", func() {
+			_, err := Deprovision()
+			Expect(dbInstance.DeleteCalled).To(BeTrue())
+			Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+			Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("doesn't delete the internaldb instance", func() {
+			_, err := Deprovision()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+		})
+
+		Context("when the instance is still being provisioned", func() {
+			BeforeEach(func() {
+				Expect(instance.Delete(internalDB)).To(Succeed())
+				instance = MakePendingInstance(time.Minute)
+			})
+
+			It("returns the proper error", func() {
+				_, err := Deprovision()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Instance is still being provisioned"))
+				Expect(dbInstance.DeleteCalled).To(BeFalse())
+			})
+		})
+
+		Context("when the instance was left pending by an abandoned provision", func() {
+			BeforeEach(func() {
+				Expect(instance.Delete(internalDB)).To(Succeed())
+				instance = MakePendingInstance(time.Hour)
+			})
+
+			It("deletes what was created along with the internaldb instance", func() {
+				deprovisionSpec, err := Deprovision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deprovisionSpec.IsAsync).To(BeFalse())
+				Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+				Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+			})
+		})
+
+		Context("when the instance has read replicas", func() {
+			BeforeEach(func() {
+				_, err := instance.AddReplica(internalDB, "cf-instance-id-replica-1")
+				Expect(err).NotTo(HaveOccurred())
+				rdsProperties1.SkipFinalSnapshot = false
+			})
+
+			It("deletes the read replicas before the DB Instance", func() {
+				_, err := Deprovision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DeleteIDs).To(Equal([]string{"cf-instance-id-replica-1", dbInstanceIdentifier}))
+				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeFalse())
+			})
+
+			Context("and deleting a read replica fails", func() {
+				BeforeEach(func() {
+					dbInstance.DeleteError = errors.New("operation failed")
+				})
+
+				It("returns the proper error", func() {
+					_, err := Deprovision()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+					Expect(dbInstance.DeleteIDs).To(Equal([]string{"cf-instance-id-replica-1"}))
+				})
+			})
+		})
+
+		Context("when the DB Cluster has several instances", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
+					Members: []string{dbInstanceIdentifier, dbInstanceIdentifier + "-2"},
+				}
+			})
+
+			It("deletes every instance before the DB Cluster", func() {
+				_, err := Deprovision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DeleteIDs).To(Equal([]string{dbInstanceIdentifier + "-2", dbInstanceIdentifier}))
+				Expect(dbCluster.DeleteCalled).To(BeTrue())
+				Expect(dbCluster.DeleteID).To(Equal(dbClusterIdentifier))
+			})
+		})
+
+		Context("when the DB Cluster is serverless", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				rdsProperties1.EngineMode = "serverless"
+			})
+
+			It("deletes the DB Cluster", func() {
+				_, err := Deprovision()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbCluster.DeleteCalled).To(BeTrue())
+				Expect(dbCluster.DeleteID).To(Equal(dbClusterIdentifier))
+				Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeTrue())
+				Expect(dbInstance.DeleteCalled).To(BeFalse())
+			})
+
+			Context("when the DB Cluster does not exist", func() {
+				BeforeEach(func() {
+					dbCluster.DeleteError = awsrds.ErrDBClusterDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					_, err := Deprovision()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when it does not skip final snaphot", func() {
+			BeforeEach(func() {
+				rdsProperties1.SkipFinalSnapshot = false
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Deprovision()
+				Expect(dbInstance.DeleteCalled).To(BeTrue())
+				Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeFalse())
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when request does not accept incomplete", func() {
+			BeforeEach(func() {
+				acceptsIncomplete = false
+			})
+
+			It("returns the proper error", func() {
+				_, err := Deprovision()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrAsyncRequired))
+			})
+		})
+
+		Context("when deleting the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.DeleteError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Deprovision()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("when the DB instance does not exists", func() {
+				BeforeEach(func() {
+					dbInstance.DeleteError = awsrds.ErrDBInstanceDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					_, err := Deprovision()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				})
+
+				It("deletes the internaldb instance", func() {
+					Deprovision()
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when shared instance", func() {
+			BeforeEach(func() {
+				rdsProperties1.Shared = true
+			})
+
+			Context("with postgres", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "postgres"
+				})
+
+				It("returns the proper response", func() {
+					deprovisionSpec, err := Deprovision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(deprovisionSpec.IsAsync).To(BeFalse())
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Deprovision()
+					Expect(dbInstance.DeleteCalled).To(BeFalse())
+					Expect(sharedPostgres.DropDBCalled).To(BeTrue())
+					Expect(sharedPostgres.DropDBDBName).To(Equal(dbName))
+					Expect(sharedMysql.DropDBCalled).To(BeFalse())
+					Expect(sharedPostgres.DropUserCalled).To(BeTrue())
+					Expect(sharedPostgres.DropUserUsername).To(Equal(instance.MasterUser().Username))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("deletes the internaldb instance", func() {
+					_, err := Deprovision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
+
+			Context("with mysql", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "mysql"
+				})
+
+				It("returns the proper response", func() {
+					deprovisionSpec, err := Deprovision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(deprovisionSpec.IsAsync).To(BeFalse())
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Deprovision()
+					Expect(dbInstance.DeleteCalled).To(BeFalse())
+					Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+					Expect(sharedMysql.DropDBCalled).To(BeTrue())
+					Expect(sharedMysql.DropDBDBName).To(Equal(dbName))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("deletes the internaldb instance", func() {
+					_, err := Deprovision()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when Engine is Aurora", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+			})
+
+			It("makes the proper calls", func() {
+				_, err := Deprovision()
+				Expect(dbCluster.DeleteCalled).To(BeTrue())
+				Expect(dbCluster.DeleteID).To(Equal(dbClusterIdentifier))
+				Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeTrue())
+				Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("when it does not skip final snaphot", func() {
+				BeforeEach(func() {
+					rdsProperties1.SkipFinalSnapshot = false
+				})
+
+				It("makes the proper calls", func() {
+					_, err := Deprovision()
+					Expect(dbCluster.DeleteSkipFinalSnapshot).To(BeFalse())
+					Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("when deleting the DB Instance fails", func() {
+				BeforeEach(func() {
+					dbCluster.DeleteError = errors.New("operation failed")
+				})
+
+				It("does not return an error", func() {
+					_, err := Deprovision()
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	var _ = Describe("Bind", func() {
+		var (
+			bindDetails brokerapi.BindDetails
+			instance    *internaldb.DBInstance
+		)
+
+		BeforeEach(func() {
+			bindDetails = brokerapi.BindDetails{
+				ServiceID:     "Service-1",
+				PlanID:        "Plan-1",
+				AppGUID:       "Application-1",
+				RawParameters: json.RawMessage(""),
+			}
+			rdsProperties1.Engine = "postgres"
+
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+				Identifier: dbInstanceIdentifier,
+				Address:    "endpoint-address",
+				Port:       3306,
+			}
+
+			dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
+				Identifier: dbClusterIdentifier,
+				Endpoint:   "endpoint-address",
+				Port:       3306,
+			}
+			instance = MakeInstance()
+		})
+
+		Bind := func() (brokerapi.Binding, error) {
+			return rdsBroker.Bind(context.Background(), instanceID, bindingID, bindDetails, false)
+		}
+
+		It("returns the proper response", func() {
+			bindingResponse, err := Bind()
+			Expect(err).ToNot(HaveOccurred())
+			credentials := bindingResponse.Credentials.(*CredentialsHash)
+			Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
+			Expect(credentials.Host).To(Equal("endpoint-address"))
+			Expect(credentials.Port).To(Equal(int64(3306)))
+			Expect(credentials.Name).To(Equal(dbName))
+			Expect(credentials.Username).ToNot(BeEmpty())
+			Expect(credentials.Password).ToNot(BeEmpty())
+			Expect(credentials.URI).To(ContainSubstring("@endpoint-address:3306/%s?reconnect=true", dbName))
+			Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://endpoint-address:3306/%s?user=%s&password=", dbName, credentials.Username))
+		})
+
+		It("makes the proper calls", func() {
+			bindingResponse, err := Bind()
+			Expect(err).ToNot(HaveOccurred())
+			credentials := bindingResponse.Credentials.(*CredentialsHash)
+			Expect(dbCluster.DescribeCalled).To(BeFalse())
+			Expect(dbInstance.DescribeCalled).To(BeTrue())
+			Expect(dbInstance.DescribeID).To(Equal(dbInstanceIdentifier))
+			Expect(sqlProvider.GetSQLEngineCalled).To(BeTrue())
+			Expect(sqlProvider.GetSQLEngineEngine).To(Equal("postgres"))
+			Expect(sqlEngine.OpenCalled).To(BeTrue())
+			Expect(sqlEngine.OpenConfig.Url).To(Equal("endpoint-address"))
+			Expect(sqlEngine.OpenConfig.Port).To(Equal(int64(3306)))
+			Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
+			Expect(sqlEngine.OpenConfig.Username).ToNot(BeEmpty())
+			Expect(sqlEngine.OpenConfig.Password).ToNot(BeEmpty())
+			Expect(sqlEngine.CreateDBCalled).To(BeFalse())
+			Expect(sqlEngine.CreateUserCalled).To(BeTrue())
+			Expect(sqlEngine.CreateUserUsername).To(Equal(credentials.Username))
+			Expect(sqlEngine.CreateUserPassword).To(Equal(credentials.Password))
+			Expect(sqlEngine.GrantPrivilegesCalled).To(BeTrue())
+			Expect(sqlEngine.GrantPrivilegesDBName).To(Equal(dbName))
+			Expect(sqlEngine.GrantPrivilegesUsername).To(Equal(credentials.Username))
+			Expect(sqlEngine.CloseCalled).To(BeTrue())
+		})
+
+		It("doesn't verify the server certificate", func() {
+			bindingResponse, err := Bind()
+			Expect(err).ToNot(HaveOccurred())
+			credentials := bindingResponse.Credentials.(*CredentialsHash)
+			Expect(sqlEngine.OpenConfig.Sslmode).To(Equal(config.SSLMode(config.RequireNoVerify)))
+			Expect(sqlEngine.OpenConfig.CACertificateFile).To(BeEmpty())
+			Expect(credentials.CACertificate).To(BeEmpty())
+		})
+
+		Context("when RDS has reset the master password but the rotation hasn't finished", func() {
+			var newPassword string
+
+			BeforeEach(func() {
+				oldPassword, err := instance.MasterUser().Password(encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				newPassword, err = instance.MasterUser().SetPendingPassword(internalDB, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+				sqlEngine.PingErrors = map[string]error{oldPassword: errors.New("password authentication failed")}
+			})
+
+			It("connects with the new password", func() {
+				_, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.OpenConfig.Password).To(Equal(newPassword))
+				Expect(sqlEngine.CreateUserCalled).To(BeTrue())
+			})
+		})
+
+		Context("when the instance is still being provisioned", func() {
+			BeforeEach(func() {
+				Expect(internalDB.Model(instance).Update("state", internaldb.InstancePending).Error).NotTo(HaveOccurred())
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+			})
+		})
+
+		Context("when a CA certificate is configured", func() {
+			BeforeEach(func() {
+				caCertificateFile = "rds-ca.pem"
+			})
+
+			It("verifies the server certificate", func() {
+				_, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.OpenConfig.Sslmode).To(Equal(config.SSLMode(config.Verify)))
+				Expect(sqlEngine.OpenConfig.CACertificateFile).To(Equal("rds-ca.pem"))
+			})
+
+			It("returns the CA certificate", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.CACertificate).To(Equal("ca-certificate"))
+			})
+
+			Context("and the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+					conf := config.DBConfig{Url: "shared-endpoint", Port: 1234, DBName: dbName,
+						Username: "master-username", Password: "master-password", Sslmode: config.Verify}
+					Expect(sharedPostgres.Open(conf)).To(Succeed())
+				})
+
+				It("doesn't return the CA certificate", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(credentials.CACertificate).To(BeEmpty())
+				})
+			})
+		})
+
+		Context("when the instance has read replicas", func() {
+			BeforeEach(func() {
+				for _, identifier := range []string{"cf-instance-id-replica-1", "cf-instance-id-replica-2"} {
+					_, err := instance.AddReplica(internalDB, identifier)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				dbInstance.DescribeDBInstancesDetails = map[string]awsrds.DBInstanceDetails{
+					"cf-instance-id-replica-1": {Address: "replica-1-address", Port: 3306},
+					"cf-instance-id-replica-2": {Status: "creating"},
+				}
+			})
+
+			It("includes the replicas which are ready", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.ReplicaHosts).To(Equal([]string{"replica-1-address"}))
+				Expect(credentials.ReadOnlyURI).To(ContainSubstring("@replica-1-address:3306/%s?reconnect=true", dbName))
+				Expect(credentials.Host).To(Equal("endpoint-address"))
+			})
+		})
+
+		Context("when the DB Cluster has a reader endpoint", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+				dbCluster.DescribeDBClusterDetails.ReaderEndpoint = "reader-endpoint-address"
+			})
+
+			It("includes the reader endpoint", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.ReaderEndpoint).To(Equal("reader-endpoint-address"))
+				Expect(credentials.ReadOnlyURI).To(ContainSubstring("@reader-endpoint-address:3306/%s?reconnect=true", dbName))
+				Expect(credentials.Host).To(Equal("endpoint-address"))
+			})
+		})
+
+		Context("when Service is not bindable", func() {
+			BeforeEach(func() {
+				serviceBindable = false
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Service is not bindable"))
+			})
+		})
+
+		Context("when the engine has an owner role", func() {
+			BeforeEach(func() {
+				sqlEngine.OwnerRoleName = "owner_role"
+			})
+
+			It("makes the user act as the owner role", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.Username).NotTo(Equal("owner_role"))
+				Expect(sqlEngine.CreateOwnerRoleCalled).To(BeTrue())
+				Expect(sqlEngine.CreateOwnerRoleDBName).To(Equal(dbName))
+				Expect(sqlEngine.CreateOwnerRoleOwner).To(Equal("owner_role"))
+				Expect(sqlEngine.GrantOwnerRoleOwner).To(Equal("owner_role"))
+				Expect(sqlEngine.GrantOwnerRoleUsername).To(Equal(credentials.Username))
+			})
+
+			It("gives every binding its own login", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				first := bindingResponse.Credentials.(*CredentialsHash)
+				bindingResponse, err = rdsBroker.Bind(context.Background(), instanceID, "binding-id-2", bindDetails, false)
+				Expect(err).ToNot(HaveOccurred())
+				second := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(second.Username).NotTo(Equal(first.Username))
+				Expect(second.Password).NotTo(Equal(first.Password))
+			})
+
+			Context("and the instance was bound before bindings had their own login", func() {
+				BeforeEach(func() {
+					_, _, err := instance.Bind(internalDB, "old-binding-id", "owner_role", internaldb.Standard, encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("keeps the old login as the owner role", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(sqlEngine.CreateOwnerRoleOwner).To(Equal("owner_role"))
+					Expect(sqlEngine.GrantOwnerRoleUsername).To(Equal(credentials.Username))
+				})
+			})
+
+			Context("and the owner role is asked for as the username", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"username": "owner_role"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Username 'owner_role' is reserved for the owner of a database"))
+					Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when a username is given", func() {
+			BeforeEach(func() {
+				bindDetails.RawParameters = json.RawMessage(`{"username": "app_user"}`)
+			})
+
+			It("creates the user", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.Username).To(Equal("app_user"))
+				Expect(sqlEngine.CreateUserUsername).To(Equal("app_user"))
+				Expect(sqlEngine.GrantPrivilegesUsername).To(Equal("app_user"))
+			})
+
+			It("shares the user with other bindings asking for it", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				first := bindingResponse.Credentials.(*CredentialsHash)
+
+				sqlEngine.CreateUserCalled = false
+				bindingResponse, err = rdsBroker.Bind(context.Background(), instanceID, "binding-id-2", bindDetails, false)
+				Expect(err).ToNot(HaveOccurred())
+				second := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(second.Username).To(Equal("app_user"))
+				Expect(second.Password).To(Equal(first.Password))
+				Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+			})
+
+			Context("that isn't a simple identifier", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"username": "app_user'; DROP USER x; --"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("username must start with a letter and only contain letters, digits and underscores"))
+					Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+				})
+			})
+
+			Context("that belongs to the master user", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"username": "` + instance.MasterUser().Username + `"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Username '" + instance.MasterUser().Username + "' is already in use"))
+					Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+				})
+			})
+
+			Context("and the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
+
+				It("creates the user on the shared server", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(credentials.Username).To(Equal("app_user"))
+					Expect(sharedPostgres.CreateUserUsername).To(Equal("app_user"))
+				})
+
+				Context("and another instance has the user", func() {
+					BeforeEach(func() {
+						other, err := internaldb.NewInstance("Service-1", "Plan-1", "other-instance-id", "cf", encryptionKey)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(internalDB.Save(other).Error).NotTo(HaveOccurred())
+						_, _, err = other.Bind(internalDB, "other-binding-id", "app_user", internaldb.Standard, encryptionKey)
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("returns the proper error", func() {
+						_, err := Bind()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Username 'app_user' is already in use"))
+						Expect(sharedPostgres.CreateUserCalled).To(BeFalse())
+					})
+				})
+
+				Context("and it's the owner role of another instance", func() {
+					BeforeEach(func() {
+						other, err := internaldb.NewInstance("Service-1", "Plan-1", "other-instance-id", "cf", encryptionKey)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(internalDB.Save(other).Error).NotTo(HaveOccurred())
+						sharedPostgres.OwnerRoles = map[string]string{"other-instance-id": "uother_instance_id"}
+						bindDetails.RawParameters = json.RawMessage(`{"username": "uother_instance_id"}`)
+					})
+
+					It("returns the proper error", func() {
+						_, err := Bind()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Username 'uother_instance_id' is reserved for the owner of a database"))
+						Expect(sharedPostgres.CreateUserCalled).To(BeFalse())
+						Expect(sharedPostgres.GrantOwnerRoleCalled).To(BeFalse())
+					})
+				})
+
+				Context("and the server already has a role of that name", func() {
+					BeforeEach(func() {
+						sharedPostgres.UserExistsResult = true
+					})
+
+					It("returns the proper error", func() {
+						_, err := Bind()
+						Expect(err).To(HaveOccurred())
+						Expect(sharedPostgres.UserExistsUsername).To(Equal("app_user"))
+						Expect(err.Error()).To(Equal("Username 'app_user' is already in use"))
+						Expect(sharedPostgres.CreateUserCalled).To(BeFalse())
+					})
+				})
+			})
+
+			Context("and user bind parameters are not allowed", func() {
+				BeforeEach(func() {
+					allowUserBindParameters = false
+				})
+
+				It("ignores the username", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(credentials.Username).ToNot(Equal("app_user"))
+				})
+			})
+		})
+
+		Context("when the read only role is asked for", func() {
+			BeforeEach(func() {
+				bindDetails.RawParameters = json.RawMessage(`{"role": "read_only"}`)
+				_, _, err := instance.Bind(internalDB, "writer-binding-id", "writer", internaldb.Standard, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("grants read only privileges", func() {
+				sqlEngine.OwnerRoleName = "owner_role"
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.ReadOnly).To(BeTrue())
+				Expect(sqlEngine.CreateUserUsername).To(Equal(credentials.Username))
+				Expect(sqlEngine.GrantPrivilegesCalled).To(BeFalse())
+				Expect(sqlEngine.GrantReadOnlyPrivilegesCalled).To(BeTrue())
+				Expect(sqlEngine.GrantReadOnlyPrivilegesDBName).To(Equal(dbName))
+				Expect(sqlEngine.GrantReadOnlyPrivilegesUsername).To(Equal(credentials.Username))
+				Expect(sqlEngine.GrantReadOnlyPrivilegesOwners).To(Equal([]string{"owner_role"}))
+				Expect(sqlEngine.GrantOwnerRoleCalled).To(BeFalse())
+				user, _ := internaldb.FindInstance(internalDB, instanceID).BindingUser(bindingID)
+				Expect(user.Type).To(Equal(internaldb.ReadOnly))
+			})
+
+			It("doesn't share the user with other bindings", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				first := bindingResponse.Credentials.(*CredentialsHash)
+				bindingResponse, err = rdsBroker.Bind(context.Background(), instanceID, "binding-id-2", bindDetails, false)
+				Expect(err).ToNot(HaveOccurred())
+				second := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(second.Username).NotTo(Equal(first.Username))
+			})
+
+			Context("with the username of a user with full access", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"role": "read_only", "username": "writer"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Username 'writer' is already in use"))
+				})
+			})
+
+			Context("and the plan is shared", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
+
+				It("grants the privileges from within the database", func() {
+					_, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.OpenCalled).To(BeTrue())
+					Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
+					Expect(sqlEngine.GrantReadOnlyPrivilegesCalled).To(BeTrue())
+					Expect(sqlEngine.CloseCalled).To(BeTrue())
+					Expect(sharedPostgres.GrantReadOnlyPrivilegesCalled).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when the service has credential templates", func() {
+			BeforeEach(func() {
+				credentialTemplates = CredentialTemplates{
+					"dsn":          "{{mysqldsn .Username .Password .Host .Port .DBName \"tls\" \"true\"}}",
+					"database_url": "{{.Engine}}://{{userinfo .Username .Password}}@{{.Host}}:{{.Port}}/{{pathescape .DBName}}?sslmode={{queryescape \"verify-full\"}}",
+				}
+			})
+
+			It("renders them", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				dsn, err := mysql.ParseDSN(credentials.Templated["dsn"])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dsn.User).To(Equal(credentials.Username))
+				Expect(dsn.Passwd).To(Equal(credentials.Password))
+				Expect(dsn.Addr).To(Equal("endpoint-address:3306"))
+				Expect(dsn.DBName).To(Equal(dbName))
+				Expect(dsn.TLSConfig).To(Equal("true"))
+
+				databaseURL, err := url.Parse(credentials.Templated["database_url"])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(databaseURL.Scheme).To(Equal("postgres"))
+				Expect(databaseURL.User.Username()).To(Equal(credentials.Username))
+				Expect(databaseURL.User.Password()).To(Equal(credentials.Password))
+				Expect(databaseURL.Path).To(Equal("/" + dbName))
+				Expect(databaseURL.Query().Get("sslmode")).To(Equal("verify-full"))
+			})
+
+			It("sends them alongside the other credentials", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				data, err := json.Marshal(bindingResponse.Credentials)
+				Expect(err).ToNot(HaveOccurred())
+				var fields map[string]interface{}
+				Expect(json.Unmarshal(data, &fields)).To(Succeed())
+				Expect(fields).To(HaveKeyWithValue("dsn", credentials.Templated["dsn"]))
+				Expect(fields).To(HaveKeyWithValue("username", credentials.Username))
+				Expect(fields).NotTo(HaveKey("Templated"))
+			})
+		})
+
+		Context("when the plan requires TLS", func() {
+			BeforeEach(func() {
+				rdsProperties1.RequireTLS = true
+			})
+
+			It("creates a user which has to use TLS", func() {
+				_, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.CreateUserCalled).To(BeTrue())
+				Expect(sqlEngine.CreateUserRequireTLS).To(BeTrue())
+			})
+		})
+
+		Context("when IAM authentication is asked for", func() {
+			BeforeEach(func() {
+				bindDetails.RawParameters = json.RawMessage(`{"iam_authentication": true}`)
+				rdsProperties1.IAMDatabaseAuthentication = true
+				dbInstance.DescribeDBInstanceDetails.DbiResourceID = "db-ABCDEFGHIJ"
+			})
+
+			It("creates a user which logs in with IAM", func() {
+				sqlEngine.OwnerRoleName = "owner_role"
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(sqlEngine.CreateIAMUserCalled).To(BeTrue())
+				Expect(sqlEngine.CreateIAMUserUsername).To(Equal(credentials.Username))
+				Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+				Expect(sqlEngine.GrantPrivilegesUsername).To(Equal(credentials.Username))
+				Expect(sqlEngine.GrantOwnerRoleUsername).To(Equal(credentials.Username))
+				user, _ := internaldb.FindInstance(internalDB, instanceID).BindingUser(bindingID)
+				Expect(user.Type).To(Equal(internaldb.IAM))
+			})
+
+			It("returns what's needed to sign authentication tokens", func() {
+				bindingResponse, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingResponse.Credentials.(*CredentialsHash)
+				Expect(credentials.IAMAuthentication).To(BeTrue())
+				Expect(credentials.Region).To(Equal("rds-region"))
+				Expect(credentials.ResourceID).To(Equal("db-ABCDEFGHIJ"))
+				Expect(credentials.Host).To(Equal("endpoint-address"))
+				Expect(credentials.Port).To(Equal(int64(3306)))
+				Expect(credentials.Username).NotTo(BeEmpty())
+				Expect(credentials.Password).To(BeEmpty())
+				Expect(credentials.URI).To(BeEmpty())
+				Expect(credentials.JDBCURI).To(BeEmpty())
+			})
+
+			Context("and the engine is Aurora", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora-postgresql"
+					dbCluster.DescribeDBClusterDetails.DbClusterResourceID = "cluster-ABCDEFGHIJ"
+				})
+
+				It("returns the resource ID of the DB Cluster", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(credentials.ResourceID).To(Equal("cluster-ABCDEFGHIJ"))
+				})
+			})
+
+			Context("and the plan doesn't support it", func() {
+				BeforeEach(func() {
+					rdsProperties1.IAMDatabaseAuthentication = false
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Service Plan 'Plan-1' doesn't support IAM database authentication"))
+					Expect(sqlEngine.CreateIAMUserCalled).To(BeFalse())
+				})
+			})
+
+			Context("with a role", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"iam_authentication": true, "role": "read_only"}`)
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("iam_authentication can't be combined with role"))
+				})
+			})
+
+			Context("with the username of a user with a password", func() {
+				BeforeEach(func() {
+					bindDetails.RawParameters = json.RawMessage(`{"iam_authentication": true, "username": "writer"}`)
+					_, _, err := instance.Bind(internalDB, "writer-binding-id", "writer", internaldb.Standard, encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Username 'writer' is already in use"))
+				})
+			})
+		})
+
+		Context("when an unknown role is asked for", func() {
+			BeforeEach(func() {
+				bindDetails.RawParameters = json.RawMessage(`{"role": "admin"}`)
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("role must be 'read_only'"))
+			})
+		})
+
+		Context("when describing the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("when the DB Instance does not exists", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				})
+			})
+		})
+
+		Context("when shared instance", func() {
+			BeforeEach(func() {
+				rdsProperties1.Shared = true
+			})
+
+			Context("with postgres", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "postgres"
+					conf := config.DBConfig{Url: "shared-endpoint", Port: 1234, DBName: dbName,
+						Username: "master-username", Password: "master-password", Sslmode: config.Verify}
+					Expect(sharedPostgres.Open(conf)).To(Succeed())
+				})
+
+				It("returns the proper response", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
+					Expect(credentials.Host).To(Equal("shared-endpoint"))
+					Expect(credentials.Port).To(Equal(int64(1234)))
+					Expect(credentials.Name).To(Equal(dbName))
+					Expect(credentials.Username).To(HavePrefix("u"))
+					Expect(credentials.Password).ToNot(BeEmpty())
+					Expect(credentials.Password).ToNot(Equal("master-password"))
+					Expect(credentials.URI).To(ContainSubstring("@shared-endpoint:1234/%s?reconnect=true", dbName))
+					Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://shared-endpoint:1234/%s?user=%s&password=", dbName, credentials.Username))
+				})
+
+				It("makes the proper calls", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(dbCluster.DescribeCalled).To(BeFalse())
+					Expect(dbInstance.DescribeCalled).To(BeFalse())
+					Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
+					Expect(sharedPostgres.CreateDBCalled).To(BeFalse())
+					Expect(sharedPostgres.CreateUserCalled).To(BeTrue())
+					Expect(sharedPostgres.CreateUserUsername).To(Equal(credentials.Username))
+					Expect(sharedPostgres.CreateUserPassword).To(Equal(credentials.Password))
+					Expect(sharedPostgres.GrantPrivilegesCalled).To(BeTrue())
+					Expect(sharedPostgres.GrantPrivilegesDBName).To(Equal(dbName))
+					Expect(sharedPostgres.GrantPrivilegesUsername).To(Equal(credentials.Username))
+					Expect(sharedPostgres.CloseCalled).To(BeFalse())
+				})
+			})
+
+			Context("with mysql", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "mysql"
+					conf := config.DBConfig{Url: "shared-endpoint", Port: 1234, DBName: dbName,
+						Username: "master-username", Password: "master-password", Sslmode: config.Verify}
+					Expect(sharedMysql.Open(conf)).To(Succeed())
+				})
+
+				It("returns the proper response", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
+					Expect(credentials.Host).To(Equal("shared-endpoint"))
+					Expect(credentials.Port).To(Equal(int64(1234)))
+					Expect(credentials.Name).To(Equal(dbName))
+					Expect(credentials.Username).ToNot(BeEmpty())
+					Expect(credentials.Password).ToNot(BeEmpty())
+					Expect(credentials.Password).ToNot(Equal("master-password"))
+					Expect(credentials.URI).To(ContainSubstring("@shared-endpoint:1234/%s?reconnect=true", dbName))
+					Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://shared-endpoint:1234/%s?user=%s&password=", dbName, credentials.Username))
+				})
+
+				It("makes the proper calls", func() {
+					bindingResponse, err := Bind()
+					Expect(err).ToNot(HaveOccurred())
+					credentials := bindingResponse.Credentials.(*CredentialsHash)
+					Expect(dbCluster.DescribeCalled).To(BeFalse())
+					Expect(dbInstance.DescribeCalled).To(BeFalse())
+					Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
+					Expect(sharedMysql.CreateDBCalled).To(BeFalse())
+					Expect(sharedMysql.CreateUserCalled).To(BeTrue())
+					Expect(sharedMysql.CreateUserUsername).To(Equal(credentials.Username))
+					Expect(sharedMysql.CreateUserPassword).To(Equal(credentials.Password))
+					Expect(sharedMysql.GrantPrivilegesCalled).To(BeTrue())
+					Expect(sharedMysql.GrantPrivilegesDBName).To(Equal(dbName))
+					Expect(sharedMysql.GrantPrivilegesUsername).To(Equal(credentials.Username))
+					Expect(sharedMysql.CloseCalled).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when Engine is aurora", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+			})
+
+			It("does not describe the DB Instance", func() {
+				_, err := Bind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DescribeCalled).To(BeFalse())
+			})
+
+			Context("when describing the DB Cluster fails", func() {
+				BeforeEach(func() {
+					dbCluster.DescribeError = errors.New("operation failed")
+				})
+
+				It("returns the proper error", func() {
+					_, err := Bind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+				})
+
+				Context("when the DB Cluster does not exists", func() {
+					BeforeEach(func() {
+						dbCluster.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+					})
+
+					It("returns the proper error", func() {
+						_, err := Bind()
+						Expect(err).To(HaveOccurred())
+						Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					})
+				})
+			})
+		})
+
+		Context("when getting the SQL Engine fails", func() {
+			BeforeEach(func() {
+				sqlProvider.GetSQLEngineError = errors.New("Engine 'unknown' not supported")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Engine 'unknown' not supported"))
+			})
+		})
+
+		Context("when opening a DB connection fails", func() {
+			BeforeEach(func() {
+				sqlEngine.OpenError = errors.New("Failed to open sqlEngine")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to open sqlEngine"))
+			})
+		})
+
+		Context("when creating a DB user fails", func() {
+			BeforeEach(func() {
+				sqlEngine.CreateUserError = errors.New("Failed to create user")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to create user"))
+				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			})
+		})
+
+		Context("when granting privileges fails", func() {
+			BeforeEach(func() {
+				sqlEngine.GrantPrivilegesError = errors.New("Failed to grant privileges")
+			})
+
+			It("returns the proper error", func() {
+				_, err := Bind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to grant privileges"))
+				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			})
+		})
+	})
+
+	var _ = Describe("Unbind", func() {
+		var (
+			unbindDetails brokerapi.UnbindDetails
+			dbUsername    string
+		)
+
+		BeforeEach(func() {
+			unbindDetails = brokerapi.UnbindDetails{
+				ServiceID: "Service-1",
+				PlanID:    "Plan-1",
+			}
+
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+				Identifier: dbInstanceIdentifier,
+				Address:    "endpoint-address",
+				Port:       3306,
+			}
+
+			dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
+				Identifier: dbClusterIdentifier,
+				Endpoint:   "endpoint-address",
+				Port:       3306,
+			}
+			instance := MakeInstance()
+			dbUsername = "username"
+			_, _, err := instance.Bind(internalDB, bindingID, dbUsername, internaldb.Standard, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Unbind := func() error {
+			_, err := rdsBroker.Unbind(context.Background(), instanceID, bindingID, unbindDetails, false)
+			return err
+		}
+
+		It("makes the proper calls", func() {
+			err := Unbind()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dbCluster.DescribeCalled).To(BeFalse())
+			Expect(dbInstance.DescribeCalled).To(BeTrue())
+			Expect(dbInstance.DescribeID).To(Equal(dbInstanceIdentifier))
+			Expect(sqlProvider.GetSQLEngineCalled).To(BeTrue())
+			Expect(sqlProvider.GetSQLEngineEngine).To(Equal("test-engine-1"))
+			Expect(sqlEngine.OpenCalled).To(BeTrue())
+			Expect(sqlEngine.OpenConfig.Url).To(Equal("endpoint-address"))
+			Expect(sqlEngine.OpenConfig.Port).To(Equal(int64(3306)))
+			Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
+			Expect(sqlEngine.OpenConfig.Username).ToNot(BeEmpty())
+			Expect(sqlEngine.OpenConfig.Password).ToNot(BeEmpty())
+			Expect(sqlEngine.RevokePrivilegesCalled).To(BeTrue())
+			Expect(sqlEngine.RevokePrivilegesUsername).To(Equal(dbUsername))
+			Expect(sqlEngine.RevokePrivilegesDBName).To(Equal(dbName))
+			Expect(sqlEngine.DropDBCalled).To(BeFalse())
+			Expect(sqlEngine.DropUserCalled).To(BeTrue())
+			Expect(sqlEngine.DropUserUsername).To(Equal(dbUsername))
+			Expect(sqlEngine.CloseCalled).To(BeTrue())
+		})
+
+		Context("when the engine has an owner role", func() {
+			BeforeEach(func() {
+				sqlEngine.OwnerRoleName = "owner_role"
+			})
+
+			It("stops the user acting as the owner role", func() {
+				err := Unbind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.RevokeOwnerRoleCalled).To(BeTrue())
+				Expect(sqlEngine.RevokeOwnerRoleOwner).To(Equal("owner_role"))
+				Expect(sqlEngine.RevokeOwnerRoleUsername).To(Equal(dbUsername))
+				Expect(sqlEngine.DropUserUsername).To(Equal(dbUsername))
+			})
+
+			Context("and the binding is from before bindings had their own login", func() {
+				BeforeEach(func() {
+					sqlEngine.OwnerRoleName = dbUsername
+				})
+
+				It("only disables the login of the owner role", func() {
+					err := Unbind()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sqlEngine.RevokePrivilegesCalled).To(BeFalse())
+					Expect(sqlEngine.RevokeOwnerRoleCalled).To(BeFalse())
+					Expect(sqlEngine.DropUserUsername).To(Equal(dbUsername))
+				})
+			})
+		})
+
+		Context("when the binding is read only", func() {
+			BeforeEach(func() {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				_, _, err := instance.Bind(internalDB, "reader-binding-id", "reader", internaldb.ReadOnly, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("revokes the read only privileges", func() {
+				sqlEngine.OwnerRoleName = "owner_role"
+				_, err := rdsBroker.Unbind(context.Background(), instanceID, "reader-binding-id", unbindDetails, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.RevokePrivilegesCalled).To(BeFalse())
+				Expect(sqlEngine.RevokeReadOnlyPrivilegesCalled).To(BeTrue())
+				Expect(sqlEngine.RevokeReadOnlyPrivilegesDBName).To(Equal(dbName))
+				Expect(sqlEngine.RevokeReadOnlyPrivilegesUsername).To(Equal("reader"))
+				Expect(sqlEngine.RevokeReadOnlyPrivilegesOwners).To(Equal([]string{"owner_role"}))
+				Expect(sqlEngine.DropUserUsername).To(Equal("reader"))
+			})
+		})
+
+		Context("when another binding has the same username", func() {
+			BeforeEach(func() {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				_, _, err := instance.Bind(internalDB, "binding-two", dbUsername, internaldb.Standard, encryptionKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("makes the proper calls", func() {
+				err := Unbind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine.RevokePrivilegesCalled).To(BeFalse())
+				Expect(sqlEngine.DropUserCalled).To(BeFalse())
+			})
+		})
+
+		Context("when describing the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				err := Unbind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			Context("when the DB Instance does not exists", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					err := Unbind()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				})
+			})
+		})
+
+		Context("when shared instance", func() {
+			BeforeEach(func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
+
+				Context("with postgres", func() {
+					BeforeEach(func() {
+						rdsProperties1.Engine = "postgres"
+					})
+
+					It("makes the proper calls", func() {
+						err := Unbind()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbCluster.DescribeCalled).To(BeFalse())
+						Expect(dbInstance.DescribeCalled).To(BeFalse())
+						Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
+						Expect(sharedPostgres.OpenCalled).To(BeFalse())
+						Expect(sharedPostgres.RevokePrivilegesCalled).To(BeTrue())
+						Expect(sharedPostgres.RevokePrivilegesUsername).To(Equal(dbUsername))
+						Expect(sharedPostgres.RevokePrivilegesDBName).To(Equal(dbName))
+						Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+						Expect(sharedPostgres.DropUserCalled).To(BeTrue())
+						Expect(sharedPostgres.DropUserUsername).To(Equal(dbUsername))
+						Expect(sharedPostgres.CloseCalled).To(BeFalse())
+					})
+				})
+
+				Context("with mysql", func() {
+					BeforeEach(func() {
+						rdsProperties1.Engine = "mysql"
+					})
+
+					It("makes the proper calls", func() {
+						err := Unbind()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbCluster.DescribeCalled).To(BeFalse())
+						Expect(dbInstance.DescribeCalled).To(BeFalse())
+						Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
+						Expect(sharedMysql.OpenCalled).To(BeFalse())
+						Expect(sharedMysql.RevokePrivilegesCalled).To(BeTrue())
+						Expect(sharedMysql.RevokePrivilegesUsername).To(Equal(dbUsername))
+						Expect(sharedMysql.RevokePrivilegesDBName).To(Equal(dbName))
+						Expect(sharedMysql.DropDBCalled).To(BeFalse())
+						Expect(sharedMysql.DropUserCalled).To(BeTrue())
+						Expect(sharedMysql.DropUserUsername).To(Equal(dbUsername))
+						Expect(sharedMysql.CloseCalled).To(BeFalse())
+					})
+				})
+			})
+		})
+
+		Context("when Engine is aurora", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora"
+			})
+
+			It("does not describe the DB Instance", func() {
+				err := Unbind()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DescribeCalled).To(BeFalse())
+			})
+
+			Context("when describing the DB Cluster fails", func() {
+				BeforeEach(func() {
+					dbCluster.DescribeError = errors.New("operation failed")
+				})
+
+				It("returns the proper error", func() {
+					err := Unbind()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("operation failed"))
+				})
+
+				Context("when the DB Cluster does not exists", func() {
+					BeforeEach(func() {
+						dbCluster.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+					})
+
+					It("returns the proper error", func() {
+						err := Unbind()
+						Expect(err).To(HaveOccurred())
+						Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					})
+				})
+			})
+		})
+
+		Context("when getting the SQL Engine fails", func() {
+			BeforeEach(func() {
+				sqlProvider.GetSQLEngineError = errors.New("SQL Engine 'unknown' not supported")
+			})
+
+			It("returns the proper error", func() {
+				err := Unbind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("SQL Engine 'unknown' not supported"))
+			})
+		})
+
+		Context("when opening a DB connection fails", func() {
+			BeforeEach(func() {
+				sqlEngine.OpenError = errors.New("Failed to open sqlEngine")
+			})
+
+			It("returns the proper error", func() {
+				err := Unbind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to open sqlEngine"))
+			})
+		})
+
+		Context("when revoking privileges fails", func() {
+			BeforeEach(func() {
+				sqlEngine.RevokePrivilegesError = errors.New("Failed to revoke privileges")
+			})
+
+			It("returns the proper error", func() {
+				err := Unbind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to revoke privileges"))
+				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			})
+		})
+
+		Context("when deleting a user fails", func() {
+			BeforeEach(func() {
+				sqlEngine.DropUserError = errors.New("Failed to delete user")
+			})
+
+			It("returns the proper error", func() {
+				err := Unbind()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to delete user"))
+				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			})
+		})
+	})
+
+	var _ = Describe("GetInstance", func() {
+		BeforeEach(func() {
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+				Identifier:                 dbInstanceIdentifier,
+				Status:                     "available",
+				EngineVersion:              "1.2.4",
+				Address:                    "endpoint-address",
+				Port:                       3306,
+				AllocatedStorage:           150,
+				BackupRetentionPeriod:      7,
+				PreferredBackupWindow:      "01:00-02:00",
+				PreferredMaintenanceWindow: "sun:03:00-sun:04:00",
+			}
+			sqlEngine.ExtensionsExtensions = []string{"postgis"}
+
+			instance := MakeInstance()
+			instance.ReadReplicaCount = 1
+			Expect(internalDB.Save(instance).Error).NotTo(HaveOccurred())
+			Expect(instance.Activate(internalDB)).To(Succeed())
+		})
+
+		GetInstance := func() (brokerapi.GetInstanceDetailsSpec, error) {
+			return rdsBroker.GetInstance(context.Background(), instanceID)
+		}
+
+		It("returns the proper response", func() {
+			instanceSpec, err := GetInstance()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceSpec.ServiceID).To(Equal("Service-1"))
+			Expect(instanceSpec.PlanID).To(Equal("Plan-1"))
+			Expect(instanceSpec.Parameters).To(Equal(InstanceParameters{
+				Plan:                       "Plan 1",
+				Status:                     "available",
+				EngineVersion:              "1.2.4",
+				Extensions:                 []string{"postgis"},
+				AllocatedStorage:           150,
+				BackupRetentionPeriod:      7,
+				PreferredBackupWindow:      "01:00-02:00",
+				PreferredMaintenanceWindow: "sun:03:00-sun:04:00",
+				ReadReplicaCount:           1,
+			}))
+		})
+
+		It("makes the proper calls", func() {
+			_, err := GetInstance()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dbInstance.DescribeCalled).To(BeTrue())
+			Expect(dbInstance.DescribeID).To(Equal(dbInstanceIdentifier))
+			Expect(sqlEngine.ExtensionsCalled).To(BeTrue())
+			Expect(sqlEngine.CloseCalled).To(BeTrue())
+		})
+
+		Context("when instances are not retrievable", func() {
+			BeforeEach(func() {
+				instancesRetrievable = false
+			})
+
+			It("returns the proper error", func() {
+				_, err := GetInstance()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Service instances are not retrievable"))
+			})
+		})
+
+		Context("when the instance is still being provisioned", func() {
+			BeforeEach(func() {
+				Expect(internalDB.Model(&internaldb.DBInstance{}).Where("instance_id = ?", instanceID).Update("state", internaldb.InstancePending).Error).NotTo(HaveOccurred())
+			})
+
+			It("returns the proper error", func() {
+				_, err := GetInstance()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+			})
+		})
+
+		Context("when the DB Instance isn't available", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeDBInstanceDetails.Status = "modifying"
+			})
+
+			It("leaves out the extensions", func() {
+				instanceSpec, err := GetInstance()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceSpec.Parameters.(InstanceParameters).Status).To(Equal("modifying"))
+				Expect(instanceSpec.Parameters.(InstanceParameters).Extensions).To(BeEmpty())
+				Expect(sqlEngine.ExtensionsCalled).To(BeFalse())
+			})
+		})
+
+		Context("when listing the extensions fails", func() {
+			BeforeEach(func() {
+				sqlEngine.ExtensionsError = errors.New("Failed to list extensions")
+			})
+
+			It("leaves out the extensions", func() {
+				instanceSpec, err := GetInstance()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceSpec.Parameters.(InstanceParameters).Extensions).To(BeEmpty())
+			})
+		})
+
+		Context("when the DB Instance does not exist", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+			})
+
+			It("returns the proper error", func() {
+				_, err := GetInstance()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+			})
+		})
+
+		Context("when describing the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeError = errors.New("Failed to describe DB Instance")
+			})
+
+			It("returns the proper error", func() {
+				_, err := GetInstance()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Failed to describe DB Instance"))
+			})
+		})
+
+		Context("when the plan is serverless", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "aurora-postgresql"
+				rdsProperties1.EngineMode = "serverless"
+				dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
+					Identifier:            dbClusterIdentifier,
+					Status:                "available",
+					EngineVersion:         "10.7",
+					BackupRetentionPeriod: 3,
+				}
+			})
+
+			It("returns the details of the DB Cluster", func() {
+				instanceSpec, err := GetInstance()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbCluster.DescribeCalled).To(BeTrue())
+				Expect(dbCluster.DescribeID).To(Equal(dbClusterIdentifier))
+				parameters := instanceSpec.Parameters.(InstanceParameters)
+				Expect(parameters.Status).To(Equal("available"))
+				Expect(parameters.EngineVersion).To(Equal("10.7"))
+				Expect(parameters.BackupRetentionPeriod).To(Equal(int64(3)))
+				Expect(parameters.AllocatedStorage).To(BeZero())
+			})
+		})
+
+		Context("when the plan is shared", func() {
+			BeforeEach(func() {
+				rdsProperties1.Engine = "postgres"
+				rdsProperties1.Shared = true
+			})
+
+			It("returns the plan and extensions", func() {
+				instanceSpec, err := GetInstance()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DescribeCalled).To(BeFalse())
+				Expect(instanceSpec.Parameters).To(Equal(InstanceParameters{
+					Plan:       "Plan 1",
+					Extensions: []string{"postgis"},
+				}))
+			})
+		})
+	})
+
+	var _ = Describe("GetBinding", func() {
+		var (
+			dbUsername string
+		)
+
+		BeforeEach(func() {
+			rdsProperties1.Engine = "postgres"
+
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+				Identifier: dbInstanceIdentifier,
+				Address:    "endpoint-address",
+				Port:       3306,
+			}
+			instance := MakeInstance()
+			dbUsername = "username"
+			_, _, err := instance.Bind(internalDB, bindingID, dbUsername, internaldb.Standard, encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		GetBinding := func() (brokerapi.GetBindingSpec, error) {
+			return rdsBroker.GetBinding(context.Background(), instanceID, bindingID)
+		}
+
+		It("returns the credentials of the binding", func() {
+			bindingSpec, err := GetBinding()
+			Expect(err).ToNot(HaveOccurred())
+			credentials := bindingSpec.Credentials.(*CredentialsHash)
+			instance := internaldb.FindInstance(internalDB, instanceID)
+			user, _ := instance.BindingUser(bindingID)
+			password, err := user.Password(encryptionKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentials.Host).To(Equal("endpoint-address"))
+			Expect(credentials.Port).To(Equal(int64(3306)))
+			Expect(credentials.Name).To(Equal(dbName))
+			Expect(credentials.Username).To(Equal(dbUsername))
+			Expect(credentials.Password).To(Equal(password))
+			Expect(credentials.URI).To(ContainSubstring("@endpoint-address:3306/%s?reconnect=true", dbName))
+		})
+
+		It("doesn't change the user", func() {
+			_, err := GetBinding()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqlEngine.CreateUserCalled).To(BeFalse())
+			Expect(sqlEngine.GrantPrivilegesCalled).To(BeFalse())
+			Expect(sqlEngine.CloseCalled).To(BeTrue())
+		})
+
+		Context("when the service has credential templates", func() {
+			BeforeEach(func() {
+				credentialTemplates = CredentialTemplates{"dsn": "{{.Username}}@{{.Host}}"}
+			})
+
+			It("renders them", func() {
+				bindingSpec, err := GetBinding()
+				Expect(err).ToNot(HaveOccurred())
+				credentials := bindingSpec.Credentials.(*CredentialsHash)
+				Expect(credentials.Templated).To(Equal(map[string]string{"dsn": dbUsername + "@endpoint-address"}))
+			})
+		})
+
+		Context("when bindings are not retrievable", func() {
+			BeforeEach(func() {
+				bindingsRetrievable = false
+			})
+
+			It("returns the proper error", func() {
+				_, err := GetBinding()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Service bindings are not retrievable"))
+			})
+		})
+
+		Context("when the binding does not exist", func() {
+			It("returns the proper error", func() {
+				_, err := rdsBroker.GetBinding(context.Background(), instanceID, "unknown-binding-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrBindingDoesNotExist))
+			})
+		})
+
+		Context("when the instance does not exist", func() {
+			It("returns the proper error", func() {
+				_, err := rdsBroker.GetBinding(context.Background(), "unknown-instance-id", bindingID)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+			})
+		})
+	})
+
+	var _ = Describe("LastOperation", func() {
+		var (
+			dbInstanceStatus            string
+			lastOperationState          brokerapi.LastOperationState
+			properLastOperationResponse brokerapi.LastOperation
+		)
+
+		JustBeforeEach(func() {
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
+				Identifier: dbInstanceIdentifier,
+				Engine:     "test-engine",
+				Address:    "endpoint-address",
+				Port:       3306,
+				DBName:     "test-db",
+				Status:     dbInstanceStatus,
+			}
+
+			properLastOperationResponse = brokerapi.LastOperation{
+				State:       lastOperationState,
+				Description: "DB Instance '" + dbInstanceIdentifier + "' status is '" + dbInstanceStatus + "'",
+			}
+			MakeInstance()
+		})
+
+		LastOperation := func() (brokerapi.LastOperation, error) {
+			return rdsBroker.LastOperation(context.Background(), instanceID, brokerapi.PollDetails{})
+		}
+
+		Context("when describing the DB Instance fails", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeError = errors.New("operation failed")
+			})
+
+			It("returns the proper error", func() {
+				_, err := LastOperation()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+			})
+
+			It("does not delete the local instance", func() {
+				_, err := LastOperation()
+				Expect(err).To(HaveOccurred())
+				Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+			})
+
+			Context("when the DB Instance does not exists", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+				})
+
+				It("returns the proper error", func() {
+					_, err := LastOperation()
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				})
+
+				It("deletes the local instance", func() {
+					_, err := LastOperation()
+					Expect(err).To(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when last operation is still in progress", func() {
+			BeforeEach(func() {
+				dbInstanceStatus = "creating"
+				lastOperationState = brokerapi.InProgress
+			})
+
+			It("returns the proper LastOperationResponse", func() {
+				lastOperationResponse, err := LastOperation()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			})
+		})
+
+		Context("when last operation failed", func() {
+			BeforeEach(func() {
+				dbInstanceStatus = "failed"
+				lastOperationState = brokerapi.Failed
+			})
+
+			It("returns the proper LastOperationResponse", func() {
+				lastOperationResponse, err := LastOperation()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			})
+		})
+
+		Context("when last operation succeeded", func() {
+			BeforeEach(func() {
+				dbInstanceStatus = "available"
+				lastOperationState = brokerapi.Succeeded
+			})
+
+			It("returns the proper LastOperationResponse", func() {
+				lastOperationResponse, err := LastOperation()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			})
+
+			Context("but has pending modifications", func() {
+				JustBeforeEach(func() {
+					dbInstance.DescribeDBInstanceDetails.PendingModifications = true
+
+					properLastOperationResponse = brokerapi.LastOperation{
+						State:       brokerapi.InProgress,
+						Description: "DB Instance '" + dbInstanceIdentifier + "' has pending modifications",
+					}
+				})
+
+				It("returns the proper LastOperationResponse", func() {
+					lastOperationResponse, err := LastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+				})
+			})
+		})
+
+		Context("when shared instance", func() {
+			BeforeEach(func() {
+				rdsProperties1.Shared = true
+			})
+
+			It("returns failed", func() {
+				lastOperationResponse, err := LastOperation()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+			})
+		})
+
+		Context("with a recorded operation", func() {
+			var (
+				operationType       internaldb.OperationType
+				operationPlan       string
+				operationParameters []byte
+				operationStage      string
+				operation           *internaldb.DBOperation
+			)
+
+			BeforeEach(func() {
+				dbInstanceStatus = "available"
+				lastOperationState = brokerapi.Succeeded
+				operationType = internaldb.ProvisionOperation
+				operationPlan = "Plan-1"
+				operationParameters = nil
+				operationStage = ""
+			})
+
+			JustBeforeEach(func() {
+				instance := internaldb.FindInstance(internalDB, instanceID)
+				operation = instance.NewOperation(operationType, operationPlan, operationParameters)
+				operation.Stage = operationStage
+				Expect(internalDB.Save(operation).Error).NotTo(HaveOccurred())
+			})
+
+			OperationLastOperation := func() (brokerapi.LastOperation, error) {
+				return rdsBroker.LastOperation(context.Background(), instanceID, brokerapi.PollDetails{OperationData: operation.OperationData()})
+			}
+
+			Context("when the provision is complete", func() {
+				It("records the outcome", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+					Expect(recorded.State).To(Equal(internaldb.OperationSucceeded))
+					Expect(recorded.FinishedAt).NotTo(BeNil())
+				})
+
+				It("does not describe the DB Instance once finished", func() {
+					_, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					dbInstance.DescribeCalled = false
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+					Expect(dbInstance.DescribeCalled).To(BeFalse())
+				})
+			})
+
+			Context("when the plan requires TLS", func() {
+				BeforeEach(func() {
+					rdsProperties1.RequireTLS = true
+					sqlEngine.TLSRequiredResult = true
+				})
+
+				It("reports that connections have to use TLS", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+					Expect(lastOperationResponse.Description).To(Equal("DB Instance '" + dbInstanceIdentifier + "' status is 'available'. Connections have to use TLS"))
+					Expect(sqlEngine.TLSRequiredCalled).To(BeTrue())
+					Expect(sqlEngine.CloseCalled).To(BeTrue())
+				})
+
+				Context("but TLS isn't enforced", func() {
+					BeforeEach(func() {
+						sqlEngine.TLSRequiredResult = false
+					})
+
+					It("reports it", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+						Expect(lastOperationResponse.Description).To(Equal("DB Instance '" + dbInstanceIdentifier + "' status is 'available'. TLS is not enforced"))
+					})
+				})
+
+				Context("and checking fails", func() {
+					BeforeEach(func() {
+						sqlEngine.TLSRequiredError = errors.New("operation failed")
+					})
+
+					It("still succeeds", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+						Expect(lastOperationResponse.Description).To(Equal("DB Instance '" + dbInstanceIdentifier + "' status is 'available'. Failed to check whether TLS is required"))
+					})
+				})
+			})
+
+			Context("when the instance should have read replicas", func() {
+				JustBeforeEach(func() {
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					Expect(instance.SetReadReplicaCount(internalDB, 2)).To(Succeed())
+					dbInstance.DescribeDBInstancesDetails = map[string]awsrds.DBInstanceDetails{
+						"cf-instance-id-replica-1": {Status: "creating"},
+						"cf-instance-id-replica-2": {Status: "creating"},
+					}
+				})
+
+				It("creates the read replicas once the DB Instance is available", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("Read replica 'cf-instance-id-replica-1' status is 'creating'"))
+					Expect(dbInstance.CreateReadReplicaIDs).To(Equal([]string{"cf-instance-id-replica-1", "cf-instance-id-replica-2"}))
+					Expect(dbInstance.CreateReadReplicaSourceID).To(Equal(dbInstanceIdentifier))
+					Expect(dbInstance.CreateReadReplicaDBInstanceDetails.DBInstanceClass).To(Equal("db.m1.test"))
+					Expect(dbInstance.CreateReadReplicaDBInstanceDetails.Tags["Managed by"]).To(Equal("github.com/AusDTO/pe-rds-broker"))
+					Expect(internaldb.FindInstance(internalDB, instanceID).Replicas).To(HaveLen(2))
+				})
+
+				It("succeeds once every read replica is available", func() {
+					_, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					dbInstance.DescribeDBInstancesDetails["cf-instance-id-replica-1"] = awsrds.DBInstanceDetails{Status: "available"}
+					dbInstance.DescribeDBInstancesDetails["cf-instance-id-replica-2"] = awsrds.DBInstanceDetails{Status: "available"}
+					dbInstance.CreateReadReplicaCalled = false
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+					Expect(dbInstance.CreateReadReplicaCalled).To(BeFalse())
+				})
+
+				Context("and the DB Instance is not available yet", func() {
+					BeforeEach(func() {
+						dbInstanceStatus = "creating"
+						lastOperationState = brokerapi.InProgress
+					})
+
+					It("waits before creating the read replicas", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+						Expect(dbInstance.CreateReadReplicaCalled).To(BeFalse())
+					})
+				})
+
+				Context("and creating a read replica fails", func() {
+					BeforeEach(func() {
+						dbInstance.CreateReadReplicaError = errors.New("operation failed")
+					})
+
+					It("returns the proper error", func() {
+						_, err := OperationLastOperation()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("operation failed"))
+						Expect(internaldb.FindInstance(internalDB, instanceID).Replicas).To(BeEmpty())
+					})
+				})
+
+				Context("and an update removes a read replica", func() {
+					BeforeEach(func() {
+						operationType = internaldb.UpdateOperation
+					})
+
+					JustBeforeEach(func() {
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						for _, identifier := range []string{"cf-instance-id-replica-1", "cf-instance-id-replica-2", "cf-instance-id-replica-3"} {
+							_, err := instance.AddReplica(internalDB, identifier)
+							Expect(err).NotTo(HaveOccurred())
+						}
+						dbInstance.DescribeDBInstancesDetails["cf-instance-id-replica-1"] = awsrds.DBInstanceDetails{Status: "available"}
+						dbInstance.DescribeDBInstancesDetails["cf-instance-id-replica-2"] = awsrds.DBInstanceDetails{Status: "available"}
+					})
+
+					It("deletes the newest read replica", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+						Expect(dbInstance.DeleteIDs).To(Equal([]string{"cf-instance-id-replica-3"}))
+						Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+						Expect(internaldb.FindInstance(internalDB, instanceID).Replicas).To(HaveLen(2))
+					})
+				})
+			})
+
+			Context("when the DB Cluster should have several instances", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties1.ClusterInstanceCount = 2
+					dbInstance.DescribeDBInstancesDetails = map[string]awsrds.DBInstanceDetails{
+						"cf-instance-id-2": {Status: "creating"},
+					}
+				})
+
+				It("waits for the other instances", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("DB Instance 'cf-instance-id-2' status is 'creating'"))
+				})
+
+				It("succeeds once every instance is available", func() {
+					dbInstance.DescribeDBInstancesDetails["cf-instance-id-2"] = awsrds.DBInstanceDetails{Status: "available"}
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+				})
+			})
+
+			Context("when the DB Cluster is serverless", func() {
+				BeforeEach(func() {
+					rdsProperties1.Engine = "aurora"
+					rdsProperties1.EngineMode = "serverless"
+					dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{Status: "creating"}
+				})
+
+				It("reports the status of the DB Cluster", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(brokerapi.LastOperation{
+						State:       brokerapi.InProgress,
+						Description: "DB Cluster 'cf-instance-id' status is 'creating'",
+					}))
+					Expect(dbInstance.DescribeCalled).To(BeFalse())
+				})
+
+				It("succeeds once the DB Cluster is available", func() {
+					dbCluster.DescribeDBClusterDetails.Status = "available"
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+				})
+
+				Context("when the DB Cluster does not exist", func() {
+					BeforeEach(func() {
+						dbCluster.DescribeError = awsrds.ErrDBClusterDoesNotExist
+					})
+
+					It("returns the proper error", func() {
+						_, err := OperationLastOperation()
+						Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+						Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+					})
+				})
+			})
+
+			Context("when a restore from a snapshot is complete", func() {
+				BeforeEach(func() {
+					operationParameters = []byte(`{"restore_from_snapshot": "snapshot-id"}`)
+				})
+
+				It("applies the plan and resets the master password", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(dbInstance.ModifyCalled).To(BeTrue())
+					Expect(dbInstance.ModifyID).To(Equal(dbInstanceIdentifier))
+					Expect(dbInstance.ModifyApplyImmediately).To(BeTrue())
+					Expect(dbInstance.ModifyDBInstanceDetails.DBInstanceClass).To(Equal("db.m1.test"))
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					password, err := instance.MasterUser().Password(encryptionKey)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dbInstance.ModifyDBInstanceDetails.MasterUserPassword).To(Equal(password))
+				})
+
+				It("uses the database name of the snapshot", func() {
+					_, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID).DBName).To(Equal("test-db"))
+				})
+
+				It("succeeds once the password has been reset", func() {
+					_, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					dbInstance.ModifyCalled = false
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+					Expect(dbInstance.ModifyCalled).To(BeFalse())
+				})
+
+				Context("and resetting the master password fails", func() {
+					BeforeEach(func() {
+						dbInstance.ModifyError = errors.New("operation failed")
+					})
+
+					It("tries again next time", func() {
+						_, err := OperationLastOperation()
+						Expect(err).To(HaveOccurred())
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.Finished()).To(BeFalse())
+						Expect(recorded.Stage).To(BeEmpty())
+					})
+				})
+			})
+
+			Context("when a clone is waiting for the snapshot of its source", func() {
+				BeforeEach(func() {
+					operationParameters = []byte(`{"clone_from": "source-instance-id"}`)
+					operationStage = "clone-snapshot"
+					dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{Status: "creating"}
+				})
+
+				It("returns the proper response", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("Snapshot 'cf-instance-id-clone' status is 'creating'"))
+					Expect(dbInstance.DescribeSnapshotID).To(Equal("cf-instance-id-clone"))
+					Expect(dbInstance.RestoreCalled).To(BeFalse())
+				})
+
+				Context("and the snapshot is available", func() {
+					BeforeEach(func() {
+						dbInstance.DescribeSnapshotDBSnapshotDetails.Status = "available"
+					})
+
+					It("restores the snapshot", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(dbInstance.RestoreCalled).To(BeTrue())
+						Expect(dbInstance.RestoreID).To(Equal(dbInstanceIdentifier))
+						Expect(dbInstance.RestoreSnapshotID).To(Equal("cf-instance-id-clone"))
+						Expect(dbInstance.RestoreDBInstanceDetails.Tags["Service ID"]).To(Equal("Service-1"))
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.Stage).To(BeEmpty())
+					})
+
+					It("resets the master password once restored", func() {
+						_, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(dbInstance.ModifyCalled).To(BeTrue())
+					})
+
+					Context("and restoring fails", func() {
+						BeforeEach(func() {
+							dbInstance.RestoreError = errors.New("operation failed")
+						})
+
+						It("fails the provision", func() {
+							lastOperationResponse, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+							Expect(lastOperationResponse.Description).To(Equal("Failed to restore snapshot 'cf-instance-id-clone': operation failed"))
+							instance := internaldb.FindInstance(internalDB, instanceID)
+							recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+							Expect(recorded.State).To(Equal(internaldb.OperationFailed))
+						})
+					})
+				})
+
+				Context("and the snapshot failed", func() {
+					BeforeEach(func() {
+						dbInstance.DescribeSnapshotDBSnapshotDetails.Status = "failed"
+					})
+
+					It("fails the provision", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+						Expect(dbInstance.RestoreCalled).To(BeFalse())
+					})
+				})
+			})
+
+			Context("when a snapshot is being created", func() {
+				BeforeEach(func() {
+					operationType = internaldb.UpdateOperation
+					operationParameters = []byte(`{"create_snapshot": "pre-release-42"}`)
+					operationStage = "create-snapshot"
+					dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{Status: "creating"}
+				})
+
+				It("reports the status of the snapshot", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("Snapshot 'cf-instance-id-pre-release-42' status is 'creating'"))
+					Expect(dbInstance.DescribeSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+				})
+
+				Context("and it is available", func() {
+					BeforeEach(func() {
+						dbInstance.DescribeSnapshotDBSnapshotDetails.Status = "available"
+					})
+
+					It("records the outcome", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.State).To(Equal(internaldb.OperationSucceeded))
+					})
+				})
+
+				Context("and it failed", func() {
+					BeforeEach(func() {
+						dbInstance.DescribeSnapshotDBSnapshotDetails.Status = "failed"
+					})
+
+					It("fails the update", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+					})
+				})
+			})
+
+			Context("when the engine version is being upgraded", func() {
+				var upgradingDBInstance awsrds.DBInstanceDetails
+
+				BeforeEach(func() {
+					operationType = internaldb.UpgradeOperation
+					operationParameters = []byte(`{"engine_version": "1.2.4"}`)
+					upgradingDBInstance = awsrds.DBInstanceDetails{
+						Identifier:    dbInstanceIdentifier,
+						Status:        "upgrading",
+						EngineVersion: "1.2.3",
+					}
+				})
+
+				JustBeforeEach(func() {
+					dbInstance.DescribeDBInstancesDetails = map[string]awsrds.DBInstanceDetails{
+						dbInstanceIdentifier: upgradingDBInstance,
+					}
+				})
+
+				It("reports the upgrade", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("DB Instance 'cf-instance-id' is upgrading from engine version '1.2.3' to '1.2.4'"))
+				})
+
+				Context("and it is pending", func() {
+					BeforeEach(func() {
+						upgradingDBInstance.Status = "available"
+						upgradingDBInstance.PendingModifications = true
+					})
+
+					It("reports the pending upgrade", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("The upgrade of DB Instance 'cf-instance-id' to engine version '1.2.4' is pending"))
+					})
+				})
+
+				Context("and it is complete", func() {
+					BeforeEach(func() {
+						upgradingDBInstance.Status = "available"
+						upgradingDBInstance.EngineVersion = "1.2.4"
+					})
+
+					It("records the new engine version", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+						Expect(lastOperationResponse.Description).To(Equal("DB Instance 'cf-instance-id' has been upgraded to engine version '1.2.4'"))
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						Expect(instance.EngineVersion).To(Equal("1.2.4"))
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.State).To(Equal(internaldb.OperationSucceeded))
+					})
+				})
+
+				Context("and it was rolled back", func() {
+					BeforeEach(func() {
+						upgradingDBInstance.Status = "available"
+					})
+
+					It("fails the upgrade", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+						Expect(lastOperationResponse.Description).To(Equal("DB Instance 'cf-instance-id' is still on engine version '1.2.3'"))
+						Expect(internaldb.FindInstance(internalDB, instanceID).EngineVersion).To(BeEmpty())
+					})
+				})
+			})
+
+			Context("when an update to a new plan is complete", func() {
+				BeforeEach(func() {
+					operationType = internaldb.UpdateOperation
+					operationPlan = "Plan-3"
+				})
+
+				It("switches the instance to the new plan", func() {
+					_, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(internaldb.FindInstance(internalDB, instanceID).PlanID).To(Equal("Plan-3"))
+				})
+			})
+
+			Context("when moving from a shared plan to a dedicated plan", func() {
+				var bindingUser internaldb.DBUser
+
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+					rdsProperties1.Engine = "postgres"
+					rdsProperties3.Engine = "postgres"
+					operationType = internaldb.UpdateOperation
+					operationPlan = "Plan-3"
+					operationStage = "migrate-to-dedicated"
+				})
+
+				JustBeforeEach(func() {
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					var err error
+					bindingUser, _, err = instance.Bind(internalDB, bindingID, "binding-user", internaldb.Standard, encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("queues the copy of the database once the DB Instance is available", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(lastOperationResponse.Description).To(Equal("Waiting to copy database 'cf_instance_id' to DB Instance 'cf-instance-id'"))
+					Expect(sqlEngine.CopyToCalled).To(BeFalse())
+					Expect(sharedPostgres.LockUserCalled).To(BeFalse())
+					instance := internaldb.FindInstance(internalDB, instanceID)
+					recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+					Expect(recorded.Stage).To(Equal("copy-queued"))
+				})
+
+				Context("and the copy has been queued", func() {
+					BeforeEach(func() {
+						operationStage = "copy-queued"
+					})
+
+					// The broker copies in the background and the cloud controller polls to find out how it went
+					CopyThenLastOperation := func() (brokerapi.LastOperation, error) {
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						return OperationLastOperation()
+					}
+
+					It("reports that it's waiting without copying", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("Waiting to copy database 'cf_instance_id' to DB Instance 'cf-instance-id'"))
+						Expect(sqlEngine.CopyToCalled).To(BeFalse())
+					})
+
+					It("copies the database and keeps the shared one until the next call", func() {
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						Expect(sqlEngine.UnsupportedObjectsCalled).To(BeTrue())
+						Expect(sqlEngine.CopyToCalled).To(BeTrue())
+						Expect(sqlEngine.CopyToTarget).To(Equal(sqlEngine))
+						Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						Expect(instance.PlanID).To(Equal("Plan-1"))
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.State).To(Equal(internaldb.OperationInProgress))
+						Expect(recorded.Stage).To(Equal("drop-shared-database"))
+					})
+
+					It("locks the binding users out of the shared database", func() {
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						Expect(sharedPostgres.LockUserCalled).To(BeTrue())
+						Expect(sharedPostgres.LockUserUsername).To(Equal("binding-user"))
+						Expect(sharedPostgres.UnlockUserCalled).To(BeFalse())
+					})
+
+					It("recreates the binding users with their credentials", func() {
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						password, err := bindingUser.Password(encryptionKey)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(sqlEngine.CreateUserUsername).To(Equal("binding-user"))
+						Expect(sqlEngine.CreateUserPassword).To(Equal(password))
+						Expect(sqlEngine.GrantPrivilegesDBName).To(Equal(dbName))
+						Expect(sqlEngine.GrantPrivilegesUsername).To(Equal("binding-user"))
+					})
+
+					Context("and a binding uses IAM database authentication", func() {
+						BeforeEach(func() {
+							rdsProperties1.IAMDatabaseAuthentication = true
+							rdsProperties3.IAMDatabaseAuthentication = true
+						})
+
+						JustBeforeEach(func() {
+							_, _, err := internaldb.FindInstance(internalDB, instanceID).Bind(internalDB, "iam-binding-id", "iam_user", internaldb.IAM, encryptionKey)
+							Expect(err).NotTo(HaveOccurred())
+						})
+
+						It("recreates the user for IAM database authentication", func() {
+							Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+							Expect(sqlEngine.CreateIAMUserCalled).To(BeTrue())
+							Expect(sqlEngine.CreateIAMUserUsername).To(Equal("iam_user"))
+							Expect(sqlEngine.CreateUserUsername).To(Equal("binding-user"))
+						})
+					})
+
+					It("creates the owner role before copying", func() {
+						sqlEngine.OwnerRoleName = "owner_role"
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						Expect(sqlEngine.CreateOwnerRoleCalled).To(BeTrue())
+						Expect(sqlEngine.CreateOwnerRoleOwner).To(Equal("owner_role"))
+						Expect(sqlEngine.GrantOwnerRoleUsername).To(Equal("binding-user"))
+					})
+
+					Context("and copying the database fails", func() {
+						BeforeEach(func() {
+							sqlEngine.CopyToError = errors.New("copy failed")
+						})
+
+						It("fails the update and keeps the shared database", func() {
+							lastOperationResponse, err := CopyThenLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+							Expect(lastOperationResponse.Description).To(Equal("Failed to copy database 'cf_instance_id' to DB Instance 'cf-instance-id': copy failed"))
+							Expect(dbInstance.DeleteCalled).To(BeTrue())
+							Expect(dbInstance.DeleteID).To(Equal(dbInstanceIdentifier))
+							Expect(dbInstance.DeleteSkipFinalSnapshot).To(BeTrue())
+							Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+							Expect(internaldb.FindInstance(internalDB, instanceID).PlanID).To(Equal("Plan-1"))
+						})
+
+						It("lets the binding users back into the shared database", func() {
+							Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+							Expect(sharedPostgres.UnlockUserCalled).To(BeTrue())
+							Expect(sharedPostgres.UnlockUserUsername).To(Equal("binding-user"))
+						})
+					})
+
+					Context("and the binding users can't be locked out", func() {
+						BeforeEach(func() {
+							sharedPostgres.LockUserError = errors.New("lock failed")
+						})
+
+						It("fails the update without copying", func() {
+							lastOperationResponse, err := CopyThenLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+							Expect(lastOperationResponse.Description).To(Equal("Failed to copy database 'cf_instance_id' to DB Instance 'cf-instance-id': lock failed"))
+							Expect(sqlEngine.CopyToCalled).To(BeFalse())
+							Expect(sharedPostgres.UnlockUserCalled).To(BeTrue())
+						})
+					})
+
+					Context("and the database has gained objects which can't be copied", func() {
+						BeforeEach(func() {
+							sqlEngine.UnsupportedObjectsResult = []string{"trigger audit on public.orders"}
+						})
+
+						It("fails the update without copying", func() {
+							lastOperationResponse, err := CopyThenLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+							Expect(lastOperationResponse.Description).To(Equal("Failed to copy database 'cf_instance_id' to DB Instance 'cf-instance-id': Database 'cf_instance_id' can't be copied as it has: trigger audit on public.orders"))
+							Expect(sqlEngine.CopyToCalled).To(BeFalse())
+							Expect(dbInstance.DeleteCalled).To(BeTrue())
+							Expect(sharedPostgres.DropDBCalled).To(BeFalse())
+						})
+					})
+				})
+
+				Context("and the database has been copied", func() {
+					BeforeEach(func() {
+						operationStage = "drop-shared-database"
+					})
+
+					It("drops the shared database and switches the instance to the new plan", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("Dropped shared database 'cf_instance_id'"))
+						Expect(sqlEngine.CopyToCalled).To(BeFalse())
+						Expect(sharedPostgres.DropDBCalled).To(BeTrue())
+						Expect(sharedPostgres.DropDBDBName).To(Equal(dbName))
+						Expect(sharedPostgres.DropUserUsername).To(Equal("binding-user"))
+						instance := internaldb.FindInstance(internalDB, instanceID)
+						Expect(instance.PlanID).To(Equal("Plan-3"))
+						recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+						Expect(recorded.State).To(Equal(internaldb.OperationInProgress))
+						Expect(recorded.Stage).To(BeEmpty())
+					})
+
+					Context("and dropping it fails", func() {
+						BeforeEach(func() {
+							sharedPostgres.DropDBError = errors.New("drop failed")
+						})
+
+						It("tries again on the next call", func() {
+							lastOperationResponse, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+							Expect(lastOperationResponse.Description).To(Equal("Failed to drop shared database 'cf_instance_id', will retry: drop failed"))
+							instance := internaldb.FindInstance(internalDB, instanceID)
+							Expect(instance.PlanID).To(Equal("Plan-1"))
+							recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+							Expect(recorded.Stage).To(Equal("drop-shared-database"))
+						})
+					})
+				})
+
+				Context("and the DB Instance is not available yet", func() {
+					BeforeEach(func() {
+						dbInstanceStatus = "creating"
+					})
+
+					It("waits for it", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(sqlEngine.CopyToCalled).To(BeFalse())
+						Expect(internaldb.FindInstance(internalDB, instanceID).PlanID).To(Equal("Plan-1"))
+						recorded := internaldb.FindOperation(internalDB, internaldb.FindInstance(internalDB, instanceID), operation.OperationData())
+						Expect(recorded.Stage).To(Equal("migrate-to-dedicated"))
+					})
+				})
+
+				Context("and the database is being copied", func() {
+					BeforeEach(func() {
+						operationStage = "copy-database"
+					})
+
+					It("doesn't copy it again", func() {
+						Expect(rdsBroker.CopySharedDatabases()).To(Succeed())
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("Copying database 'cf_instance_id' to DB Instance 'cf-instance-id'"))
+						Expect(sqlEngine.CopyToCalled).To(BeFalse())
+					})
+
+					Context("for too long", func() {
+						JustBeforeEach(func() {
+							Expect(internalDB.Model(operation).Update("stage_started_at", time.Now().Add(-3*time.Hour)).Error).NotTo(HaveOccurred())
+						})
+
+						It("gives up on the copy", func() {
+							lastOperationResponse, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+							Expect(lastOperationResponse.Description).To(Equal("Copying database 'cf_instance_id' to DB Instance 'cf-instance-id' took longer than 2h0m0s"))
+							Expect(sqlEngine.CopyToCalled).To(BeFalse())
+							Expect(dbInstance.DeleteCalled).To(BeTrue())
+							Expect(sharedPostgres.UnlockUserCalled).To(BeTrue())
+							instance := internaldb.FindInstance(internalDB, instanceID)
+							Expect(instance.PlanID).To(Equal("Plan-1"))
+							recorded := internaldb.FindOperation(internalDB, instance, operation.OperationData())
+							Expect(recorded.State).To(Equal(internaldb.OperationFailed))
+							Expect(recorded.Stage).To(Equal("copy-timed-out"))
+						})
+					})
 				})
 
-				It("returns the proper error", func() {
-					_, err := Bind()
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+				Context("and the DB Instance has gone", func() {
+					BeforeEach(func() {
+						dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+					})
+
+					It("fails the update and keeps the local instance", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+						Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+					})
 				})
 			})
-		})
 
-		Context("when shared instance", func() {
-			BeforeEach(func() {
-				rdsProperties1.Shared = true
-			})
+			Context("when rotating the master password", func() {
+				var (
+					oldPassword string
+					newPassword string
+				)
 
-			Context("with postgres", func() {
 				BeforeEach(func() {
-					rdsProperties1.Engine = "postgres"
-					conf := config.DBConfig{Url: "shared-endpoint", Port: 1234, DBName: dbName,
-						Username: "master-username", Password: "master-password", Sslmode: config.Verify}
-					Expect(sharedPostgres.Open(conf)).To(Succeed())
+					operationType = internaldb.UpdateOperation
+					operationStage = "rotate-master-password"
 				})
 
-				It("returns the proper response", func() {
-					bindingResponse, err := Bind()
-					Expect(err).ToNot(HaveOccurred())
-					credentials := bindingResponse.Credentials.(*CredentialsHash)
-					Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
-					Expect(credentials.Host).To(Equal("shared-endpoint"))
-					Expect(credentials.Port).To(Equal(int64(1234)))
-					Expect(credentials.Name).To(Equal(dbName))
-					Expect(credentials.Username).To(HavePrefix("u"))
-					Expect(credentials.Password).ToNot(BeEmpty())
-					Expect(credentials.Password).ToNot(Equal("master-password"))
-					Expect(credentials.URI).To(ContainSubstring("@shared-endpoint:1234/%s?reconnect=true", dbName))
-					Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://shared-endpoint:1234/%s?user=%s&password=", dbName, credentials.Username))
+				JustBeforeEach(func() {
+					masterUser := internaldb.FindInstance(internalDB, instanceID).MasterUser()
+					var err error
+					oldPassword, err = masterUser.Password(encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
+					newPassword, err = masterUser.SetPendingPassword(internalDB, encryptionKey)
+					Expect(err).NotTo(HaveOccurred())
 				})
 
-				It("makes the proper calls", func() {
-					bindingResponse, err := Bind()
+				masterUser := func() *internaldb.DBUser {
+					return internaldb.FindInstance(internalDB, instanceID).MasterUser()
+				}
+
+				It("records the new password once it works", func() {
+					lastOperationResponse, err := OperationLastOperation()
 					Expect(err).ToNot(HaveOccurred())
-					credentials := bindingResponse.Credentials.(*CredentialsHash)
-					Expect(dbCluster.DescribeCalled).To(BeFalse())
-					Expect(dbInstance.DescribeCalled).To(BeFalse())
-					Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
-					Expect(sharedPostgres.CreateDBCalled).To(BeFalse())
-					Expect(sharedPostgres.CreateUserCalled).To(BeTrue())
-					Expect(sharedPostgres.CreateUserUsername).To(Equal(credentials.Username))
-					Expect(sharedPostgres.CreateUserPassword).To(Equal(credentials.Password))
-					Expect(sharedPostgres.GrantPrivilegesCalled).To(BeTrue())
-					Expect(sharedPostgres.GrantPrivilegesDBName).To(Equal(dbName))
-					Expect(sharedPostgres.GrantPrivilegesUsername).To(Equal(credentials.Username))
-					Expect(sharedPostgres.CloseCalled).To(BeFalse())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+					Expect(lastOperationResponse.Description).To(Equal("The master password of DB Instance 'cf-instance-id' has been rotated"))
+					Expect(sqlEngine.OpenConfig.Password).To(Equal(newPassword))
+					Expect(sqlEngine.PingCalled).To(BeTrue())
+					Expect(masterUser().Password(encryptionKey)).To(Equal(newPassword))
+					Expect(masterUser().PendingPassword(encryptionKey)).To(BeEmpty())
+					Expect(masterUser().PasswordRotatedAt).NotTo(BeNil())
 				})
-			})
 
-			Context("with mysql", func() {
-				BeforeEach(func() {
-					rdsProperties1.Engine = "mysql"
-					conf := config.DBConfig{Url: "shared-endpoint", Port: 1234, DBName: dbName,
-						Username: "master-username", Password: "master-password", Sslmode: config.Verify}
-					Expect(sharedMysql.Open(conf)).To(Succeed())
+				Context("and RDS is still resetting it", func() {
+					BeforeEach(func() {
+						dbInstanceStatus = "resetting-master-credentials"
+					})
+
+					It("keeps the old password", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+						Expect(masterUser().PendingPassword(encryptionKey)).To(Equal(newPassword))
+					})
 				})
 
-				It("returns the proper response", func() {
-					bindingResponse, err := Bind()
-					Expect(err).ToNot(HaveOccurred())
-					credentials := bindingResponse.Credentials.(*CredentialsHash)
-					Expect(bindingResponse.SyslogDrainURL).To(BeEmpty())
-					Expect(credentials.Host).To(Equal("shared-endpoint"))
-					Expect(credentials.Port).To(Equal(int64(1234)))
-					Expect(credentials.Name).To(Equal(dbName))
-					Expect(credentials.Username).ToNot(BeEmpty())
-					Expect(credentials.Password).ToNot(BeEmpty())
-					Expect(credentials.Password).ToNot(Equal("master-password"))
-					Expect(credentials.URI).To(ContainSubstring("@shared-endpoint:1234/%s?reconnect=true", dbName))
-					Expect(credentials.JDBCURI).To(ContainSubstring("jdbc:fake://shared-endpoint:1234/%s?user=%s&password=", dbName, credentials.Username))
+				Context("and the new password doesn't work yet", func() {
+					BeforeEach(func() {
+						sqlEngine.OpenError = errors.New("password authentication failed")
+					})
+
+					It("keeps the old password", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("Waiting for DB Instance 'cf-instance-id' to accept the new master password"))
+						Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+					})
 				})
 
-				It("makes the proper calls", func() {
-					bindingResponse, err := Bind()
-					Expect(err).ToNot(HaveOccurred())
-					credentials := bindingResponse.Credentials.(*CredentialsHash)
-					Expect(dbCluster.DescribeCalled).To(BeFalse())
-					Expect(dbInstance.DescribeCalled).To(BeFalse())
-					Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
-					Expect(sharedMysql.CreateDBCalled).To(BeFalse())
-					Expect(sharedMysql.CreateUserCalled).To(BeTrue())
-					Expect(sharedMysql.CreateUserUsername).To(Equal(credentials.Username))
-					Expect(sharedMysql.CreateUserPassword).To(Equal(credentials.Password))
-					Expect(sharedMysql.GrantPrivilegesCalled).To(BeTrue())
-					Expect(sharedMysql.GrantPrivilegesDBName).To(Equal(dbName))
-					Expect(sharedMysql.GrantPrivilegesUsername).To(Equal(credentials.Username))
-					Expect(sharedMysql.CloseCalled).To(BeFalse())
+				Context("and the new password can't connect yet", func() {
+					BeforeEach(func() {
+						sqlEngine.PingError = errors.New("password authentication failed")
+					})
+
+					It("keeps the old password", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("Waiting for DB Instance 'cf-instance-id' to accept the new master password"))
+						Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+						Expect(masterUser().PendingPassword(encryptionKey)).To(Equal(newPassword))
+					})
 				})
-			})
-		})
 
-		Context("when Engine is aurora", func() {
-			BeforeEach(func() {
-				rdsProperties1.Engine = "aurora"
+				Context("and the DB Instance has failed", func() {
+					BeforeEach(func() {
+						dbInstanceStatus = "failed"
+					})
+
+					It("fails the update and keeps the old password", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+						Expect(masterUser().Password(encryptionKey)).To(Equal(oldPassword))
+						Expect(masterUser().PendingPassword(encryptionKey)).To(BeEmpty())
+					})
+				})
 			})
 
-			It("does not describe the DB Instance", func() {
-				_, err := Bind()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.DescribeCalled).To(BeFalse())
+			Context("when an update is still in progress", func() {
+				BeforeEach(func() {
+					operationType = internaldb.UpdateOperation
+					operationPlan = "Plan-3"
+					dbInstanceStatus = "modifying"
+				})
+
+				It("keeps the current plan", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+					Expect(internaldb.FindInstance(internalDB, instanceID).PlanID).To(Equal("Plan-1"))
+				})
 			})
 
-			Context("when describing the DB Cluster fails", func() {
+			Context("when deprovisioning", func() {
 				BeforeEach(func() {
-					dbCluster.DescribeError = errors.New("operation failed")
+					operationType = internaldb.DeprovisionOperation
 				})
 
-				It("returns the proper error", func() {
-					_, err := Bind()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(Equal("operation failed"))
+				Context("and the DB Instance still exists", func() {
+					BeforeEach(func() {
+						dbInstanceStatus = "deleting"
+					})
+
+					It("returns in progress", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+						Expect(lastOperationResponse.Description).To(Equal("DB Instance '" + dbInstanceIdentifier + "' status is 'deleting'"))
+						Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+					})
 				})
 
-				Context("when the DB Cluster does not exists", func() {
+				Context("and the DB Instance is gone", func() {
 					BeforeEach(func() {
-						dbCluster.DescribeError = awsrds.ErrDBInstanceDoesNotExist
+						dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
 					})
 
-					It("returns the proper error", func() {
-						_, err := Bind()
-						Expect(err).To(HaveOccurred())
-						Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					It("returns succeeded", func() {
+						lastOperationResponse, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+					})
+
+					It("deletes the internaldb instance", func() {
+						_, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+					})
+
+					It("doesn't look for a final snapshot", func() {
+						_, err := OperationLastOperation()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(dbInstance.ListSnapshotsCalled).To(BeFalse())
+					})
+
+					Context("and the plan takes a final snapshot", func() {
+						BeforeEach(func() {
+							rdsProperties1.SkipFinalSnapshot = false
+							dbInstance.ListSnapshotsDBSnapshotsDetails = []awsrds.DBSnapshotDetails{
+								{Identifier: "rds-broker-cf-instance-id-2017-10-20-01-02-03"},
+								{Identifier: "cf-instance-id-pre-release-42"},
+							}
+						})
+
+						It("records the final snapshot", func() {
+							_, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(dbInstance.ListSnapshotsID).To(Equal(dbInstanceIdentifier))
+							snapshots, err := internaldb.ListSnapshots(internalDB, instanceID)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(snapshots).To(HaveLen(1))
+							Expect(snapshots[0].SnapshotID).To(Equal("rds-broker-cf-instance-id-2017-10-20-01-02-03"))
+							Expect(snapshots[0].Type).To(Equal(internaldb.FinalSnapshot))
+						})
+
+						Context("when listing snapshots fails", func() {
+							BeforeEach(func() {
+								dbInstance.ListSnapshotsError = errors.New("operation failed")
+							})
+
+							It("still returns succeeded", func() {
+								lastOperationResponse, err := OperationLastOperation()
+								Expect(err).ToNot(HaveOccurred())
+								Expect(lastOperationResponse.State).To(Equal(brokerapi.Succeeded))
+							})
+						})
+					})
+
+					Context("but a read replica still exists", func() {
+						JustBeforeEach(func() {
+							instance := internaldb.FindInstance(internalDB, instanceID)
+							_, err := instance.AddReplica(internalDB, "cf-instance-id-replica-1")
+							Expect(err).NotTo(HaveOccurred())
+							dbInstance.DescribeDBInstancesDetails = map[string]awsrds.DBInstanceDetails{
+								"cf-instance-id-replica-1": {Status: "deleting"},
+							}
+						})
+
+						It("returns in progress", func() {
+							lastOperationResponse, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+							Expect(lastOperationResponse.Description).To(Equal("Read replica 'cf-instance-id-replica-1' status is 'deleting'"))
+							Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+						})
+					})
+
+					Context("but the DB Cluster still exists", func() {
+						BeforeEach(func() {
+							rdsProperties1.Engine = "aurora"
+							dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{Status: "deleting"}
+						})
+
+						It("returns in progress", func() {
+							lastOperationResponse, err := OperationLastOperation()
+							Expect(err).ToNot(HaveOccurred())
+							Expect(lastOperationResponse.State).To(Equal(brokerapi.InProgress))
+							Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+						})
 					})
 				})
 			})
-		})
 
-		Context("when getting the SQL Engine fails", func() {
-			BeforeEach(func() {
-				sqlProvider.GetSQLEngineError = errors.New("Engine 'unknown' not supported")
-			})
+			Context("when shared instance", func() {
+				BeforeEach(func() {
+					rdsProperties1.Shared = true
+				})
 
-			It("returns the proper error", func() {
-				_, err := Bind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Engine 'unknown' not supported"))
+				JustBeforeEach(func() {
+					Expect(operation.Succeed(internalDB, "Provision completed")).To(Succeed())
+				})
+
+				It("returns the recorded outcome", func() {
+					lastOperationResponse, err := OperationLastOperation()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lastOperationResponse).To(Equal(brokerapi.LastOperation{State: brokerapi.Succeeded, Description: "Provision completed"}))
+					Expect(dbInstance.DescribeCalled).To(BeFalse())
+				})
 			})
 		})
+	})
 
-		Context("when opening a DB connection fails", func() {
-			BeforeEach(func() {
-				sqlEngine.OpenError = errors.New("Failed to open sqlEngine")
-			})
+	var _ = Describe("ListSnapshots", func() {
+		BeforeEach(func() {
+			_, err := internaldb.RecordSnapshot(internalDB, instanceID, "cf-instance-id-pre-release-42", internaldb.ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = internaldb.RecordSnapshot(internalDB, "other-instance-id", "cf-other-instance-id-pre-release-42", internaldb.ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{
+				Status:     "available",
+				CreateTime: time.Date(2017, 10, 20, 1, 2, 3, 0, time.UTC),
+			}
+		})
 
-			It("returns the proper error", func() {
-				_, err := Bind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to open sqlEngine"))
-			})
+		It("returns the snapshots of the instance", func() {
+			snapshots, err := rdsBroker.ListSnapshots(instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(Equal([]Snapshot{{
+				SnapshotID: "cf-instance-id-pre-release-42",
+				InstanceID: instanceID,
+				Type:       "manual",
+				Status:     "available",
+				CreatedAt:  time.Date(2017, 10, 20, 1, 2, 3, 0, time.UTC),
+			}}))
+			Expect(dbInstance.DescribeSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
 		})
 
-		Context("when creating a DB user fails", func() {
+		It("returns the snapshots of every instance", func() {
+			snapshots, err := rdsBroker.ListSnapshots("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+		})
+
+		Context("when the snapshot has been deleted outside the broker", func() {
 			BeforeEach(func() {
-				sqlEngine.CreateUserError = errors.New("Failed to create user")
+				dbInstance.DescribeSnapshotError = awsrds.ErrDBSnapshotDoesNotExist
 			})
 
-			It("returns the proper error", func() {
-				_, err := Bind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to create user"))
-				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			It("forgets the snapshot", func() {
+				snapshots, err := rdsBroker.ListSnapshots(instanceID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(snapshots).To(BeEmpty())
+				Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")).To(BeNil())
 			})
 		})
 
-		Context("when granting privileges fails", func() {
+		Context("when describing the snapshot fails", func() {
 			BeforeEach(func() {
-				sqlEngine.GrantPrivilegesError = errors.New("Failed to grant privileges")
+				dbInstance.DescribeSnapshotError = errors.New("operation failed")
 			})
 
 			It("returns the proper error", func() {
-				_, err := Bind()
+				_, err := rdsBroker.ListSnapshots(instanceID)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to grant privileges"))
-				Expect(sqlEngine.CloseCalled).To(BeTrue())
+				Expect(err.Error()).To(Equal("operation failed"))
 			})
 		})
 	})
 
-	var _ = Describe("Unbind", func() {
-		var (
-			unbindDetails brokerapi.UnbindDetails
-			dbUsername    string
-		)
+	var _ = Describe("DeleteSnapshot", func() {
+		var cluster bool
 
 		BeforeEach(func() {
-			unbindDetails = brokerapi.UnbindDetails{
-				ServiceID: "Service-1",
-				PlanID:    "Plan-1",
-			}
-
-			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
-				Identifier: dbInstanceIdentifier,
-				Address:    "endpoint-address",
-				Port:       3306,
+			cluster = false
+			dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{
+				Status: "available",
+				Tags:   map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"},
 			}
+			dbCluster.DescribeSnapshotDBSnapshotDetails = dbInstance.DescribeSnapshotDBSnapshotDetails
+		})
 
-			dbCluster.DescribeDBClusterDetails = awsrds.DBClusterDetails{
-				Identifier: dbClusterIdentifier,
-				Endpoint:   "endpoint-address",
-				Port:       3306,
-			}
-			instance := MakeInstance()
-			dbUsername = "username"
-			_, _, err := instance.Bind(internalDB, bindingID, dbUsername, internaldb.Standard, encryptionKey)
+		JustBeforeEach(func() {
+			_, err := internaldb.RecordSnapshot(internalDB, instanceID, "cf-instance-id-pre-release-42", internaldb.ManualSnapshot, cluster)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		Unbind := func() error {
-			return rdsBroker.Unbind(context.Background(), instanceID, bindingID, unbindDetails)
-		}
-
-		It("makes the proper calls", func() {
-			err := Unbind()
+		It("deletes the snapshot", func() {
+			err := rdsBroker.DeleteSnapshot("cf-instance-id-pre-release-42")
 			Expect(err).ToNot(HaveOccurred())
-			Expect(dbCluster.DescribeCalled).To(BeFalse())
-			Expect(dbInstance.DescribeCalled).To(BeTrue())
-			Expect(dbInstance.DescribeID).To(Equal(dbInstanceIdentifier))
-			Expect(sqlProvider.GetSQLEngineCalled).To(BeTrue())
-			Expect(sqlProvider.GetSQLEngineEngine).To(Equal("test-engine-1"))
-			Expect(sqlEngine.OpenCalled).To(BeTrue())
-			Expect(sqlEngine.OpenConfig.Url).To(Equal("endpoint-address"))
-			Expect(sqlEngine.OpenConfig.Port).To(Equal(int64(3306)))
-			Expect(sqlEngine.OpenConfig.DBName).To(Equal(dbName))
-			Expect(sqlEngine.OpenConfig.Username).ToNot(BeEmpty())
-			Expect(sqlEngine.OpenConfig.Password).ToNot(BeEmpty())
-			Expect(sqlEngine.RevokePrivilegesCalled).To(BeTrue())
-			Expect(sqlEngine.RevokePrivilegesUsername).To(Equal(dbUsername))
-			Expect(sqlEngine.RevokePrivilegesDBName).To(Equal(dbName))
-			Expect(sqlEngine.DropDBCalled).To(BeFalse())
-			Expect(sqlEngine.DropUserCalled).To(BeTrue())
-			Expect(sqlEngine.DropUserUsername).To(Equal(dbUsername))
-			Expect(sqlEngine.CloseCalled).To(BeTrue())
+			Expect(dbInstance.DeleteSnapshotCalled).To(BeTrue())
+			Expect(dbInstance.DeleteSnapshotSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+			Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")).To(BeNil())
 		})
 
-		Context("when another binding has the same username", func() {
+		Context("when the snapshot is of a DB Cluster", func() {
 			BeforeEach(func() {
-				instance := internaldb.FindInstance(internalDB, instanceID)
-				_, _, err := instance.Bind(internalDB, "binding-two", dbUsername, internaldb.Standard, encryptionKey)
-				Expect(err).NotTo(HaveOccurred())
+				cluster = true
 			})
 
-			It("makes the proper calls", func() {
-				err := Unbind()
+			It("deletes the DB Cluster snapshot", func() {
+				err := rdsBroker.DeleteSnapshot("cf-instance-id-pre-release-42")
 				Expect(err).ToNot(HaveOccurred())
-				Expect(sqlEngine.RevokePrivilegesCalled).To(BeFalse())
-				Expect(sqlEngine.DropUserCalled).To(BeFalse())
+				Expect(dbCluster.DeleteSnapshotCalled).To(BeTrue())
+				Expect(dbCluster.DeleteSnapshotSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+				Expect(dbInstance.DeleteSnapshotCalled).To(BeFalse())
 			})
 		})
 
-		Context("when describing the DB Instance fails", func() {
+		Context("when the snapshot isn't recorded", func() {
+			It("returns the proper error", func() {
+				err := rdsBroker.DeleteSnapshot("unknown-snapshot")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Snapshot 'unknown-snapshot' not found"))
+				Expect(dbInstance.DeleteSnapshotCalled).To(BeFalse())
+			})
+		})
+
+		Context("when the snapshot isn't managed by the broker", func() {
 			BeforeEach(func() {
-				dbInstance.DescribeError = errors.New("operation failed")
+				dbInstance.DescribeSnapshotDBSnapshotDetails.Tags = map[string]string{}
 			})
 
 			It("returns the proper error", func() {
-				err := Unbind()
+				err := rdsBroker.DeleteSnapshot("cf-instance-id-pre-release-42")
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("operation failed"))
+				Expect(err.Error()).To(Equal("Snapshot 'cf-instance-id-pre-release-42' is not managed by this broker"))
+				Expect(dbInstance.DeleteSnapshotCalled).To(BeFalse())
+				Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")).NotTo(BeNil())
 			})
+		})
 
-			Context("when the DB Instance does not exists", func() {
-				BeforeEach(func() {
-					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
-				})
+		Context("when the snapshot has already gone", func() {
+			BeforeEach(func() {
+				dbInstance.DescribeSnapshotError = awsrds.ErrDBSnapshotDoesNotExist
+			})
 
-				It("returns the proper error", func() {
-					err := Unbind()
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
-				})
+			It("forgets the snapshot", func() {
+				err := rdsBroker.DeleteSnapshot("cf-instance-id-pre-release-42")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dbInstance.DeleteSnapshotCalled).To(BeFalse())
+				Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")).To(BeNil())
 			})
 		})
 
-		Context("when shared instance", func() {
+		Context("when deleting the snapshot fails", func() {
 			BeforeEach(func() {
-				BeforeEach(func() {
-					rdsProperties1.Shared = true
-				})
+				dbInstance.DeleteSnapshotError = errors.New("operation failed")
+			})
 
-				Context("with postgres", func() {
-					BeforeEach(func() {
-						rdsProperties1.Engine = "postgres"
-					})
+			It("keeps the snapshot record", func() {
+				err := rdsBroker.DeleteSnapshot("cf-instance-id-pre-release-42")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("operation failed"))
+				Expect(internaldb.FindSnapshot(internalDB, "cf-instance-id-pre-release-42")).NotTo(BeNil())
+			})
+		})
+	})
 
-					It("makes the proper calls", func() {
-						err := Unbind()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(dbCluster.DescribeCalled).To(BeFalse())
-						Expect(dbInstance.DescribeCalled).To(BeFalse())
-						Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
-						Expect(sharedPostgres.OpenCalled).To(BeFalse())
-						Expect(sharedPostgres.RevokePrivilegesCalled).To(BeTrue())
-						Expect(sharedPostgres.RevokePrivilegesUsername).To(Equal(dbUsername))
-						Expect(sharedPostgres.RevokePrivilegesDBName).To(Equal(dbName))
-						Expect(sharedPostgres.DropDBCalled).To(BeFalse())
-						Expect(sharedPostgres.DropUserCalled).To(BeTrue())
-						Expect(sharedPostgres.DropUserUsername).To(Equal(dbUsername))
-						Expect(sharedPostgres.CloseCalled).To(BeFalse())
-					})
-				})
+	var _ = Describe("ExpireSnapshots", func() {
+		var now time.Time
 
-				Context("with mysql", func() {
-					BeforeEach(func() {
-						rdsProperties1.Engine = "mysql"
-					})
+		BeforeEach(func() {
+			now = time.Now().AddDate(0, 0, 31)
+			snapshotRetention.FinalDays = 30
+			snapshotRetention.ManualDays = 60
+			dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{
+				Status: "available",
+				Tags:   map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"},
+			}
 
-					It("makes the proper calls", func() {
-						err := Unbind()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(dbCluster.DescribeCalled).To(BeFalse())
-						Expect(dbInstance.DescribeCalled).To(BeFalse())
-						Expect(sqlProvider.GetSQLEngineCalled).To(BeFalse())
-						Expect(sharedMysql.OpenCalled).To(BeFalse())
-						Expect(sharedMysql.RevokePrivilegesCalled).To(BeTrue())
-						Expect(sharedMysql.RevokePrivilegesUsername).To(Equal(dbUsername))
-						Expect(sharedMysql.RevokePrivilegesDBName).To(Equal(dbName))
-						Expect(sharedMysql.DropDBCalled).To(BeFalse())
-						Expect(sharedMysql.DropUserCalled).To(BeTrue())
-						Expect(sharedMysql.DropUserUsername).To(Equal(dbUsername))
-						Expect(sharedMysql.CloseCalled).To(BeFalse())
-					})
-				})
-			})
+			_, err := internaldb.RecordSnapshot(internalDB, instanceID, "rds-broker-cf-instance-id-2017-10-20-01-02-03", internaldb.FinalSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = internaldb.RecordSnapshot(internalDB, instanceID, "cf-instance-id-pre-release-42", internaldb.ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = internaldb.RecordSnapshot(internalDB, instanceID, "cf-instance-id-clone", internaldb.CloneSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		Context("when Engine is aurora", func() {
+		It("deletes the snapshots older than their retention period", func() {
+			expired, err := rdsBroker.ExpireSnapshots(now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expired).To(Equal([]string{"rds-broker-cf-instance-id-2017-10-20-01-02-03"}))
+			Expect(dbInstance.DeleteSnapshotSnapshotID).To(Equal("rds-broker-cf-instance-id-2017-10-20-01-02-03"))
+			snapshots, err := internaldb.ListSnapshots(internalDB, instanceID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+		})
+
+		Context("when a snapshot can't be deleted", func() {
 			BeforeEach(func() {
-				rdsProperties1.Engine = "aurora"
+				dbInstance.DeleteSnapshotError = errors.New("operation failed")
 			})
 
-			It("does not describe the DB Instance", func() {
-				err := Unbind()
+			It("skips it", func() {
+				expired, err := rdsBroker.ExpireSnapshots(now)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(dbInstance.DescribeCalled).To(BeFalse())
+				Expect(expired).To(BeEmpty())
+				Expect(internaldb.FindSnapshot(internalDB, "rds-broker-cf-instance-id-2017-10-20-01-02-03")).NotTo(BeNil())
 			})
+		})
+	})
 
-			Context("when describing the DB Cluster fails", func() {
-				BeforeEach(func() {
-					dbCluster.DescribeError = errors.New("operation failed")
-				})
-
-				It("returns the proper error", func() {
-					err := Unbind()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(Equal("operation failed"))
-				})
-
-				Context("when the DB Cluster does not exists", func() {
-					BeforeEach(func() {
-						dbCluster.DescribeError = awsrds.ErrDBInstanceDoesNotExist
-					})
+	var _ = Describe("RotateMasterPasswords", func() {
+		var now time.Time
 
-					It("returns the proper error", func() {
-						err := Unbind()
-						Expect(err).To(HaveOccurred())
-						Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
-					})
-				})
-			})
+		BeforeEach(func() {
+			now = time.Now().AddDate(0, 0, 31)
+			masterPasswordRotation.MaxAgeDays = 30
+			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{Status: "available"}
+			instance := MakeInstance()
+			Expect(instance.Activate(internalDB)).To(Succeed())
 		})
 
-		Context("when getting the SQL Engine fails", func() {
-			BeforeEach(func() {
-				sqlProvider.GetSQLEngineError = errors.New("SQL Engine 'unknown' not supported")
-			})
+		It("rotates the master passwords older than the maximum age", func() {
+			rotated, err := rdsBroker.RotateMasterPasswords(now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rotated).To(Equal([]string{instanceID}))
+			Expect(dbInstance.ModifyMasterPasswordPassword).NotTo(BeEmpty())
+		})
 
-			It("returns the proper error", func() {
-				err := Unbind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("SQL Engine 'unknown' not supported"))
-			})
+		It("finishes the rotations it started", func() {
+			_, err := rdsBroker.RotateMasterPasswords(now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rdsBroker.FinishMasterPasswordRotations()).To(Succeed())
+			masterUser := internaldb.FindInstance(internalDB, instanceID).MasterUser()
+			Expect(masterUser.Password(encryptionKey)).To(Equal(dbInstance.ModifyMasterPasswordPassword))
+			operation := internaldb.LatestOperation(internalDB, internaldb.FindInstance(internalDB, instanceID))
+			Expect(operation.State).To(Equal(internaldb.OperationSucceeded))
 		})
 
-		Context("when opening a DB connection fails", func() {
+		Context("when the master password is recent", func() {
 			BeforeEach(func() {
-				sqlEngine.OpenError = errors.New("Failed to open sqlEngine")
+				now = time.Now()
 			})
 
-			It("returns the proper error", func() {
-				err := Unbind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to open sqlEngine"))
+			It("leaves it", func() {
+				rotated, err := rdsBroker.RotateMasterPasswords(now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rotated).To(BeEmpty())
+				Expect(dbInstance.ModifyMasterPasswordCalled).To(BeFalse())
 			})
 		})
 
-		Context("when revoking privileges fails", func() {
+		Context("when the instance can't be rotated", func() {
 			BeforeEach(func() {
-				sqlEngine.RevokePrivilegesError = errors.New("Failed to revoke privileges")
+				dbInstance.DescribeDBInstanceDetails.Status = "modifying"
 			})
 
-			It("returns the proper error", func() {
-				err := Unbind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to revoke privileges"))
-				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			It("skips it", func() {
+				rotated, err := rdsBroker.RotateMasterPasswords(now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rotated).To(BeEmpty())
 			})
 		})
 
-		Context("when deleting a user fails", func() {
+		Context("when the rotation is not scheduled", func() {
 			BeforeEach(func() {
-				sqlEngine.DropUserError = errors.New("Failed to delete user")
+				masterPasswordRotation.MaxAgeDays = 0
 			})
 
-			It("returns the proper error", func() {
-				err := Unbind()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("Failed to delete user"))
-				Expect(sqlEngine.CloseCalled).To(BeTrue())
+			It("does nothing", func() {
+				rotated, err := rdsBroker.RotateMasterPasswords(now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rotated).To(BeEmpty())
+				Expect(dbInstance.DescribeCalled).To(BeFalse())
 			})
 		})
 	})
 
-	var _ = Describe("LastOperation", func() {
+	var _ = Describe("AdminHandler", func() {
 		var (
-			dbInstanceStatus            string
-			lastOperationState          brokerapi.LastOperationState
-			properLastOperationResponse brokerapi.LastOperation
+			handler  http.Handler
+			recorder *httptest.ResponseRecorder
+			request  *http.Request
+			username string
+			password string
 		)
 
-		JustBeforeEach(func() {
-			dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{
-				Identifier: dbInstanceIdentifier,
-				Engine:     "test-engine",
-				Address:    "endpoint-address",
-				Port:       3306,
-				DBName:     "test-db",
-				Status:     dbInstanceStatus,
-			}
-
-			properLastOperationResponse = brokerapi.LastOperation{
-				State:       lastOperationState,
-				Description: "DB Instance '" + dbInstanceIdentifier + "' status is '" + dbInstanceStatus + "'",
+		BeforeEach(func() {
+			username = "username"
+			password = "password"
+			recorder = httptest.NewRecorder()
+			dbInstance.DescribeSnapshotDBSnapshotDetails = awsrds.DBSnapshotDetails{
+				Status: "available",
+				Tags:   map[string]string{"Managed by": "github.com/AusDTO/pe-rds-broker"},
 			}
-			MakeInstance()
+			_, err := internaldb.RecordSnapshot(internalDB, instanceID, "cf-instance-id-pre-release-42", internaldb.ManualSnapshot, false)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		LastOperation := func() (brokerapi.LastOperation, error) {
-			return rdsBroker.LastOperation(context.Background(), instanceID, "")
-		}
+		JustBeforeEach(func() {
+			handler = NewAdminHandler(rdsBroker, brokerapi.BrokerCredentials{Username: "username", Password: "password"}, logger)
+			request.SetBasicAuth(username, password)
+			handler.ServeHTTP(recorder, request)
+		})
 
-		Context("when describing the DB Instance fails", func() {
+		Context("when listing snapshots", func() {
 			BeforeEach(func() {
-				dbInstance.DescribeError = errors.New("operation failed")
-			})
-
-			It("returns the proper error", func() {
-				_, err := LastOperation()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("operation failed"))
+				request = httptest.NewRequest("GET", "/admin/snapshots?instance_id="+instanceID, nil)
 			})
 
-			It("does not delete the local instance", func() {
-				_, err := LastOperation()
-				Expect(err).To(HaveOccurred())
-				Expect(internaldb.FindInstance(internalDB, instanceID)).NotTo(BeNil())
+			It("returns the snapshots", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				var snapshots []Snapshot
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &snapshots)).To(Succeed())
+				Expect(snapshots).To(HaveLen(1))
+				Expect(snapshots[0].SnapshotID).To(Equal("cf-instance-id-pre-release-42"))
 			})
 
-			Context("when the DB Instance does not exists", func() {
+			Context("with the wrong credentials", func() {
 				BeforeEach(func() {
-					dbInstance.DescribeError = awsrds.ErrDBInstanceDoesNotExist
-				})
-
-				It("returns the proper error", func() {
-					_, err := LastOperation()
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(brokerapi.ErrInstanceDoesNotExist))
+					password = "wrong-password"
 				})
 
-				It("deletes the local instance", func() {
-					_, err := LastOperation()
-					Expect(err).To(HaveOccurred())
-					Expect(internaldb.FindInstance(internalDB, instanceID)).To(BeNil())
+				It("is unauthorized", func() {
+					Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+					Expect(dbInstance.DescribeSnapshotCalled).To(BeFalse())
 				})
 			})
 		})
 
-		Context("when last operation is still in progress", func() {
+		Context("when deleting a snapshot", func() {
 			BeforeEach(func() {
-				dbInstanceStatus = "creating"
-				lastOperationState = brokerapi.InProgress
+				request = httptest.NewRequest("DELETE", "/admin/snapshots/cf-instance-id-pre-release-42", nil)
 			})
 
-			It("returns the proper LastOperationResponse", func() {
-				lastOperationResponse, err := LastOperation()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			It("deletes the snapshot", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(dbInstance.DeleteSnapshotSnapshotID).To(Equal("cf-instance-id-pre-release-42"))
+			})
+
+			Context("that isn't recorded", func() {
+				BeforeEach(func() {
+					request = httptest.NewRequest("DELETE", "/admin/snapshots/unknown-snapshot", nil)
+				})
+
+				It("is not found", func() {
+					Expect(recorder.Code).To(Equal(http.StatusNotFound))
+					Expect(dbInstance.DeleteSnapshotCalled).To(BeFalse())
+				})
+			})
+
+			Context("that isn't managed by the broker", func() {
+				BeforeEach(func() {
+					dbInstance.DescribeSnapshotDBSnapshotDetails.Tags = map[string]string{}
+				})
+
+				It("returns the error", func() {
+					Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+					Expect(recorder.Body.String()).To(ContainSubstring("is not managed by this broker"))
+				})
 			})
 		})
 
-		Context("when last operation failed", func() {
+		Context("when applying the retention policy", func() {
 			BeforeEach(func() {
-				dbInstanceStatus = "failed"
-				lastOperationState = brokerapi.Failed
+				request = httptest.NewRequest("POST", "/admin/retention", nil)
 			})
 
-			It("returns the proper LastOperationResponse", func() {
-				lastOperationResponse, err := LastOperation()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			It("returns the expired snapshots", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(MatchJSON(`{"expired": []}`))
 			})
 		})
 
-		Context("when last operation succeeded", func() {
+		Context("when rotating a master password", func() {
 			BeforeEach(func() {
-				dbInstanceStatus = "available"
-				lastOperationState = brokerapi.Succeeded
+				dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{Status: "available"}
+				MakeInstance()
+				request = httptest.NewRequest("POST", "/admin/instances/"+instanceID+"/rotate-master-password", nil)
 			})
 
-			It("returns the proper LastOperationResponse", func() {
-				lastOperationResponse, err := LastOperation()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+			It("starts the rotation", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"operation"`))
+				Expect(dbInstance.ModifyMasterPasswordPassword).NotTo(BeEmpty())
 			})
 
-			Context("but has pending modifications", func() {
-				JustBeforeEach(func() {
-					dbInstance.DescribeDBInstanceDetails.PendingModifications = true
-
-					properLastOperationResponse = brokerapi.LastOperation{
-						State:       brokerapi.InProgress,
-						Description: "DB Instance '" + dbInstanceIdentifier + "' has pending modifications",
-					}
+			Context("of an unknown instance", func() {
+				BeforeEach(func() {
+					request = httptest.NewRequest("POST", "/admin/instances/unknown-instance/rotate-master-password", nil)
 				})
 
-				It("returns the proper LastOperationResponse", func() {
-					lastOperationResponse, err := LastOperation()
-					Expect(err).ToNot(HaveOccurred())
-					Expect(lastOperationResponse).To(Equal(properLastOperationResponse))
+				It("is not found", func() {
+					Expect(recorder.Code).To(Equal(http.StatusNotFound))
+					Expect(dbInstance.ModifyMasterPasswordCalled).To(BeFalse())
 				})
 			})
 		})
 
-		Context("when shared instance", func() {
+		Context("when asking about a master password rotation", func() {
 			BeforeEach(func() {
-				rdsProperties1.Shared = true
+				dbInstance.DescribeDBInstanceDetails = awsrds.DBInstanceDetails{Status: "available"}
+				MakeInstance()
+				operationData, err := rdsBroker.RotateMasterPassword(instanceID)
+				Expect(err).NotTo(HaveOccurred())
+				request = httptest.NewRequest("GET", "/admin/instances/"+instanceID+"/last-operation?operation="+url.QueryEscape(operationData), nil)
 			})
 
-			It("returns failed", func() {
-				lastOperationResponse, err := LastOperation()
-				Expect(err).ToNot(HaveOccurred())
-				Expect(lastOperationResponse.State).To(Equal(brokerapi.Failed))
+			It("returns the state of the rotation", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(MatchJSON(`{"state": "succeeded", "description": "The master password of DB Instance 'cf-instance-id' has been rotated"}`))
 			})
 		})
 	})
@@ -18,16 +18,21 @@ type Catalog struct {
 }
 
 type Service struct {
-	ID              string           `json:"id" yaml:"id"`
-	Name            string           `json:"name" yaml:"name"`
-	Description     string           `json:"description" yaml:"description"`
-	Bindable        bool             `json:"bindable,omitempty" yaml:"bindable,omitempty"`
-	Tags            []string         `json:"tags,omitempty" yaml:"tags,omitempty"`
-	Metadata        *ServiceMetadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
-	Requires        []string         `json:"requires,omitempty" yaml:"requires,omitempty"`
-	PlanUpdateable  bool             `json:"plan_updateable" yaml:"plan_updateable"`
-	Plans           []ServicePlan    `json:"plans,omitempty" yaml:"plans,omitempty"`
-	DashboardClient *DashboardClient `json:"dashboard_client,omitempty" yaml:"dashboard_client,omitempty"`
+	ID                   string           `json:"id" yaml:"id"`
+	Name                 string           `json:"name" yaml:"name"`
+	Description          string           `json:"description" yaml:"description"`
+	Bindable             bool             `json:"bindable,omitempty" yaml:"bindable,omitempty"`
+	InstancesRetrievable bool             `json:"instances_retrievable,omitempty" yaml:"instances_retrievable,omitempty"`
+	BindingsRetrievable  bool             `json:"bindings_retrievable,omitempty" yaml:"bindings_retrievable,omitempty"`
+	Tags                 []string         `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Metadata             *ServiceMetadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Requires             []string         `json:"requires,omitempty" yaml:"requires,omitempty"`
+	PlanUpdateable       bool             `json:"plan_updateable" yaml:"plan_updateable"`
+	Plans                []ServicePlan    `json:"plans,omitempty" yaml:"plans,omitempty"`
+	DashboardClient      *DashboardClient `json:"dashboard_client,omitempty" yaml:"dashboard_client,omitempty"`
+
+	// Only for bindings, not part of the catalog sent to cloud foundry
+	CredentialTemplates CredentialTemplates `json:"-" yaml:"credential_templates,omitempty"`
 }
 
 type ServiceMetadata struct {
@@ -69,7 +74,9 @@ type RDSProperties struct {
 	DBInstanceClass             string   `json:"db_instance_class,omitempty" yaml:"db_instance_class,omitempty"`
 	Engine                      string   `json:"engine" yaml:"engine"`
 	EngineVersion               string   `json:"engine_version,omitempty" yaml:"engine_version,omitempty"`
+	AllowedEngineVersions       []string `json:"allowed_engine_versions,omitempty" yaml:"allowed_engine_versions,omitempty"`
 	AllocatedStorage            int64    `json:"allocated_storage,omitempty" yaml:"allocated_storage,omitempty"`
+	MaxAllocatedStorage         int64    `json:"max_allocated_storage,omitempty" yaml:"max_allocated_storage,omitempty"`
 	AutoMinorVersionUpgrade     bool     `json:"auto_minor_version_upgrade,omitempty" yaml:"auto_minor_version_upgrade,omitempty"`
 	AvailabilityZone            string   `json:"availability_zone,omitempty" yaml:"availability_zone,omitempty"`
 	BackupRetentionPeriod       int64    `json:"backup_retention_period,omitempty" yaml:"backup_retention_period,omitempty"`
@@ -91,7 +98,16 @@ type RDSProperties struct {
 	Iops                        int64    `json:"iops,omitempty" yaml:"iops,omitempty"`
 	VpcSecurityGroupIds         []string `json:"vpc_security_group_ids,omitempty" yaml:"vpc_security_group_ids,omitempty"`
 	CopyTagsToSnapshot          bool     `json:"copy_tags_to_snapshot,omitempty" yaml:"copy_tags_to_snapshot,omitempty"`
+	IAMDatabaseAuthentication   bool     `json:"iam_database_authentication,omitempty" yaml:"iam_database_authentication,omitempty"`
+	RequireTLS                  bool     `json:"require_tls,omitempty" yaml:"require_tls,omitempty"`
 	SkipFinalSnapshot           bool     `json:"skip_final_snapshot,omitempty" yaml:"skip_final_snapshot,omitempty"`
+	ReadReplicaCount            int64    `json:"read_replica_count,omitempty" yaml:"read_replica_count,omitempty"`
+	ClusterInstanceCount        int64    `json:"cluster_instance_count,omitempty" yaml:"cluster_instance_count,omitempty"`
+	EngineMode                  string   `json:"engine_mode,omitempty" yaml:"engine_mode,omitempty"`
+	MinCapacity                 int64    `json:"min_capacity,omitempty" yaml:"min_capacity,omitempty"`
+	MaxCapacity                 int64    `json:"max_capacity,omitempty" yaml:"max_capacity,omitempty"`
+	AutoPause                   bool     `json:"auto_pause,omitempty" yaml:"auto_pause,omitempty"`
+	SecondsUntilAutoPause       int64    `json:"seconds_until_auto_pause,omitempty" yaml:"seconds_until_auto_pause,omitempty"`
 	Shared                      bool     `json:"shared" yaml:"shared"`
 }
 
@@ -142,12 +158,20 @@ func (s Service) Validate() error {
 		return fmt.Errorf("Must provide a non-empty Description (%+v)", s)
 	}
 
+	if s.BindingsRetrievable && !s.Bindable {
+		return fmt.Errorf("BindingsRetrievable requires the service to be Bindable (%+v)", s)
+	}
+
 	for _, servicePlan := range s.Plans {
 		if err := servicePlan.Validate(); err != nil {
 			return fmt.Errorf("Validating Plans configuration: %s", err)
 		}
 	}
 
+	if err := s.CredentialTemplates.Validate(); err != nil {
+		return fmt.Errorf("Validating Credential Templates configuration: %s", err)
+	}
+
 	return nil
 }
 
@@ -172,7 +196,7 @@ func (sp ServicePlan) Validate() error {
 }
 
 func (rp RDSProperties) Validate() error {
-	if !rp.Shared && rp.DBInstanceClass == "" {
+	if !rp.Shared && !rp.serverless() && rp.DBInstanceClass == "" {
 		return fmt.Errorf("Must provide a non-empty DBInstanceClass (%+v)", rp)
 	}
 
@@ -181,7 +205,7 @@ func (rp RDSProperties) Validate() error {
 	}
 
 	switch strings.ToLower(rp.Engine) {
-	case "aurora":
+	case "aurora", "aurora-mysql", "aurora-postgresql":
 	case "mariadb":
 	case "mysql":
 	case "postgres":
@@ -198,5 +222,125 @@ func (rp RDSProperties) Validate() error {
 		}
 	}
 
+	if rp.ReadReplicaCount < 0 || rp.ReadReplicaCount > maxReadReplicaCount {
+		return fmt.Errorf("ReadReplicaCount must be between 0 and %d (%+v)", maxReadReplicaCount, rp)
+	}
+
+	if rp.ReadReplicaCount > 0 && !rp.supportsReadReplicas() {
+		return fmt.Errorf("This broker does not support read replicas with RDS engine '%s' or a shared instance (%+v)", rp.Engine, rp)
+	}
+
+	if rp.ClusterInstanceCount < 0 || rp.ClusterInstanceCount > maxClusterInstanceCount {
+		return fmt.Errorf("ClusterInstanceCount must be between 0 and %d (%+v)", maxClusterInstanceCount, rp)
+	}
+
+	if rp.ClusterInstanceCount > 0 && !isAurora(rp.Engine) {
+		return fmt.Errorf("ClusterInstanceCount is only supported with Aurora RDS engines (%+v)", rp)
+	}
+
+	if rp.MaxAllocatedStorage > 0 {
+		if rp.Shared || isAurora(rp.Engine) {
+			return fmt.Errorf("MaxAllocatedStorage is only supported with dedicated MariaDB, MySQL and PostgreSQL instances (%+v)", rp)
+		}
+		// RDS only turns on storage autoscaling when there's room to grow
+		if rp.MaxAllocatedStorage <= rp.AllocatedStorage {
+			return fmt.Errorf("MaxAllocatedStorage must be more than AllocatedStorage (%+v)", rp)
+		}
+	}
+
+	if len(rp.AllowedEngineVersions) > 0 && !rp.supportsEngineUpgrades() {
+		return fmt.Errorf("AllowedEngineVersions is only supported with dedicated MariaDB, MySQL and PostgreSQL instances (%+v)", rp)
+	}
+
+	if rp.IAMDatabaseAuthentication && !rp.supportsIAMDatabaseAuthentication() {
+		return fmt.Errorf("IAMDatabaseAuthentication is only supported with dedicated MySQL, PostgreSQL and provisioned Aurora instances (%+v)", rp)
+	}
+
+	if rp.RequireTLS && rp.lacksForceSSLParameterGroup() {
+		return fmt.Errorf("RequireTLS with a dedicated PostgreSQL engine needs a parameter group setting rds.force_ssl (%+v)", rp)
+	}
+
+	switch strings.ToLower(rp.EngineMode) {
+	case "", "provisioned":
+		if rp.MinCapacity > 0 || rp.MaxCapacity > 0 || rp.AutoPause || rp.SecondsUntilAutoPause > 0 {
+			return fmt.Errorf("Capacity scaling is only supported in the 'serverless' engine mode (%+v)", rp)
+		}
+	case "serverless":
+		if !isAurora(rp.Engine) || rp.Shared {
+			return fmt.Errorf("The 'serverless' engine mode is only supported with dedicated Aurora RDS engines (%+v)", rp)
+		}
+		if rp.ClusterInstanceCount > 0 {
+			return fmt.Errorf("Serverless DB clusters don't have DB instances so can't have a ClusterInstanceCount (%+v)", rp)
+		}
+		if rp.MinCapacity > 0 && rp.MaxCapacity > 0 && rp.MinCapacity > rp.MaxCapacity {
+			return fmt.Errorf("MinCapacity can't be larger than MaxCapacity (%+v)", rp)
+		}
+	default:
+		return fmt.Errorf("This broker does not support engine mode '%s' (%+v)", rp.EngineMode, rp)
+	}
+
 	return nil
 }
+
+// Serverless Aurora clusters scale their own capacity and have no DB instances to manage
+func (rp RDSProperties) serverless() bool {
+	return strings.ToLower(rp.EngineMode) == "serverless"
+}
+
+// isAurora is true for the engines whose DB instances belong to a DB cluster. Plain `aurora` is
+// MySQL 5.6 compatible.
+func isAurora(engine string) bool {
+	switch strings.ToLower(engine) {
+	case "aurora", "aurora-mysql", "aurora-postgresql":
+		return true
+	}
+	return false
+}
+
+// Aurora clusters scale reads with their own instances rather than RDS read replicas
+func (rp RDSProperties) supportsReadReplicas() bool {
+	return !rp.Shared && !isAurora(rp.Engine)
+}
+
+// Engine versions of Aurora clusters are upgraded through the cluster rather than its DB instances
+func (rp RDSProperties) supportsEngineUpgrades() bool {
+	return !rp.Shared && !isAurora(rp.Engine)
+}
+
+// allowsEngineVersion is true if instances on the plan can be upgraded to the engine version
+func (rp RDSProperties) allowsEngineVersion(version string) bool {
+	for _, allowed := range rp.AllowedEngineVersions {
+		if allowed == version {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterInstanceCount is the number of DB instances in an Aurora cluster, the first being the writer
+func (rp RDSProperties) clusterInstanceCount() int64 {
+	if rp.ClusterInstanceCount < 1 {
+		return 1
+	}
+	return rp.ClusterInstanceCount
+}
+
+// Postgres can only require TLS for the whole server, through rds.force_ssl in the plan's parameter group.
+// Other engines require it per user and shared instances rely on the settings of the shared server.
+func (rp RDSProperties) lacksForceSSLParameterGroup() bool {
+	if rp.Shared {
+		return false
+	}
+	switch strings.ToLower(rp.Engine) {
+	case "postgres":
+		return rp.DBParameterGroupName == ""
+	case "aurora-postgresql":
+		return rp.DBClusterParameterGroupName == ""
+	}
+	return false
+}
+
+// MariaDB and serverless Aurora don't support IAM database authentication
+func (rp RDSProperties) supportsIAMDatabaseAuthentication() bool {
+	return !rp.Shared && !rp.serverless() && strings.ToLower(rp.Engine) != "mariadb"
+}
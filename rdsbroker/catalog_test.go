@@ -136,6 +136,15 @@ var _ = Describe("Service", func() {
 			Expect(err.Error()).To(ContainSubstring("Must provide a non-empty Description"))
 		})
 
+		It("returns error if BindingsRetrievable is set for a service which isn't Bindable", func() {
+			service.Bindable = false
+			service.BindingsRetrievable = true
+
+			err := service.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("BindingsRetrievable requires the service to be Bindable"))
+		})
+
 		It("returns error if Plans are not valid", func() {
 			service.Plans = []ServicePlan{
 				ServicePlan{},
@@ -145,6 +154,50 @@ var _ = Describe("Service", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Validating Plans configuration"))
 		})
+
+		It("does not return error if CredentialTemplates render", func() {
+			service.CredentialTemplates = CredentialTemplates{
+				"database_url":      "{{.Engine}}://{{userinfo .Username .Password}}@{{.Host}}:{{.Port}}/{{pathescape .DBName}}?sslmode={{queryescape \"require\"}}",
+				"connection_string": "Server={{adovalue .Host}};Port={{.Port}};Database={{adovalue .DBName}};User Id={{adovalue .Username}};Password={{adovalue .Password}}",
+				"libpq":             "host={{keywordvalue .Host}} dbname={{keywordvalue .DBName}} password={{keywordvalue .Password}}",
+				"mysql_dsn":         "{{mysqldsn .Username .Password .Host .Port .DBName \"tls\" \"true\"}}",
+			}
+
+			err := service.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if a CredentialTemplate doesn't parse", func() {
+			service.CredentialTemplates = CredentialTemplates{"dsn": "{{.Username"}
+
+			err := service.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Credential template 'dsn' is invalid"))
+		})
+
+		It("returns error if a CredentialTemplate fails to render", func() {
+			service.CredentialTemplates = CredentialTemplates{"dsn": "{{.Hostname}}"}
+
+			err := service.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Credential template 'dsn' fails to render"))
+		})
+
+		It("returns error if a CredentialTemplate can't build a MySQL DSN from the details", func() {
+			service.CredentialTemplates = CredentialTemplates{"dsn": "{{mysqldsn .Username .Password .Host .Port .DBName \"tls\"}}"}
+
+			err := service.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("mysqldsn params must be pairs of names and values"))
+		})
+
+		It("returns error if a CredentialTemplate replaces a credential of the broker", func() {
+			service.CredentialTemplates = CredentialTemplates{"uri": "{{.Host}}"}
+
+			err := service.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Credential template 'uri' would replace a credential the broker sets"))
+		})
 	})
 })
 
@@ -257,5 +310,219 @@ var _ = Describe("RDSProperties", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("This broker does not support RDS engine"))
 		})
+
+		It("does not return error if Engine is an Aurora flavour", func() {
+			for _, engine := range []string{"aurora", "aurora-mysql", "aurora-postgresql"} {
+				rdsProperties.Engine = engine
+				rdsProperties.ClusterInstanceCount = 2
+
+				err := rdsProperties.Validate()
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		Context("when EngineMode is serverless", func() {
+			BeforeEach(func() {
+				rdsProperties.Engine = "aurora"
+				rdsProperties.EngineMode = "serverless"
+				rdsProperties.DBInstanceClass = ""
+				rdsProperties.MinCapacity = 2
+				rdsProperties.MaxCapacity = 8
+			})
+
+			It("does not return error without a DBInstanceClass", func() {
+				err := rdsProperties.Validate()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns error if Engine is not Aurora", func() {
+				rdsProperties.Engine = "mysql"
+
+				err := rdsProperties.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("The 'serverless' engine mode is only supported with dedicated Aurora RDS engines"))
+			})
+
+			It("returns error if MinCapacity is larger than MaxCapacity", func() {
+				rdsProperties.MinCapacity = 16
+
+				err := rdsProperties.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("MinCapacity can't be larger than MaxCapacity"))
+			})
+
+			It("returns error if ClusterInstanceCount is set", func() {
+				rdsProperties.ClusterInstanceCount = 2
+
+				err := rdsProperties.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Serverless DB clusters don't have DB instances"))
+			})
+		})
+
+		It("returns error if EngineMode is not supported", func() {
+			rdsProperties.EngineMode = "parallelquery"
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("This broker does not support engine mode 'parallelquery'"))
+		})
+
+		It("returns error if capacity is set without the serverless EngineMode", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.MaxCapacity = 8
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Capacity scaling is only supported in the 'serverless' engine mode"))
+		})
+
+		It("returns error if ReadReplicaCount is too large", func() {
+			rdsProperties.ReadReplicaCount = 6
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ReadReplicaCount must be between 0 and 5"))
+		})
+
+		It("returns error if ReadReplicaCount is set for Aurora", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.ReadReplicaCount = 1
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("This broker does not support read replicas"))
+		})
+
+		It("returns error if ClusterInstanceCount is too large", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.ClusterInstanceCount = 17
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ClusterInstanceCount must be between 0 and 16"))
+		})
+
+		It("returns error if ClusterInstanceCount is set for a non Aurora engine", func() {
+			rdsProperties.ClusterInstanceCount = 2
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ClusterInstanceCount is only supported with Aurora RDS engines"))
+		})
+
+		It("does not return error if MaxAllocatedStorage is set", func() {
+			rdsProperties.MaxAllocatedStorage = 100
+
+			err := rdsProperties.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if MaxAllocatedStorage is less than AllocatedStorage", func() {
+			rdsProperties.MaxAllocatedStorage = 4
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("MaxAllocatedStorage must be more than AllocatedStorage"))
+		})
+
+		It("returns error if MaxAllocatedStorage is the same as AllocatedStorage", func() {
+			rdsProperties.MaxAllocatedStorage = rdsProperties.AllocatedStorage
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("MaxAllocatedStorage must be more than AllocatedStorage"))
+		})
+
+		It("returns error if MaxAllocatedStorage is set for an Aurora engine", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.MaxAllocatedStorage = 100
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("MaxAllocatedStorage is only supported with dedicated MariaDB, MySQL and PostgreSQL instances"))
+		})
+
+		It("does not return error if AllowedEngineVersions is set", func() {
+			rdsProperties.AllowedEngineVersions = []string{"1.2.4"}
+
+			err := rdsProperties.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if AllowedEngineVersions is set for an Aurora engine", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.AllowedEngineVersions = []string{"1.2.4"}
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("AllowedEngineVersions is only supported with dedicated MariaDB, MySQL and PostgreSQL instances"))
+		})
+
+		It("does not return error if RequireTLS is set", func() {
+			rdsProperties.RequireTLS = true
+
+			err := rdsProperties.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if RequireTLS is set for PostgreSQL without a DB parameter group", func() {
+			rdsProperties.Engine = "postgres"
+			rdsProperties.RequireTLS = true
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RequireTLS with a dedicated PostgreSQL engine needs a parameter group setting rds.force_ssl"))
+
+			rdsProperties.DBParameterGroupName = "force-ssl"
+			Expect(rdsProperties.Validate()).To(Succeed())
+		})
+
+		It("returns error if RequireTLS is set for Aurora PostgreSQL without a DB cluster parameter group", func() {
+			rdsProperties.Engine = "aurora-postgresql"
+			rdsProperties.RequireTLS = true
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RequireTLS with a dedicated PostgreSQL engine needs a parameter group setting rds.force_ssl"))
+
+			rdsProperties.DBClusterParameterGroupName = "force-ssl"
+			Expect(rdsProperties.Validate()).To(Succeed())
+		})
+
+		It("does not return error if IAMDatabaseAuthentication is set", func() {
+			rdsProperties.IAMDatabaseAuthentication = true
+
+			err := rdsProperties.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if IAMDatabaseAuthentication is set for a shared instance", func() {
+			rdsProperties.Shared = true
+			rdsProperties.IAMDatabaseAuthentication = true
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IAMDatabaseAuthentication is only supported with dedicated MySQL, PostgreSQL and provisioned Aurora instances"))
+		})
+
+		It("returns error if IAMDatabaseAuthentication is set for a MariaDB engine", func() {
+			rdsProperties.Engine = "mariadb"
+			rdsProperties.IAMDatabaseAuthentication = true
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IAMDatabaseAuthentication is only supported with dedicated MySQL, PostgreSQL and provisioned Aurora instances"))
+		})
+
+		It("returns error if IAMDatabaseAuthentication is set for a serverless Aurora cluster", func() {
+			rdsProperties.Engine = "aurora"
+			rdsProperties.EngineMode = "serverless"
+			rdsProperties.IAMDatabaseAuthentication = true
+
+			err := rdsProperties.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IAMDatabaseAuthentication is only supported with dedicated MySQL, PostgreSQL and provisioned Aurora instances"))
+		})
 	})
 })
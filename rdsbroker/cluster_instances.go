@@ -0,0 +1,152 @@
+package rdsbroker
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// An Aurora cluster has a writer and up to 15 Aurora Replicas
+const maxClusterInstanceCount = 16
+
+// The first instance of a cluster keeps the DB instance identifier so clusters created before they could
+// have more than one instance carry on as they were
+func (b *RDSBroker) clusterInstanceIdentifier(instance *internaldb.DBInstance, n int) string {
+	if n == 1 {
+		return b.dbInstanceIdentifier(instance)
+	}
+	return fmt.Sprintf("%s-%d", b.dbInstanceIdentifier(instance), n)
+}
+
+// createClusterInstances adds the instances after the first to a new Aurora cluster. Unlike read replicas
+// they don't have to wait for the cluster to be available.
+func (b *RDSBroker) createClusterInstances(instance *internaldb.DBInstance, servicePlan ServicePlan, dbInstanceDetails awsrds.DBInstanceDetails, rollback *saga) error {
+	for n := 2; int64(n) <= servicePlan.RDSProperties.clusterInstanceCount(); n++ {
+		identifier := b.clusterInstanceIdentifier(instance, n)
+		if err := b.dbInstance.Create(identifier, dbInstanceDetails); err != nil {
+			return err
+		}
+		rollback.add("delete-db-instance", func() error {
+			return b.dbInstance.Delete(identifier, true)
+		})
+	}
+	return nil
+}
+
+// scaleClusterInstances brings an Aurora cluster to the number of instances of its new plan. Existing
+// instances get the same modifications as the first and the ones beyond the plan's count are deleted.
+func (b *RDSBroker) scaleClusterInstances(instance *internaldb.DBInstance, servicePlan ServicePlan, modifyDBInstance awsrds.DBInstanceDetails, applyImmediately bool) error {
+	dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+	if err != nil {
+		return err
+	}
+	members := make(map[string]bool)
+	for _, member := range dbClusterDetails.Members {
+		members[member] = true
+	}
+
+	count := int(servicePlan.RDSProperties.clusterInstanceCount())
+	for n := 2; n <= count; n++ {
+		identifier := b.clusterInstanceIdentifier(instance, n)
+		if members[identifier] {
+			err = b.dbInstance.Modify(identifier, modifyDBInstance, applyImmediately)
+		} else {
+			err = b.dbInstance.Create(identifier, *b.clusterInstanceFromPlan(instance, servicePlan))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for n := count + 1; n <= maxClusterInstanceCount; n++ {
+		identifier := b.clusterInstanceIdentifier(instance, n)
+		if !members[identifier] {
+			continue
+		}
+		if err := b.dbInstance.Delete(identifier, true); err != nil && err != awsrds.ErrDBInstanceDoesNotExist {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *RDSBroker) clusterInstanceFromPlan(instance *internaldb.DBInstance, servicePlan ServicePlan) *awsrds.DBInstanceDetails {
+	dbInstanceDetails := b.dbInstanceFromPlan(servicePlan)
+	dbInstanceDetails.DBClusterIdentifier = b.dbClusterIdentifier(instance)
+	dbInstanceDetails.Tags = b.dbTags("Created", instance.ServiceID, servicePlan.ID, instance.OrganizationID, instance.SpaceID)
+	return dbInstanceDetails
+}
+
+// deleteClusterInstances starts deleting the instances of an Aurora cluster other than the first, which
+// has to happen before the cluster itself can be deleted
+func (b *RDSBroker) deleteClusterInstances(instance *internaldb.DBInstance) error {
+	dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBClusterDoesNotExist {
+			return nil
+		}
+		return err
+	}
+
+	for _, member := range dbClusterDetails.Members {
+		if member == b.dbInstanceIdentifier(instance) {
+			continue
+		}
+		if err := b.dbInstance.Delete(member, true); err != nil && err != awsrds.ErrDBInstanceDoesNotExist {
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterInstancesLastOperation waits for every instance of an Aurora cluster once the first is available
+func (b *RDSBroker) clusterInstancesLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, primary brokerapi.LastOperation) (brokerapi.LastOperation, error) {
+	for n := 2; int64(n) <= servicePlan.RDSProperties.clusterInstanceCount(); n++ {
+		identifier := b.clusterInstanceIdentifier(instance, n)
+		dbInstanceDetails, err := b.dbInstance.Describe(identifier)
+		if err != nil {
+			if err == awsrds.ErrDBInstanceDoesNotExist {
+				return brokerapi.LastOperation{
+					State:       brokerapi.Failed,
+					Description: fmt.Sprintf("DB Instance '%s' of the cluster has gone", identifier),
+				}, nil
+			}
+			return brokerapi.LastOperation{State: brokerapi.Failed}, err
+		}
+
+		state, ok := rdsStatus2State[dbInstanceDetails.Status]
+		if !ok {
+			state = brokerapi.Failed
+		}
+		if state != brokerapi.Succeeded {
+			return brokerapi.LastOperation{
+				State:       state,
+				Description: fmt.Sprintf("DB Instance '%s' status is '%s'", identifier, dbInstanceDetails.Status),
+			}, nil
+		}
+	}
+
+	return primary, nil
+}
+
+// secondaryInstancesLastOperation waits for the read replicas of an instance or the rest of an Aurora cluster
+func (b *RDSBroker) secondaryInstancesLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, primary brokerapi.LastOperation) (brokerapi.LastOperation, error) {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return b.clusterInstancesLastOperation(instance, servicePlan, primary)
+	}
+	return b.replicasLastOperation(instance, servicePlan, primary)
+}
+
+// readerEndpoint is the address which balances connections across the Aurora Replicas of a cluster
+func (b *RDSBroker) readerEndpoint(instance *internaldb.DBInstance) string {
+	dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+	if err != nil {
+		b.logger.Error("describe-db-cluster", err)
+		return ""
+	}
+	return dbClusterDetails.ReaderEndpoint
+}
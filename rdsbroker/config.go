@@ -1,6 +1,7 @@
 package rdsbroker
 
 import (
+	"crypto/x509"
 	"errors"
 	"fmt"
 
@@ -8,12 +9,33 @@ import (
 )
 
 type Config struct {
-	Region                       string  `yaml:"region"`
-	DBPrefix                     string  `yaml:"db_prefix"`
-	AllowUserProvisionParameters bool    `yaml:"allow_user_provision_parameters"`
-	AllowUserUpdateParameters    bool    `yaml:"allow_user_update_parameters"`
-	AllowUserBindParameters      bool    `yaml:"allow_user_bind_parameters"`
-	Catalog                      Catalog `yaml:"catalog"`
+	Region                       string                 `yaml:"region"`
+	DBPrefix                     string                 `yaml:"db_prefix"`
+	AllowUserProvisionParameters bool                   `yaml:"allow_user_provision_parameters"`
+	AllowUserUpdateParameters    bool                   `yaml:"allow_user_update_parameters"`
+	AllowUserBindParameters      bool                   `yaml:"allow_user_bind_parameters"`
+	SnapshotRetention            SnapshotRetention      `yaml:"snapshot_retention"`
+	MasterPasswordRotation       MasterPasswordRotation `yaml:"master_password_rotation"`
+	CACertificateFile            string                 `yaml:"ca_certificate_file,omitempty"`
+	Catalog                      Catalog                `yaml:"catalog"`
+
+	// The contents of CACertificateFile
+	CACertificate string `yaml:"-"`
+}
+
+// SnapshotRetention is the number of days each type of snapshot is kept for. Zero keeps them forever.
+type SnapshotRetention struct {
+	FinalDays     int `yaml:"final_days"`
+	ManualDays    int `yaml:"manual_days"`
+	CloneDays     int `yaml:"clone_days"`
+	IntervalHours int `yaml:"interval_hours"`
+}
+
+// MasterPasswordRotation is how old the master password of a dedicated instance can get before it's rotated.
+// Zero never rotates them other than when asked to.
+type MasterPasswordRotation struct {
+	MaxAgeDays    int `yaml:"max_age_days"`
+	IntervalHours int `yaml:"interval_hours"`
 }
 
 func (c Config) Validate() error {
@@ -29,9 +51,45 @@ func (c Config) Validate() error {
 		return errors.New("DBPrefix must begin with a letter and contain only alphanumeric characters")
 	}
 
+	if err := c.SnapshotRetention.Validate(); err != nil {
+		return fmt.Errorf("Validating Snapshot Retention configuration: %s", err)
+	}
+
+	if err := c.MasterPasswordRotation.Validate(); err != nil {
+		return fmt.Errorf("Validating Master Password Rotation configuration: %s", err)
+	}
+
+	if c.CACertificateFile != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(c.CACertificate)) {
+		return fmt.Errorf("CACertificateFile '%s' doesn't contain any PEM encoded certificates", c.CACertificateFile)
+	}
+
 	if err := c.Catalog.Validate(); err != nil {
 		return fmt.Errorf("Validating Catalog configuration: %s", err)
 	}
 
 	return nil
 }
+
+func (r SnapshotRetention) Validate() error {
+	if r.FinalDays < 0 || r.ManualDays < 0 || r.CloneDays < 0 {
+		return errors.New("Days to keep snapshots for can't be negative")
+	}
+
+	if r.IntervalHours < 0 {
+		return errors.New("IntervalHours can't be negative")
+	}
+
+	return nil
+}
+
+func (r MasterPasswordRotation) Validate() error {
+	if r.MaxAgeDays < 0 {
+		return errors.New("MaxAgeDays can't be negative")
+	}
+
+	if r.IntervalHours < 0 {
+		return errors.New("IntervalHours can't be negative")
+	}
+
+	return nil
+}
@@ -68,6 +68,31 @@ var _ = Describe("Config", func() {
 			Expect(err.Error()).To(ContainSubstring("DBPrefix must begin with a letter and contain only alphanumeric characters"))
 		})
 
+		It("returns error if SnapshotRetention is negative", func() {
+			config.SnapshotRetention.FinalDays = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating Snapshot Retention configuration"))
+		})
+
+		It("returns error if CACertificateFile has no certificates", func() {
+			config.CACertificateFile = "rds-ca.pem"
+			config.CACertificate = "not a certificate"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("CACertificateFile 'rds-ca.pem' doesn't contain any PEM encoded certificates"))
+		})
+
+		It("returns error if MasterPasswordRotation is negative", func() {
+			config.MasterPasswordRotation.MaxAgeDays = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating Master Password Rotation configuration"))
+		})
+
 		It("returns error if Catalog is not valid", func() {
 			config.Catalog = Catalog{
 				[]Service{
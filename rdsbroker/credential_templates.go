@@ -0,0 +1,148 @@
+package rdsbroker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CredentialTemplates are extra binding credentials rendered from Go templates, for apps which want the
+// connection details in a shape of their own. The templates are given a credentialTemplateData and can
+// escape each part with credentialTemplateFuncs.
+type CredentialTemplates map[string]string
+
+type credentialTemplateData struct {
+	Host     string
+	Port     int64
+	DBName   string
+	Username string
+	Password string
+	Engine   string
+}
+
+var credentialTemplateFuncs = template.FuncMap{
+	// The user info of a URL, e.g. postgres://{{userinfo .Username .Password}}@{{.Host}}:{{.Port}}/{{pathescape .DBName}}
+	"userinfo": func(username, password string) string {
+		return url.UserPassword(username, password).String()
+	},
+	// A segment of the path of a URL
+	"pathescape": func(s string) string {
+		return strings.Replace((&url.URL{Path: s}).EscapedPath(), "/", "%2F", -1)
+	},
+	// A value in the query of a URL
+	"queryescape": url.QueryEscape,
+	// A value of a libpq style keyword/value connection string, e.g. host={{keywordvalue .Host}}
+	"keywordvalue": func(s string) string {
+		return `'` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `'`, `\'`, -1) + `'`
+	},
+	// A value of an ADO.NET connection string, e.g. Password={{adovalue .Password}}
+	"adovalue": func(s string) string {
+		if !strings.ContainsAny(s, `;'"=`) && strings.TrimSpace(s) == s {
+			return s
+		}
+		return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	},
+	// A DSN for the Go MySQL driver, e.g. {{mysqldsn .Username .Password .Host .Port .DBName "tls" "true"}}
+	"mysqldsn": mysqlDSN,
+}
+
+// mysqlDSN has the MySQL driver format a DSN, with any params given as pairs of names and values. The
+// driver doesn't escape the user, password or database name, so the DSN is read back to check that
+// none of them got in the way of the others.
+func mysqlDSN(username, password, host string, port int64, dbName string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.New("mysqldsn params must be pairs of names and values")
+	}
+	config := mysql.Config{
+		User:                 username,
+		Passwd:               password,
+		Net:                  "tcp",
+		Addr:                 fmt.Sprintf("%s:%d", host, port),
+		DBName:               dbName,
+		AllowNativePasswords: true,
+	}
+	if len(params) > 0 {
+		config.Params = map[string]string{}
+		for i := 0; i < len(params); i += 2 {
+			config.Params[params[i]] = params[i+1]
+		}
+	}
+
+	dsn := config.FormatDSN()
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if parsed.User != username || parsed.Passwd != password || parsed.DBName != dbName {
+		return "", errors.New("mysqldsn can't keep the username, password and database name apart")
+	}
+	return dsn, nil
+}
+
+// Credential templates are rendered with these details when validating the catalog, with a password
+// which needs escaping everywhere
+var exampleCredentialTemplateData = credentialTemplateData{
+	Host:     "example.rds.amazonaws.com",
+	Port:     5432,
+	DBName:   "cf_example",
+	Username: "example",
+	Password: `p@ss:w/rd?#&=;'" \`,
+	Engine:   "postgres",
+}
+
+func (t CredentialTemplates) Validate() error {
+	builtIn := credentialsHashFields()
+	for name := range t {
+		if name == "" {
+			return fmt.Errorf("Credential templates must have a non-empty name (%+v)", t)
+		}
+		if builtIn[name] {
+			return fmt.Errorf("Credential template '%s' would replace a credential the broker sets", name)
+		}
+	}
+
+	if _, err := t.render(exampleCredentialTemplateData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t CredentialTemplates) render(data credentialTemplateData) (map[string]string, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+
+	rendered := map[string]string{}
+	for name, text := range t {
+		tmpl, err := template.New(name).Funcs(credentialTemplateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Credential template '%s' is invalid: %s", name, err)
+		}
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("Credential template '%s' fails to render: %s", name, err)
+		}
+		rendered[name] = buf.String()
+	}
+	return rendered, nil
+}
+
+// credentialsHashFields are the names of the credentials the broker sets itself
+func credentialsHashFields() map[string]bool {
+	fields := map[string]bool{}
+	credentialsHashType := reflect.TypeOf(CredentialsHash{})
+	for i := 0; i < credentialsHashType.NumField(); i++ {
+		name := strings.Split(credentialsHashType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
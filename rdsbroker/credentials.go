@@ -0,0 +1,103 @@
+package rdsbroker
+
+import (
+	"errors"
+	"fmt"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// rotateBindingCredentials gives binding users new passwords, for when credentials have leaked. Bindings
+// with the same username share a user so they all get its new password. Either every password changes or
+// none do; apps pick up the new ones when they are restaged.
+func (b *RDSBroker) rotateBindingCredentials(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) (brokerapi.UpdateServiceSpec, error) {
+	updateSpec := brokerapi.UpdateServiceSpec{IsAsync: false}
+
+	others := updateParameters
+	others.RotateBindingCredentials = nil
+	if (details.PlanID != "" && details.PlanID != instance.PlanID) || others.modifies() || others.CreateSnapshot != "" {
+		return updateSpec, errors.New("rotate_binding_credentials can't be combined with other changes")
+	}
+
+	users, err := rotatedUsers(instance, *updateParameters.RotateBindingCredentials)
+	if err != nil {
+		return updateSpec, err
+	}
+
+	sqlEngine, closeEngine, err := b.bindingSqlEngine(instance, servicePlan, internaldb.Standard)
+	if err != nil {
+		return updateSpec, err
+	}
+	if closeEngine {
+		defer sqlEngine.Close()
+	}
+
+	rollback := newSaga(b.logger.Session("rotate-binding-credentials", lager.Data{instanceIDLogKey: instance.InstanceID}))
+	for i := range users {
+		user := &users[i]
+		oldPassword, err := user.Password(b.encryptionKey)
+		if err != nil {
+			rollback.run()
+			return updateSpec, err
+		}
+		if err = user.SetRandomPassword(b.encryptionKey); err != nil {
+			rollback.run()
+			return updateSpec, err
+		}
+		password, err := user.Password(b.encryptionKey)
+		if err != nil {
+			rollback.run()
+			return updateSpec, err
+		}
+		if err = sqlEngine.SetPassword(user.Username, password); err != nil {
+			rollback.run()
+			return updateSpec, err
+		}
+		username := user.Username
+		rollback.add("set-password", func() error {
+			return sqlEngine.SetPassword(username, oldPassword)
+		})
+	}
+
+	if err = internaldb.SavePasswords(b.internalDB, users); err != nil {
+		rollback.run()
+		return updateSpec, errors.New("Failed to save passwords to local database")
+	}
+
+	updateSpec.OperationData = b.startOperation(instance, internaldb.UpdateOperation, instance.PlanID, details.RawParameters, updateSpec.IsAsync, "")
+
+	return updateSpec, nil
+}
+
+// rotatedUsers are the binding users picked by rotate_binding_credentials, each once however many of its
+// bindings were given. Users logging in with IAM have no password to rotate.
+func rotatedUsers(instance *internaldb.DBInstance, selection BindingSelection) ([]internaldb.DBUser, error) {
+	var users []internaldb.DBUser
+	if selection.All {
+		for _, user := range bindingUsers(instance) {
+			if user.Type != internaldb.IAM {
+				users = append(users, user)
+			}
+		}
+		return users, nil
+	}
+
+	seen := map[string]bool{}
+	for _, bindingID := range selection.BindingIDs {
+		user, _ := instance.BindingUser(bindingID)
+		if user == nil {
+			return nil, fmt.Errorf("Binding '%s' not found", bindingID)
+		}
+		if user.Type == internaldb.IAM {
+			return nil, fmt.Errorf("Binding '%s' uses IAM database authentication so has no password to rotate", bindingID)
+		}
+		if !seen[user.Username] {
+			seen[user.Username] = true
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,213 @@
+package rdsbroker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// RDS can't carry these extensions through a major PostgreSQL upgrade so they have to be dropped first
+var majorUpgradeBlockingExtensions = []string{"chkpass", "pg_repack", "tsearch2"}
+
+// majorEngineVersion is the part of an engine version which changes in a major upgrade. That's the first
+// number for PostgreSQL 10 onwards and the first two numbers for everything else.
+func majorEngineVersion(engine, version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return version
+	}
+	if isPostgres(engine) {
+		if major, err := strconv.Atoi(parts[0]); err == nil && major >= 10 {
+			return parts[0]
+		}
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// compareEngineVersions compares two engine versions number by number, returning -1, 0 or 1
+func compareEngineVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNumber, bNumber int
+		if i < len(aParts) {
+			aNumber, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNumber, _ = strconv.Atoi(bParts[i])
+		}
+		if aNumber < bNumber {
+			return -1
+		}
+		if aNumber > bNumber {
+			return 1
+		}
+	}
+	return 0
+}
+
+func isPostgres(engine string) bool {
+	return strings.ToLower(engine) == "postgres"
+}
+
+// engineVersion is the engine version an instance should be on. That's its plan's unless it has been
+// upgraded past it with the engine_version update parameter.
+func engineVersion(instance *internaldb.DBInstance, servicePlan ServicePlan) string {
+	if instance.EngineVersion != "" && compareEngineVersions(instance.EngineVersion, servicePlan.RDSProperties.EngineVersion) > 0 {
+		return instance.EngineVersion
+	}
+	return servicePlan.RDSProperties.EngineVersion
+}
+
+// upgradeEngineVersion handles an update which moves an instance to another engine version allowed by its
+// plan. It's kept apart from other changes so the upgrade is tracked as an operation of its own.
+func (b *RDSBroker) upgradeEngineVersion(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) (brokerapi.UpdateServiceSpec, error) {
+	updateSpec := brokerapi.UpdateServiceSpec{IsAsync: true}
+	targetVersion := updateParameters.EngineVersion
+
+	if !servicePlan.RDSProperties.supportsEngineUpgrades() {
+		return updateSpec, fmt.Errorf("Engine version upgrades are not supported for plan '%s'", servicePlan.Name)
+	}
+	others := updateParameters
+	others.EngineVersion = ""
+	others.ApplyImmediately = false
+	if (details.PlanID != "" && details.PlanID != instance.PlanID) || others.modifies() {
+		return updateSpec, errors.New("engine_version can't be combined with other changes")
+	}
+	if !servicePlan.RDSProperties.allowsEngineVersion(targetVersion) {
+		return updateSpec, fmt.Errorf("Engine version '%s' is not allowed for plan '%s'", targetVersion, servicePlan.Name)
+	}
+
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return updateSpec, brokerapi.ErrInstanceDoesNotExist
+		}
+		return updateSpec, err
+	}
+	switch compareEngineVersions(targetVersion, dbInstanceDetails.EngineVersion) {
+	case 0:
+		return updateSpec, fmt.Errorf("DB Instance '%s' is already on engine version '%s'", b.dbInstanceIdentifier(instance), targetVersion)
+	case -1:
+		return updateSpec, fmt.Errorf("DB Instance '%s' can't be downgraded from engine version '%s' to '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.EngineVersion, targetVersion)
+	}
+
+	engine := servicePlan.RDSProperties.Engine
+	majorUpgrade := majorEngineVersion(engine, targetVersion) != majorEngineVersion(engine, dbInstanceDetails.EngineVersion)
+	if majorUpgrade {
+		if !updateParameters.AllowMajorVersionUpgrade {
+			return updateSpec, fmt.Errorf("Upgrading from engine version '%s' to '%s' is a major version upgrade and needs allow_major_version_upgrade", dbInstanceDetails.EngineVersion, targetVersion)
+		}
+		if err := b.checkMajorVersionUpgrade(instance, servicePlan); err != nil {
+			return updateSpec, err
+		}
+	}
+
+	modifyDBInstance := b.modifyDBInstance(instance, servicePlan, updateParameters, details)
+	modifyDBInstance.EngineVersion = targetVersion
+	modifyDBInstance.AllowMajorVersionUpgrade = majorUpgrade
+	if err := b.dbInstance.Modify(b.dbInstanceIdentifier(instance), *modifyDBInstance, updateParameters.ApplyImmediately); err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return updateSpec, brokerapi.ErrInstanceDoesNotExist
+		}
+		return updateSpec, err
+	}
+
+	updateSpec.OperationData = b.startOperation(instance, internaldb.UpgradeOperation, instance.PlanID, details.RawParameters, updateSpec.IsAsync, "")
+
+	return updateSpec, nil
+}
+
+// checkMajorVersionUpgrade refuses major upgrades which RDS would reject or fail part way through
+func (b *RDSBroker) checkMajorVersionUpgrade(instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	// Parameter and option groups belong to a single major version
+	if servicePlan.RDSProperties.DBParameterGroupName != "" || servicePlan.RDSProperties.OptionGroupName != "" {
+		return fmt.Errorf("Major version upgrades are not supported for plan '%s' as it has its own parameter or option group", servicePlan.Name)
+	}
+
+	engine := servicePlan.RDSProperties.Engine
+	if !isPostgres(engine) {
+		// Unlike PostgreSQL, MySQL and MariaDB read replicas have to be upgraded before their source
+		if len(instance.Replicas) > 0 {
+			return errors.New("Read replicas have to be removed with read_replica_count before a major version upgrade")
+		}
+		return nil
+	}
+
+	sqlEngine, err := b.dedicatedSqlEngine(instance, engine)
+	if err != nil {
+		return err
+	}
+	defer sqlEngine.Close()
+
+	extensions, err := sqlEngine.Extensions()
+	if err != nil {
+		return err
+	}
+	for _, extension := range extensions {
+		for _, blocking := range majorUpgradeBlockingExtensions {
+			if extension == blocking {
+				return fmt.Errorf("Extension '%s' has to be removed before a major version upgrade", extension)
+			}
+		}
+	}
+	return nil
+}
+
+// Upgrades finish once the instance is available on the new engine version. Unless the upgrade was
+// applied immediately it waits as a pending modification until the maintenance window.
+func (b *RDSBroker) upgradeLastOperation(instance *internaldb.DBInstance, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
+
+	updateParameters, err := b.updateParameters([]byte(operation.Parameters))
+	if err != nil {
+		return lastOperation, err
+	}
+	targetVersion := updateParameters.EngineVersion
+
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return lastOperation, brokerapi.ErrInstanceDoesNotExist
+		}
+		return lastOperation, err
+	}
+
+	if dbInstanceDetails.Status == "upgrading" {
+		lastOperation.State = brokerapi.InProgress
+		lastOperation.Description = fmt.Sprintf("DB Instance '%s' is upgrading from engine version '%s' to '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.EngineVersion, targetVersion)
+		return lastOperation, nil
+	}
+
+	lastOperation.Description = fmt.Sprintf("DB Instance '%s' status is '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.Status)
+	if state, ok := rdsStatus2State[dbInstanceDetails.Status]; ok {
+		lastOperation.State = state
+	}
+	if lastOperation.State != brokerapi.Succeeded {
+		return lastOperation, nil
+	}
+
+	switch {
+	case dbInstanceDetails.EngineVersion == targetVersion:
+		if err := instance.SetEngineVersion(b.internalDB, targetVersion); err != nil {
+			b.logger.Error("set-engine-version", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		}
+		lastOperation.Description = fmt.Sprintf("DB Instance '%s' has been upgraded to engine version '%s'", b.dbInstanceIdentifier(instance), targetVersion)
+	case dbInstanceDetails.PendingModifications:
+		lastOperation.State = brokerapi.InProgress
+		lastOperation.Description = fmt.Sprintf("The upgrade of DB Instance '%s' to engine version '%s' is pending", b.dbInstanceIdentifier(instance), targetVersion)
+	default:
+		// RDS rolls back upgrades which fail
+		lastOperation.State = brokerapi.Failed
+		lastOperation.Description = fmt.Sprintf("DB Instance '%s' is still on engine version '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.EngineVersion)
+	}
+
+	return lastOperation, nil
+}
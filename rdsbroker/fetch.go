@@ -0,0 +1,159 @@
+package rdsbroker
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+func (b *RDSBroker) GetInstance(context context.Context, instanceID string) (brokerapi.GetInstanceDetailsSpec, error) {
+	b.logger.Debug("get-instance", lager.Data{
+		instanceIDLogKey: instanceID,
+	})
+
+	instanceSpec := brokerapi.GetInstanceDetailsSpec{}
+
+	instance, service, servicePlan, err := b.findObjects(instanceID)
+	if err != nil {
+		return instanceSpec, err
+	}
+
+	if !service.InstancesRetrievable {
+		return instanceSpec, errors.New("Service instances are not retrievable")
+	}
+
+	parameters := InstanceParameters{Plan: servicePlan.Name}
+	if !servicePlan.RDSProperties.Shared {
+		if err = b.dedicatedInstanceParameters(&parameters, instance, servicePlan); err != nil {
+			return instanceSpec, err
+		}
+	}
+
+	// The database can only be asked for its extensions while it's up
+	if parameters.Status == "" || parameters.Status == "available" {
+		parameters.Extensions = b.instanceExtensions(instance, servicePlan)
+	}
+
+	instanceSpec.ServiceID = instance.ServiceID
+	instanceSpec.PlanID = instance.PlanID
+	instanceSpec.Parameters = parameters
+
+	return instanceSpec, nil
+}
+
+// dedicatedInstanceParameters fills in what RDS reports for the instance, or the DB cluster when it's serverless
+func (b *RDSBroker) dedicatedInstanceParameters(parameters *InstanceParameters, instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	if servicePlan.RDSProperties.serverless() {
+		dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+		if err != nil {
+			if err == awsrds.ErrDBClusterDoesNotExist {
+				return brokerapi.ErrInstanceDoesNotExist
+			}
+			return err
+		}
+		parameters.Status = dbClusterDetails.Status
+		parameters.EngineVersion = dbClusterDetails.EngineVersion
+		parameters.BackupRetentionPeriod = dbClusterDetails.BackupRetentionPeriod
+		parameters.PreferredBackupWindow = dbClusterDetails.PreferredBackupWindow
+		parameters.PreferredMaintenanceWindow = dbClusterDetails.PreferredMaintenanceWindow
+		return nil
+	}
+
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			return brokerapi.ErrInstanceDoesNotExist
+		}
+		return err
+	}
+	parameters.Status = dbInstanceDetails.Status
+	parameters.EngineVersion = dbInstanceDetails.EngineVersion
+	parameters.BackupRetentionPeriod = dbInstanceDetails.BackupRetentionPeriod
+	parameters.PreferredBackupWindow = dbInstanceDetails.PreferredBackupWindow
+	parameters.PreferredMaintenanceWindow = dbInstanceDetails.PreferredMaintenanceWindow
+	parameters.ReadReplicaCount = instance.ReadReplicaCount
+	// Aurora storage grows by itself and isn't set on the instance
+	if !isAurora(servicePlan.RDSProperties.Engine) {
+		parameters.AllocatedStorage = dbInstanceDetails.AllocatedStorage
+	}
+	return nil
+}
+
+// instanceExtensions are left out, rather than failing the request, when the database can't be reached
+func (b *RDSBroker) instanceExtensions(instance *internaldb.DBInstance, servicePlan ServicePlan) []string {
+	sqlEngine, err := b.instanceSqlEngine(instance, servicePlan)
+	if err != nil {
+		b.logger.Error("instance-extensions", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return nil
+	}
+	defer sqlEngine.Close()
+
+	extensions, err := sqlEngine.Extensions()
+	if err != nil {
+		b.logger.Error("instance-extensions", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return nil
+	}
+	return extensions
+}
+
+// GetBinding regenerates the credentials of a binding from the user recorded for it, the same as Bind
+// returned unless the password has since been rotated
+func (b *RDSBroker) GetBinding(context context.Context, instanceID, bindingID string) (brokerapi.GetBindingSpec, error) {
+	b.logger.Debug("get-binding", lager.Data{
+		instanceIDLogKey: instanceID,
+		bindingIDLogKey:  bindingID,
+	})
+
+	bindingSpec := brokerapi.GetBindingSpec{}
+
+	instance, service, servicePlan, err := b.findObjects(instanceID)
+	if err != nil {
+		return bindingSpec, err
+	}
+
+	if !service.BindingsRetrievable {
+		return bindingSpec, errors.New("Service bindings are not retrievable")
+	}
+
+	user, _ := instance.BindingUser(bindingID)
+	if user == nil {
+		return bindingSpec, brokerapi.ErrBindingDoesNotExist
+	}
+
+	userPassword, err := user.Password(b.encryptionKey)
+	if err != nil {
+		return bindingSpec, err
+	}
+
+	sqlEngine, closeEngine, err := b.bindingSqlEngine(instance, servicePlan, user.Type)
+	if err != nil {
+		return bindingSpec, err
+	}
+	if closeEngine {
+		defer sqlEngine.Close()
+	}
+
+	credentials, err := b.bindingCredentials(sqlEngine, instance, service, servicePlan, *user, userPassword)
+	if err != nil {
+		return bindingSpec, err
+	}
+	bindingSpec.Credentials = credentials
+
+	return bindingSpec, nil
+}
+
+// Bindings are always created synchronously so there's never an operation to ask about
+func (b *RDSBroker) LastBindingOperation(context context.Context, instanceID, bindingID string, details brokerapi.PollDetails) (brokerapi.LastOperation, error) {
+	b.logger.Debug("last-binding-operation", lager.Data{
+		instanceIDLogKey:    instanceID,
+		bindingIDLogKey:     bindingID,
+		operationDataLogKey: details.OperationData,
+	})
+
+	return brokerapi.LastOperation{State: brokerapi.Failed}, errors.New("Bindings don't have asynchronous operations")
+}
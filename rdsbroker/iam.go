@@ -0,0 +1,44 @@
+package rdsbroker
+
+import (
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// iamCredentials swaps the password of a binding for what apps need to sign IAM authentication tokens,
+// which they use as the password of each connection. The URIs go with the password.
+func (b *RDSBroker) iamCredentials(credentials *CredentialsHash, instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	resourceID, err := b.iamResourceID(instance, servicePlan)
+	if err != nil {
+		return err
+	}
+
+	credentials.Password = ""
+	credentials.URI = ""
+	credentials.JDBCURI = ""
+	credentials.ReadOnlyURI = ""
+	credentials.IAMAuthentication = true
+	credentials.Region = b.region
+	credentials.ResourceID = resourceID
+	return nil
+}
+
+// iamResourceID identifies what the rds-db:connect permission of an app has to cover, the DB cluster of
+// Aurora instances and the DB instance otherwise
+func (b *RDSBroker) iamResourceID(instance *internaldb.DBInstance, servicePlan ServicePlan) (string, error) {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+		return dbClusterDetails.DbClusterResourceID, err
+	}
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	return dbInstanceDetails.DbiResourceID, err
+}
+
+// hasIAMUsers is true if any binding of the instance logs in with IAM
+func hasIAMUsers(instance *internaldb.DBInstance) bool {
+	for _, user := range instance.Users {
+		if user.Type == internaldb.IAM {
+			return true
+		}
+	}
+	return false
+}
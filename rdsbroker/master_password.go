@@ -0,0 +1,248 @@
+package rdsbroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+const rotateMasterPasswordStage = "rotate-master-password"
+
+// RDS resets master passwords within minutes so rotations are finished this often
+const masterPasswordFinishInterval = time.Minute
+
+// RotateMasterPassword gives the master user of a dedicated instance a new password and returns the
+// operation tracking it. RDS resets the password in the background so the broker keeps using the old
+// one until the new one works, see masterPasswordLastOperation.
+func (b *RDSBroker) RotateMasterPassword(instanceID string) (string, error) {
+	b.logger.Debug("rotate-master-password", lager.Data{instanceIDLogKey: instanceID})
+
+	instance, _, servicePlan, err := b.findObjects(instanceID)
+	if err != nil {
+		return "", err
+	}
+	return b.rotateMasterPassword(instance, servicePlan, []byte(`{"rotate_master_password":true}`))
+}
+
+// rotateMasterPasswordUpdate handles an update that only rotates the master password
+func (b *RDSBroker) rotateMasterPasswordUpdate(instance *internaldb.DBInstance, servicePlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) (brokerapi.UpdateServiceSpec, error) {
+	updateSpec := brokerapi.UpdateServiceSpec{IsAsync: true}
+
+	others := updateParameters
+	others.RotateMasterPassword = false
+	if (details.PlanID != "" && details.PlanID != instance.PlanID) || others.modifies() || others.CreateSnapshot != "" || others.RotateBindingCredentials != nil {
+		return updateSpec, errors.New("rotate_master_password can't be combined with other changes")
+	}
+
+	operationData, err := b.rotateMasterPassword(instance, servicePlan, details.RawParameters)
+	if err != nil {
+		return updateSpec, err
+	}
+	updateSpec.OperationData = operationData
+
+	return updateSpec, nil
+}
+
+func (b *RDSBroker) rotateMasterPassword(instance *internaldb.DBInstance, servicePlan ServicePlan, parameters []byte) (string, error) {
+	if servicePlan.RDSProperties.Shared {
+		return "", errors.New("Master password rotation is not supported for shared plans")
+	}
+
+	masterUser := instance.MasterUser()
+	if masterUser == nil {
+		return "", errors.New("Failed to find master user")
+	}
+
+	status, _, err := b.masterPasswordStatus(instance, servicePlan)
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist || err == awsrds.ErrDBClusterDoesNotExist {
+			return "", brokerapi.ErrInstanceDoesNotExist
+		}
+		return "", err
+	}
+	if status != "available" {
+		return "", fmt.Errorf("%s must be available to rotate its master password, its status is '%s'", b.masterPasswordResource(instance, servicePlan), status)
+	}
+
+	// A rotation which never finished is replaced, RDS doesn't need the current password to reset it
+	password, err := masterUser.SetPendingPassword(b.internalDB, b.encryptionKey)
+	if err != nil {
+		return "", errors.New("Failed to save reference to local database")
+	}
+	if err = b.setMasterPassword(instance, servicePlan, password); err != nil {
+		// RDS hasn't taken the new password so the old one is still the one to use
+		if err := masterUser.ClearPendingPassword(b.internalDB); err != nil {
+			b.logger.Error("clear-pending-password", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		}
+		return "", err
+	}
+
+	return b.startOperation(instance, internaldb.UpdateOperation, instance.PlanID, parameters, true, rotateMasterPasswordStage), nil
+}
+
+// masterPasswordLastOperation waits for RDS to finish resetting the master password and for the new one
+// to work before recording it. Should the reset fail the old password is kept.
+func (b *RDSBroker) masterPasswordLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
+	resource := b.masterPasswordResource(instance, servicePlan)
+
+	masterUser := instance.MasterUser()
+	if masterUser == nil {
+		return lastOperation, errors.New("Failed to find master user")
+	}
+	password, err := masterUser.PendingPassword(b.encryptionKey)
+	if err != nil {
+		return lastOperation, err
+	}
+	if password == "" {
+		// Someone else asking about the same operation got there first
+		if masterUser.PasswordRotatedAt != nil && masterUser.PasswordRotatedAt.After(operation.StartedAt) {
+			lastOperation.State = brokerapi.Succeeded
+			lastOperation.Description = fmt.Sprintf("The master password of %s has been rotated", resource)
+		} else {
+			lastOperation.Description = fmt.Sprintf("The master password of %s is no longer being rotated", resource)
+		}
+		return lastOperation, nil
+	}
+
+	status, pendingModifications, err := b.masterPasswordStatus(instance, servicePlan)
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist || err == awsrds.ErrDBClusterDoesNotExist {
+			return lastOperation, brokerapi.ErrInstanceDoesNotExist
+		}
+		return lastOperation, err
+	}
+
+	lastOperation.Description = fmt.Sprintf("%s status is '%s'", resource, status)
+	if state, ok := rdsStatus2State[status]; ok {
+		lastOperation.State = state
+	}
+	switch {
+	case lastOperation.State == brokerapi.Failed:
+		if err := masterUser.ClearPendingPassword(b.internalDB); err != nil {
+			b.logger.Error("clear-pending-password", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		}
+		return lastOperation, nil
+	case lastOperation.State == brokerapi.InProgress:
+		return lastOperation, nil
+	case pendingModifications:
+		lastOperation.State = brokerapi.InProgress
+		lastOperation.Description = fmt.Sprintf("%s has pending modifications", resource)
+		return lastOperation, nil
+	}
+
+	// RDS can report the instance as available before it has started resetting the password. Opening
+	// doesn't connect so the new password is only known to work once a ping gets through.
+	sqlEngine, err := b.masterSqlEngine(instance, servicePlan.RDSProperties.Engine, password)
+	if err == nil {
+		err = sqlEngine.Ping()
+		sqlEngine.Close()
+	}
+	if err != nil {
+		b.logger.Info("new-master-password-not-accepted", lager.Data{instanceIDLogKey: instance.InstanceID, "error": err.Error()})
+		lastOperation.State = brokerapi.InProgress
+		lastOperation.Description = fmt.Sprintf("Waiting for %s to accept the new master password", resource)
+		return lastOperation, nil
+	}
+
+	if err = masterUser.CommitPendingPassword(b.internalDB); err != nil {
+		return brokerapi.LastOperation{State: brokerapi.InProgress}, errors.New("Failed to save reference to local database")
+	}
+
+	lastOperation.Description = fmt.Sprintf("The master password of %s has been rotated", resource)
+	return lastOperation, nil
+}
+
+// masterPasswordStatus describes whatever holds the master user, the DB cluster of Aurora instances
+func (b *RDSBroker) masterPasswordStatus(instance *internaldb.DBInstance, servicePlan ServicePlan) (status string, pendingModifications bool, err error) {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		dbClusterDetails, err := b.dbCluster.Describe(b.dbClusterIdentifier(instance))
+		return dbClusterDetails.Status, false, err
+	}
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	return dbInstanceDetails.Status, dbInstanceDetails.PendingModifications, err
+}
+
+func (b *RDSBroker) masterPasswordResource(instance *internaldb.DBInstance, servicePlan ServicePlan) string {
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		return fmt.Sprintf("DB Cluster '%s'", b.dbClusterIdentifier(instance))
+	}
+	return fmt.Sprintf("DB Instance '%s'", b.dbInstanceIdentifier(instance))
+}
+
+// FinishMasterPasswordRotations moves on the master password rotations which weren't started by the cloud
+// controller, which would otherwise ask about them through LastOperation
+func (b *RDSBroker) FinishMasterPasswordRotations() error {
+	operations, err := internaldb.InProgressOperations(b.internalDB, rotateMasterPasswordStage)
+	if err != nil {
+		return err
+	}
+	for instanceID, operation := range operations {
+		lastOperation, err := b.LastOperation(context.Background(), instanceID, brokerapi.PollDetails{OperationData: operation.OperationData()})
+		if err != nil {
+			b.logger.Error("finish-master-password-rotation", err, lager.Data{instanceIDLogKey: instanceID})
+			continue
+		}
+		b.logger.Info("finish-master-password-rotation", lager.Data{instanceIDLogKey: instanceID, "state": lastOperation.State})
+	}
+	return nil
+}
+
+// RotateMasterPasswords starts rotating the master passwords older than the maximum age and returns the IDs
+// of the instances being rotated. Instances which can't be rotated are logged and skipped.
+func (b *RDSBroker) RotateMasterPasswords(now time.Time) ([]string, error) {
+	rotated := []string{}
+	if b.masterPasswordRotation.MaxAgeDays == 0 {
+		return rotated, nil
+	}
+
+	instances, err := internaldb.MasterPasswordsRotatedBefore(b.internalDB, now.AddDate(0, 0, -b.masterPasswordRotation.MaxAgeDays))
+	if err != nil {
+		return rotated, err
+	}
+	for _, instance := range instances {
+		servicePlan, ok := b.catalog.FindServicePlan(instance.ServiceID, instance.PlanID)
+		if !ok || servicePlan.RDSProperties.Shared || !instance.IsActive() {
+			continue
+		}
+		if _, err := b.RotateMasterPassword(instance.InstanceID); err != nil {
+			b.logger.Error("rotate-master-password", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+			continue
+		}
+		rotated = append(rotated, instance.InstanceID)
+	}
+	return rotated, nil
+}
+
+// RunMasterPasswordRotation starts new rotations every interval and finishes the ones in progress every
+// masterPasswordFinishInterval, as RDS has the new password well before the next rotation is due. It never
+// returns so should be run in its own goroutine.
+func (b *RDSBroker) RunMasterPasswordRotation(interval time.Duration) {
+	rotateTicker := time.NewTicker(interval)
+	defer rotateTicker.Stop()
+	finishTicker := time.NewTicker(masterPasswordFinishInterval)
+	defer finishTicker.Stop()
+
+	for {
+		select {
+		case <-finishTicker.C:
+			if err := b.FinishMasterPasswordRotations(); err != nil {
+				b.logger.Error("master-password-rotation", err)
+			}
+		case now := <-rotateTicker.C:
+			rotated, err := b.RotateMasterPasswords(now)
+			if err != nil {
+				b.logger.Error("master-password-rotation", err)
+				continue
+			}
+			b.logger.Info("master-password-rotation", lager.Data{"rotated": rotated})
+		}
+	}
+}
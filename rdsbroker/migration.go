@@ -0,0 +1,394 @@
+package rdsbroker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+	"github.com/AusDTO/pe-rds-broker/sqlengine"
+)
+
+const migrateToDedicatedStage = "migrate-to-dedicated"
+const copyQueuedStage = "copy-queued"
+const copyDatabaseStage = "copy-database"
+const copyFailedStage = "copy-failed"
+const dropSharedDatabaseStage = "drop-shared-database"
+const copyTimedOutStage = "copy-timed-out"
+
+// copyDatabaseTimeout is how long a copy can take before the request making it is taken to have died with
+// the broker. Shared databases are small so it's generous.
+const copyDatabaseTimeout = 2 * time.Hour
+
+// Queued copies are started this often, see RunSharedDatabaseCopies
+const copySharedDatabasesInterval = 30 * time.Second
+
+// migrateToDedicated moves an instance from a shared plan to a dedicated one. The DB instance is created
+// here and the database copied across once it's available, see migrationLastOperation. The instance stays
+// on its shared plan until the copy has succeeded and the shared database has gone.
+func (b *RDSBroker) migrateToDedicated(instance *internaldb.DBInstance, newPlan ServicePlan, updateParameters UpdateParameters, details brokerapi.UpdateDetails) (brokerapi.UpdateServiceSpec, error) {
+	updateSpec := brokerapi.UpdateServiceSpec{IsAsync: true}
+
+	if updateParameters.modifies() {
+		return updateSpec, errors.New("Moving to a dedicated plan can't be combined with other changes")
+	}
+
+	// Don't create a DB instance for a database which can't be copied into it
+	source, err := b.sharedSqlEngine(instance, newPlan.RDSProperties.Engine)
+	if err != nil {
+		return updateSpec, err
+	}
+	err = checkCopyable(source, instance.DBName)
+	source.Close()
+	if err != nil {
+		return updateSpec, err
+	}
+
+	provisionDetails := brokerapi.ProvisionDetails{
+		ServiceID:        instance.ServiceID,
+		PlanID:           newPlan.ID,
+		OrganizationGUID: instance.OrganizationID,
+		SpaceGUID:        instance.SpaceID,
+	}
+
+	rollback := newSaga(b.logger.Session("migrate-to-dedicated", lager.Data{instanceIDLogKey: instance.InstanceID}))
+	if err := b.createDedicatedResources(instance, newPlan, ProvisionParameters{}, provisionDetails, restoreSource{}, rollback); err != nil {
+		rollback.run()
+		return updateSpec, err
+	}
+
+	// The replicas are created once the database has been copied, see updateLastOperation
+	if newPlan.RDSProperties.ReadReplicaCount != instance.ReadReplicaCount {
+		if err := instance.SetReadReplicaCount(b.internalDB, newPlan.RDSProperties.ReadReplicaCount); err != nil {
+			rollback.run()
+			return updateSpec, errors.New("Failed to save reference to local database")
+		}
+	}
+
+	updateSpec.OperationData = b.startOperation(instance, internaldb.UpdateOperation, newPlan.ID, details.RawParameters, updateSpec.IsAsync, migrateToDedicatedStage)
+
+	return updateSpec, nil
+}
+
+// migrationLastOperation waits for the new DB instance then queues the copy of the shared database and its
+// binding users into it, which CopySharedDatabases makes in the background. Only once that has worked does
+// the shared database go, on the following call, and the instance move to its new plan. From then on the
+// update carries on like any other, waiting for the read replicas of the new plan.
+func (b *RDSBroker) migrationLastOperation(instance *internaldb.DBInstance, sharedPlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{State: brokerapi.Failed}
+
+	newPlan, ok := b.catalog.FindServicePlan(instance.ServiceID, operation.PlanID)
+	if !ok {
+		return lastOperation, fmt.Errorf("Service Plan '%s' not found", operation.PlanID)
+	}
+
+	switch operation.Stage {
+	case copyQueuedStage:
+		return b.copyQueuedLastOperation(instance), nil
+	case copyDatabaseStage, copyFailedStage:
+		return b.copyingLastOperation(instance, sharedPlan, operation)
+	case copyTimedOutStage:
+		lastOperation.Description = b.copyTimedOutDescription(instance)
+		return lastOperation, nil
+	case dropSharedDatabaseStage:
+		return b.dropSharedDBLastOperation(instance, sharedPlan, newPlan, operation)
+	}
+
+	// The instance still has its shared database so unlike dbInstanceLastOperation a missing DB
+	// instance doesn't remove the local reference
+	dbInstanceDetails, err := b.dbInstance.Describe(b.dbInstanceIdentifier(instance))
+	if err != nil {
+		if err == awsrds.ErrDBInstanceDoesNotExist {
+			lastOperation.Description = fmt.Sprintf("DB Instance '%s' has gone", b.dbInstanceIdentifier(instance))
+			return lastOperation, nil
+		}
+		return lastOperation, err
+	}
+
+	lastOperation.Description = fmt.Sprintf("DB Instance '%s' status is '%s'", b.dbInstanceIdentifier(instance), dbInstanceDetails.Status)
+	if state, ok := rdsStatus2State[dbInstanceDetails.Status]; ok {
+		lastOperation.State = state
+	}
+	if lastOperation.State != brokerapi.Succeeded {
+		return lastOperation, nil
+	}
+
+	// A concurrent request may have queued it first, which is just as good
+	if _, err = operation.ClaimStage(b.internalDB, migrateToDedicatedStage, copyQueuedStage); err != nil {
+		return lastOperation, err
+	}
+
+	return b.copyQueuedLastOperation(instance), nil
+}
+
+func (b *RDSBroker) copyQueuedLastOperation(instance *internaldb.DBInstance) brokerapi.LastOperation {
+	return brokerapi.LastOperation{
+		State:       brokerapi.InProgress,
+		Description: fmt.Sprintf("Waiting to copy database '%s' to DB Instance '%s'", instance.DBName, b.dbInstanceIdentifier(instance)),
+	}
+}
+
+// CopySharedDatabases makes the copies queued by migrationLastOperation. Copies can take far longer than the
+// cloud controller waits for a LastOperation request, so they're made here and only reported on there.
+// Copies which fail are logged and fail their update.
+func (b *RDSBroker) CopySharedDatabases() error {
+	operations, err := internaldb.InProgressOperations(b.internalDB, copyQueuedStage)
+	if err != nil {
+		return err
+	}
+	for instanceID, operation := range operations {
+		operation := operation
+		if err := b.copyQueuedDB(instanceID, &operation); err != nil {
+			b.logger.Error("copy-shared-databases", err, lager.Data{instanceIDLogKey: instanceID})
+		}
+	}
+	return nil
+}
+
+// RunSharedDatabaseCopies makes queued copies every copySharedDatabasesInterval, for ever
+func (b *RDSBroker) RunSharedDatabaseCopies() {
+	ticker := time.NewTicker(copySharedDatabasesInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := b.CopySharedDatabases(); err != nil {
+			b.logger.Error("copy-shared-databases", err)
+		}
+	}
+}
+
+func (b *RDSBroker) copyQueuedDB(instanceID string, operation *internaldb.DBOperation) error {
+	instance, _, sharedPlan, err := b.findObjects(instanceID)
+	if err != nil {
+		return err
+	}
+	newPlan, ok := b.catalog.FindServicePlan(instance.ServiceID, operation.PlanID)
+	if !ok {
+		return fmt.Errorf("Service Plan '%s' not found", operation.PlanID)
+	}
+
+	// Every broker sharing the internal database copies, so they mustn't both take the same one
+	claimed, err := operation.ClaimStage(b.internalDB, copyQueuedStage, copyDatabaseStage)
+	if err != nil || !claimed {
+		return err
+	}
+
+	copyErr := b.copySharedDB(instance, sharedPlan, newPlan)
+
+	// The copy may have taken long enough to have been given up on, in which case the new instance is going
+	nextStage := dropSharedDatabaseStage
+	if copyErr != nil {
+		nextStage = copyFailedStage
+	}
+	claimed, err = operation.ClaimStage(b.internalDB, copyDatabaseStage, nextStage)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return fmt.Errorf("Copy of database '%s' finished after it had timed out", instance.DBName)
+	}
+	if copyErr == nil {
+		return nil
+	}
+
+	b.abandonMigration(instance, sharedPlan)
+	description := fmt.Sprintf("Failed to copy database '%s' to DB Instance '%s': %s", instance.DBName, b.dbInstanceIdentifier(instance), copyErr)
+	if err = operation.Fail(b.internalDB, description); err != nil {
+		return err
+	}
+	return copyErr
+}
+
+// copyingLastOperation reports on a copy being made by CopySharedDatabases. A copy which has gone on for too
+// long is taken to have died with the broker and the update fails, as the DB instance may have part of a copy.
+func (b *RDSBroker) copyingLastOperation(instance *internaldb.DBInstance, sharedPlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	lastOperation := brokerapi.LastOperation{
+		State:       brokerapi.InProgress,
+		Description: fmt.Sprintf("Copying database '%s' to DB Instance '%s'", instance.DBName, b.dbInstanceIdentifier(instance)),
+	}
+
+	// A failed copy is moments from failing the update
+	if operation.Stage == copyFailedStage || operation.StageStartedAt != nil && time.Since(*operation.StageStartedAt) < copyDatabaseTimeout {
+		return lastOperation, nil
+	}
+
+	claimed, err := operation.ClaimStage(b.internalDB, copyDatabaseStage, copyTimedOutStage)
+	if err != nil || !claimed {
+		// Whoever got there first reports on it next time
+		return lastOperation, err
+	}
+
+	b.abandonMigration(instance, sharedPlan)
+	return brokerapi.LastOperation{State: brokerapi.Failed, Description: b.copyTimedOutDescription(instance)}, nil
+}
+
+func (b *RDSBroker) copyTimedOutDescription(instance *internaldb.DBInstance) string {
+	return fmt.Sprintf("Copying database '%s' to DB Instance '%s' took longer than %s", instance.DBName, b.dbInstanceIdentifier(instance), copyDatabaseTimeout)
+}
+
+// abandonMigration puts an instance back how it was on its shared plan after the copy has failed. Nothing
+// but the copy has been written to the new DB instance so it's deleted.
+func (b *RDSBroker) abandonMigration(instance *internaldb.DBInstance, sharedPlan ServicePlan) {
+	b.unlockSharedUsers(instance, sharedPlan)
+	if err := b.dbInstance.Delete(b.dbInstanceIdentifier(instance), true); err != nil {
+		b.logger.Error("delete-db-instance", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+	if err := instance.SetReadReplicaCount(b.internalDB, sharedPlan.RDSProperties.ReadReplicaCount); err != nil {
+		b.logger.Error("set-read-replica-count", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+}
+
+// dropSharedDBLastOperation removes the shared database once it has been copied then moves the instance to
+// its new plan. Failing to drop the database is retried on the next call.
+func (b *RDSBroker) dropSharedDBLastOperation(instance *internaldb.DBInstance, sharedPlan ServicePlan, newPlan ServicePlan, operation *internaldb.DBOperation) (brokerapi.LastOperation, error) {
+	if err := b.dropSharedDB(instance, sharedPlan); err != nil {
+		b.logger.Error("drop-shared-db", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		return brokerapi.LastOperation{
+			State:       brokerapi.InProgress,
+			Description: fmt.Sprintf("Failed to drop shared database '%s', will retry: %s", instance.DBName, err),
+		}, nil
+	}
+
+	b.updateInstancePlan(instance, newPlan.ID)
+
+	if err := operation.SetStage(b.internalDB, ""); err != nil {
+		b.logger.Error("set-operation-stage", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+	}
+
+	return brokerapi.LastOperation{
+		State:       brokerapi.InProgress,
+		Description: fmt.Sprintf("Dropped shared database '%s'", instance.DBName),
+	}, nil
+}
+
+// copySharedDB recreates the binding users of an instance on its new DB instance, keeping their
+// credentials so existing bindings only need the new host, then copies the database itself. Privileges
+// are granted once the tables are there for read only users to be given them. The binding users are
+// locked out of the shared database first so nothing written to it is left behind.
+func (b *RDSBroker) copySharedDB(instance *internaldb.DBInstance, sharedPlan ServicePlan, newPlan ServicePlan) error {
+	engine := sharedPlan.RDSProperties.Engine
+
+	source, err := b.sharedSqlEngine(instance, engine)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := b.dedicatedSqlEngine(instance, engine)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	if err = b.lockSharedUsers(instance, sharedPlan); err != nil {
+		return err
+	}
+
+	// The owner role has to exist for the copied objects to keep their owner
+	if owner := target.OwnerRole(instance.InstanceID); owner != "" {
+		if err = target.CreateOwnerRole(instance.DBName, owner); err != nil {
+			return err
+		}
+	}
+
+	users := bindingUsers(instance)
+	for _, user := range users {
+		if user.Type == internaldb.IAM {
+			err = target.CreateIAMUser(user.Username)
+		} else {
+			var password string
+			if password, err = user.Password(b.encryptionKey); err == nil {
+				err = target.CreateUser(user.Username, password, newPlan.RDSProperties.RequireTLS)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = checkCopyable(source, instance.DBName); err != nil {
+		return err
+	}
+	if err = source.CopyTo(target); err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err = b.grantPrivileges(target, instance, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockSharedUsers stops the binding users of an instance using its shared database. They stay locked out
+// until they're dropped with the database, or unlocked if the copy fails.
+func (b *RDSBroker) lockSharedUsers(instance *internaldb.DBInstance, sharedPlan ServicePlan) error {
+	sqlEngine := b.sharedEngines[sharedPlan.RDSProperties.Engine]
+
+	for _, user := range bindingUsers(instance) {
+		if err := sqlEngine.LockUser(user.Username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlockSharedUsers undoes lockSharedUsers, logging failures as the users may not all have been locked
+func (b *RDSBroker) unlockSharedUsers(instance *internaldb.DBInstance, sharedPlan ServicePlan) {
+	sqlEngine := b.sharedEngines[sharedPlan.RDSProperties.Engine]
+
+	for _, user := range bindingUsers(instance) {
+		if err := sqlEngine.UnlockUser(user.Username); err != nil {
+			b.logger.Error("unlock-user", err, lager.Data{"username": user.Username})
+		}
+	}
+}
+
+// checkCopyable fails when the database has objects which CopyTo would leave behind
+func checkCopyable(source sqlengine.SQLEngine, dbName string) error {
+	objects, err := source.UnsupportedObjects()
+	if err != nil {
+		return err
+	}
+	if len(objects) > 0 {
+		return fmt.Errorf("Database '%s' can't be copied as it has: %s", dbName, strings.Join(objects, ", "))
+	}
+	return nil
+}
+
+// dropSharedDB removes what an instance had on its shared server once it has been copied. Only failing to
+// drop the database is returned, leftover users are logged.
+func (b *RDSBroker) dropSharedDB(instance *internaldb.DBInstance, sharedPlan ServicePlan) error {
+	sqlEngine := b.sharedEngines[sharedPlan.RDSProperties.Engine]
+
+	for _, user := range bindingUsers(instance) {
+		// Read only privileges go with the database or the user
+		if user.Type != internaldb.ReadOnly {
+			if err := b.revokePrivileges(sqlEngine, instance, user); err != nil {
+				b.logger.Error("revoke-privileges", err, lager.Data{"username": user.Username})
+			}
+		}
+		if err := sqlEngine.DropUser(user.Username); err != nil {
+			b.logger.Error("drop-user", err, lager.Data{"username": user.Username})
+		}
+	}
+
+	return sqlEngine.DropDB(instance.DBName)
+}
+
+// bindingUsers are the users of an instance which apps bind with
+func bindingUsers(instance *internaldb.DBInstance) []internaldb.DBUser {
+	var users []internaldb.DBUser
+	for _, user := range instance.Users {
+		if user.Type == internaldb.Standard || user.Type == internaldb.ReadOnly || user.Type == internaldb.IAM {
+			users = append(users, user)
+		}
+	}
+	return users
+}
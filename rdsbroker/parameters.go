@@ -1,5 +1,14 @@
 package rdsbroker
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
 /* Currently the provision parameters are a json.RawMessage in brokerapi
  * while the update and bind parameters are a map[string]interface{}
  * There is some interest in changing everything to json.RawMessage
@@ -10,18 +19,114 @@ type ProvisionParameters struct {
 	CharacterSetName           string `json:"character_set_name"`
 	PreferredBackupWindow      string `json:"preferred_backup_window"`
 	PreferredMaintenanceWindow string `json:"preferred_maintenance_window"`
+	RestoreFromSnapshot        string `json:"restore_from_snapshot"`
+	RestoreFromInstance        string `json:"restore_from_instance"`
+	RestoreTime                string `json:"restore_time"`
+	CloneFrom                  string `json:"clone_from"`
+	ReadReplicaCount           *int64 `json:"read_replica_count"`
+}
+
+const latestRestorableTime = "latest"
+
+func (p ProvisionParameters) restoring() bool {
+	return p.RestoreFromSnapshot != "" || p.RestoreFromInstance != "" || p.CloneFrom != ""
+}
+
+// pointInTime returns the time to restore to with the zero time meaning the latest restorable time
+func (p ProvisionParameters) pointInTime() (time.Time, error) {
+	switch p.RestoreTime {
+	case "":
+		return time.Time{}, errors.New("restore_time is required when restoring from an instance")
+	case latestRestorableTime:
+		return time.Time{}, nil
+	}
+	restoreTime, err := time.Parse(time.RFC3339, p.RestoreTime)
+	if err != nil {
+		return restoreTime, errors.New("restore_time must be 'latest' or an RFC3339 timestamp")
+	}
+	return restoreTime, nil
 }
 
 type UpdateParameters struct {
-	ApplyImmediately           bool      `json:"apply_immediately"`
-	BackupRetentionPeriod      int64     `json:"backup_retention_period"`
-	PreferredBackupWindow      string    `json:"preferred_backup_window"`
-	PreferredMaintenanceWindow string    `json:"preferred_maintenance_window"`
-	Extensions                 *[]string `json:"extensions"`
+	ApplyImmediately           bool              `json:"apply_immediately"`
+	BackupRetentionPeriod      int64             `json:"backup_retention_period"`
+	PreferredBackupWindow      string            `json:"preferred_backup_window"`
+	PreferredMaintenanceWindow string            `json:"preferred_maintenance_window"`
+	Extensions                 *[]string         `json:"extensions"`
+	CreateSnapshot             string            `json:"create_snapshot"`
+	ReadReplicaCount           *int64            `json:"read_replica_count"`
+	EngineVersion              string            `json:"engine_version"`
+	AllowMajorVersionUpgrade   bool              `json:"allow_major_version_upgrade"`
+	AllocatedStorage           int64             `json:"allocated_storage"`
+	RotateBindingCredentials   *BindingSelection `json:"rotate_binding_credentials"`
+	RotateMasterPassword       bool              `json:"rotate_master_password"`
+}
+
+const allBindings = "all"
+
+// BindingSelection is either every binding of an instance, given as "all", or a list of binding IDs
+type BindingSelection struct {
+	All        bool
+	BindingIDs []string
+}
+
+func (s *BindingSelection) UnmarshalJSON(data []byte) error {
+	var all string
+	if err := json.Unmarshal(data, &all); err == nil && all == allBindings {
+		s.All = true
+		return nil
+	}
+	if err := json.Unmarshal(data, &s.BindingIDs); err != nil || len(s.BindingIDs) == 0 {
+		return fmt.Errorf("rotate_binding_credentials must be '%s' or a list of binding IDs", allBindings)
+	}
+	return nil
+}
+
+// modifies is true if any of the parameters change the instance itself, which can't be done in the
+// same request as creating a snapshot
+func (p UpdateParameters) modifies() bool {
+	return p.ApplyImmediately || p.BackupRetentionPeriod > 0 || p.PreferredBackupWindow != "" || p.PreferredMaintenanceWindow != "" || p.Extensions != nil || p.ReadReplicaCount != nil || p.EngineVersion != "" || p.AllocatedStorage > 0
+}
+
+// InstanceParameters are returned when an instance is fetched, the plan it's on and how RDS has it set up.
+// Shared instances don't have their own RDS instance so only have the plan and extensions.
+type InstanceParameters struct {
+	Plan                       string   `json:"plan"`
+	Status                     string   `json:"status,omitempty"`
+	EngineVersion              string   `json:"engine_version,omitempty"`
+	Extensions                 []string `json:"extensions,omitempty"`
+	AllocatedStorage           int64    `json:"allocated_storage,omitempty"`
+	BackupRetentionPeriod      int64    `json:"backup_retention_period,omitempty"`
+	PreferredBackupWindow      string   `json:"preferred_backup_window,omitempty"`
+	PreferredMaintenanceWindow string   `json:"preferred_maintenance_window,omitempty"`
+	ReadReplicaCount           int64    `json:"read_replica_count,omitempty"`
 }
 
 type BindParameters struct {
-	Username string `json:"username"`
+	Username          string `json:"username"`
+	Role              string `json:"role"`
+	IAMAuthentication bool   `json:"iam_authentication"`
+}
+
+const readOnlyRole = "read_only"
+
+// userType is the kind of user the binding gets, full access unless it asks for the read only role
+// or to log in with IAM
+func (p BindParameters) userType() (internaldb.DBUserType, error) {
+	if p.IAMAuthentication {
+		if p.Role != "" {
+			return "", errors.New("iam_authentication can't be combined with role")
+		}
+		return internaldb.IAM, nil
+	}
+
+	switch p.Role {
+	case "":
+		return internaldb.Standard, nil
+	case readOnlyRole:
+		return internaldb.ReadOnly, nil
+	}
+	return "", fmt.Errorf("role must be '%s'", readOnlyRole)
 }
 
 type CredentialsHash struct {
@@ -33,7 +138,52 @@ type CredentialsHash struct {
 	URI      string `json:"uri,omitempty"`
 	JDBCURI  string `json:"jdbcUrl,omitempty"`
 
+	// PEM encoded CA certificates to verify the server certificate against, when the URIs require it
+	CACertificate string `json:"ca_certificate,omitempty"`
+
+	// Set when the user can only read the database
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// Set for users which log in with an IAM authentication token instead of a password. Tokens are signed
+	// for the region and resource ID of the DB instance or cluster.
+	IAMAuthentication bool   `json:"iam_authentication,omitempty"`
+	Region            string `json:"region,omitempty"`
+	ResourceID        string `json:"resource_id,omitempty"`
+
+	// Read replicas of dedicated instances, or the reader endpoint of Aurora clusters
+	ReplicaHosts []string `json:"replica_hosts,omitempty"`
+	ReadOnlyURI  string   `json:"read_only_uri,omitempty"`
+
+	// Aurora clusters balance read only connections across their replicas
+	ReaderEndpoint string `json:"reader_endpoint,omitempty"`
+
 	// Some apps expect these alternate names, I'm looking at you Stratos: https://github.com/cloudfoundry-incubator/stratos/blob/v2-master/deploy/cloud-foundry/db-migration/README.md#note-on-service-bindings
 	Hostname string `json:"hostname,omitempty"`
 	DBName   string `json:"dbname,omitempty"`
+
+	// Rendered from the credential templates of the service and sent alongside the other credentials
+	Templated map[string]string `json:"-"`
+}
+
+func (c CredentialsHash) MarshalJSON() ([]byte, error) {
+	// A type without this method to marshal the fields as usual
+	type credentialsHash CredentialsHash
+	data, err := json.Marshal(credentialsHash(c))
+	if err != nil || len(c.Templated) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range c.Templated {
+		if _, ok := fields[name]; ok {
+			continue
+		}
+		if fields[name], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
 }
@@ -0,0 +1,101 @@
+package rdsbroker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// Instances are saved as pending and activated within the Provision request, so one which has been
+// pending for longer than this was left behind by a broker which died part way through
+const abandonedProvisionAge = 10 * time.Minute
+
+// abandoned is whether instance is pending with no Provision request still working on it
+func abandoned(instance *internaldb.DBInstance) bool {
+	return !instance.IsActive() && time.Since(instance.CreatedAt) > abandonedProvisionAge
+}
+
+// deleteAbandoned deletes whatever the abandoned provision of instance got as far as creating. It returns
+// whether RDS is still deleting any of it, in which case the identifiers can't be used again yet.
+func (b *RDSBroker) deleteAbandoned(instance *internaldb.DBInstance) (bool, error) {
+	servicePlan, ok := b.catalog.FindServicePlan(instance.ServiceID, instance.PlanID)
+	if !ok {
+		return false, fmt.Errorf("Service Plan '%s' not found", instance.PlanID)
+	}
+
+	engine := servicePlan.RDSProperties.Engine
+	if servicePlan.RDSProperties.Shared {
+		return false, b.sharedEngines[engine].DropDB(instance.DBName)
+	}
+
+	// Nothing was ever written to them so there's no point keeping final snapshots
+	deleting := false
+	if !servicePlan.RDSProperties.serverless() {
+		if isAurora(engine) {
+			if err := b.deleteClusterInstances(instance); err != nil {
+				return false, err
+			}
+		}
+		err := b.dbInstance.Delete(b.dbInstanceIdentifier(instance), true)
+		if err != nil && err != awsrds.ErrDBInstanceDoesNotExist {
+			return false, err
+		}
+		deleting = err == nil
+	}
+
+	if isAurora(engine) {
+		err := b.dbCluster.Delete(b.dbClusterIdentifier(instance), true)
+		switch {
+		case err == nil:
+			deleting = true
+		case err == awsrds.ErrDBClusterDoesNotExist:
+		case deleting:
+			// The DB Cluster can't be deleted until its DB Instances have gone, which a later attempt sees to
+		default:
+			return false, err
+		}
+	}
+
+	return deleting, nil
+}
+
+// takeOverPending lets Provision start again on an instance whose earlier provision was abandoned
+func (b *RDSBroker) takeOverPending(instance *internaldb.DBInstance) error {
+	if !abandoned(instance) {
+		return errors.New("Instance is already being provisioned")
+	}
+
+	deleting, err := b.deleteAbandoned(instance)
+	if err != nil {
+		return err
+	}
+	if deleting {
+		// The pending instance is kept so that retries come back here until RDS has finished
+		return fmt.Errorf("Instance '%s' is being cleaned up after an earlier provision failed, try again later", instance.InstanceID)
+	}
+
+	if err := instance.Delete(b.internalDB); err != nil {
+		return errors.New("Failed to delete reference from local database")
+	}
+	return nil
+}
+
+// deprovisionPending deletes an instance whose provision was abandoned. Anything RDS is still deleting
+// needs no more from the broker, so the instance is forgotten straight away.
+func (b *RDSBroker) deprovisionPending(instance *internaldb.DBInstance) error {
+	if !abandoned(instance) {
+		return errors.New("Instance is still being provisioned")
+	}
+
+	if _, err := b.deleteAbandoned(instance); err != nil {
+		return err
+	}
+
+	if err := instance.Delete(b.internalDB); err != nil {
+		return errors.New("Failed to delete reference from local database")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package rdsbroker
+
+import (
+	"fmt"
+	"net/url"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// RDS allows up to five read replicas of a MySQL, MariaDB or PostgreSQL instance
+const maxReadReplicaCount = 5
+
+// readReplicaCount works out how many read replicas an instance on the plan should have, with the
+// count requested by the user taking precedence over the plan's
+func readReplicaCount(servicePlan ServicePlan, requested *int64) (int64, error) {
+	if requested == nil {
+		return servicePlan.RDSProperties.ReadReplicaCount, nil
+	}
+	if *requested < 0 || *requested > maxReadReplicaCount {
+		return 0, fmt.Errorf("read_replica_count must be between 0 and %d", maxReadReplicaCount)
+	}
+	if *requested > 0 && !servicePlan.RDSProperties.supportsReadReplicas() {
+		return 0, fmt.Errorf("Read replicas are not supported for plan '%s'", servicePlan.Name)
+	}
+	return *requested, nil
+}
+
+func (b *RDSBroker) replicaIdentifier(instance *internaldb.DBInstance, n int) string {
+	return fmt.Sprintf("%s-replica-%d", b.dbInstanceIdentifier(instance), n)
+}
+
+// replicasLastOperation brings the read replicas of an available instance in line with the number
+// wanted. Replicas can only be created from an available instance, so they are added once the
+// primary is ready, and the operation only succeeds once every replica is available too.
+func (b *RDSBroker) replicasLastOperation(instance *internaldb.DBInstance, servicePlan ServicePlan, primary brokerapi.LastOperation) (brokerapi.LastOperation, error) {
+	if err := b.syncReplicas(instance, servicePlan); err != nil {
+		return brokerapi.LastOperation{State: brokerapi.Failed}, err
+	}
+
+	for _, replica := range instance.Replicas {
+		dbInstanceDetails, err := b.dbInstance.Describe(replica.Identifier)
+		if err != nil {
+			if err == awsrds.ErrDBInstanceDoesNotExist {
+				// Deleted outside the broker. Forget it so it's replaced next time round.
+				if err := instance.RemoveReplica(b.internalDB, replica.Identifier); err != nil {
+					b.logger.Error("remove-replica", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+				}
+				return brokerapi.LastOperation{
+					State:       brokerapi.InProgress,
+					Description: fmt.Sprintf("Read replica '%s' has gone and will be replaced", replica.Identifier),
+				}, nil
+			}
+			return brokerapi.LastOperation{State: brokerapi.Failed}, err
+		}
+
+		state, ok := rdsStatus2State[dbInstanceDetails.Status]
+		if !ok {
+			state = brokerapi.Failed
+		}
+		if state != brokerapi.Succeeded {
+			return brokerapi.LastOperation{
+				State:       state,
+				Description: fmt.Sprintf("Read replica '%s' status is '%s'", replica.Identifier, dbInstanceDetails.Status),
+			}, nil
+		}
+	}
+
+	return primary, nil
+}
+
+// syncReplicas creates or deletes read replicas until the instance has as many as it should. The newest
+// replicas are deleted first and new ones take the lowest free number.
+func (b *RDSBroker) syncReplicas(instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	for int64(len(instance.Replicas)) > instance.ReadReplicaCount {
+		identifier := instance.Replicas[len(instance.Replicas)-1].Identifier
+		if err := b.dbInstance.Delete(identifier, true); err != nil && err != awsrds.ErrDBInstanceDoesNotExist {
+			return err
+		}
+		if err := instance.RemoveReplica(b.internalDB, identifier); err != nil {
+			return err
+		}
+	}
+
+	for n := 1; int64(len(instance.Replicas)) < instance.ReadReplicaCount; n++ {
+		identifier := b.replicaIdentifier(instance, n)
+		if instance.Replica(identifier) != nil {
+			continue
+		}
+		if err := b.dbInstance.CreateReadReplica(identifier, b.dbInstanceIdentifier(instance), *b.readReplicaFromPlan(instance, servicePlan)); err != nil {
+			return err
+		}
+		if _, err := instance.AddReplica(b.internalDB, identifier); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *RDSBroker) readReplicaFromPlan(instance *internaldb.DBInstance, servicePlan ServicePlan) *awsrds.DBInstanceDetails {
+	dbInstanceDetails := b.dbInstanceFromPlan(servicePlan)
+	dbInstanceDetails.Tags = b.dbTags("Created", instance.ServiceID, servicePlan.ID, instance.OrganizationID, instance.SpaceID)
+	return dbInstanceDetails
+}
+
+// deleteReplicas starts deleting every read replica of an instance. Replicas can't have final snapshots.
+func (b *RDSBroker) deleteReplicas(instance *internaldb.DBInstance) error {
+	for _, replica := range instance.Replicas {
+		if err := b.dbInstance.Delete(replica.Identifier, true); err != nil && err != awsrds.ErrDBInstanceDoesNotExist {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicasDeprovisionLastOperation reports on the first read replica still being deleted, if any
+func (b *RDSBroker) replicasDeprovisionLastOperation(instance *internaldb.DBInstance) (*brokerapi.LastOperation, error) {
+	for _, replica := range instance.Replicas {
+		dbInstanceDetails, err := b.dbInstance.Describe(replica.Identifier)
+		if err == nil {
+			return &brokerapi.LastOperation{
+				State:       brokerapi.InProgress,
+				Description: fmt.Sprintf("Read replica '%s' status is '%s'", replica.Identifier, dbInstanceDetails.Status),
+			}, nil
+		}
+		if err != awsrds.ErrDBInstanceDoesNotExist {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// replicaHosts returns the addresses of the read replicas which are ready to be connected to
+func (b *RDSBroker) replicaHosts(instance *internaldb.DBInstance) []string {
+	var hosts []string
+	for _, replica := range instance.Replicas {
+		dbInstanceDetails, err := b.dbInstance.Describe(replica.Identifier)
+		if err != nil {
+			b.logger.Error("describe-replica", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+			continue
+		}
+		if dbInstanceDetails.Address != "" {
+			hosts = append(hosts, dbInstanceDetails.Address)
+		}
+	}
+	return hosts
+}
+
+// replicaURI points a connection URI at a read replica. Replicas listen on the same port as their source.
+func replicaURI(uri, host string, port int64) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	u.Host = fmt.Sprintf("%s:%d", host, port)
+	return u.String()
+}
@@ -0,0 +1,39 @@
+package rdsbroker
+
+import (
+	"code.cloudfoundry.org/lager"
+)
+
+// saga keeps track of how to undo each completed step of a multi-step change (e.g. provisioning)
+// so that a failure part way through doesn't leave behind resources the broker no longer knows about.
+type saga struct {
+	logger lager.Logger
+	steps  []sagaStep
+}
+
+type sagaStep struct {
+	name       string
+	compensate func() error
+}
+
+func newSaga(logger lager.Logger) *saga {
+	return &saga{logger: logger}
+}
+
+// add registers how to undo the step that has just completed
+func (s *saga) add(name string, compensate func() error) {
+	s.steps = append(s.steps, sagaStep{name: name, compensate: compensate})
+}
+
+// run undoes every completed step in reverse order. A failed compensation is logged and
+// the remaining steps are still attempted so we clean up as much as we can.
+func (s *saga) run() {
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		s.logger.Info("rollback", lager.Data{"step": step.name})
+		if err := step.compensate(); err != nil {
+			s.logger.Error("rollback-failed", err, lager.Data{"step": step.name})
+		}
+	}
+	s.steps = nil
+}
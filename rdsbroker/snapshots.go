@@ -0,0 +1,179 @@
+package rdsbroker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+
+	"github.com/AusDTO/pe-rds-broker/awsrds"
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+)
+
+// Snapshot is a snapshot the broker has recorded along with its current state on AWS
+type Snapshot struct {
+	SnapshotID string    `json:"snapshot_id"`
+	InstanceID string    `json:"instance_id"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListSnapshots returns the recorded snapshots of an instance, or of every instance when instanceID is empty.
+// Records of snapshots that have been deleted outside the broker are cleaned up as they're found.
+func (b *RDSBroker) ListSnapshots(instanceID string) ([]Snapshot, error) {
+	b.logger.Debug("list-snapshots", lager.Data{instanceIDLogKey: instanceID})
+
+	records, err := internaldb.ListSnapshots(b.internalDB, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for i := range records {
+		record := &records[i]
+		details, err := b.describeRecordedSnapshot(record)
+		if err != nil {
+			if err == awsrds.ErrDBSnapshotDoesNotExist {
+				if err := record.Delete(b.internalDB); err != nil {
+					b.logger.Error("delete-snapshot-record", err, lager.Data{"snapshot-id": record.SnapshotID})
+				}
+				continue
+			}
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{
+			SnapshotID: record.SnapshotID,
+			InstanceID: record.InstanceID,
+			Type:       string(record.Type),
+			Status:     details.Status,
+			CreatedAt:  details.CreateTime,
+		})
+	}
+
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes a recorded snapshot. Only snapshots tagged as managed by the broker are
+// deleted, which is also all the IAM policy allows.
+func (b *RDSBroker) DeleteSnapshot(snapshotID string) error {
+	b.logger.Debug("delete-snapshot", lager.Data{"snapshot-id": snapshotID})
+
+	record := internaldb.FindSnapshot(b.internalDB, snapshotID)
+	if record == nil {
+		return fmt.Errorf("Snapshot '%s' not found", snapshotID)
+	}
+
+	details, err := b.describeRecordedSnapshot(record)
+	if err != nil {
+		if err == awsrds.ErrDBSnapshotDoesNotExist {
+			// Already gone so only the record is left to clean up
+			return record.Delete(b.internalDB)
+		}
+		return err
+	}
+	if details.Tags[managedByTag] != managedByValue {
+		return fmt.Errorf("Snapshot '%s' is not managed by this broker", snapshotID)
+	}
+
+	if record.Cluster {
+		err = b.dbCluster.DeleteSnapshot(snapshotID)
+	} else {
+		err = b.dbInstance.DeleteSnapshot(snapshotID)
+	}
+	if err != nil && err != awsrds.ErrDBSnapshotDoesNotExist {
+		return err
+	}
+
+	return record.Delete(b.internalDB)
+}
+
+// ExpireSnapshots deletes the snapshots which are older than the retention period of their type
+// and returns the IDs of those deleted. A snapshot which can't be deleted is logged and skipped so
+// that one bad snapshot doesn't hold up the rest.
+func (b *RDSBroker) ExpireSnapshots(now time.Time) ([]string, error) {
+	b.logger.Debug("expire-snapshots", lager.Data{"retention": b.snapshotRetention})
+
+	retentionDays := map[internaldb.SnapshotType]int{
+		internaldb.FinalSnapshot:  b.snapshotRetention.FinalDays,
+		internaldb.ManualSnapshot: b.snapshotRetention.ManualDays,
+		internaldb.CloneSnapshot:  b.snapshotRetention.CloneDays,
+	}
+
+	expired := []string{}
+	for _, snapshotType := range []internaldb.SnapshotType{internaldb.FinalSnapshot, internaldb.ManualSnapshot, internaldb.CloneSnapshot} {
+		days := retentionDays[snapshotType]
+		if days == 0 {
+			continue
+		}
+		records, err := internaldb.ExpiredSnapshots(b.internalDB, snapshotType, now.AddDate(0, 0, -days))
+		if err != nil {
+			return expired, err
+		}
+		for _, record := range records {
+			if err := b.DeleteSnapshot(record.SnapshotID); err != nil {
+				b.logger.Error("expire-snapshot", err, lager.Data{"snapshot-id": record.SnapshotID})
+				continue
+			}
+			expired = append(expired, record.SnapshotID)
+		}
+	}
+
+	return expired, nil
+}
+
+// RunSnapshotRetention expires snapshots every interval. It never returns so should be run in its own goroutine.
+func (b *RDSBroker) RunSnapshotRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expired, err := b.ExpireSnapshots(now)
+		if err != nil {
+			b.logger.Error("snapshot-retention", err)
+			continue
+		}
+		b.logger.Info("snapshot-retention", lager.Data{"expired": expired})
+	}
+}
+
+func (b *RDSBroker) recordSnapshot(instanceID, snapshotID string, snapshotType internaldb.SnapshotType, servicePlan ServicePlan) {
+	cluster := isAurora(servicePlan.RDSProperties.Engine)
+	if _, err := internaldb.RecordSnapshot(b.internalDB, instanceID, snapshotID, snapshotType, cluster); err != nil {
+		// log and move on because the snapshot itself has been taken
+		b.logger.Error("record-snapshot", err, lager.Data{"snapshot-id": snapshotID})
+	}
+}
+
+// recordFinalSnapshots finds the snapshots RDS took while deleting an instance. They are named after
+// the instance identifier with a timestamp appended.
+func (b *RDSBroker) recordFinalSnapshots(instance *internaldb.DBInstance, servicePlan ServicePlan) {
+	var snapshots []awsrds.DBSnapshotDetails
+	var err error
+	prefix := "rds-broker-"
+	if isAurora(servicePlan.RDSProperties.Engine) {
+		prefix += b.dbClusterIdentifier(instance) + "-"
+		snapshots, err = b.dbCluster.ListSnapshots(b.dbClusterIdentifier(instance))
+	} else {
+		prefix += b.dbInstanceIdentifier(instance) + "-"
+		snapshots, err = b.dbInstance.ListSnapshots(b.dbInstanceIdentifier(instance))
+	}
+	if err != nil {
+		b.logger.Error("list-final-snapshots", err)
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Identifier, prefix) {
+			b.recordSnapshot(instance.InstanceID, snapshot.Identifier, internaldb.FinalSnapshot, servicePlan)
+		}
+	}
+}
+
+func (b *RDSBroker) describeRecordedSnapshot(record *internaldb.DBSnapshot) (awsrds.DBSnapshotDetails, error) {
+	if record.Cluster {
+		return b.dbCluster.DescribeSnapshot(record.SnapshotID)
+	}
+	return b.dbInstance.DescribeSnapshot(record.SnapshotID)
+}
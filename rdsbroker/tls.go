@@ -0,0 +1,75 @@
+package rdsbroker
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/brokerapi"
+
+	"github.com/AusDTO/pe-rds-broker/internaldb"
+	"github.com/AusDTO/pe-rds-broker/sqlengine"
+)
+
+// setRequireTLS brings the existing binding users of an instance in line with the plan it's moving to
+func (b *RDSBroker) setRequireTLS(instance *internaldb.DBInstance, servicePlan ServicePlan) error {
+	users := bindingUsers(instance)
+	if len(users) == 0 {
+		return nil
+	}
+
+	sqlEngine, err := b.instanceSqlEngine(instance, servicePlan)
+	if err != nil {
+		return err
+	}
+	defer sqlEngine.Close()
+
+	for _, user := range users {
+		if err = sqlEngine.SetRequireTLS(user.Username, servicePlan.RDSProperties.RequireTLS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tlsLastOperation reports whether connections have to use TLS once an instance is on a plan requiring
+// it. Postgres relies on the parameter group of the plan so it's only known once the instance is up.
+func (b *RDSBroker) tlsLastOperation(instance *internaldb.DBInstance, operation *internaldb.DBOperation, lastOperation brokerapi.LastOperation) brokerapi.LastOperation {
+	servicePlan, ok := b.catalog.FindServicePlan(instance.ServiceID, operation.PlanID)
+	if !ok || !servicePlan.RDSProperties.RequireTLS {
+		return lastOperation
+	}
+
+	required, err := b.tlsRequired(instance, servicePlan)
+	switch {
+	case err != nil:
+		b.logger.Error("tls-required", err, lager.Data{instanceIDLogKey: instance.InstanceID})
+		lastOperation.Description = fmt.Sprintf("%s. Failed to check whether TLS is required", lastOperation.Description)
+	case required:
+		lastOperation.Description = fmt.Sprintf("%s. Connections have to use TLS", lastOperation.Description)
+	default:
+		lastOperation.Description = fmt.Sprintf("%s. TLS is not enforced", lastOperation.Description)
+	}
+	return lastOperation
+}
+
+func (b *RDSBroker) tlsRequired(instance *internaldb.DBInstance, servicePlan ServicePlan) (bool, error) {
+	sqlEngine, err := b.instanceSqlEngine(instance, servicePlan)
+	if err != nil {
+		return false, err
+	}
+	defer sqlEngine.Close()
+
+	var usernames []string
+	for _, user := range bindingUsers(instance) {
+		usernames = append(usernames, user.Username)
+	}
+	return sqlEngine.TLSRequired(usernames)
+}
+
+// instanceSqlEngine connects to the database of an instance as the master user of its server
+func (b *RDSBroker) instanceSqlEngine(instance *internaldb.DBInstance, servicePlan ServicePlan) (sqlengine.SQLEngine, error) {
+	if servicePlan.RDSProperties.Shared {
+		return b.sharedSqlEngine(instance, servicePlan.RDSProperties.Engine)
+	}
+	return b.dedicatedSqlEngine(instance, servicePlan.RDSProperties.Engine)
+}
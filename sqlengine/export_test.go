@@ -0,0 +1,33 @@
+package sqlengine
+
+import (
+	"database/sql"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// The quoting helpers are tested directly as there's no database to run the statements against
+var (
+	MySQLQuoteIdentifier = mysqlQuoteIdentifier
+	MySQLQuoteString     = mysqlQuoteString
+	MySQLQuoteAccount    = mysqlQuoteAccount
+)
+
+// NewPostgresEngineWithDB returns an engine using db, for tests to check the statements it runs
+func NewPostgresEngineWithDB(logger lager.Logger, db *sql.DB) *PostgresEngine {
+	engine := NewPostgresEngine(logger)
+	engine.db = db
+	return engine
+}
+
+// NewMySQLEngineWithDB returns an engine using db, for tests to check the statements it runs
+func NewMySQLEngineWithDB(logger lager.Logger, db *sql.DB) *MySQLEngine {
+	engine := NewMySQLEngine(logger)
+	engine.db = db
+	return engine
+}
+
+// ConnectionString is the DSN the engine opens, for tests to check the driver reads it back
+func (d *MySQLEngine) ConnectionString() string {
+	return d.connectionString()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/AusDTO/pe-rds-broker/config"
+	"github.com/AusDTO/pe-rds-broker/sqlengine"
 	"github.com/AusDTO/pe-rds-broker/utils"
 )
 
@@ -14,6 +15,11 @@ type FakeSQLEngine struct {
 
 	CloseCalled bool
 
+	PingCalled bool
+	PingError  error
+	// PingErrors fails the pings of engines opened with particular passwords
+	PingErrors map[string]error
+
 	ExistsDBCalled bool
 	ExistsDBDBName string
 	ExistsDBError  error
@@ -26,15 +32,53 @@ type FakeSQLEngine struct {
 	DropDBDBName string
 	DropDBError  error
 
-	CreateUserCalled   bool
-	CreateUserUsername string
-	CreateUserPassword string
-	CreateUserError    error
+	CopyDBCalled       bool
+	CopyDBSourceDBName string
+	CopyDBDBName       string
+	CopyDBError        error
+
+	CreateUserCalled     bool
+	CreateUserUsername   string
+	CreateUserPassword   string
+	CreateUserRequireTLS bool
+	CreateUserError      error
+
+	CreateIAMUserCalled   bool
+	CreateIAMUserUsername string
+	CreateIAMUserError    error
+
+	UserExistsCalled   bool
+	UserExistsUsername string
+	UserExistsResult   bool
+	UserExistsError    error
 
 	DropUserCalled   bool
 	DropUserUsername string
 	DropUserError    error
 
+	LockUserCalled   bool
+	LockUserUsername string
+	LockUserError    error
+
+	UnlockUserCalled   bool
+	UnlockUserUsername string
+	UnlockUserError    error
+
+	SetPasswordCalled    bool
+	SetPasswordPasswords map[string]string
+	SetPasswordError     error
+	// SetPasswordFailUsername limits SetPasswordError to one user
+	SetPasswordFailUsername string
+
+	SetRequireTLSCalled bool
+	SetRequireTLSUsers  map[string]bool
+	SetRequireTLSError  error
+
+	TLSRequiredCalled    bool
+	TLSRequiredUsernames []string
+	TLSRequiredResult    bool
+	TLSRequiredError     error
+
 	GrantPrivilegesCalled   bool
 	GrantPrivilegesDBName   string
 	GrantPrivilegesUsername string
@@ -45,9 +89,57 @@ type FakeSQLEngine struct {
 	RevokePrivilegesUsername string
 	RevokePrivilegesError    error
 
+	GrantReadOnlyPrivilegesCalled   bool
+	GrantReadOnlyPrivilegesDBName   string
+	GrantReadOnlyPrivilegesUsername string
+	GrantReadOnlyPrivilegesOwners   []string
+	GrantReadOnlyPrivilegesError    error
+
+	RevokeReadOnlyPrivilegesCalled   bool
+	RevokeReadOnlyPrivilegesDBName   string
+	RevokeReadOnlyPrivilegesUsername string
+	RevokeReadOnlyPrivilegesOwners   []string
+	RevokeReadOnlyPrivilegesError    error
+
 	SetExtensionsCalled     bool
 	SetExtensionsExtensions []string
 	SetExtensionsError      error
+
+	ExtensionsCalled     bool
+	ExtensionsExtensions []string
+	ExtensionsError      error
+
+	ReassignOwnershipCalled       bool
+	ReassignOwnershipFromUsername string
+	ReassignOwnershipToUsername   string
+	ReassignOwnershipError        error
+
+	CopyToCalled bool
+	CopyToTarget sqlengine.SQLEngine
+	CopyToError  error
+
+	UnsupportedObjectsCalled bool
+	UnsupportedObjectsResult []string
+	UnsupportedObjectsError  error
+
+	OwnerRoleName string
+	// OwnerRoles are the owner roles of specific instances, falling back to OwnerRoleName
+	OwnerRoles map[string]string
+
+	CreateOwnerRoleCalled bool
+	CreateOwnerRoleDBName string
+	CreateOwnerRoleOwner  string
+	CreateOwnerRoleError  error
+
+	GrantOwnerRoleCalled   bool
+	GrantOwnerRoleOwner    string
+	GrantOwnerRoleUsername string
+	GrantOwnerRoleError    error
+
+	RevokeOwnerRoleCalled   bool
+	RevokeOwnerRoleOwner    string
+	RevokeOwnerRoleUsername string
+	RevokeOwnerRoleError    error
 }
 
 func (f *FakeSQLEngine) Open(conf config.DBConfig) error {
@@ -61,6 +153,15 @@ func (f *FakeSQLEngine) Close() {
 	f.CloseCalled = true
 }
 
+func (f *FakeSQLEngine) Ping() error {
+	f.PingCalled = true
+
+	if err, ok := f.PingErrors[f.OpenConfig.Password]; ok {
+		return err
+	}
+	return f.PingError
+}
+
 func (f *FakeSQLEngine) ExistsDB(dbname string) (bool, error) {
 	f.ExistsDBCalled = true
 	f.ExistsDBDBName = dbname
@@ -82,14 +183,67 @@ func (f *FakeSQLEngine) DropDB(dbname string) error {
 	return f.DropDBError
 }
 
-func (f *FakeSQLEngine) CreateUser(username string, password string) error {
+func (f *FakeSQLEngine) CopyDB(sourceDBName string, dbname string) error {
+	f.CopyDBCalled = true
+	f.CopyDBSourceDBName = sourceDBName
+	f.CopyDBDBName = dbname
+
+	return f.CopyDBError
+}
+
+func (f *FakeSQLEngine) CreateUser(username string, password string, requireTLS bool) error {
 	f.CreateUserCalled = true
 	f.CreateUserUsername = username
 	f.CreateUserPassword = password
+	f.CreateUserRequireTLS = requireTLS
 
 	return f.CreateUserError
 }
 
+func (f *FakeSQLEngine) CreateIAMUser(username string) error {
+	f.CreateIAMUserCalled = true
+	f.CreateIAMUserUsername = username
+
+	return f.CreateIAMUserError
+}
+
+func (f *FakeSQLEngine) UserExists(username string) (bool, error) {
+	f.UserExistsCalled = true
+	f.UserExistsUsername = username
+
+	return f.UserExistsResult, f.UserExistsError
+}
+
+func (f *FakeSQLEngine) SetRequireTLS(username string, requireTLS bool) error {
+	f.SetRequireTLSCalled = true
+	if f.SetRequireTLSUsers == nil {
+		f.SetRequireTLSUsers = map[string]bool{}
+	}
+	f.SetRequireTLSUsers[username] = requireTLS
+
+	return f.SetRequireTLSError
+}
+
+func (f *FakeSQLEngine) TLSRequired(usernames []string) (bool, error) {
+	f.TLSRequiredCalled = true
+	f.TLSRequiredUsernames = usernames
+
+	return f.TLSRequiredResult, f.TLSRequiredError
+}
+
+func (f *FakeSQLEngine) SetPassword(username string, password string) error {
+	f.SetPasswordCalled = true
+	if f.SetPasswordError != nil && (f.SetPasswordFailUsername == "" || f.SetPasswordFailUsername == username) {
+		return f.SetPasswordError
+	}
+	if f.SetPasswordPasswords == nil {
+		f.SetPasswordPasswords = map[string]string{}
+	}
+	f.SetPasswordPasswords[username] = password
+
+	return nil
+}
+
 func (f *FakeSQLEngine) DropUser(username string) error {
 	f.DropUserCalled = true
 	f.DropUserUsername = username
@@ -97,6 +251,20 @@ func (f *FakeSQLEngine) DropUser(username string) error {
 	return f.DropUserError
 }
 
+func (f *FakeSQLEngine) LockUser(username string) error {
+	f.LockUserCalled = true
+	f.LockUserUsername = username
+
+	return f.LockUserError
+}
+
+func (f *FakeSQLEngine) UnlockUser(username string) error {
+	f.UnlockUserCalled = true
+	f.UnlockUserUsername = username
+
+	return f.UnlockUserError
+}
+
 func (f *FakeSQLEngine) GrantPrivileges(dbname string, username string) error {
 	f.GrantPrivilegesCalled = true
 	f.GrantPrivilegesDBName = dbname
@@ -113,6 +281,24 @@ func (f *FakeSQLEngine) RevokePrivileges(dbname string, username string) error {
 	return f.RevokePrivilegesError
 }
 
+func (f *FakeSQLEngine) GrantReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	f.GrantReadOnlyPrivilegesCalled = true
+	f.GrantReadOnlyPrivilegesDBName = dbname
+	f.GrantReadOnlyPrivilegesUsername = username
+	f.GrantReadOnlyPrivilegesOwners = owners
+
+	return f.GrantReadOnlyPrivilegesError
+}
+
+func (f *FakeSQLEngine) RevokeReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	f.RevokeReadOnlyPrivilegesCalled = true
+	f.RevokeReadOnlyPrivilegesDBName = dbname
+	f.RevokeReadOnlyPrivilegesUsername = username
+	f.RevokeReadOnlyPrivilegesOwners = owners
+
+	return f.RevokeReadOnlyPrivilegesError
+}
+
 func (f *FakeSQLEngine) SetExtensions(extensions []string) error {
 	f.SetExtensionsCalled = true
 	f.SetExtensionsExtensions = extensions
@@ -120,6 +306,33 @@ func (f *FakeSQLEngine) SetExtensions(extensions []string) error {
 	return f.SetExtensionsError
 }
 
+func (f *FakeSQLEngine) Extensions() ([]string, error) {
+	f.ExtensionsCalled = true
+
+	return f.ExtensionsExtensions, f.ExtensionsError
+}
+
+func (f *FakeSQLEngine) ReassignOwnership(fromUsername string, toUsername string) error {
+	f.ReassignOwnershipCalled = true
+	f.ReassignOwnershipFromUsername = fromUsername
+	f.ReassignOwnershipToUsername = toUsername
+
+	return f.ReassignOwnershipError
+}
+
+func (f *FakeSQLEngine) CopyTo(target sqlengine.SQLEngine) error {
+	f.CopyToCalled = true
+	f.CopyToTarget = target
+
+	return f.CopyToError
+}
+
+func (f *FakeSQLEngine) UnsupportedObjects() ([]string, error) {
+	f.UnsupportedObjectsCalled = true
+
+	return f.UnsupportedObjectsResult, f.UnsupportedObjectsError
+}
+
 func (f *FakeSQLEngine) URI(dbname string, username string, password string) string {
 	return fmt.Sprintf("fake://%s:%s@%s:%d/%s?reconnect=true", username, password, f.OpenConfig.Url, f.OpenConfig.Port, dbname)
 }
@@ -135,3 +348,34 @@ func (d *FakeSQLEngine) Config() config.DBConfig {
 func (d *FakeSQLEngine) CreateUsername(instanceid string) (string, error) {
 	return utils.RandUsername()
 }
+
+func (f *FakeSQLEngine) OwnerRole(instanceID string) string {
+	if owner, ok := f.OwnerRoles[instanceID]; ok {
+		return owner
+	}
+	return f.OwnerRoleName
+}
+
+func (f *FakeSQLEngine) CreateOwnerRole(dbname string, owner string) error {
+	f.CreateOwnerRoleCalled = true
+	f.CreateOwnerRoleDBName = dbname
+	f.CreateOwnerRoleOwner = owner
+
+	return f.CreateOwnerRoleError
+}
+
+func (f *FakeSQLEngine) GrantOwnerRole(owner string, username string) error {
+	f.GrantOwnerRoleCalled = true
+	f.GrantOwnerRoleOwner = owner
+	f.GrantOwnerRoleUsername = username
+
+	return f.GrantOwnerRoleError
+}
+
+func (f *FakeSQLEngine) RevokeOwnerRole(owner string, username string) error {
+	f.RevokeOwnerRoleCalled = true
+	f.RevokeOwnerRoleOwner = owner
+	f.RevokeOwnerRoleUsername = username
+
+	return f.RevokeOwnerRoleError
+}
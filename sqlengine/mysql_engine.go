@@ -1,10 +1,17 @@
 package sqlengine
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
 
-	_ "github.com/go-sql-driver/mysql" // MySQL Driver
+	"github.com/go-sql-driver/mysql"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/AusDTO/pe-rds-broker/config"
@@ -25,6 +32,11 @@ func NewMySQLEngine(logger lager.Logger) *MySQLEngine {
 
 func (d *MySQLEngine) Open(conf config.DBConfig) error {
 	d.config = conf
+	if d.config.Sslmode == config.Verify && d.config.CACertificateFile != "" {
+		if err := d.registerTLSConfig(); err != nil {
+			return err
+		}
+	}
 	connectionString := d.connectionString()
 	d.logger.Debug("sql-open", lager.Data{"connection-string": connectionString})
 
@@ -44,12 +56,20 @@ func (d *MySQLEngine) Close() {
 	}
 }
 
+func (d *MySQLEngine) Ping() error {
+	if err := d.db.Ping(); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	return nil
+}
+
 func (d *MySQLEngine) ExistsDB(dbname string) (bool, error) {
-	selectDatabaseStatement := "SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = '" + dbname + "'"
-	d.logger.Debug("database-exists", lager.Data{"statement": selectDatabaseStatement})
+	selectDatabaseStatement := "SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = ?"
+	d.logger.Debug("database-exists", lager.Data{"statement": selectDatabaseStatement, "params": []string{dbname}})
 
 	var dummy string
-	err := d.db.QueryRow(selectDatabaseStatement).Scan(&dummy)
+	err := d.db.QueryRow(selectDatabaseStatement, dbname).Scan(&dummy)
 	switch {
 	case err == sql.ErrNoRows:
 		return false, nil
@@ -69,7 +89,7 @@ func (d *MySQLEngine) CreateDB(dbname string) error {
 		return nil
 	}
 
-	createDBStatement := "CREATE DATABASE IF NOT EXISTS " + dbname
+	createDBStatement := "CREATE DATABASE IF NOT EXISTS " + mysqlQuoteIdentifier(dbname)
 	d.logger.Debug("create-database", lager.Data{"statement": createDBStatement})
 
 	if _, err := d.db.Exec(createDBStatement); err != nil {
@@ -81,7 +101,7 @@ func (d *MySQLEngine) CreateDB(dbname string) error {
 }
 
 func (d *MySQLEngine) DropDB(dbname string) error {
-	dropDBStatement := "DROP DATABASE IF EXISTS " + dbname
+	dropDBStatement := "DROP DATABASE IF EXISTS " + mysqlQuoteIdentifier(dbname)
 	d.logger.Debug("drop-database", lager.Data{"statement": dropDBStatement})
 
 	if _, err := d.db.Exec(dropDBStatement); err != nil {
@@ -92,9 +112,199 @@ func (d *MySQLEngine) DropDB(dbname string) error {
 	return nil
 }
 
-func (d *MySQLEngine) CreateUser(username string, password string) error {
-	createUserStatement := "CREATE USER '" + username + "' IDENTIFIED BY '" + password + "'"
-	d.logger.Debug("create-user", lager.Data{"statement": createUserStatement})
+func (d *MySQLEngine) CopyDB(sourceDBName string, dbname string) error {
+	if err := d.CreateDB(dbname); err != nil {
+		return err
+	}
+
+	if err := d.copyTables(sourceDBName, dbname); err != nil {
+		// Don't leave a partial copy behind
+		if err := d.DropDB(dbname); err != nil {
+			d.logger.Error("drop-partial-copy", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (d *MySQLEngine) baseTables(dbname string) ([]string, error) {
+	rows, err := d.db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'", dbname)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func (d *MySQLEngine) copyTables(sourceDBName string, dbname string) error {
+	tables, err := d.baseTables(sourceDBName)
+	if err != nil {
+		return err
+	}
+
+	// CREATE TABLE ... LIKE doesn't copy foreign keys so the order the tables are copied in doesn't matter
+	for _, table := range tables {
+		sourceTable := mysqlQuoteIdentifier(sourceDBName) + "." + mysqlQuoteIdentifier(table)
+		targetTable := mysqlQuoteIdentifier(dbname) + "." + mysqlQuoteIdentifier(table)
+		statements := []string{
+			"CREATE TABLE " + targetTable + " LIKE " + sourceTable,
+			"INSERT INTO " + targetTable + " SELECT * FROM " + sourceTable,
+		}
+		for _, statement := range statements {
+			d.logger.Debug("copy-table", lager.Data{"statement": statement})
+
+			if _, err := d.db.Exec(statement); err != nil {
+				d.logger.Error("sql-error", err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CopyTo copies the tables and data of the open database into the open database of target, which has
+// to be MySQL too. Unlike CopyDB it works across servers, so each table is created from its definition
+// and its rows are inserted one by one.
+func (d *MySQLEngine) CopyTo(target SQLEngine) error {
+	t, ok := target.(*MySQLEngine)
+	if !ok {
+		return errors.New("MySQL databases can only be copied to MySQL")
+	}
+
+	tables, err := d.baseTables(d.config.DBName)
+	if err != nil {
+		return err
+	}
+
+	// The transaction keeps every statement on one connection so foreign key checks stay off while the
+	// tables are filled in any order. MySQL commits after each CREATE TABLE so it doesn't make the copy atomic.
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		var name, createTableStatement string
+		if err := d.db.QueryRow("SHOW CREATE TABLE "+mysqlQuoteIdentifier(table)).Scan(&name, &createTableStatement); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+		d.logger.Debug("copy-table", lager.Data{"statement": createTableStatement})
+
+		if _, err := tx.Exec(createTableStatement); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+		if err := d.copyRows(tx, table); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
+	if _, err := tx.Exec("SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UnsupportedObjects describes what CopyTo would leave behind or couldn't insert into: views, triggers,
+// routines, events and generated columns
+func (d *MySQLEngine) UnsupportedObjects() ([]string, error) {
+	dbname := d.config.DBName
+	rows, err := d.db.Query(`SELECT CONCAT('view ', TABLE_NAME) FROM INFORMATION_SCHEMA.VIEWS WHERE TABLE_SCHEMA = ?
+		UNION ALL SELECT CONCAT('trigger ', TRIGGER_NAME) FROM INFORMATION_SCHEMA.TRIGGERS WHERE TRIGGER_SCHEMA = ?
+		UNION ALL SELECT CONCAT(LOWER(ROUTINE_TYPE), ' ', ROUTINE_NAME) FROM INFORMATION_SCHEMA.ROUTINES WHERE ROUTINE_SCHEMA = ?
+		UNION ALL SELECT CONCAT('event ', EVENT_NAME) FROM INFORMATION_SCHEMA.EVENTS WHERE EVENT_SCHEMA = ?
+		UNION ALL SELECT CONCAT('generated column ', TABLE_NAME, '.', COLUMN_NAME) FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND (EXTRA LIKE '%VIRTUAL GENERATED%' OR EXTRA LIKE '%STORED GENERATED%')`,
+		dbname, dbname, dbname, dbname, dbname)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var objects []string
+	for rows.Next() {
+		var object string
+		if err := rows.Scan(&object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+func (d *MySQLEngine) copyRows(tx *sql.Tx, table string) error {
+	rows, err := d.db.Query("SELECT * FROM " + mysqlQuoteIdentifier(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insert, err := tx.Prepare("INSERT INTO " + mysqlQuoteIdentifier(table) + " VALUES (" + placeholders + ")")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	// The values come back as raw bytes which MySQL converts back to the column's type on insert
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if _, err := insert.Exec(values...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (d *MySQLEngine) CreateUser(username string, password string, requireTLS bool) error {
+	// Account management statements can't be prepared with placeholders for the user or password
+	createUserStatement := "CREATE USER " + mysqlQuoteAccount(username) + " IDENTIFIED BY " + mysqlQuoteString(password)
+	d.logger.Debug("create-user", lager.Data{"username": username})
+
+	if _, err := d.db.Exec(createUserStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	if requireTLS {
+		return d.SetRequireTLS(username, true)
+	}
+
+	return nil
+}
+
+// CreateIAMUser creates a user which authenticates through the RDS plugin for IAM authentication tokens
+func (d *MySQLEngine) CreateIAMUser(username string) error {
+	createUserStatement := "CREATE USER " + mysqlQuoteAccount(username) + " IDENTIFIED WITH AWSAuthenticationPlugin AS 'RDS'"
+	d.logger.Debug("create-iam-user", lager.Data{"statement": createUserStatement})
 
 	if _, err := d.db.Exec(createUserStatement); err != nil {
 		d.logger.Error("sql-error", err)
@@ -104,8 +314,90 @@ func (d *MySQLEngine) CreateUser(username string, password string) error {
 	return nil
 }
 
+// UserExists looks for username from any host, as accounts are only created for '%' but others can exist
+func (d *MySQLEngine) UserExists(username string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM mysql.user WHERE User = ?)", username).Scan(&exists)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// SetPassword uses ALTER USER, falling back to SET PASSWORD with PASSWORD() on servers from before
+// ALTER USER could change passwords. MySQL 8.0 has no PASSWORD() function.
+func (d *MySQLEngine) SetPassword(username string, password string) error {
+	version, err := d.serverVersion()
+	if err != nil {
+		return err
+	}
+
+	setPasswordStatement := "ALTER USER " + mysqlQuoteAccount(username) + " IDENTIFIED BY " + mysqlQuoteString(password)
+	if !version.hasAlterUser() {
+		setPasswordStatement = "SET PASSWORD FOR " + mysqlQuoteAccount(username) + " = PASSWORD(" + mysqlQuoteString(password) + ")"
+	}
+	d.logger.Debug("set-password", lager.Data{"username": username})
+
+	if _, err := d.db.Exec(setPasswordStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetRequireTLS uses ALTER USER, falling back to GRANT on servers from before ALTER USER could
+// take REQUIRE. MySQL 8.0 doesn't let GRANT change anything but privileges.
+func (d *MySQLEngine) SetRequireTLS(username string, requireTLS bool) error {
+	version, err := d.serverVersion()
+	if err != nil {
+		return err
+	}
+
+	require := "NONE"
+	if requireTLS {
+		require = "SSL"
+	}
+	requireStatement := "ALTER USER " + mysqlQuoteAccount(username) + " REQUIRE " + require
+	if !version.hasAlterUser() {
+		requireStatement = "GRANT USAGE ON *.* TO " + mysqlQuoteAccount(username) + " REQUIRE " + require
+	}
+	d.logger.Debug("set-require-tls", lager.Data{"statement": requireStatement})
+
+	if _, err := d.db.Exec(requireStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+// TLSRequired checks the users have an ssl_type, which REQUIRE SSL sets to ANY
+func (d *MySQLEngine) TLSRequired(usernames []string) (bool, error) {
+	if len(usernames) == 0 {
+		return true, nil
+	}
+
+	placeholders := make([]string, len(usernames))
+	args := make([]interface{}, len(usernames))
+	for i, username := range usernames {
+		placeholders[i] = "?"
+		args[i] = username
+	}
+
+	var count int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM mysql.user WHERE ssl_type = '' AND User IN ("+strings.Join(placeholders, ", ")+")", args...).Scan(&count)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
 func (d *MySQLEngine) DropUser(username string) error {
-	dropUserStatement := "DROP USER '" + username + "'@'%'"
+	dropUserStatement := "DROP USER " + mysqlQuoteAccount(username)
 	d.logger.Debug("drop-user", lager.Data{"statement": dropUserStatement})
 
 	if _, err := d.db.Exec(dropUserStatement); err != nil {
@@ -116,8 +408,77 @@ func (d *MySQLEngine) DropUser(username string) error {
 	return nil
 }
 
+// LockUser needs account locking, which MySQL 5.6 doesn't have. The connections are killed with
+// mysql.rds_kill as the master user of an RDS server can't kill the threads of other users itself.
+func (d *MySQLEngine) LockUser(username string) error {
+	version, err := d.serverVersion()
+	if err != nil {
+		return err
+	}
+	if !version.hasAccountLocking() {
+		return fmt.Errorf("Locking out user '%s' needs MySQL 5.7.6 or MariaDB 10.4 or later", username)
+	}
+
+	lockStatement := "ALTER USER " + mysqlQuoteAccount(username) + " ACCOUNT LOCK"
+	d.logger.Debug("lock-user", lager.Data{"statement": lockStatement})
+
+	if _, err := d.db.Exec(lockStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	threads, err := d.threads(username)
+	if err != nil {
+		return err
+	}
+	for _, thread := range threads {
+		_, err := d.db.Exec("CALL mysql.rds_kill(?)", thread)
+		// The connection may have closed by itself since
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlUnknownThread {
+			continue
+		}
+		if err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *MySQLEngine) UnlockUser(username string) error {
+	unlockStatement := "ALTER USER " + mysqlQuoteAccount(username) + " ACCOUNT UNLOCK"
+	d.logger.Debug("unlock-user", lager.Data{"statement": unlockStatement})
+
+	if _, err := d.db.Exec(unlockStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+// threads lists the IDs of the connections username has open
+func (d *MySQLEngine) threads(username string) ([]int64, error) {
+	rows, err := d.db.Query("SELECT ID FROM INFORMATION_SCHEMA.PROCESSLIST WHERE USER = ?", username)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var threads []int64
+	for rows.Next() {
+		var thread int64
+		if err := rows.Scan(&thread); err != nil {
+			return nil, err
+		}
+		threads = append(threads, thread)
+	}
+	return threads, rows.Err()
+}
+
 func (d *MySQLEngine) GrantPrivileges(dbname string, username string) error {
-	grantPrivilegesStatement := "GRANT ALL PRIVILEGES ON " + dbname + ".* TO '" + username + "'@'%'"
+	grantPrivilegesStatement := "GRANT ALL PRIVILEGES ON " + mysqlQuoteIdentifier(dbname) + ".* TO " + mysqlQuoteAccount(username)
 	d.logger.Debug("grant-privileges", lager.Data{"statement": grantPrivilegesStatement})
 
 	if _, err := d.db.Exec(grantPrivilegesStatement); err != nil {
@@ -129,7 +490,7 @@ func (d *MySQLEngine) GrantPrivileges(dbname string, username string) error {
 }
 
 func (d *MySQLEngine) RevokePrivileges(dbname string, username string) error {
-	revokePrivilegesStatement := "REVOKE ALL PRIVILEGES ON " + dbname + ".* from '" + username + "'@'%'"
+	revokePrivilegesStatement := "REVOKE ALL PRIVILEGES ON " + mysqlQuoteIdentifier(dbname) + ".* FROM " + mysqlQuoteAccount(username)
 	d.logger.Debug("revoke-privileges", lager.Data{"statement": revokePrivilegesStatement})
 
 	if _, err := d.db.Exec(revokePrivilegesStatement); err != nil {
@@ -140,30 +501,137 @@ func (d *MySQLEngine) RevokePrivileges(dbname string, username string) error {
 	return nil
 }
 
+// GrantReadOnlyPrivileges lets username read every table of dbname. MySQL grants on the whole database
+// cover tables created later so the owners don't matter.
+func (d *MySQLEngine) GrantReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	grantPrivilegesStatement := "GRANT SELECT ON " + mysqlQuoteIdentifier(dbname) + ".* TO " + mysqlQuoteAccount(username)
+	d.logger.Debug("grant-read-only-privileges", lager.Data{"statement": grantPrivilegesStatement})
+
+	if _, err := d.db.Exec(grantPrivilegesStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *MySQLEngine) RevokeReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	revokePrivilegesStatement := "REVOKE SELECT ON " + mysqlQuoteIdentifier(dbname) + ".* FROM " + mysqlQuoteAccount(username)
+	d.logger.Debug("revoke-read-only-privileges", lager.Data{"statement": revokePrivilegesStatement})
+
+	if _, err := d.db.Exec(revokePrivilegesStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *MySQLEngine) ReassignOwnership(fromUsername string, toUsername string) error {
+	// mysql privileges are granted on the whole database when binding so there's nothing to reassign
+	return nil
+}
+
+// OwnerRole is empty as every binding is granted the whole database so objects don't need a common owner
+func (d *MySQLEngine) OwnerRole(instanceID string) string {
+	return ""
+}
+
+func (d *MySQLEngine) CreateOwnerRole(dbname string, owner string) error {
+	return errors.New("MySQL databases don't have an owner role")
+}
+
+func (d *MySQLEngine) GrantOwnerRole(owner string, username string) error {
+	return errors.New("MySQL databases don't have an owner role")
+}
+
+func (d *MySQLEngine) RevokeOwnerRole(owner string, username string) error {
+	return errors.New("MySQL databases don't have an owner role")
+}
+
 func (d *MySQLEngine) SetExtensions(extensions []string) error {
 	// mysql doesn't have extensions
 	return nil
 }
 
+func (d *MySQLEngine) Extensions() ([]string, error) {
+	return nil, nil
+}
+
 func (d *MySQLEngine) URI(dbname string, username string, password string) string {
-	return fmt.Sprintf("mysql://%s:%s@%s:%d/%s?reconnect=true", username, password, d.config.Url, d.config.Port, dbname)
+	return (&url.URL{
+		Scheme:   "mysql",
+		User:     url.UserPassword(username, password),
+		Host:     fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
+		Path:     "/" + dbname,
+		RawQuery: "reconnect=true" + d.tlsURIParameters(),
+	}).String()
 }
 
 func (d *MySQLEngine) JDBCURI(dbname string, username string, password string) string {
-	return fmt.Sprintf("jdbc:mysql://%s:%d/%s?user=%s&password=%s", d.config.Url, d.config.Port, dbname, username, password)
+	return fmt.Sprintf("jdbc:%s", (&url.URL{
+		Scheme:   "mysql",
+		Host:     fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
+		Path:     "/" + dbname,
+		RawQuery: "user=" + url.QueryEscape(username) + "&password=" + url.QueryEscape(password) + d.tlsURIParameters(),
+	}).String())
+}
+
+// tlsURIParameters has clients verify the server certificate when the broker does
+func (d *MySQLEngine) tlsURIParameters() string {
+	if d.config.Sslmode != config.Verify {
+		return ""
+	}
+	return "&useSSL=true&verifyServerCertificate=true"
 }
 
 func (d *MySQLEngine) connectionString() string {
-	var tls string
+	var tlsMode string
 	switch d.config.Sslmode {
 	case config.Disable:
-		tls = "false"
+		tlsMode = "false"
 	case config.RequireNoVerify:
-		tls = "skip-verify"
+		tlsMode = "skip-verify"
 	case config.Verify:
-		tls = "true"
+		tlsMode = "true"
+		if d.config.CACertificateFile != "" {
+			tlsMode = d.tlsConfigName()
+		}
+	}
+	// The driver formats the DSN so that credentials containing its separators survive. A Config
+	// literal doesn't get the driver's defaults, so native passwords are allowed explicitly.
+	return (&mysql.Config{
+		User:                 d.config.Username,
+		Passwd:               d.config.Password,
+		Net:                  "tcp",
+		Addr:                 fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
+		DBName:               d.config.DBName,
+		TLSConfig:            tlsMode,
+		AllowNativePasswords: true,
+		// mysqlQuoteString relies on backslashes having no special meaning in string literals
+		Params: map[string]string{"sql_mode": mysqlSQLMode},
+	}).FormatDSN()
+}
+
+// Registered TLS configs are checked against a single server name, so each host gets its own
+func (d *MySQLEngine) tlsConfigName() string {
+	return "verify-" + d.config.Url
+}
+
+// registerTLSConfig has the driver verify the server certificate against the CA certificates of the config
+func (d *MySQLEngine) registerTLSConfig() error {
+	pem, err := ioutil.ReadFile(d.config.CACertificateFile)
+	if err != nil {
+		return err
 	}
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?tls=%s", d.config.Username, d.config.Password, d.config.Url, d.config.Port, d.config.DBName, tls)
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("No PEM encoded certificates in '%s'", d.config.CACertificateFile)
+	}
+	return mysql.RegisterTLSConfig(d.tlsConfigName(), &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: d.config.Url,
+	})
 }
 
 func (d *MySQLEngine) Config() config.DBConfig {
@@ -173,3 +641,80 @@ func (d *MySQLEngine) Config() config.DBConfig {
 func (d *MySQLEngine) CreateUsername(instanceid string) (string, error) {
 	return utils.RandUsername()
 }
+
+// mysqlUnknownThread is the error number of ER_NO_SUCH_THREAD
+const mysqlUnknownThread = 1094
+
+// mysqlVersion is the version of a MySQL server, or of a MariaDB one which numbers its versions differently
+type mysqlVersion struct {
+	major, minor, patch int
+	mariaDB             bool
+}
+
+func (d *MySQLEngine) serverVersion() (mysqlVersion, error) {
+	var version string
+	if err := d.db.QueryRow("SELECT VERSION()").Scan(&version); err != nil {
+		d.logger.Error("sql-error", err)
+		return mysqlVersion{}, err
+	}
+	return parseMySQLVersion(version), nil
+}
+
+// parseMySQLVersion reads versions like "5.7.16-log" and "10.4.8-MariaDB"
+func parseMySQLVersion(version string) mysqlVersion {
+	v := mysqlVersion{mariaDB: strings.Contains(version, "MariaDB")}
+	numbers := strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3)
+	parts := []*int{&v.major, &v.minor, &v.patch}
+	for i, number := range numbers {
+		*parts[i], _ = strconv.Atoi(number)
+	}
+	return v
+}
+
+func (v mysqlVersion) atLeast(major, minor, patch int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	if v.minor != minor {
+		return v.minor > minor
+	}
+	return v.patch >= patch
+}
+
+// hasAlterUser is whether ALTER USER can change passwords and TLS requirements
+func (v mysqlVersion) hasAlterUser() bool {
+	if v.mariaDB {
+		return v.atLeast(10, 2, 0)
+	}
+	return v.atLeast(5, 7, 6)
+}
+
+// hasAccountLocking is whether ALTER USER can lock accounts
+func (v mysqlVersion) hasAccountLocking() bool {
+	if v.mariaDB {
+		return v.atLeast(10, 4, 0)
+	}
+	return v.atLeast(5, 7, 6)
+}
+
+// mysqlQuoteIdentifier quotes the given identifier with backticks, doubling any backticks within it
+func mysqlQuoteIdentifier(v string) string {
+	return "`" + strings.Replace(v, "`", "``", -1) + "`"
+}
+
+// mysqlSQLMode adds NO_BACKSLASH_ESCAPES to the server's SQL mode for each connection. CONCAT_WS skips an
+// empty mode, which would otherwise leave a leading comma.
+const mysqlSQLMode = "CONCAT_WS(',', NULLIF(@@SESSION.sql_mode, ''), 'NO_BACKSLASH_ESCAPES')"
+
+// mysqlQuoteString quotes the given value as a string literal, doubling any single quotes within it.
+// Everything else is taken literally with the NO_BACKSLASH_ESCAPES SQL mode, which connections are opened
+// with. This function should only be used where MySQL doesn't take placeholders, such as the passwords of
+// account management statements.
+func mysqlQuoteString(v string) string {
+	return "'" + strings.Replace(v, "'", "''", -1) + "'"
+}
+
+// mysqlQuoteAccount quotes the name of a user which can connect from any host
+func mysqlQuoteAccount(username string) string {
+	return mysqlQuoteString(username) + "@'%'"
+}
@@ -0,0 +1,340 @@
+package sqlengine_test
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/AusDTO/pe-rds-broker/sqlengine"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/AusDTO/pe-rds-broker/config"
+)
+
+// Pieces of identifiers and passwords which SQL, URIs or connection strings treat specially
+var hostilePieces = []string{
+	"'", `"`, `\`, "`", "\x00", "\n", "\r", "\t", "\x1a", "%", "_", "@", ":", "/", "?", "#", "&", "=", "+", ";",
+	" ", "--", "/*", "*/", "''", `\'`, `\\`, "%27", "%00", "é", "☃", "a", "Z", "0",
+}
+
+// hostileStrings are the same each run so failures can be reproduced
+func hostileStrings(n int) []string {
+	random := rand.New(rand.NewSource(42))
+	values := []string{
+		"",
+		"x'; DROP USER root; --",
+		"x` ON *.* TO 'attacker'@'%'; --",
+		`\' OR 1=1 --`,
+		"p@ss:w/rd?#",
+	}
+	for i := 0; i < n; i++ {
+		var value string
+		for j := random.Intn(12); j >= 0; j-- {
+			value += hostilePieces[random.Intn(len(hostilePieces))]
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// mysqlUnquoteString reads a string literal the way MySQL does with the NO_BACKSLASH_ESCAPES SQL mode, where
+// only a doubled quote is special, returning the value and whatever follows the literal
+func mysqlUnquoteString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "'") {
+		return "", "", fmt.Errorf("%q doesn't start with a quote", s)
+	}
+	var value string
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+			value += "'"
+		case s[i] == '\'':
+			return value, s[i+1:], nil
+		default:
+			value += s[i : i+1]
+		}
+	}
+	return "", "", fmt.Errorf("%q isn't terminated", s)
+}
+
+// mysqlUnquoteIdentifier reads a backtick quoted identifier, returning the identifier and whatever follows it
+func mysqlUnquoteIdentifier(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "`") {
+		return "", "", fmt.Errorf("%q doesn't start with a backtick", s)
+	}
+	var identifier string
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '`' && i+1 < len(s) && s[i+1] == '`':
+			i++
+			identifier += "`"
+		case s[i] == '`':
+			return identifier, s[i+1:], nil
+		default:
+			identifier += s[i : i+1]
+		}
+	}
+	return "", "", fmt.Errorf("%q isn't terminated", s)
+}
+
+var _ = Describe("MySQLEngine", func() {
+	values := hostileStrings(1000)
+
+	Describe("quoting", func() {
+		It("round-trips string literals", func() {
+			for _, value := range values {
+				unquoted, rest, err := mysqlUnquoteString(MySQLQuoteString(value))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unquoted).To(Equal(value))
+				Expect(rest).To(BeEmpty(), "the literal of %q ends early", value)
+			}
+		})
+
+		It("round-trips identifiers", func() {
+			for _, value := range values {
+				unquoted, rest, err := mysqlUnquoteIdentifier(MySQLQuoteIdentifier(value))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unquoted).To(Equal(value))
+				Expect(rest).To(BeEmpty(), "the identifier %q ends early", value)
+			}
+		})
+
+		It("round-trips accounts", func() {
+			for _, value := range values {
+				username, rest, err := mysqlUnquoteString(MySQLQuoteAccount(value))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(username).To(Equal(value))
+				Expect(rest).To(Equal("@'%'"))
+			}
+		})
+	})
+
+	Describe("statements", func() {
+		var (
+			mock        sqlmock.Sqlmock
+			mysqlEngine *MySQLEngine
+		)
+
+		BeforeEach(func() {
+			db, m, err := sqlmock.New()
+			Expect(err).NotTo(HaveOccurred())
+			mock = m
+			mysqlEngine = NewMySQLEngineWithDB(lager.NewLogger("mysql_engine_test"), db)
+		})
+
+		AfterEach(func() {
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+			mysqlEngine.Close()
+		})
+
+		serverVersion := func(version string) {
+			mock.ExpectQuery(exactly("SELECT VERSION()")).
+				WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow(version))
+		}
+
+		Describe("quoting", func() {
+			It("keeps a hostile username and password inside their literals", func() {
+				mock.ExpectExec(exactly(`CREATE USER 'x''; DROP USER root; --'@'%' IDENTIFIED BY '\'' OR 1=1 --'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.CreateUser("x'; DROP USER root; --", `\' OR 1=1 --`, false)).To(Succeed())
+			})
+
+			It("leaves backslashes and control characters as they are", func() {
+				mock.ExpectExec(exactly("CREATE USER 'app_user'@'%' IDENTIFIED BY 'a\\nb\nc\x00d'")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.CreateUser("app_user", "a\\nb\nc\x00d", false)).To(Succeed())
+			})
+
+			It("keeps a hostile database name inside its identifier", func() {
+				mock.ExpectExec(exactly("GRANT ALL PRIVILEGES ON `x`` ON *.* TO 'attacker'@'%'; --`.* TO 'app''user'@'%'")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.GrantPrivileges("x` ON *.* TO 'attacker'@'%'; --", "app'user")).To(Succeed())
+			})
+
+			It("quotes the database name of DROP DATABASE", func() {
+				mock.ExpectExec(exactly("DROP DATABASE IF EXISTS `db``; DROP DATABASE mysql; --`")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.DropDB("db`; DROP DATABASE mysql; --")).To(Succeed())
+			})
+		})
+
+		Describe("CreateUser", func() {
+			It("creates the user and then requires TLS", func() {
+				mock.ExpectExec(exactly("CREATE USER 'app''user'@'%' IDENTIFIED BY 'pass''word'")).WillReturnResult(sqlmock.NewResult(0, 0))
+				serverVersion("8.0.35")
+				mock.ExpectExec(exactly("ALTER USER 'app''user'@'%' REQUIRE SSL")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.CreateUser("app'user", "pass'word", true)).To(Succeed())
+			})
+
+			It("leaves TLS optional when it's not required", func() {
+				mock.ExpectExec(exactly("CREATE USER 'app_user'@'%' IDENTIFIED BY 'password'")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.CreateUser("app_user", "password", false)).To(Succeed())
+			})
+		})
+
+		Describe("SetRequireTLS", func() {
+			It("alters the user", func() {
+				serverVersion("8.0.35")
+				mock.ExpectExec(exactly("ALTER USER 'app_user'@'%' REQUIRE NONE")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.SetRequireTLS("app_user", false)).To(Succeed())
+			})
+
+			It("grants usage with the requirement on MySQL 5.6", func() {
+				serverVersion("5.6.23-log")
+				mock.ExpectExec(exactly("GRANT USAGE ON *.* TO 'app_user'@'%' REQUIRE SSL")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.SetRequireTLS("app_user", true)).To(Succeed())
+			})
+		})
+
+		Describe("SetPassword", func() {
+			It("alters the user", func() {
+				serverVersion("8.0.35")
+				mock.ExpectExec(exactly(`ALTER USER 'app''user'@'%' IDENTIFIED BY 'pa\ss''word'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.SetPassword("app'user", `pa\ss'word`)).To(Succeed())
+			})
+
+			It("sets the password on MySQL 5.6", func() {
+				serverVersion("5.6.23-log")
+				mock.ExpectExec(exactly("SET PASSWORD FOR 'app_user'@'%' = PASSWORD('password')")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.SetPassword("app_user", "password")).To(Succeed())
+			})
+
+			It("alters the user on MariaDB 10.2", func() {
+				serverVersion("10.2.11-MariaDB")
+				mock.ExpectExec(exactly("ALTER USER 'app_user'@'%' IDENTIFIED BY 'password'")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				Expect(mysqlEngine.SetPassword("app_user", "password")).To(Succeed())
+			})
+		})
+
+		Describe("LockUser", func() {
+			It("locks the account and kills its connections", func() {
+				serverVersion("8.0.35")
+				mock.ExpectExec(exactly("ALTER USER 'app''user'@'%' ACCOUNT LOCK")).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(exactly("SELECT ID FROM INFORMATION_SCHEMA.PROCESSLIST WHERE USER = ?")).
+					WithArgs("app'user").
+					WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(12).AddRow(34))
+				mock.ExpectExec(exactly("CALL mysql.rds_kill(?)")).WithArgs(12).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(exactly("CALL mysql.rds_kill(?)")).WithArgs(34).
+					WillReturnError(&mysql.MySQLError{Number: 1094, Message: "Unknown thread id: 34"})
+
+				Expect(mysqlEngine.LockUser("app'user")).To(Succeed())
+			})
+
+			It("needs account locking", func() {
+				serverVersion("5.6.23-log")
+
+				err := mysqlEngine.LockUser("app_user")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Locking out user 'app_user' needs MySQL 5.7.6 or MariaDB 10.4 or later"))
+			})
+
+			It("knows MariaDB versions", func() {
+				serverVersion("10.3.8-MariaDB")
+
+				Expect(mysqlEngine.LockUser("app_user")).NotTo(Succeed())
+			})
+		})
+	})
+
+	Describe("URIs", func() {
+		var (
+			mysqlEngine *MySQLEngine
+			sslmode     config.SSLMode
+		)
+
+		BeforeEach(func() {
+			sslmode = config.RequireNoVerify
+		})
+
+		JustBeforeEach(func() {
+			mysqlEngine = NewMySQLEngine(lager.NewLogger("mysql_engine_test"))
+			// The driver only connects once a statement is run
+			Expect(mysqlEngine.Open(config.DBConfig{Url: "mysql-host", Port: 3306, Sslmode: sslmode})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			mysqlEngine.Close()
+		})
+
+		It("round-trips the database name, username and password of URI", func() {
+			for _, value := range values {
+				uri, err := url.Parse(mysqlEngine.URI(value, value, value))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uri.Scheme).To(Equal("mysql"))
+				Expect(uri.Host).To(Equal("mysql-host:3306"))
+				Expect(uri.Path).To(Equal("/" + value))
+				Expect(uri.User.Username()).To(Equal(value))
+				password, _ := uri.User.Password()
+				Expect(password).To(Equal(value))
+				Expect(uri.Query()).To(Equal(url.Values{"reconnect": []string{"true"}}))
+			}
+		})
+
+		It("round-trips the database name, username and password of JDBCURI", func() {
+			for _, value := range values {
+				jdbcURI := mysqlEngine.JDBCURI(value, value, value)
+				Expect(jdbcURI).To(HavePrefix("jdbc:"))
+				uri, err := url.Parse(strings.TrimPrefix(jdbcURI, "jdbc:"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uri.Scheme).To(Equal("mysql"))
+				Expect(uri.Host).To(Equal("mysql-host:3306"))
+				Expect(uri.Path).To(Equal("/" + value))
+				Expect(uri.Query()).To(Equal(url.Values{"user": []string{value}, "password": []string{value}}))
+			}
+		})
+
+		It("round-trips the password of the connection string", func() {
+			for _, value := range values {
+				mysqlEngine.Close()
+				Expect(mysqlEngine.Open(config.DBConfig{Url: "mysql-host", Port: 3306, DBName: "mysql", Username: "master", Password: value, Sslmode: sslmode})).To(Succeed())
+				dsn, err := mysql.ParseDSN(mysqlEngine.ConnectionString())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dsn.User).To(Equal("master"))
+				Expect(dsn.Passwd).To(Equal(value))
+				Expect(dsn.Addr).To(Equal("mysql-host:3306"))
+				Expect(dsn.DBName).To(Equal("mysql"))
+				Expect(dsn.TLSConfig).To(Equal("skip-verify"))
+			}
+		})
+
+		It("connects with the NO_BACKSLASH_ESCAPES SQL mode which string literals are quoted for", func() {
+			dsn, err := mysql.ParseDSN(mysqlEngine.ConnectionString())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dsn.Params).To(Equal(map[string]string{
+				"sql_mode": "CONCAT_WS(',', NULLIF(@@SESSION.sql_mode, ''), 'NO_BACKSLASH_ESCAPES')",
+			}))
+		})
+
+		Context("when verifying the server certificate", func() {
+			BeforeEach(func() {
+				sslmode = config.Verify
+			})
+
+			It("has clients verify it too", func() {
+				uri, err := url.Parse(mysqlEngine.URI("db", "user", "pass"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uri.Query().Get("useSSL")).To(Equal("true"))
+				Expect(uri.Query().Get("verifyServerCertificate")).To(Equal("true"))
+
+				jdbcURI, err := url.Parse(strings.TrimPrefix(mysqlEngine.JDBCURI("db", "user", "pass"), "jdbc:"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jdbcURI.Query().Get("useSSL")).To(Equal("true"))
+				Expect(jdbcURI.Query().Get("verifyServerCertificate")).To(Equal("true"))
+			})
+		})
+	})
+})
@@ -2,9 +2,11 @@ package sqlengine
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/lib/pq" // PostgreSQL Driver
 
@@ -13,6 +15,14 @@ import (
 	"github.com/AusDTO/pe-rds-broker/utils"
 )
 
+// CopyDB tries this many times, waiting postgresCopyDBRetryDelay in between, for the source database's
+// connections to close
+const postgresCopyDBAttempts = 5
+const postgresCopyDBRetryDelay = time.Second
+
+// postgresObjectInUse is the error code of CREATE DATABASE when its template has connections
+const postgresObjectInUse = "55006"
+
 type PostgresEngine struct {
 	logger lager.Logger
 	db     *sql.DB
@@ -46,6 +56,14 @@ func (d *PostgresEngine) Close() {
 	}
 }
 
+func (d *PostgresEngine) Ping() error {
+	if err := d.db.Ping(); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	return nil
+}
+
 func (d *PostgresEngine) ExistsDB(dbname string) (bool, error) {
 	d.logger.Debug("database-exists", lager.Data{"statement": "Checking if database exists:" + dbname})
 
@@ -98,37 +116,631 @@ func (d *PostgresEngine) DropDB(dbname string) error {
 	return nil
 }
 
-func (d *PostgresEngine) CreateUser(username string, password string) error {
-	// If the user has been created and "dropped" previously, the user will
-	// still exist but with NOLOGIN
-	var exists bool
-	err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", username).Scan(&exists)
+// CopyDB copies a database on this server. Postgres can only copy a database nobody is connected to, so
+// the source refuses new connections and its existing ones are closed until the copy has been made.
+func (d *PostgresEngine) CopyDB(sourceDBName string, dbname string) error {
+	if err := d.setAllowConnections(sourceDBName, false); err != nil {
+		return err
+	}
+	defer func() {
+		if err := d.setAllowConnections(sourceDBName, true); err != nil {
+			d.logger.Error("allow-connections", err, lager.Data{"dbname": sourceDBName})
+		}
+	}()
+
+	copyDBStatement := fmt.Sprintf("CREATE DATABASE %s WITH TEMPLATE %s", pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(sourceDBName))
+	for attempt := 1; ; attempt++ {
+		if err := d.dropConnections(sourceDBName); err != nil {
+			return err
+		}
+
+		d.logger.Debug("copy-database", lager.Data{"statement": copyDBStatement, "attempt": attempt})
+		_, err := d.db.Exec(copyDBStatement)
+		if err == nil {
+			return nil
+		}
+		d.logger.Error("sql-error", err)
+
+		// Terminated backends take a moment to go
+		if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != postgresObjectInUse || attempt == postgresCopyDBAttempts {
+			return err
+		}
+		time.Sleep(postgresCopyDBRetryDelay)
+	}
+}
+
+func (d *PostgresEngine) setAllowConnections(dbname string, allow bool) error {
+	statement := fmt.Sprintf("ALTER DATABASE %s ALLOW_CONNECTIONS %t", pq.QuoteIdentifier(dbname), allow)
+	d.logger.Debug("allow-connections", lager.Data{"statement": statement})
+
+	if _, err := d.db.Exec(statement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	return nil
+}
+
+// CopyTo copies the public schema of the open database into the open database of target, which has to
+// be PostgreSQL too. Without pg_dump this covers what apps usually create rather than everything: the
+// extensions, sequences and tables with their defaults, identity and generated columns, constraints,
+// indexes and data. Tables and sequences keep their owner when the owner exists on the target.
+func (d *PostgresEngine) CopyTo(target SQLEngine) error {
+	t, ok := target.(*PostgresEngine)
+	if !ok {
+		return errors.New("PostgreSQL databases can only be copied to PostgreSQL")
+	}
+
+	// Extensions provide types and functions that the tables may use
+	extensions, err := d.Extensions()
+	if err != nil {
+		return err
+	}
+	if err := t.SetExtensions(extensions); err != nil {
+		return err
+	}
+
+	// The catalogs describing identity and generated columns and sequences differ between versions
+	version, err := d.serverVersion()
 	if err != nil {
 		return err
 	}
-	if exists {
-		// Password is not recognized a parameter, nor an identifier. Use our own escape method.
-		loginStatement := fmt.Sprintf("ALTER ROLE %s WITH LOGIN PASSWORD %s", pq.QuoteIdentifier(username), postgresQuoteValue(password))
-		d.logger.Debug("login", lager.Data{"statement": loginStatement})
 
-		if _, err := d.db.Exec(loginStatement); err != nil {
+	relations, err := d.publicRelations()
+	if err != nil {
+		return err
+	}
+
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Sequences come first as column defaults refer to them
+	for _, relation := range relations {
+		if relation.kind != "S" {
+			continue
+		}
+		if err := d.copySequence(tx, version, relation.name); err != nil {
+			return err
+		}
+	}
+	for _, relation := range relations {
+		if relation.kind != "r" {
+			continue
+		}
+		if err := d.copyTable(tx, version, relation.name); err != nil {
+			return err
+		}
+	}
+
+	// Sequences of serial columns go with their column, and their table's owner
+	for _, relation := range relations {
+		if relation.ownedBy == "" {
+			continue
+		}
+		statement := fmt.Sprintf("ALTER SEQUENCE public.%s OWNED BY %s", pq.QuoteIdentifier(relation.name), relation.ownedBy)
+		d.logger.Debug("copy-sequence-owner", lager.Data{"statement": statement})
+		if _, err := tx.Exec(statement); err != nil {
 			d.logger.Error("sql-error", err)
 			return err
 		}
-	} else {
-		// Password is not recognized a parameter, nor an identifier. Use our own escape method.
-		createUserStatement := fmt.Sprintf("CREATE USER %s WITH PASSWORD %s", pq.QuoteIdentifier(username), postgresQuoteValue(password))
-		d.logger.Debug("create-user", lager.Data{"statement": createUserStatement})
+	}
 
-		if _, err := d.db.Exec(createUserStatement); err != nil {
+	// Constraints and indexes are added once the data is in, so foreign keys don't depend on the
+	// order the tables were copied in
+	statements, err := d.constraintAndIndexStatements()
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		d.logger.Debug("copy-constraint", lager.Data{"statement": statement})
+		if _, err := tx.Exec(statement); err != nil {
 			d.logger.Error("sql-error", err)
 			return err
 		}
 	}
 
+	if err := t.restoreOwners(tx, relations); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type postgresRelation struct {
+	name  string
+	kind  string
+	owner string
+	// ownedBy is the column a sequence belongs to, as in ALTER SEQUENCE OWNED BY
+	ownedBy string
+}
+
+// postgresSequence is what CREATE SEQUENCE needs to recreate a sequence, along with where it had got to
+type postgresSequence struct {
+	dataType  string
+	start     int64
+	increment int64
+	min       int64
+	max       int64
+	cache     int64
+	cycle     bool
+	lastValue int64
+	isCalled  bool
+}
+
+// options are the options of CREATE SEQUENCE, or of an identity column, which recreate the sequence
+func (s postgresSequence) options() string {
+	options := fmt.Sprintf("INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d", s.increment, s.min, s.max, s.start, s.cache)
+	// Sequences were all bigint before PostgreSQL 10, which is still the default
+	if s.dataType != "bigint" {
+		options = "AS " + s.dataType + " " + options
+	}
+	if s.cycle {
+		return options + " CYCLE"
+	}
+	return options + " NO CYCLE"
+}
+
+// UnsupportedObjects describes what CopyTo would leave behind: tables and sequences outside the public
+// schema, views, functions, triggers and types, other than the ones which come with an extension
+func (d *PostgresEngine) UnsupportedObjects() ([]string, error) {
+	rows, err := d.db.Query(`SELECT description FROM (
+		SELECT CASE c.relkind WHEN 'r' THEN 'table' WHEN 'S' THEN 'sequence' WHEN 'v' THEN 'view'
+				WHEN 'm' THEN 'materialized view' WHEN 'f' THEN 'foreign table' ELSE 'partitioned table' END
+				|| ' ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname) AS description
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('r', 'S', 'v', 'm', 'f', 'p') AND (n.nspname != 'public' OR c.relkind NOT IN ('r', 'S'))
+			AND n.nspname NOT LIKE 'pg\_%' AND n.nspname != 'information_schema'
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e WHERE e.classid = 'pg_class'::regclass AND e.objid = c.oid AND e.deptype = 'e')
+		UNION ALL
+		SELECT 'function ' || quote_ident(n.nspname) || '.' || quote_ident(p.proname)
+			FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname NOT LIKE 'pg\_%' AND n.nspname != 'information_schema'
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e WHERE e.classid = 'pg_proc'::regclass AND e.objid = p.oid AND e.deptype = 'e')
+		UNION ALL
+		SELECT 'trigger ' || quote_ident(t.tgname) || ' on ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname)
+			FROM pg_trigger t JOIN pg_class c ON c.oid = t.tgrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE NOT t.tgisinternal AND n.nspname NOT LIKE 'pg\_%' AND n.nspname != 'information_schema'
+		UNION ALL
+		SELECT 'type ' || quote_ident(n.nspname) || '.' || quote_ident(t.typname)
+			FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace LEFT JOIN pg_class c ON c.oid = t.typrelid
+			WHERE (t.typtype IN ('d', 'e', 'r') OR c.relkind = 'c')
+			AND n.nspname NOT LIKE 'pg\_%' AND n.nspname != 'information_schema'
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e WHERE e.classid = 'pg_type'::regclass AND e.objid = t.oid AND e.deptype = 'e')
+		) objects ORDER BY description`)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var objects []string
+	for rows.Next() {
+		var object string
+		if err := rows.Scan(&object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+// serverVersion is the version of the server as a number, such as 90624 for 9.6.24 or 120003 for 12.3
+func (d *PostgresEngine) serverVersion() (int, error) {
+	var version int
+	if err := d.db.QueryRow("SELECT current_setting('server_version_num')::integer").Scan(&version); err != nil {
+		d.logger.Error("sql-error", err)
+		return 0, err
+	}
+	return version, nil
+}
+
+// publicRelations lists the tables ("r") and sequences ("S") in the public schema of the open database.
+// The sequences of identity columns are left out as they're created with their column.
+func (d *PostgresEngine) publicRelations() ([]postgresRelation, error) {
+	rows, err := d.db.Query(`SELECT c.relname, c.relkind, pg_get_userbyid(c.relowner),
+			COALESCE((SELECT 'public.' || quote_ident(t.relname) || '.' || quote_ident(a.attname)
+				FROM pg_depend d JOIN pg_class t ON t.oid = d.refobjid
+				JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = d.refobjsubid
+				WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.refclassid = 'pg_class'::regclass
+				AND d.deptype = 'a' AND c.relkind = 'S'), '')
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relkind IN ('r', 'S')
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'i')
+		ORDER BY c.relname`)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var relations []postgresRelation
+	for rows.Next() {
+		var relation postgresRelation
+		if err := rows.Scan(&relation.name, &relation.kind, &relation.owner, &relation.ownedBy); err != nil {
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+// describeSequence reads the definition and state of a sequence. Before PostgreSQL 10 the definition was
+// part of the sequence itself rather than in pg_sequence.
+func (d *PostgresEngine) describeSequence(version int, qualified string) (postgresSequence, error) {
+	var sequence postgresSequence
+
+	query := "SELECT 'bigint', start_value, increment_by, min_value, max_value, cache_value, is_cycled, last_value, is_called FROM " + qualified
+	var args []interface{}
+	if version >= 100000 {
+		query = `SELECT format_type(s.seqtypid, NULL), s.seqstart, s.seqincrement, s.seqmin, s.seqmax, s.seqcache, s.seqcycle, q.last_value, q.is_called
+			FROM pg_sequence s, ` + qualified + ` q WHERE s.seqrelid = $1::regclass`
+		args = append(args, qualified)
+	}
+
+	err := d.db.QueryRow(query, args...).Scan(&sequence.dataType, &sequence.start, &sequence.increment, &sequence.min,
+		&sequence.max, &sequence.cache, &sequence.cycle, &sequence.lastValue, &sequence.isCalled)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+	}
+	return sequence, err
+}
+
+func (d *PostgresEngine) copySequence(tx *sql.Tx, version int, sequence string) error {
+	qualified := "public." + pq.QuoteIdentifier(sequence)
+
+	definition, err := d.describeSequence(version, qualified)
+	if err != nil {
+		return err
+	}
+
+	createSequenceStatement := fmt.Sprintf("CREATE SEQUENCE %s %s", qualified, definition.options())
+	d.logger.Debug("copy-sequence", lager.Data{"statement": createSequenceStatement})
+	if _, err := tx.Exec(createSequenceStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	if _, err := tx.Exec("SELECT setval($1, $2, $3)", qualified, definition.lastValue, definition.isCalled); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	return nil
+}
+
+type postgresColumn struct {
+	name         string
+	dataType     string
+	notNull      bool
+	defaultValue string
+	// identity is "a" for GENERATED ALWAYS AS IDENTITY and "d" for GENERATED BY DEFAULT
+	identity string
+	// generated is "s" for a stored generated column and "v" for a virtual one, whose defaultValue is
+	// the expression generating it
+	generated string
+	// sequence is the sequence of an identity column
+	sequence string
+}
+
+// columns describes the columns of a table. Identity columns came in PostgreSQL 10 and generated
+// columns in PostgreSQL 12.
+func (d *PostgresEngine) columns(version int, qualified string) ([]postgresColumn, error) {
+	identity, sequence, generated := "''", "''", "''"
+	if version >= 100000 {
+		identity = "a.attidentity::text"
+		sequence = "CASE WHEN a.attidentity != '' THEN pg_get_serial_sequence(a.attrelid::regclass::text, a.attname) ELSE '' END"
+	}
+	if version >= 120000 {
+		generated = "a.attgenerated::text"
+	}
+
+	rows, err := d.db.Query(fmt.Sprintf(`SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, COALESCE(pg_get_expr(ad.adbin, ad.adrelid), ''), %s, %s, %s
+		FROM pg_attribute a LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, identity, generated, sequence), qualified)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []postgresColumn
+	for rows.Next() {
+		var column postgresColumn
+		if err := rows.Scan(&column.name, &column.dataType, &column.notNull, &column.defaultValue, &column.identity, &column.generated, &column.sequence); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// copyTable creates a table from the definitions of its columns and copies its rows across as text,
+// which PostgreSQL parses back into each column's type. Generated columns are left for the target to
+// generate, and identity columns carry on from where they were.
+func (d *PostgresEngine) copyTable(tx *sql.Tx, version int, table string) error {
+	qualified := "public." + pq.QuoteIdentifier(table)
+
+	tableColumns, err := d.columns(version, qualified)
+	if err != nil {
+		return err
+	}
+
+	var columns, definitions, selects []string
+	identities := map[string]postgresSequence{}
+	for _, column := range tableColumns {
+		definition := pq.QuoteIdentifier(column.name) + " " + column.dataType
+		switch {
+		case column.generated == "s":
+			definition += " GENERATED ALWAYS AS (" + column.defaultValue + ") STORED"
+		case column.generated == "v":
+			definition += " GENERATED ALWAYS AS (" + column.defaultValue + ") VIRTUAL"
+		case column.identity != "":
+			sequence, err := d.describeSequence(version, column.sequence)
+			if err != nil {
+				return err
+			}
+			identities[column.name] = sequence
+			generated := "ALWAYS"
+			if column.identity == "d" {
+				generated = "BY DEFAULT"
+			}
+			definition += fmt.Sprintf(" GENERATED %s AS IDENTITY (%s)", generated, sequence.options())
+		case column.defaultValue != "":
+			definition += " DEFAULT " + column.defaultValue
+		}
+		if column.notNull {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+
+		// Generated columns can't be written to
+		if column.generated == "" {
+			columns = append(columns, column.name)
+			selects = append(selects, pq.QuoteIdentifier(column.name)+"::text")
+		}
+	}
+
+	createTableStatement := fmt.Sprintf("CREATE TABLE %s (%s)", qualified, strings.Join(definitions, ", "))
+	d.logger.Debug("copy-table", lager.Data{"statement": createTableStatement})
+	if _, err := tx.Exec(createTableStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	if len(columns) > 0 {
+		if err := d.copyRows(tx, table, columns, selects); err != nil {
+			return err
+		}
+	}
+
+	// COPY writes identity columns without moving their sequences on
+	for _, column := range tableColumns {
+		sequence, ok := identities[column.name]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec("SELECT setval(pg_get_serial_sequence($1, $2), $3, $4)", qualified, column.name, sequence.lastValue, sequence.isCalled); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
 	return nil
 }
 
+// copyRows copies the given columns of a table into the same table in tx
+func (d *PostgresEngine) copyRows(tx *sql.Tx, table string, columns []string, selects []string) error {
+	data, err := d.db.Query(fmt.Sprintf("SELECT %s FROM public.%s", strings.Join(selects, ", "), pq.QuoteIdentifier(table)))
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+	defer data.Close()
+
+	copyIn, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	row := make([]interface{}, len(columns))
+	for data.Next() {
+		if err := data.Scan(pointers...); err != nil {
+			copyIn.Close()
+			return err
+		}
+		for i, value := range values {
+			if value.Valid {
+				row[i] = value.String
+			} else {
+				row[i] = nil
+			}
+		}
+		if _, err := copyIn.Exec(row...); err != nil {
+			copyIn.Close()
+			return err
+		}
+	}
+	if err := data.Err(); err != nil {
+		copyIn.Close()
+		return err
+	}
+	// An Exec without arguments flushes the copy
+	if _, err := copyIn.Exec(); err != nil {
+		copyIn.Close()
+		return err
+	}
+	return copyIn.Close()
+}
+
+// constraintAndIndexStatements recreates the constraints of the public tables, with foreign keys last,
+// followed by the indexes which don't belong to a constraint
+func (d *PostgresEngine) constraintAndIndexStatements() ([]string, error) {
+	var statements []string
+
+	rows, err := d.db.Query(`SELECT con.conrelid::regclass::text, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con JOIN pg_namespace n ON n.oid = con.connamespace
+		WHERE n.nspname = 'public' AND con.conrelid <> 0 AND con.contype IN ('p', 'u', 'c', 'x', 'f')
+		ORDER BY con.contype = 'f', con.conname`)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, name, definition string
+		if err := rows.Scan(&table, &name, &definition); err != nil {
+			return nil, err
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", table, pq.QuoteIdentifier(name), definition))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes, err := d.db.Query(`SELECT pg_get_indexdef(i.indexrelid)
+		FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid)`)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		var definition string
+		if err := indexes.Scan(&definition); err != nil {
+			return nil, err
+		}
+		statements = append(statements, definition)
+	}
+	return statements, indexes.Err()
+}
+
+// restoreOwners gives copied tables and sequences back to their owners when they exist here. The RDS master
+// user isn't a superuser so it has to be a member of a role to give it anything. Sequences owned by a column
+// can't be given away, they go with their table.
+func (d *PostgresEngine) restoreOwners(tx *sql.Tx, relations []postgresRelation) error {
+	for _, relation := range relations {
+		if relation.ownedBy != "" {
+			continue
+		}
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", relation.owner).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		kind := "TABLE"
+		if relation.kind == "S" {
+			kind = "SEQUENCE"
+		}
+		owner := pq.QuoteIdentifier(relation.owner)
+		statements := []string{
+			fmt.Sprintf("GRANT %s TO CURRENT_USER", owner),
+			fmt.Sprintf("ALTER %s public.%s OWNER TO %s", kind, pq.QuoteIdentifier(relation.name), owner),
+			fmt.Sprintf("REVOKE %s FROM CURRENT_USER", owner),
+		}
+		for _, statement := range statements {
+			d.logger.Debug("restore-owner", lager.Data{"statement": statement})
+			if _, err := tx.Exec(statement); err != nil {
+				d.logger.Error("sql-error", err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CreateUser ignores requireTLS as postgres can only require TLS for the whole server, see TLSRequired.
+// Existing roles are never given a login, whether they're dropped users, which still exist with NOLOGIN,
+// or roles such as the owner role of an instance which anyone logging in as would take over.
+func (d *PostgresEngine) CreateUser(username string, password string, requireTLS bool) error {
+	if err := d.checkNewRole(username); err != nil {
+		return err
+	}
+
+	// Password is not recognized a parameter, nor an identifier. Use our own escape method.
+	createUserStatement := fmt.Sprintf("CREATE USER %s WITH PASSWORD %s", pq.QuoteIdentifier(username), postgresQuoteValue(password))
+	d.logger.Debug("create-user", lager.Data{"username": username})
+
+	if _, err := d.db.Exec(createUserStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateIAMUser creates a user which RDS lets log in with an IAM authentication token, which it does for
+// members of the rds_iam role instead of checking their password
+func (d *PostgresEngine) CreateIAMUser(username string) error {
+	if err := d.checkNewRole(username); err != nil {
+		return err
+	}
+
+	user := pq.QuoteIdentifier(username)
+	return d.execStatements("create-iam-user", []string{
+		fmt.Sprintf("CREATE USER %s", user),
+		fmt.Sprintf("GRANT rds_iam TO %s", user),
+	})
+}
+
+func (d *PostgresEngine) checkNewRole(username string) error {
+	exists, err := d.UserExists(username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("Role '%s' already exists", username)
+	}
+	return nil
+}
+
+func (d *PostgresEngine) UserExists(username string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", username).Scan(&exists)
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+func (d *PostgresEngine) SetPassword(username string, password string) error {
+	// Password is not recognized a parameter, nor an identifier. Use our own escape method.
+	setPasswordStatement := fmt.Sprintf("ALTER ROLE %s WITH PASSWORD %s", pq.QuoteIdentifier(username), postgresQuoteValue(password))
+	d.logger.Debug("set-password", lager.Data{"username": username})
+
+	if _, err := d.db.Exec(setPasswordStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetRequireTLS does nothing as postgres can only require TLS for the whole server, see TLSRequired
+func (d *PostgresEngine) SetRequireTLS(username string, requireTLS bool) error {
+	return nil
+}
+
+// TLSRequired is whether the rds.force_ssl parameter is on, RDS's only way of keeping users from
+// connecting without TLS. Servers other than RDS don't have the parameter.
+func (d *PostgresEngine) TLSRequired(usernames []string) (bool, error) {
+	var setting string
+	err := d.db.QueryRow("SELECT setting FROM pg_settings WHERE name = 'rds.force_ssl'").Scan(&setting)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		d.logger.Error("sql-error", err)
+		return false, err
+	}
+
+	return setting == "1" || setting == "on", nil
+}
+
 func (d *PostgresEngine) DropUser(username string) error {
 	// For PostgreSQL we don't drop the user because it might still be owner of some objects
 	// We make it so they can't log in instead
@@ -144,6 +756,35 @@ func (d *PostgresEngine) DropUser(username string) error {
 	return nil
 }
 
+func (d *PostgresEngine) LockUser(username string) error {
+	nologinStatement := fmt.Sprintf("ALTER ROLE %s WITH NOLOGIN", pq.QuoteIdentifier(username))
+	d.logger.Debug("lock-user", lager.Data{"statement": nologinStatement})
+
+	if _, err := d.db.Exec(nologinStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	if _, err := d.db.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = $1", username); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *PostgresEngine) UnlockUser(username string) error {
+	loginStatement := fmt.Sprintf("ALTER ROLE %s WITH LOGIN", pq.QuoteIdentifier(username))
+	d.logger.Debug("unlock-user", lager.Data{"statement": loginStatement})
+
+	if _, err := d.db.Exec(loginStatement); err != nil {
+		d.logger.Error("sql-error", err)
+		return err
+	}
+
+	return nil
+}
+
 func (d *PostgresEngine) GrantPrivileges(dbname string, username string) error {
 	grantPrivilegesStatement := fmt.Sprintf("GRANT ALL PRIVILEGES ON DATABASE %s TO %s", pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(username))
 	d.logger.Debug("grant-privileges", lager.Data{"statement": grantPrivilegesStatement})
@@ -168,27 +809,221 @@ func (d *PostgresEngine) RevokePrivileges(dbname string, username string) error
 	return nil
 }
 
-func (d *PostgresEngine) SetExtensions(extensions []string) error {
-	// validate extensions
-	for _, extension := range extensions {
-		if !utils.IsValidExtensionName(extension) {
-			return fmt.Errorf("Invalid extension name '%s'", extension)
-		}
+// CreateOwnerRole creates the owner role of dbname unless it exists, which is the case for instances bound
+// before each binding had its own login, and gives it all privileges on the database
+func (d *PostgresEngine) CreateOwnerRole(dbname string, owner string) error {
+	if err := d.createRoles([]string{owner}); err != nil {
+		return err
 	}
+	return d.GrantPrivileges(dbname, owner)
+}
 
-	// get current extensions
-	rows, err := d.db.Query("SELECT extname FROM pg_extension WHERE extname != 'plpgsql'")
+// GrantOwnerRole makes username a member of owner which it acts as from when it logs in, so the objects it
+// creates belong to owner and can be used by the other bindings
+func (d *PostgresEngine) GrantOwnerRole(owner string, username string) error {
+	return d.execStatements("grant-owner-role", []string{
+		fmt.Sprintf("GRANT %s TO %s", pq.QuoteIdentifier(owner), pq.QuoteIdentifier(username)),
+		fmt.Sprintf("ALTER ROLE %s SET ROLE %s", pq.QuoteIdentifier(username), pq.QuoteIdentifier(owner)),
+	})
+}
+
+// RevokeOwnerRole stops username acting as owner, leaving what it created with owner
+func (d *PostgresEngine) RevokeOwnerRole(owner string, username string) error {
+	return d.execStatements("revoke-owner-role", []string{
+		fmt.Sprintf("ALTER ROLE %s RESET ROLE", pq.QuoteIdentifier(username)),
+		fmt.Sprintf("REVOKE %s FROM %s", pq.QuoteIdentifier(owner), pq.QuoteIdentifier(username)),
+	})
+}
+
+// GrantReadOnlyPrivileges lets username read the tables of dbname, which has to be the open database as
+// schemas and tables belong to it. Tables the owners create later are readable too.
+func (d *PostgresEngine) GrantReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	schemas, err := d.schemas()
+	if err != nil {
+		return err
+	}
+
+	user := pq.QuoteIdentifier(username)
+	statements := []string{fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s", pq.QuoteIdentifier(dbname), user)}
+	for _, schema := range schemas {
+		statements = append(statements,
+			fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", pq.QuoteIdentifier(schema), user),
+			fmt.Sprintf("GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s", pq.QuoteIdentifier(schema), user),
+		)
+	}
+	for _, owner := range owners {
+		statements = append(statements, d.defaultPrivilegesStatements(owner, fmt.Sprintf("GRANT SELECT ON TABLES TO %s", user))...)
+	}
+
+	// Default privileges can only be set for roles which exist
+	if err := d.createRoles(owners); err != nil {
+		return err
+	}
+	return d.execStatements("grant-read-only-privileges", statements)
+}
+
+// RevokeReadOnlyPrivileges takes back everything GrantReadOnlyPrivileges gave username
+func (d *PostgresEngine) RevokeReadOnlyPrivileges(dbname string, username string, owners []string) error {
+	schemas, err := d.schemas()
 	if err != nil {
 		return err
 	}
+
+	user := pq.QuoteIdentifier(username)
+	var statements []string
+	for _, owner := range owners {
+		var exists bool
+		if err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", owner).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			statements = append(statements, d.defaultPrivilegesStatements(owner, fmt.Sprintf("REVOKE SELECT ON TABLES FROM %s", user))...)
+		}
+	}
+	for _, schema := range schemas {
+		statements = append(statements,
+			fmt.Sprintf("REVOKE SELECT ON ALL TABLES IN SCHEMA %s FROM %s", pq.QuoteIdentifier(schema), user),
+			fmt.Sprintf("REVOKE USAGE ON SCHEMA %s FROM %s", pq.QuoteIdentifier(schema), user),
+		)
+	}
+	statements = append(statements, fmt.Sprintf("REVOKE CONNECT ON DATABASE %s FROM %s", pq.QuoteIdentifier(dbname), user))
+
+	return d.execStatements("revoke-read-only-privileges", statements)
+}
+
+// The RDS master user isn't a superuser so it has to be a member of a role to change its default privileges
+func (d *PostgresEngine) defaultPrivilegesStatements(owner string, action string) []string {
+	return []string{
+		fmt.Sprintf("GRANT %s TO CURRENT_USER", pq.QuoteIdentifier(owner)),
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s %s", pq.QuoteIdentifier(owner), action),
+		fmt.Sprintf("REVOKE %s FROM CURRENT_USER", pq.QuoteIdentifier(owner)),
+	}
+}
+
+// createRoles creates the roles which don't exist yet. CreateUser gives them a login and password when they're bound.
+func (d *PostgresEngine) createRoles(roles []string) error {
+	for _, role := range roles {
+		var exists bool
+		if err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", role).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		createRoleStatement := fmt.Sprintf("CREATE ROLE %s WITH NOLOGIN", pq.QuoteIdentifier(role))
+		d.logger.Debug("create-role", lager.Data{"statement": createRoleStatement})
+
+		if _, err := d.db.Exec(createRoleStatement); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// schemas lists the schemas of the open database other than the system ones
+func (d *PostgresEngine) schemas() ([]string, error) {
+	rows, err := d.db.Query("SELECT nspname FROM pg_namespace WHERE nspname NOT LIKE 'pg\\_%' AND nspname != 'information_schema'")
+	if err != nil {
+		d.logger.Error("sql-error", err)
+		return nil, err
+	}
 	defer rows.Close()
-	var oldExtensions []string
+	var schemas []string
 	for rows.Next() {
 		var name string
 		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, name)
+	}
+	return schemas, rows.Err()
+}
+
+func (d *PostgresEngine) execStatements(action string, statements []string) error {
+	for _, statement := range statements {
+		d.logger.Debug(action, lager.Data{"statement": statement})
+
+		if _, err := d.db.Exec(statement); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *PostgresEngine) ReassignOwnership(fromUsername string, toUsername string) error {
+	var exists bool
+	err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", fromUsername).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// Nothing can be owned by a user that doesn't exist
+		return nil
+	}
+
+	err = d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)", toUsername).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// It's the owner role of the new instance, which bindings act as without logging in as it
+		createRoleStatement := fmt.Sprintf("CREATE ROLE %s WITH NOLOGIN", pq.QuoteIdentifier(toUsername))
+		d.logger.Debug("create-role", lager.Data{"statement": createRoleStatement})
+
+		if _, err := d.db.Exec(createRoleStatement); err != nil {
+			d.logger.Error("sql-error", err)
+			return err
+		}
+	}
+
+	// The RDS master user isn't a superuser so it has to be a member of both roles to reassign objects between them
+	statements := []string{
+		fmt.Sprintf("GRANT %s, %s TO CURRENT_USER", pq.QuoteIdentifier(fromUsername), pq.QuoteIdentifier(toUsername)),
+		fmt.Sprintf("REASSIGN OWNED BY %s TO %s", pq.QuoteIdentifier(fromUsername), pq.QuoteIdentifier(toUsername)),
+		fmt.Sprintf("REVOKE %s, %s FROM CURRENT_USER", pq.QuoteIdentifier(fromUsername), pq.QuoteIdentifier(toUsername)),
+	}
+	for _, statement := range statements {
+		d.logger.Debug("reassign-ownership", lager.Data{"statement": statement})
+
+		if _, err := d.db.Exec(statement); err != nil {
+			d.logger.Error("sql-error", err)
 			return err
 		}
-		oldExtensions = append(oldExtensions, name)
+	}
+
+	return nil
+}
+
+func (d *PostgresEngine) Extensions() ([]string, error) {
+	rows, err := d.db.Query("SELECT extname FROM pg_extension WHERE extname != 'plpgsql'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var extensions []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, name)
+	}
+	return extensions, rows.Err()
+}
+
+func (d *PostgresEngine) SetExtensions(extensions []string) error {
+	// validate extensions
+	for _, extension := range extensions {
+		if !utils.IsValidExtensionName(extension) {
+			return fmt.Errorf("Invalid extension name '%s'", extension)
+		}
+	}
+
+	oldExtensions, err := d.Extensions()
+	if err != nil {
+		return err
 	}
 	// Add and remove extensions as required
 	// Note: There are more efficient ways to do this involving sorting both lists. But I'm assuming that the lists
@@ -232,23 +1067,31 @@ func (d *PostgresEngine) SetExtensions(extensions []string) error {
 }
 
 func (d *PostgresEngine) URI(dbname string, username string, password string) string {
-	return (&url.URL{
+	uri := &url.URL{
 		Scheme: "postgres",
 		User:   url.UserPassword(username, password),
 		Host:   fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
 		Path:   fmt.Sprintf("/%s", url.QueryEscape(dbname)), // TODO: should be url.PathEscape() - not present in targeted version of Go.
-	}).String()
+	}
+	if d.config.Sslmode == config.Verify {
+		uri.RawQuery = (&url.Values{"sslmode": []string{config.Verify}}).Encode()
+	}
+	return uri.String()
 }
 
 func (d *PostgresEngine) JDBCURI(dbname string, username string, password string) string {
+	query := url.Values{
+		"user":     []string{username},
+		"password": []string{password},
+	}
+	if d.config.Sslmode == config.Verify {
+		query.Set("sslmode", config.Verify)
+	}
 	return fmt.Sprintf("jdbc:%s", (&url.URL{
-		Scheme: "postgresql",
-		Host:   fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
-		Path:   fmt.Sprintf("/%s", url.QueryEscape(dbname)), // TODO: should be url.PathEscape() - not present in targeted version of Go.
-		RawQuery: (&url.Values{
-			"user":     []string{username},
-			"password": []string{password},
-		}).Encode(),
+		Scheme:   "postgresql",
+		Host:     fmt.Sprintf("%s:%d", d.config.Url, d.config.Port),
+		Path:     fmt.Sprintf("/%s", url.QueryEscape(dbname)), // TODO: should be url.PathEscape() - not present in targeted version of Go.
+		RawQuery: query.Encode(),
 	}).String())
 }
 
@@ -264,13 +1107,17 @@ func (d *PostgresEngine) dropConnections(dbname string) error {
 }
 
 func (d *PostgresEngine) connectionString() string {
-	return fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
+	connectionString := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
 		postgresQuoteConnectionStringValue(d.config.Url),
 		d.config.Port, // no escape as is an integer
 		postgresQuoteConnectionStringValue(d.config.DBName),
 		postgresQuoteConnectionStringValue(d.config.Username),
 		postgresQuoteConnectionStringValue(d.config.Password),
 		postgresQuoteConnectionStringValue(string(d.config.Sslmode)))
+	if d.config.CACertificateFile != "" {
+		connectionString += " sslrootcert=" + postgresQuoteConnectionStringValue(d.config.CACertificateFile)
+	}
+	return connectionString
 }
 
 func (d *PostgresEngine) Config() config.DBConfig {
@@ -278,15 +1125,21 @@ func (d *PostgresEngine) Config() config.DBConfig {
 }
 
 func (d *PostgresEngine) CreateUsername(instanceID string) (string, error) {
-	// Always use the same username in for all bindings, else objects become inaccessible
-	username := "u" + strings.Replace(instanceID, "-", "_", -1)
+	// Every binding gets its own login which acts as the owner role of the instance
+	return utils.RandUsername()
+}
+
+// OwnerRole is the role which owns the objects in the database of an instance. Bindings used to share a
+// login of that name, so instances bound before each binding had its own login keep their objects.
+func (d *PostgresEngine) OwnerRole(instanceID string) string {
+	role := "u" + strings.Replace(instanceID, "-", "_", -1)
 
 	// Check max len for pg
-	if len(username) > 63 {
-		username = username[:63]
+	if len(role) > 63 {
+		role = role[:63]
 	}
 
-	return username, nil
+	return role
 }
 
 // postgresQuoteValue will quote the given value and escape
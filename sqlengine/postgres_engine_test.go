@@ -0,0 +1,264 @@
+package sqlengine_test
+
+import (
+	"regexp"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/AusDTO/pe-rds-broker/sqlengine"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// exactly matches the whole of statement, as sqlmock takes a regular expression
+func exactly(statement string) string {
+	return "^" + regexp.QuoteMeta(statement) + "$"
+}
+
+var _ = Describe("PostgresEngine", func() {
+	var (
+		mock           sqlmock.Sqlmock
+		postgresEngine *PostgresEngine
+	)
+
+	BeforeEach(func() {
+		db, m, err := sqlmock.New()
+		Expect(err).NotTo(HaveOccurred())
+		mock = m
+		postgresEngine = NewPostgresEngineWithDB(lager.NewLogger("postgres_engine_test"), db)
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+		postgresEngine.Close()
+	})
+
+	roleExists := func(username string, exists bool) {
+		mock.ExpectQuery(exactly("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)")).
+			WithArgs(username).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+	}
+
+	Describe("CreateUser", func() {
+		It("creates the user", func() {
+			roleExists(`app"user`, false)
+			mock.ExpectExec(exactly(`CREATE USER "app""user" WITH PASSWORD 'pa''ss'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			Expect(postgresEngine.CreateUser(`app"user`, "pa'ss", false)).To(Succeed())
+		})
+
+		It("doesn't give an existing role a login", func() {
+			roleExists("uother_instance_id", true)
+
+			err := postgresEngine.CreateUser("uother_instance_id", "password", false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Role 'uother_instance_id' already exists"))
+		})
+	})
+
+	Describe("CreateIAMUser", func() {
+		It("creates the user", func() {
+			roleExists("app_user", false)
+			mock.ExpectExec(exactly(`CREATE USER "app_user"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(exactly(`GRANT rds_iam TO "app_user"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			Expect(postgresEngine.CreateIAMUser("app_user")).To(Succeed())
+		})
+
+		It("doesn't give an existing role a login", func() {
+			roleExists("uother_instance_id", true)
+
+			err := postgresEngine.CreateIAMUser("uother_instance_id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Role 'uother_instance_id' already exists"))
+		})
+	})
+
+	Describe("LockUser", func() {
+		It("stops the user logging in and ends its sessions", func() {
+			mock.ExpectExec(exactly(`ALTER ROLE "app_user" WITH NOLOGIN`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(exactly("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = $1")).
+				WithArgs("app_user").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			Expect(postgresEngine.LockUser("app_user")).To(Succeed())
+		})
+	})
+
+	Describe("CopyDB", func() {
+		allowConnections := func(allow string) {
+			mock.ExpectExec(exactly(`ALTER DATABASE "source_db" ALLOW_CONNECTIONS ` + allow)).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+
+		dropConnections := func() {
+			mock.ExpectExec(exactly("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()")).
+				WithArgs("source_db").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		copyDB := exactly(`CREATE DATABASE "clone_db" WITH TEMPLATE "source_db"`)
+
+		It("keeps connections out of the source until it has been copied", func() {
+			allowConnections("false")
+			dropConnections()
+			mock.ExpectExec(copyDB).WillReturnResult(sqlmock.NewResult(0, 0))
+			allowConnections("true")
+
+			Expect(postgresEngine.CopyDB("source_db", "clone_db")).To(Succeed())
+		})
+
+		It("tries again while the closed connections go", func() {
+			allowConnections("false")
+			dropConnections()
+			mock.ExpectExec(copyDB).WillReturnError(&pq.Error{Code: "55006", Message: `source database "source_db" is being accessed by other users`})
+			dropConnections()
+			mock.ExpectExec(copyDB).WillReturnResult(sqlmock.NewResult(0, 0))
+			allowConnections("true")
+
+			Expect(postgresEngine.CopyDB("source_db", "clone_db")).To(Succeed())
+		})
+
+		It("lets connections back in when the copy fails", func() {
+			allowConnections("false")
+			dropConnections()
+			mock.ExpectExec(copyDB).WillReturnError(&pq.Error{Code: "42P04", Message: `database "clone_db" already exists`})
+			allowConnections("true")
+
+			err := postgresEngine.CopyDB("source_db", "clone_db")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already exists"))
+		})
+	})
+
+	Describe("CopyTo", func() {
+		var (
+			targetMock sqlmock.Sqlmock
+			target     *PostgresEngine
+		)
+
+		BeforeEach(func() {
+			db, m, err := sqlmock.New()
+			Expect(err).NotTo(HaveOccurred())
+			targetMock = m
+			target = NewPostgresEngineWithDB(lager.NewLogger("postgres_engine_test"), db)
+
+			mock.ExpectQuery(exactly("SELECT extname FROM pg_extension WHERE extname != 'plpgsql'")).WillReturnRows(sqlmock.NewRows([]string{"extname"}))
+			targetMock.ExpectQuery(exactly("SELECT extname FROM pg_extension WHERE extname != 'plpgsql'")).WillReturnRows(sqlmock.NewRows([]string{"extname"}))
+		})
+
+		AfterEach(func() {
+			Expect(targetMock.ExpectationsWereMet()).To(Succeed())
+			target.Close()
+		})
+
+		serverVersion := func(version int) {
+			mock.ExpectQuery(exactly("SELECT current_setting('server_version_num')::integer")).
+				WillReturnRows(sqlmock.NewRows([]string{"current_setting"}).AddRow(version))
+		}
+
+		relations := func(rows ...[]interface{}) {
+			result := sqlmock.NewRows([]string{"relname", "relkind", "owner", "owned_by"})
+			for _, row := range rows {
+				result.AddRow(row[0], row[1], row[2], row[3])
+			}
+			mock.ExpectQuery("SELECT c.relname, c.relkind, .* FROM pg_class c").WillReturnRows(result)
+		}
+
+		sequenceColumns := []string{"type", "start", "increment", "min", "max", "cache", "cycle", "last_value", "is_called"}
+
+		noConstraintsOrIndexes := func() {
+			mock.ExpectQuery("FROM pg_constraint con").WillReturnRows(sqlmock.NewRows([]string{"table", "name", "definition"}))
+			mock.ExpectQuery("FROM pg_index i").WillReturnRows(sqlmock.NewRows([]string{"definition"}))
+		}
+
+		ownerDoesNotExist := func(owner string) {
+			targetMock.ExpectQuery(exactly("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname=$1)")).
+				WithArgs(owner).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		}
+
+		It("recreates identity and generated columns and the sequences of serial columns", func() {
+			serverVersion(120000)
+			relations(
+				[]interface{}{"orders", "r", "app", ""},
+				[]interface{}{"orders_number_seq", "S", "app", "public.orders.number"},
+			)
+			targetMock.ExpectBegin()
+
+			mock.ExpectQuery(`FROM pg_sequence s, public\."orders_number_seq" q WHERE s\.seqrelid = \$1::regclass`).
+				WithArgs(`public."orders_number_seq"`).
+				WillReturnRows(sqlmock.NewRows(sequenceColumns).AddRow("integer", 1, 2, 1, 2147483647, 1, true, 7, true))
+			targetMock.ExpectExec(exactly(`CREATE SEQUENCE public."orders_number_seq" AS integer INCREMENT BY 2 MINVALUE 1 MAXVALUE 2147483647 START WITH 1 CACHE 1 CYCLE`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			targetMock.ExpectExec(exactly("SELECT setval($1, $2, $3)")).
+				WithArgs(`public."orders_number_seq"`, 7, true).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			mock.ExpectQuery("a.attidentity::text, a.attgenerated::text, .*pg_get_serial_sequence").
+				WithArgs(`public."orders"`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "type", "not_null", "default", "identity", "generated", "sequence"}).
+					AddRow("id", "bigint", true, "", "a", "", "public.orders_id_seq").
+					AddRow("number", "integer", true, "nextval('orders_number_seq'::regclass)", "", "", "").
+					AddRow("total", "integer", false, "(number * 2)", "", "s", ""))
+			mock.ExpectQuery(`FROM pg_sequence s, public\.orders_id_seq q`).
+				WithArgs("public.orders_id_seq").
+				WillReturnRows(sqlmock.NewRows(sequenceColumns).AddRow("bigint", 100, 1, 1, int64(9223372036854775807), 1, false, 105, true))
+			targetMock.ExpectExec(exactly(`CREATE TABLE public."orders" (` +
+				`"id" bigint GENERATED ALWAYS AS IDENTITY (INCREMENT BY 1 MINVALUE 1 MAXVALUE 9223372036854775807 START WITH 100 CACHE 1 NO CYCLE) NOT NULL, ` +
+				`"number" integer DEFAULT nextval('orders_number_seq'::regclass) NOT NULL, ` +
+				`"total" integer GENERATED ALWAYS AS ((number * 2)) STORED)`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(exactly(`SELECT "id"::text, "number"::text FROM public."orders"`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "number"}).AddRow("105", "7"))
+			copyIn := targetMock.ExpectPrepare(exactly(`COPY "orders" ("id", "number") FROM STDIN`))
+			copyIn.ExpectExec().WithArgs("105", "7").WillReturnResult(sqlmock.NewResult(0, 1))
+			copyIn.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+			targetMock.ExpectExec(exactly("SELECT setval(pg_get_serial_sequence($1, $2), $3, $4)")).
+				WithArgs(`public."orders"`, "id", 105, true).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			targetMock.ExpectExec(exactly(`ALTER SEQUENCE public."orders_number_seq" OWNED BY public.orders.number`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			noConstraintsOrIndexes()
+			// The sequence goes with its table so only the table is given back
+			ownerDoesNotExist("app")
+			targetMock.ExpectCommit()
+
+			Expect(postgresEngine.CopyTo(target)).To(Succeed())
+		})
+
+		It("reads sequence definitions from the sequence itself before PostgreSQL 10", func() {
+			serverVersion(90624)
+			relations([]interface{}{"hits", "S", "app", ""})
+			targetMock.ExpectBegin()
+
+			mock.ExpectQuery(exactly(`SELECT 'bigint', start_value, increment_by, min_value, max_value, cache_value, is_cycled, last_value, is_called FROM public."hits"`)).
+				WillReturnRows(sqlmock.NewRows(sequenceColumns).AddRow("bigint", 10, -1, -100, 10, 5, false, 3, false))
+			targetMock.ExpectExec(exactly(`CREATE SEQUENCE public."hits" INCREMENT BY -1 MINVALUE -100 MAXVALUE 10 START WITH 10 CACHE 5 NO CYCLE`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			targetMock.ExpectExec(exactly("SELECT setval($1, $2, $3)")).
+				WithArgs(`public."hits"`, 3, false).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			noConstraintsOrIndexes()
+			ownerDoesNotExist("app")
+			targetMock.ExpectCommit()
+
+			Expect(postgresEngine.CopyTo(target)).To(Succeed())
+		})
+	})
+
+	Describe("UnsupportedObjects", func() {
+		It("describes the objects CopyTo can't copy", func() {
+			mock.ExpectQuery("FROM pg_class .* FROM pg_proc .* FROM pg_trigger .* FROM pg_type").
+				WillReturnRows(sqlmock.NewRows([]string{"description"}).AddRow("view public.totals").AddRow("type public.mood"))
+
+			objects, err := postgresEngine.UnsupportedObjects()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objects).To(Equal([]string{"view public.totals", "type public.mood"}))
+		})
+	})
+})
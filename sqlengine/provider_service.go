@@ -17,9 +17,9 @@ func NewProviderService(logger lager.Logger) *ProviderService {
 
 func (p *ProviderService) GetSQLEngine(engine string) (SQLEngine, error) {
 	switch strings.ToLower(engine) {
-	case "aurora", "mariadb", "mysql":
+	case "aurora", "aurora-mysql", "mariadb", "mysql":
 		return NewMySQLEngine(p.logger), nil
-	case "postgres", "postgresql":
+	case "aurora-postgresql", "postgres", "postgresql":
 		return NewPostgresEngine(p.logger), nil
 	}
 
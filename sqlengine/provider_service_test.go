@@ -35,6 +35,22 @@ var _ = Describe("Provider Service", func() {
 			})
 		})
 
+		Context("when engine is aurora-mysql", func() {
+			It("return the proper SQL Engine", func() {
+				sqlEngine, err := sqlProvider.GetSQLEngine("aurora-mysql")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine).To(BeAssignableToTypeOf(&MySQLEngine{}))
+			})
+		})
+
+		Context("when engine is aurora-postgresql", func() {
+			It("return the proper SQL Engine", func() {
+				sqlEngine, err := sqlProvider.GetSQLEngine("aurora-postgresql")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sqlEngine).To(BeAssignableToTypeOf(&PostgresEngine{}))
+			})
+		})
+
 		Context("when engine is mariadb", func() {
 			It("return the proper SQL Engine", func() {
 				sqlEngine, err := sqlProvider.GetSQLEngine("mariadb")
@@ -5,14 +5,46 @@ import "github.com/AusDTO/pe-rds-broker/config"
 type SQLEngine interface {
 	Open(conf config.DBConfig) error
 	Close()
+	// Ping connects to check the details given to Open work, which Open itself doesn't do
+	Ping() error
 	ExistsDB(dbname string) (bool, error)
 	CreateDB(dbname string) error
 	DropDB(dbname string) error
-	CreateUser(username string, password string) error
+	// CopyDB creates dbname as a copy of the tables and data in sourceDBName
+	CopyDB(sourceDBName string, dbname string) error
+	// CopyTo copies the tables and data of the open database into the open database of target,
+	// which can be on another server but has to be the same kind of engine
+	CopyTo(target SQLEngine) error
+	// UnsupportedObjects describes the objects in the open database which CopyTo can't copy
+	UnsupportedObjects() ([]string, error)
+	// CreateUser creates a user, which has to connect with TLS when requireTLS is set and the engine
+	// enforces it per user
+	CreateUser(username string, password string, requireTLS bool) error
+	// CreateIAMUser creates a user which logs in with an IAM authentication token rather than a password
+	CreateIAMUser(username string) error
+	// UserExists is whether the server has a user or role called username, including ones the broker didn't create
+	UserExists(username string) (bool, error)
 	DropUser(username string) error
+	// LockUser stops username logging in and closes the connections it already has
+	LockUser(username string) error
+	// UnlockUser lets a locked user log in again
+	UnlockUser(username string) error
+	// SetPassword changes the password of an existing user
+	SetPassword(username string, password string) error
+	// SetRequireTLS changes whether an existing user has to connect with TLS
+	SetRequireTLS(username string, requireTLS bool) error
+	// TLSRequired is whether all of the given users have to connect with TLS
+	TLSRequired(usernames []string) (bool, error)
 	GrantPrivileges(dbname string, username string) error
 	RevokePrivileges(dbname string, username string) error
+	// GrantReadOnlyPrivileges lets username read the tables of dbname, including the ones owners create later
+	GrantReadOnlyPrivileges(dbname string, username string, owners []string) error
+	RevokeReadOnlyPrivileges(dbname string, username string, owners []string) error
 	SetExtensions(extensions []string) error
+	// Extensions lists the extensions installed in the open database, other than the ones which always are
+	Extensions() ([]string, error)
+	// ReassignOwnership gives the objects in the open database owned by one user to another
+	ReassignOwnership(fromUsername string, toUsername string) error
 	URI(dbname string, username string, password string) string
 	JDBCURI(dbname string, username string, password string) string
 	Config() config.DBConfig
@@ -20,4 +52,14 @@ type SQLEngine interface {
 	// CreateUsername returns an appropriate username for an app to use for a given instance.
 	// Often this is random, but same databases need specific behaviour.
 	CreateUsername(instanceID string) (string, error)
+
+	// OwnerRole returns the role which owns the objects in the database of an instance and which binding
+	// users act as, so each binding can have its own login. It's empty for engines which don't need one.
+	OwnerRole(instanceID string) string
+	// CreateOwnerRole creates owner if it doesn't exist and gives it all privileges on dbname
+	CreateOwnerRole(dbname string, owner string) error
+	// GrantOwnerRole makes username act as owner
+	GrantOwnerRole(owner string, username string) error
+	// RevokeOwnerRole undoes GrantOwnerRole
+	RevokeOwnerRole(owner string, username string) error
 }
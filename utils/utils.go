@@ -88,6 +88,11 @@ func IsValidExtensionName(arg string) bool {
 	return regexp.MustCompile("^[[:alpha:]][-_[:alnum:]]*$").MatchString(arg)
 }
 
+// RDS identifiers can't contain consecutive hyphens or end with a hyphen
+func IsValidSnapshotName(arg string) bool {
+	return regexp.MustCompile("^[[:alnum:]]+(-[[:alnum:]]+)*$").MatchString(arg)
+}
+
 func BuildLogger(logLevel, component string) lager.Logger {
 	logLevels := map[string]lager.LogLevel{
 		"DEBUG": lager.DEBUG,
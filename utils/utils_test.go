@@ -105,3 +105,19 @@ var _ = Describe("IsValidExtensionName", func() {
 		Expect(IsValidExtensionName("123hi")).To(BeFalse())
 	})
 })
+
+var _ = Describe("IsValidSnapshotName", func() {
+	It("allows valid strings", func() {
+		Expect(IsValidSnapshotName("pre-release-42")).To(BeTrue())
+		Expect(IsValidSnapshotName("42")).To(BeTrue())
+		Expect(IsValidSnapshotName("Backup")).To(BeTrue())
+	})
+
+	It("rejects invalid strings", func() {
+		Expect(IsValidSnapshotName("")).To(BeFalse())
+		Expect(IsValidSnapshotName("pre--release")).To(BeFalse())
+		Expect(IsValidSnapshotName("release-")).To(BeFalse())
+		Expect(IsValidSnapshotName("-release")).To(BeFalse())
+		Expect(IsValidSnapshotName("pre_release")).To(BeFalse())
+	})
+})